@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// advise проверяет описанную флагами конфигурацию MyCipher и печатает предупреждения Analyze.
+// Код возврата 1, если найдено хотя бы одно критическое предупреждение.
+func main() {
+	mode := flag.String("mode", mycrypto.ModeGCM, "cipher mode")
+	block := flag.Int("block", mycrypto.AESBlockSize, "block size in bytes (8 for Magma or 3DES)")
+	keyBits := flag.Int("key", 128, "key size in bits")
+	mac := flag.String("mac", "", "MAC applied over the ciphertext (empty for none)")
+	tag := flag.Int("tag", 0, "tag size in bytes (0 for the full tag)")
+	iv := flag.String("iv", "", "IV or nonce passed to Encrypt (hex:, base64:, ...)")
+	static := flag.Bool("static-iv", false, "the same IV is used for every message")
+	data := flag.Int64("data", 0, "bytes encrypted under one key")
+	flag.Parse()
+
+	cfg := mycrypto.Config{
+		Mode:      *mode,
+		BlockSize: *block,
+		KeySize:   *keyBits / 8,
+		MAC:       *mac,
+		TagSize:   *tag,
+		StaticIV:  *static,
+		DataBytes: *data,
+	}
+	if *iv != "" {
+		b, err := mycrypto.ParseBytes(*iv)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.IV = b
+	}
+	ws := mycrypto.Analyze(cfg)
+	critical := false
+	for _, w := range ws {
+		fmt.Println(w)
+		critical = critical || w.Severity == mycrypto.SeverityCritical
+	}
+	if len(ws) == 0 {
+		fmt.Println("no known misuse found")
+	} else if rec := mycrypto.RecommendMode(cfg); rec != *mode {
+		fmt.Printf("recommended mode: %s\n", rec)
+	}
+	if critical {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	"github.com/sagilyp/lab1/myaes"
+	"github.com/sagilyp/lab1/mysidechannel"
+)
+
+func main() {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	c, err := myaes.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	probe := func(pt []byte) mysidechannel.CacheObservation {
+		_, obs := mysidechannel.ProbeEncrypt(c, pt)
+		return obs
+	}
+	fmt.Println("Secret key:      ", hex.EncodeToString(key))
+	for _, samples := range []int{10, 50, 100, 200, 500, 1000} {
+		recovered, err := mysidechannel.FirstRoundAttack(probe, samples)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%5d encryptions: %s  (%d/16 high nibbles correct)\n",
+			samples, hex.EncodeToString(recovered), mysidechannel.CorrectNibbles(recovered, key))
+	}
+	// Константная по времени реализация не даёт наблюдений: все строки "заняты" всегда
+	constant := func(pt []byte) mysidechannel.CacheObservation {
+		return mysidechannel.CacheObservation{0xffff, 0xffff, 0xffff, 0xffff}
+	}
+	recovered, err := mysidechannel.FirstRoundAttack(constant, 1000)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Constant-time AES, 1000 encryptions: %d/16 high nibbles correct\n",
+		mysidechannel.CorrectNibbles(recovered, key))
+}
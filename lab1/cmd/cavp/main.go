@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sagilyp/lab1/mycavp"
+)
+
+// maxListed - сколько непройденных записей файла выводить подробно
+const maxListed = 20
+
+func main() {
+	dir := flag.String("dir", "testdata/cavp", "directory with CAVP .rsp files (see fetch.sh there)")
+	verbose := flag.Bool("v", false, "list every failure")
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		var err error
+		if paths, err = filepath.Glob(filepath.Join(*dir, "*.rsp")); err != nil {
+			log.Fatal(err)
+		}
+		if len(paths) == 0 {
+			log.Fatalf("no vectors in %s, run sh %s/fetch.sh first", *dir, *dir)
+		}
+	}
+	failed, total := 0, 0
+	for _, path := range paths {
+		f, err := mycavp.Load(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		start := time.Now()
+		r, err := mycavp.Run(f)
+		if err != nil {
+			fmt.Printf("%s: %v, skipped\n", filepath.Base(path), err)
+			continue
+		}
+		total += r.Total
+		failed += r.Failed
+		fmt.Printf("%s (%s): %d/%d passed, %d failed, %v\n",
+			filepath.Base(path), r.Info, r.Passed, r.Total, r.Failed, time.Since(start).Round(time.Millisecond))
+		for i, fl := range r.Failures {
+			if i == maxListed && !*verbose {
+				fmt.Printf("  ... %d more, use -v\n", r.Failed-i)
+				break
+			}
+			fmt.Printf("  FAIL [%s] COUNT = %d (line %d): %s\n", fl.Section, fl.Count, fl.Line, fl.Reason)
+		}
+	}
+	fmt.Printf("total: %d records, %d failed\n", total, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
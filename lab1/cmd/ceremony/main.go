@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/myqr"
+	"github.com/sagilyp/lab1/myshamir"
+)
+
+const usage = `usage:
+  ceremony [-keystore file] init [-k 3] [-n 5] [-dir shares] [-qr] name...
+  ceremony [-keystore file] verify share-file...
+  ceremony [-keystore file] unlock [-reveal] share-file...`
+
+// keystore - ключи данных, обёрнутые мастер-ключом (AES-KW, RFC 3394).
+// Сам мастер-ключ не хранится: он существует только в виде долей Шамира.
+type keystore struct {
+	Fingerprint string            `json:"fingerprint"`
+	Keys        map[string]string `json:"keys"`
+}
+
+func loadKeystore(path string) (*keystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ks keystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %v", path, err)
+	}
+	return &ks, nil
+}
+
+// readShareFile читает долю из текстового файла или из PNG с QR-кодом
+func readShareFile(path string) ([]byte, error) {
+	if filepath.Ext(path) != ".png" {
+		return os.ReadFile(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	return myqr.Decode(img)
+}
+
+func readShares(files []string) ([]myshamir.Share, error) {
+	var shares []myshamir.Share
+	for _, f := range files {
+		data, err := readShareFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f, err)
+		}
+		s, err := myshamir.ParseShare(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f, err)
+		}
+		shares = append(shares, s)
+	}
+	return shares, nil
+}
+
+func main() {
+	ksPath := flag.String("keystore", "keystore.json", "keystore file")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "init":
+		fs := flag.NewFlagSet("init", flag.ExitOnError)
+		k := fs.Int("k", 3, "shares needed to unlock")
+		n := fs.Int("n", 5, "shares to issue")
+		dir := fs.String("dir", "shares", "directory for share files")
+		qr := fs.Bool("qr", false, "also write each share as a QR code (share-N.png) for air-gapped transfer")
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			log.Fatal("init: name at least one data key to create")
+		}
+		if _, err := os.Stat(*ksPath); err == nil {
+			log.Fatalf("init: %s already exists, refusing to overwrite", *ksPath)
+		}
+		fmt.Println("Step 1: generating a 256-bit master key")
+		master := make([]byte, mycrypto.AESKeySize32)
+		if _, err := mycrypto.Rand.Read(master); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Step 2: generating and wrapping %d data keys\n", fs.NArg())
+		ks := keystore{Keys: make(map[string]string)}
+		for _, name := range fs.Args() {
+			key := make([]byte, mycrypto.AESKeySize32)
+			if _, err := mycrypto.Rand.Read(key); err != nil {
+				log.Fatal(err)
+			}
+			wrapped, err := mycrypto.WrapKey(master, key)
+			if err != nil {
+				log.Fatal(err)
+			}
+			ks.Keys[name] = hex.EncodeToString(wrapped)
+		}
+		fmt.Printf("Step 3: splitting the master key into %d shares, any %d unlock the keystore\n", *n, *k)
+		shares, err := myshamir.Split(master, *k, *n)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i := range master {
+			master[i] = 0
+		}
+		ks.Fingerprint = hex.EncodeToString(shares[0].Fingerprint)
+		if err := os.MkdirAll(*dir, 0o700); err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range shares {
+			path := filepath.Join(*dir, fmt.Sprintf("share-%d.txt", s.X))
+			if err := os.WriteFile(path, []byte(s.String()+"\n"), 0o600); err != nil {
+				log.Fatal(err)
+			}
+			if *qr {
+				code, err := myqr.Encode([]byte(s.String()), myqr.LevelQ)
+				if err != nil {
+					log.Fatal(err)
+				}
+				img, err := code.PNG(8)
+				if err != nil {
+					log.Fatal(err)
+				}
+				qrPath := filepath.Join(*dir, fmt.Sprintf("share-%d.png", s.X))
+				if err := os.WriteFile(qrPath, img, 0o600); err != nil {
+					log.Fatal(err)
+				}
+				path += ", " + qrPath
+			}
+			fmt.Printf("  %s -> hand to custodian %d\n", path, s.X)
+		}
+		data, err := json.MarshalIndent(ks, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*ksPath, data, 0o600); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Step 4: keystore written to %s (master fingerprint %s)\n", *ksPath, ks.Fingerprint)
+		fmt.Println("Each custodian should run 'ceremony verify' on their share before the files are distributed.")
+	case "verify":
+		ks, err := loadKeystore(*ksPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		failed := false
+		for _, f := range args {
+			shares, err := readShares([]string{f})
+			if err != nil {
+				fmt.Printf("FAIL %v\n", err)
+				failed = true
+				continue
+			}
+			s := shares[0]
+			if hex.EncodeToString(s.Fingerprint) != ks.Fingerprint {
+				fmt.Printf("FAIL %s: share belongs to a different master key\n", f)
+				failed = true
+				continue
+			}
+			fmt.Printf("OK   %s: share %d of a %d-of-N split\n", f, s.X, s.Threshold)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	case "unlock":
+		fs := flag.NewFlagSet("unlock", flag.ExitOnError)
+		reveal := fs.Bool("reveal", false, "print unwrapped data keys")
+		fs.Parse(args)
+		ks, err := loadKeystore(*ksPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		shares, err := readShares(fs.Args())
+		if err != nil {
+			log.Fatal(err)
+		}
+		master, err := myshamir.Combine(shares)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fp, err := hex.DecodeString(ks.Fingerprint)
+		if err != nil || !bytes.Equal(fp, shares[0].Fingerprint) {
+			log.Fatal("unlock: shares belong to a different keystore")
+		}
+		names := make([]string, 0, len(ks.Keys))
+		for name := range ks.Keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Printf("keystore unlocked with %d shares\n", len(shares))
+		for _, name := range names {
+			wrapped, err := hex.DecodeString(ks.Keys[name])
+			if err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			key, err := mycrypto.UnwrapKey(master, wrapped)
+			if err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			if *reveal {
+				fmt.Printf("  %-12s %x\n", name, key)
+			} else {
+				fmt.Printf("  %-12s unwrapped (%d bytes)\n", name, len(key))
+			}
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sagilyp/lab1/myaes"
+	"github.com/sagilyp/lab1/mycamellia"
+	"github.com/sagilyp/lab1/mycrypto"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// backend - блочный шифр, подключаемый к MyCipher через SetBlockCipher
+type backend struct {
+	name string
+	new  func(key []byte) (mycrypto.BlockCipher, error)
+}
+
+var backends = []backend{
+	{"AES (crypto/aes)", func(key []byte) (mycrypto.BlockCipher, error) { return aes.NewCipher(key) }},
+	{"AES (myaes)", func(key []byte) (mycrypto.BlockCipher, error) { return myaes.NewCipher(key) }},
+	{"Camellia", func(key []byte) (mycrypto.BlockCipher, error) { return mycamellia.NewCipher(key) }},
+}
+
+var modes = []string{mycrypto.ModeECB, mycrypto.ModeCBC, mycrypto.ModeCFB, mycrypto.ModeOFB, mycrypto.ModeCTR, mycrypto.ModeCTS, mycrypto.ModeGCM, mycrypto.ModeOCB}
+
+// throughput возвращает скорость шифрования (МБ/с) лучшего из runs прогонов;
+// CTR и ECB делятся между workers горутинами
+func throughput(b mycrypto.BlockCipher, mode string, msg []byte, runs, workers int) (float64, error) {
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetBlockCipher(b); err != nil {
+		return 0, err
+	}
+	if err := mc.SetMode(mode); err != nil {
+		return 0, err
+	}
+	if err := mc.SetParallelism(workers); err != nil {
+		return 0, err
+	}
+	dst := make([]byte, mc.EncryptedLen(len(msg), nil))
+	best := time.Duration(0)
+	for i := 0; i < runs; i++ {
+		start := time.Now()
+		if _, err := mc.EncryptTo(dst, msg, nil); err != nil {
+			return 0, err
+		}
+		if d := time.Since(start); best == 0 || d < best {
+			best = d
+		}
+	}
+	return float64(len(msg)) / best.Seconds() / (1 << 20), nil
+}
+
+// stdModes - режимы, которые есть в crypto/cipher; у остальных строка стандартной библиотеки пустая
+var stdModes = map[string]bool{mycrypto.ModeCBC: true, mycrypto.ModeCTR: true, mycrypto.ModeGCM: true}
+
+// stdThroughput возвращает скорость шифрования (МБ/с) лучшего из runs прогонов через crypto/aes и crypto/cipher:
+// ориентир, насколько учебная реализация режимов медленнее стандартной. Как и EncryptTo, каждый прогон
+// берёт новый IV (nonce у GCM); CBC шифрует сообщение целыми блоками, без блока паддинга MyCipher.
+func stdThroughput(key []byte, mode string, msg []byte, runs int) (float64, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return 0, err
+	}
+	gcm, err := cipher.NewGCM(b)
+	if err != nil {
+		return 0, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	dst := make([]byte, len(msg)+gcm.Overhead())
+	best := time.Duration(0)
+	for i := 0; i < runs; i++ {
+		start := time.Now()
+		if _, err := mycrypto.Rand.Read(iv); err != nil {
+			return 0, err
+		}
+		switch mode {
+		case mycrypto.ModeCBC:
+			n := len(msg) &^ (aes.BlockSize - 1)
+			cipher.NewCBCEncrypter(b, iv).CryptBlocks(dst[:n], msg[:n])
+		case mycrypto.ModeCTR:
+			cipher.NewCTR(b, iv).XORKeyStream(dst[:len(msg)], msg)
+		case mycrypto.ModeGCM:
+			gcm.Seal(dst[:0], iv[:gcm.NonceSize()], msg, nil)
+		default:
+			return 0, fmt.Errorf("mode %s is not in crypto/cipher", mode)
+		}
+		if d := time.Since(start); best == 0 || d < best {
+			best = d
+		}
+	}
+	return float64(len(msg)) / best.Seconds() / (1 << 20), nil
+}
+
+func main() {
+	size := flag.Int("size", 1<<20, "message size in bytes")
+	keyBits := flag.Int("key", 128, "key size in bits (128, 192 or 256)")
+	runs := flag.Int("runs", 3, "runs per measurement, the fastest is kept")
+	workers := flag.Int("workers", 1, "goroutines for CTR and ECB (MyCipher.SetParallelism)")
+	flag.Parse()
+
+	key := make([]byte, *keyBits/8)
+	msg := make([]byte, *size)
+	for _, b := range [][]byte{key, msg} {
+		if _, err := mycrypto.Rand.Read(b); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	p := plot.New()
+	p.Title.Text = fmt.Sprintf("Encryption throughput, %d-bit key, %d KB message", *keyBits, *size>>10)
+	p.Y.Label.Text = "MB/s"
+	p.Legend.Top = true
+	width := vg.Points(12)
+
+	fmt.Printf("%-18s", "backend")
+	for _, mode := range modes {
+		fmt.Printf(" %8s", mode)
+	}
+	fmt.Println()
+	for i, be := range backends {
+		b, err := be.new(key)
+		if err != nil {
+			log.Fatalf("%s: %v", be.name, err)
+		}
+		speeds := make(plotter.Values, len(modes))
+		fmt.Printf("%-18s", be.name)
+		for j, mode := range modes {
+			if speeds[j], err = throughput(b, mode, msg, *runs, *workers); err != nil {
+				log.Fatalf("%s-%s: %v", be.name, mode, err)
+			}
+			fmt.Printf(" %8.2f", speeds[j])
+		}
+		fmt.Println()
+
+		bars, err := plotter.NewBarChart(speeds, width)
+		if err != nil {
+			log.Fatal(err)
+		}
+		bars.Color = plotutil.Color(i)
+		bars.LineStyle.Width = 0
+		bars.Offset = width * vg.Length(i-(len(backends)+1)/2)
+		p.Add(bars)
+		p.Legend.Add(be.name, bars)
+	}
+
+	// те же режимы целиком из стандартной библиотеки - на том же графике
+	const stdName = "crypto/cipher"
+	speeds := make(plotter.Values, len(modes))
+	fmt.Printf("%-18s", stdName)
+	for j, mode := range modes {
+		if !stdModes[mode] {
+			fmt.Printf(" %8s", "-")
+			continue
+		}
+		var err error
+		if speeds[j], err = stdThroughput(key, mode, msg, *runs); err != nil {
+			log.Fatalf("%s-%s: %v", stdName, mode, err)
+		}
+		fmt.Printf(" %8.2f", speeds[j])
+	}
+	fmt.Println()
+	bars, err := plotter.NewBarChart(speeds, width)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bars.Color = plotutil.Color(len(backends))
+	bars.LineStyle.Width = 0
+	bars.Offset = width * vg.Length(len(backends)-(len(backends)+1)/2)
+	p.Add(bars)
+	p.Legend.Add(stdName, bars)
+	p.NominalX(modes...)
+	p.Y.Max *= 1.25 // место для легенды
+	if err := p.Save(8*vg.Inch, 4*vg.Inch, "graphs/cipher_throughput.png"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"log"
+
+	"github.com/sagilyp/lab1/myaes"
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/mytiming"
+)
+
+const samples = 200000
+
+func randomBlock() []byte {
+	b := make([]byte, mycrypto.AESBlockSize)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	return b
+}
+
+func main() {
+	// Фиксированный класс - блок с корректным паддингом длины 16,
+	// случайный класс почти всегда падает на первой же проверке.
+	validPad := bytes.Repeat([]byte{mycrypto.AESBlockSize}, mycrypto.AESBlockSize)
+	secret := randomBlock()
+	// табличный AES и побитовое умножение GHASH: индексы таблиц и ветвления зависят от данных
+	aes, err := myaes.NewCipher(randomBlock())
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := make([]byte, mycrypto.AESBlockSize)
+	h := randomBlock()
+	tests := []struct {
+		name  string
+		fn    func([]byte)
+		fixed []byte
+	}{
+		{"Pkcs7Unpad", func(in []byte) { mycrypto.Pkcs7Unpad(in, mycrypto.AESBlockSize) }, validPad},
+		{"bytes.Equal", func(in []byte) { bytes.Equal(in, secret) }, secret},
+		{"subtle.ConstantTimeCompare", func(in []byte) { subtle.ConstantTimeCompare(in, secret) }, secret},
+		{"myaes.Encrypt", func(in []byte) { aes.Encrypt(out, in) }, make([]byte, mycrypto.AESBlockSize)},
+		{"mycrypto.GFMul", func(in []byte) { mycrypto.GFMul(in, h, mycrypto.BitOrderReflected) }, make([]byte, mycrypto.AESBlockSize)},
+	}
+	for _, t := range tests {
+		mycrypto.ResetAuditCounters()
+		res, err := mytiming.LeakageTest(t.name, t.fn, t.fixed, randomBlock, samples)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%-28s t = %8.2f  p = %.3g  fixed = %.1f ns  random = %.1f ns  leak = %v\n",
+			res.Name, res.T, res.P, res.MeanA, res.MeanB, res.Leak)
+		if mycrypto.AuditEnabled {
+			fmt.Printf("  audit counters: %v\n", mycrypto.AuditCounters())
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Команда detkey печатает воспроизводимый закрытый ключ (PKCS#8 PEM), выведенный из зерна.
+// Только для лабораторных и тестовых фикстур; собирается с тегом detkeys:
+//
+//	go run -tags detkeys ./cmd/detkey -seed lab4 -label alice -type rsa -bits 2048
+package main
+
+import (
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/sagilyp/lab1/mykeys"
+)
+
+func main() {
+	seed := flag.String("seed", "", "seed string (NOT a secret: anyone who knows it gets the key)")
+	label := flag.String("label", "", "key label, different labels give independent keys")
+	typ := flag.String("type", "rsa", "key type: rsa, ecdsa or ed25519")
+	bits := flag.Int("bits", 2048, "RSA modulus size")
+	curveName := flag.String("curve", "P-256", "ECDSA curve: P-224, P-256, P-384 or P-521")
+	flag.Parse()
+	if !mykeys.Enabled {
+		log.Fatal(mykeys.ErrDisabled)
+	}
+	if *seed == "" {
+		log.Fatal("-seed is required")
+	}
+
+	var key any
+	var err error
+	switch *typ {
+	case "rsa":
+		key, err = mykeys.RSA([]byte(*seed), *label, *bits)
+	case "ecdsa":
+		curves := map[string]elliptic.Curve{
+			"P-224": elliptic.P224(), "P-256": elliptic.P256(),
+			"P-384": elliptic.P384(), "P-521": elliptic.P521(),
+		}
+		curve, ok := curves[*curveName]
+		if !ok {
+			log.Fatalf("unknown curve %q", *curveName)
+		}
+		key, err = mykeys.ECDSA([]byte(*seed), *label, curve)
+	case "ed25519":
+		key, err = mykeys.Ed25519([]byte(*seed), *label)
+	default:
+		log.Fatalf("unknown key type %q", *typ)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pem.Encode(os.Stdout, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
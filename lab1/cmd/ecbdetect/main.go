@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sagilyp/lab1/myanalysis"
+)
+
+// sample - проверяемые данные и их название (файл или файл:строка)
+type sample struct {
+	name  string
+	data  []byte
+	score myanalysis.ECBScore
+}
+
+// ecbdetect ищет повторяющиеся блоки в файлах (или stdin) и сообщает, похожи ли данные на шифротекст ECB.
+// С -hex каждая непустая строка файла - отдельный шифротекст в hex; результаты сортируются по доле повторов.
+// Код возврата 1, если ни один образец не похож на ECB.
+func main() {
+	blockSize := flag.Int("block", 16, "block size in bytes")
+	hexLines := flag.Bool("hex", false, "each non-empty input line is a separate hex-encoded ciphertext")
+	flag.Parse()
+
+	inputs := flag.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+	var samples []sample
+	for _, name := range inputs {
+		var r io.Reader = os.Stdin
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			r = f
+		}
+		if !*hexLines {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				log.Fatal(err)
+			}
+			samples = append(samples, sample{name: name, data: data})
+			continue
+		}
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 1<<20), 1<<26)
+		for line := 1; sc.Scan(); line++ {
+			text := strings.TrimSpace(sc.Text())
+			if text == "" {
+				continue
+			}
+			data, err := hex.DecodeString(text)
+			if err != nil {
+				log.Fatalf("%s:%d: %v", name, line, err)
+			}
+			samples = append(samples, sample{name: fmt.Sprintf("%s:%d", name, line), data: data})
+		}
+		if err := sc.Err(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	found := false
+	for i := range samples {
+		samples[i].score = myanalysis.DetectECB(samples[i].data, *blockSize)
+		found = found || samples[i].score.LikelyECB()
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].score.Ratio > samples[j].score.Ratio })
+	for _, s := range samples {
+		verdict := "no repeats"
+		if s.score.LikelyECB() {
+			verdict = "likely ECB"
+		}
+		fmt.Printf("%-10s %5d/%-6d repeated blocks (%5.1f%%, offset %2d)  %s\n",
+			verdict, s.score.Repeated, s.score.Blocks, 100*s.score.Ratio, s.score.Offset, s.name)
+	}
+	if !found {
+		os.Exit(1)
+	}
+}
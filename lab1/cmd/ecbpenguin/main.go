@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/sagilyp/lab1/myanalysis"
+	"github.com/sagilyp/lab1/mycrypto"
+	"golang.org/x/image/bmp"
+)
+
+// Промежуток между картинками на итоговом изображении
+const gap = 12
+
+// penguin рисует картинку с крупными одноцветными областями - на ней ECB виден лучше всего
+func penguin(w, h int) *image.RGBA {
+	var (
+		white  = color.RGBA{255, 255, 255, 255}
+		black  = color.RGBA{24, 24, 24, 255}
+		orange = color.RGBA{240, 150, 20, 255}
+	)
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	fw, fh := float64(w), float64(h)
+	in := func(x, y, cx, cy, rx, ry float64) bool {
+		dx, dy := (x-cx)/(rx*fw), (y-cy)/(ry*fh)
+		return dx*dx+dy*dy <= 1
+	}
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			x, y := float64(px), float64(py)
+			c := white
+			switch {
+			case in(x, y, 0.38*fw, 0.93*fh, 0.12, 0.04), in(x, y, 0.62*fw, 0.93*fh, 0.12, 0.04):
+				c = orange // лапы
+			case in(x, y, 0.5*fw, 0.41*fh, 0.06, 0.03):
+				c = orange // клюв
+			case in(x, y, 0.43*fw, 0.32*fh, 0.025, 0.025), in(x, y, 0.57*fw, 0.32*fh, 0.025, 0.025):
+				c = black // зрачки
+			case in(x, y, 0.43*fw, 0.32*fh, 0.07, 0.06), in(x, y, 0.57*fw, 0.32*fh, 0.07, 0.06):
+				c = white // глаза
+			case in(x, y, 0.5*fw, 0.66*fh, 0.22, 0.24):
+				c = white // живот
+			case in(x, y, 0.5*fw, 0.33*fh, 0.2, 0.18), in(x, y, 0.5*fw, 0.63*fh, 0.33, 0.3):
+				c = black // голова и туловище
+			}
+			img.SetRGBA(px, py, c)
+		}
+	}
+	return img
+}
+
+// encryptPixels шифрует пиксели BMP в режиме mode, оставляя заголовок нетронутым, чтобы
+// результат оставался картинкой. Шифруются целые блоки, хвост короче блока и паддинг не меняются.
+func encryptPixels(data []byte, mode string, key []byte) ([]byte, error) {
+	if len(data) < 14 || data[0] != 'B' || data[1] != 'M' {
+		return nil, errors.New("not a BMP file")
+	}
+	off := int(binary.LittleEndian.Uint32(data[10:14]))
+	if off > len(data) {
+		return nil, errors.New("BMP pixel data offset is out of range")
+	}
+	pixels := data[off:]
+	n := len(pixels) / mycrypto.AESBlockSize * mycrypto.AESBlockSize
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(mode); err != nil {
+		return nil, err
+	}
+	ct, err := mc.Encrypt(pixels[:n], nil)
+	if err != nil {
+		return nil, err
+	}
+	if mode != mycrypto.ModeECB {
+		ct = ct[mycrypto.AESBlockSize:] // IV не входит в картинку
+	}
+	out := append([]byte{}, data[:off]...)
+	out = append(out, ct[:n]...)
+	return append(out, pixels[n:]...), nil
+}
+
+func main() {
+	in := flag.String("in", "", "BMP image to encrypt (default: a generated penguin)")
+	out := flag.String("out", "graphs/ecb_penguin.png", "side-by-side comparison: original, ECB, CBC")
+	bmpDir := flag.String("bmpdir", "", "also write the original and encrypted BMP files to this directory")
+	flag.Parse()
+
+	var orig []byte
+	if *in != "" {
+		var err error
+		if orig, err = os.ReadFile(*in); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := bmp.Encode(&buf, penguin(320, 360)); err != nil {
+			log.Fatal(err)
+		}
+		orig = buf.Bytes()
+	}
+	key := make([]byte, mycrypto.AESKeySize16)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+
+	files := map[string][]byte{"original": orig}
+	names := []string{"original", mycrypto.ModeECB, mycrypto.ModeCBC}
+	for _, mode := range names[1:] {
+		enc, err := encryptPixels(orig, mode, key)
+		if err != nil {
+			log.Fatal(err)
+		}
+		files[mode] = enc
+		s := myanalysis.DetectECB(enc, mycrypto.AESBlockSize)
+		fmt.Printf("%s: %d of %d blocks repeated (%.1f%%), likely ECB: %v\n", mode, s.Repeated, s.Blocks, 100*s.Ratio, s.LikelyECB())
+	}
+
+	// Картинки в ряд: оригинал, ECB, CBC
+	var imgs []image.Image
+	for _, name := range names {
+		img, err := bmp.Decode(bytes.NewReader(files[name]))
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+		imgs = append(imgs, img)
+	}
+	b := imgs[0].Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, len(imgs)*b.Dx()+(len(imgs)-1)*gap, b.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	for i, img := range imgs {
+		r := image.Rect(i*(b.Dx()+gap), 0, i*(b.Dx()+gap)+b.Dx(), b.Dy())
+		draw.Draw(canvas, r, img, img.Bounds().Min, draw.Src)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, canvas); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("comparison saved to", *out)
+
+	if *bmpDir != "" {
+		for _, name := range names {
+			path := filepath.Join(*bmpDir, "penguin_"+name+".bmp")
+			if err := os.WriteFile(path, files[name], 0o644); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+
+	"github.com/sagilyp/lab1/myanalysis"
+	"github.com/sagilyp/lab1/mycrypto"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// Длина сообщения: 8 блоков без трёх байтов, чтобы в CBC/ECB паддинг занимал часть последнего блока
+const msgLen = 8*mycrypto.AESBlockSize - 3
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+func main() {
+	key := make([]byte, mycrypto.AESKeySize16)
+	msg := make([]byte, msgLen)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := rand.Read(msg); err != nil {
+		log.Fatal(err)
+	}
+	modes := []string{mycrypto.ModeECB, mycrypto.ModeCBC, mycrypto.ModeCFB, mycrypto.ModeOFB, mycrypto.ModeCTR}
+	series := []interface{}{}
+	for _, mode := range modes {
+		mc := &mycrypto.MyCipher{}
+		if err := mc.SetKey(key); err != nil {
+			log.Fatal(err)
+		}
+		if err := mc.SetMode(mode); err != nil {
+			log.Fatal(err)
+		}
+		ct, err := mc.Encrypt(msg, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// Переворачиваем бит 3 в каждом блоке шифротекста (включая IV) и смотрим,
+		// какие блоки открытого текста испорчены после расшифрования
+		pts := make(plotter.XYs, 0)
+		fmt.Printf("\n<<<--- Mode: %s --->>>\n", mode)
+		for block := 0; block*mycrypto.AESBlockSize < len(ct); block++ {
+			bit := block*mycrypto.AESBlockSize*8 + 3
+			corrupted, err := myanalysis.FlipBit(ct, bit)
+			if err != nil {
+				log.Fatal(err)
+			}
+			pt, err := mc.Decrypt(corrupted, nil)
+			var damaged int
+			var footprint []int
+			if err != nil {
+				damaged = len(msg)
+				fmt.Printf("flip in ciphertext block %d: decryption error (%v)\n", block, err)
+			} else {
+				damaged = myanalysis.CorruptedBytes(msg, pt)
+				footprint = myanalysis.BlockDiff(msg, pt, mycrypto.AESBlockSize)
+				fmt.Printf("flip in ciphertext block %d: %3d bytes corrupted, per block %v\n", block, damaged, footprint)
+			}
+			pts = append(pts, plotter.XY{X: float64(block), Y: float64(damaged)})
+		}
+		series = append(series, mode, pts)
+	}
+	err := plotResults(
+		"Error propagation of a single bit flip",
+		"Flipped ciphertext block (0 = IV)", "Corrupted plaintext bytes",
+		"graphs/error_propagation.png",
+		series...,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("\nGraph saved as graphs/error_propagation.png")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/sagilyp/lab1/myaes"
+	"github.com/sagilyp/lab1/mysidechannel"
+)
+
+func main() {
+	// Атака Bellcore на RSA-CRT: одна подпись с ошибкой в половине по модулю p раскрывает ключ
+	fmt.Println("<<<--- Bellcore attack on RSA-CRT --->>>")
+	signer, err := mysidechannel.NewCRTSigner(1024)
+	if err != nil {
+		log.Fatal(err)
+	}
+	m := big.NewInt(0x1337)
+	signer.Fault = func(sp *big.Int) { sp.SetBit(sp, 0, sp.Bit(0)^1) }
+	faulty := signer.Sign(m)
+	signer.Fault = nil
+	p, q, err := mysidechannel.BellcoreAttack(&signer.Key.PublicKey, m, faulty)
+	if err != nil {
+		log.Fatal(err)
+	}
+	found := (p.Cmp(signer.Key.Primes[0]) == 0 && q.Cmp(signer.Key.Primes[1]) == 0) ||
+		(p.Cmp(signer.Key.Primes[1]) == 0 && q.Cmp(signer.Key.Primes[0]) == 0)
+	fmt.Printf("Factors recovered from one faulty signature: %v\n", found)
+
+	// DFA на AES-128: инверсия одного бита перед последним раундом
+	fmt.Println("\n<<<--- Differential fault analysis on AES-128 --->>>")
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	c, err := myaes.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Secret key:   ", hex.EncodeToString(key))
+	var pairs []mysidechannel.FaultPair
+	for n := 1; n <= 200; n++ {
+		pt := make([]byte, 16)
+		if _, err := rand.Read(pt); err != nil {
+			log.Fatal(err)
+		}
+		correct := make([]byte, 16)
+		faulty := make([]byte, 16)
+		c.Encrypt(correct, pt)
+		c.Fault = mysidechannel.BitFlipBeforeLastRound(c.Rounds())
+		c.Encrypt(faulty, pt)
+		c.Fault = nil
+		pairs = append(pairs, mysidechannel.FaultPair{Correct: correct, Faulty: faulty})
+		lastKey, solved, err := mysidechannel.LastRoundDFA(pairs)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if solved == 16 {
+			master, err := mysidechannel.MasterKeyFromLastRoundKey128(lastKey)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println("Recovered key:", hex.EncodeToString(master))
+			fmt.Printf("Faulty encryptions used: %d, key correct: %v\n", n, bytes.Equal(master, key))
+			return
+		}
+	}
+	fmt.Println("Not enough faulty encryptions to recover the key")
+}
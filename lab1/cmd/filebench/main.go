@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// writeRandomFile создаёт файл из size случайных байт, записывая его кусками, и возвращает его SHA-256
+func writeRandomFile(path string, size int64) ([]byte, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.CopyN(io.MultiWriter(f, h), mycrypto.Rand, size)
+	if err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), f.Close()
+}
+
+func fileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// progress печатает процент обработки и запоминает наибольший объём кучи
+func progress(label string, peak *uint64) func(done, total int64) {
+	var ms runtime.MemStats
+	last := -1
+	return func(done, total int64) {
+		runtime.ReadMemStats(&ms)
+		*peak = max(*peak, ms.HeapInuse)
+		if total <= 0 {
+			return
+		}
+		if pct := int(done * 100 / total); pct != last {
+			last = pct
+			fmt.Printf("\r%s: %3d%%", label, pct)
+		}
+	}
+}
+
+func run(label string, f func(mycrypto.FileOptions) (int64, error), opts mycrypto.FileOptions, size int64) {
+	var peak uint64
+	opts.Progress = progress(label, &peak)
+	start := time.Now()
+	if _, err := f(opts); err != nil {
+		log.Fatalf("\n%s: %v", label, err)
+	}
+	d := time.Since(start)
+	fmt.Printf("\r%s: %8.1f MB/s, %v, peak heap %.1f MB\n",
+		label, float64(size)/d.Seconds()/(1<<20), d.Round(time.Millisecond), float64(peak)/(1<<20))
+}
+
+func main() {
+	sizeMB := flag.Int64("size", 1024, "file size in MB")
+	mode := flag.String("mode", mycrypto.ModeCTR, "mode (ECB, CBC, CFB, OFB, CTR)")
+	chunk := flag.Int("chunk", mycrypto.DefaultFileChunk, "chunk size in bytes")
+	mmap := flag.Bool("mmap", false, "read input through a memory mapping")
+	dir := flag.String("dir", os.TempDir(), "directory for temporary files")
+	flag.Parse()
+
+	tmp, err := os.MkdirTemp(*dir, "filebench")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	plain := filepath.Join(tmp, "plain")
+	enc := filepath.Join(tmp, "enc")
+	dec := filepath.Join(tmp, "dec")
+	size := *sizeMB << 20
+	want, err := writeRandomFile(plain, size)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	key := make([]byte, mycrypto.AESKeySize16)
+	if _, err := mycrypto.Rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		log.Fatal(err)
+	}
+	if err := mc.SetMode(*mode); err != nil {
+		log.Fatal(err)
+	}
+	opts := mycrypto.FileOptions{ChunkSize: *chunk, Mmap: *mmap}
+	fmt.Printf("AES-%s, %d MB, chunk %d KB, mmap %v\n", *mode, *sizeMB, *chunk>>10, *mmap)
+	run("encrypt", func(o mycrypto.FileOptions) (int64, error) { return mc.EncryptFile(enc, plain, o) }, opts, size)
+	run("decrypt", func(o mycrypto.FileOptions) (int64, error) { return mc.DecryptFile(dec, enc, o) }, opts, size)
+
+	got, err := fileHash(dec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		log.Fatal("decrypted file does not match the original")
+	}
+	fmt.Println("round trip OK")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/mygames"
+)
+
+const trials = 1000
+
+func main() {
+	fixedIV := make([]byte, mycrypto.AESBlockSize)
+	schemes := []struct {
+		name string
+		new  func() (mygames.Scheme, error)
+	}{
+		{"ECB", mygames.NewCipherScheme(mycrypto.ModeECB, nil)},
+		{"CBC, fixed IV", mygames.NewCipherScheme(mycrypto.ModeCBC, fixedIV)},
+		{"CBC, random IV", mygames.NewCipherScheme(mycrypto.ModeCBC, nil)},
+		{"CTR, random IV", mygames.NewCipherScheme(mycrypto.ModeCTR, nil)},
+	}
+	games := []struct {
+		game mygames.Game
+		adv  mygames.Adversary
+	}{
+		{mygames.INDCPA, mygames.RepeatedBlocks{}},
+		{mygames.INDCPA, mygames.ReplayEncryption{}},
+		{mygames.INDCCA, mygames.FlipAndDecrypt{}},
+	}
+	fmt.Printf("%-16s %-8s %-18s %s\n", "Scheme", "Game", "Adversary", "Advantage")
+	for _, s := range schemes {
+		for _, g := range games {
+			res, err := mygames.Play(g.game, s.new, g.adv, trials)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%-16s %-8s %-18s %.3f\n", s.name, res.Game, res.Adversary, res.Advantage)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// Vector - эталонный вектор. Out - шифротекст без IV в том виде, в каком его выдаёт
+// "openssl enc" (для CBC - с дополнением PKCS7); для CTR IV - полный начальный блок счётчика.
+// Segment - размер сегмента CFB в битах (0 - полный блок).
+// Векторы есть только от OpenSSL (gen.sh) и NIST; векторов Python-библиотеки cryptography нет.
+type Vector struct {
+	Tool    string `json:"tool"`
+	Mode    string `json:"mode"`
+	Segment int    `json:"segment,omitempty"`
+	Key     string `json:"key"`
+	IV      string `json:"iv"`
+	Msg     string `json:"msg"`
+	Out     string `json:"out"`
+}
+
+// newCipher настраивает MyCipher по ключу, режиму и размеру сегмента вектора
+func newCipher(key []byte, v Vector) (*mycrypto.MyCipher, error) {
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(v.Mode); err != nil {
+		return nil, err
+	}
+	if v.Segment != 0 {
+		if err := mc.SetSegmentSize(v.Segment); err != nil {
+			return nil, err
+		}
+	}
+	return mc, nil
+}
+
+// check сверяет MyCipher с вектором в обе стороны: шифрование и расшифрование
+func check(v Vector) error {
+	key, err := mycrypto.ParseKey(v.Key)
+	if err != nil {
+		return err
+	}
+	iv, err := mycrypto.ParseIV(v.IV, v.Mode)
+	if err != nil {
+		return err
+	}
+	msg, err := mycrypto.ParseBytes(v.Msg)
+	if err != nil {
+		return err
+	}
+	want, err := mycrypto.ParseBytes(v.Out)
+	if err != nil {
+		return err
+	}
+	mc, err := newCipher(key, v)
+	if err != nil {
+		return err
+	}
+	ct, err := mc.Encrypt(msg, iv)
+	if err != nil {
+		return fmt.Errorf("encrypt: %v", err)
+	}
+	// MyCipher прикрепляет IV в начало шифротекста, а OpenSSL - нет
+	if !bytes.Equal(ct[:len(iv)], iv) {
+		return fmt.Errorf("encrypt: IV is not placed at the start of the ciphertext")
+	}
+	if got := ct[len(iv):]; !bytes.Equal(got, want) {
+		return fmt.Errorf("encrypt: got %x, want %x", got, want)
+	}
+	pt, err := mc.Decrypt(want, iv)
+	if err != nil {
+		return fmt.Errorf("decrypt: %v", err)
+	}
+	if !bytes.Equal(pt, msg) {
+		return fmt.Errorf("decrypt: got %x, want %x", pt, msg)
+	}
+	return nil
+}
+
+// golden шифрует сообщения векторов собственными случайными IV и возвращает
+// векторы в том же формате, чтобы их можно было проверить внешним инструментом
+func golden(vs []Vector) ([]Vector, error) {
+	var res []Vector
+	for _, v := range vs {
+		key, err := mycrypto.ParseKey(v.Key)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := mycrypto.ParseBytes(v.Msg)
+		if err != nil {
+			return nil, err
+		}
+		mc, err := newCipher(key, v)
+		if err != nil {
+			return nil, err
+		}
+		ct, err := mc.Encrypt(msg, nil)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Vector{
+			Tool:    "mycrypto",
+			Mode:    v.Mode,
+			Segment: v.Segment,
+			Key:     v.Key,
+			IV:      hex.EncodeToString(ct[:mycrypto.AESBlockSize]),
+			Msg:     v.Msg,
+			Out:     hex.EncodeToString(ct[mycrypto.AESBlockSize:]),
+		})
+	}
+	return res, nil
+}
+
+// segmentName возвращает суффикс размера сегмента для сообщений ("8" для CFB8)
+func segmentName(bits int) string {
+	if bits == 0 {
+		return ""
+	}
+	return fmt.Sprint(bits)
+}
+
+func main() {
+	path := flag.String("vectors", "testdata/interop/vectors.json", "reference vectors produced by testdata/interop/gen.sh")
+	out := flag.String("golden", "", "write package outputs to this file for testdata/interop/check.sh")
+	flag.Parse()
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var vs []Vector
+	if err := json.Unmarshal(data, &vs); err != nil {
+		log.Fatalf("cannot parse %s: %v", *path, err)
+	}
+	failed := 0
+	for i, v := range vs {
+		if err := check(v); err != nil {
+			failed++
+			fmt.Printf("FAIL #%d %s%s key=%d bits msg=%d bytes (%s): %v\n", i, v.Mode, segmentName(v.Segment), len(v.Key)*4, len(v.Msg)/2, v.Tool, err)
+		}
+	}
+	fmt.Printf("%d/%d vectors passed\n", len(vs)-failed, len(vs))
+
+	if *out != "" {
+		gs, err := golden(vs)
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err := json.MarshalIndent(gs, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*out, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("golden vectors written to %s\n", *out)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/mykdf"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	p.X.Scale = plot.LogScale{}
+	p.Y.Scale = plot.LogScale{}
+	p.X.Tick.Marker = plot.LogTicks{}
+	p.Y.Tick.Marker = plot.LogTicks{}
+	p.Legend.Top = true
+	p.Legend.Left = true
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+// measure возвращает среднее время runs вызовов f
+func measure(runs int, f func() error) time.Duration {
+	start := time.Now()
+	for i := 0; i < runs; i++ {
+		if err := f(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	return time.Since(start) / time.Duration(runs)
+}
+
+func main() {
+	maxMiB := flag.Int("max-mib", 256, "largest memory cost in MiB")
+	runs := flag.Int("runs", 3, "runs per point")
+	threads := flag.Int("threads", 4, "Argon2id lanes and scrypt p")
+	out := flag.String("out", "graphs/kdf_cost.png", "output plot")
+	flag.Parse()
+
+	password := []byte("correct horse battery staple")
+	salt, err := mykdf.NewSalt(mykdf.SaltSize)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// скорость PBKDF2: сколько итераций укладывается в то же время, что и точка memory-hard KDF
+	const pbkdfProbe = 100000
+	pbkdfTime := measure(*runs, func() error {
+		_, err := mykdf.PBKDF2(password, salt, pbkdfProbe, mycrypto.AESKeySize32)
+		return err
+	})
+	perIter := pbkdfTime / pbkdfProbe
+	fmt.Printf("PBKDF2-HMAC-SHA256: %v per iteration, %d iterations (OWASP) take %v\n",
+		perIter, mykdf.DefaultIterations, perIter*mykdf.DefaultIterations)
+
+	argonPts, scryptPts := plotter.XYs{}, plotter.XYs{}
+	for mib := 1; mib <= *maxMiB; mib *= 2 {
+		ap := mykdf.Argon2Params{Time: 3, Memory: uint32(mib) << 10, Threads: uint8(*threads), KeyLen: mycrypto.AESKeySize32}
+		at := measure(*runs, func() error {
+			_, err := mykdf.Argon2id(password, salt, ap)
+			return err
+		})
+		// память scrypt - 128*r*N на каждый из p параллельных ROMix
+		sp := mykdf.ScryptParams{N: mib << 20 / (128 * 8 * *threads), R: 8, P: *threads, KeyLen: mycrypto.AESKeySize32}
+		st := measure(*runs, func() error {
+			_, err := mykdf.Scrypt(password, salt, sp)
+			return err
+		})
+		fmt.Printf("%4d MiB: Argon2id t=3 %9v (= %8d PBKDF2 iterations), scrypt N=%-7d %9v (= %8d PBKDF2 iterations)\n",
+			mib, at.Round(time.Microsecond), at/perIter, sp.N, st.Round(time.Microsecond), st/perIter)
+		argonPts = append(argonPts, plotter.XY{X: float64(mib), Y: float64(at.Microseconds()) / 1000})
+		scryptPts = append(scryptPts, plotter.XY{X: float64(mib), Y: float64(st.Microseconds()) / 1000})
+	}
+	pbkdfMs := float64((perIter * mykdf.DefaultIterations).Microseconds()) / 1000
+	pbkdfPts := plotter.XYs{{X: float64(1), Y: pbkdfMs}, {X: float64(*maxMiB), Y: pbkdfMs}}
+	if err := plotResults("KDF cost: derivation time vs memory", "Memory (MiB)", "Time (ms)", *out,
+		fmt.Sprintf("Argon2id (t=3, p=%d)", *threads), argonPts,
+		fmt.Sprintf("scrypt (r=8, p=%d)", *threads), scryptPts,
+		fmt.Sprintf("PBKDF2, %d iterations (no memory)", mykdf.DefaultIterations), pbkdfPts); err != nil {
+		log.Fatal(err)
+	}
+}
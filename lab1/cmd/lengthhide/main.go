@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// Число сообщений и диапазон их длин (длины распределены логарифмически равномерно)
+const (
+	numMessages = 100000
+	minLen      = 16
+	maxLen      = 1 << 16
+)
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	p.X.Scale = plot.LogScale{}
+	p.X.Tick.Marker = plot.LogTicks{Prec: -1}
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+func main() {
+	policies := []struct {
+		name   string
+		policy mycrypto.BucketPolicy
+	}{
+		{"multiple of 256", mycrypto.BucketMultiple(256)},
+		{"multiple of 4096", mycrypto.BucketMultiple(4096)},
+		{"power of 2", mycrypto.BucketPow2(minLen)},
+		{"Padme", mycrypto.BucketPadme()},
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	lengths := make([]int, numMessages)
+	for i := range lengths {
+		lengths[i] = int(math.Exp(math.Log(minLen) + rng.Float64()*(math.Log(maxLen)-math.Log(minLen))))
+	}
+
+	// Средние издержки и число различимых длин шифротекста для каждой политики
+	fmt.Printf("%-17s %12s %16s %12s\n", "policy", "overhead, %", "distinct lengths", "leak, bits")
+	distinct := make(map[int]bool)
+	for _, n := range lengths {
+		distinct[n] = true
+	}
+	fmt.Printf("%-17s %12.2f %16d %12.2f\n", "none", 0.0, len(distinct), math.Log2(float64(len(distinct))))
+	series := []interface{}{}
+	for _, p := range policies {
+		var total, padded float64
+		distinct := make(map[int]bool)
+		for _, n := range lengths {
+			size := p.policy(n)
+			total += float64(n)
+			padded += float64(size)
+			distinct[size] = true
+		}
+		fmt.Printf("%-17s %12.2f %16d %12.2f\n", p.name, 100*(padded/total-1), len(distinct), math.Log2(float64(len(distinct))))
+
+		// Издержки в зависимости от длины сообщения
+		pts := make(plotter.XYs, 0)
+		for n := minLen; n <= maxLen; n = n*9/8 + 1 {
+			pts = append(pts, plotter.XY{X: float64(n), Y: 100 * (float64(p.policy(n))/float64(n) - 1)})
+		}
+		series = append(series, p.name, pts)
+	}
+	if err := plotResults("Length hiding overhead", "Message length (bytes)", "Overhead (%)", "graphs/length_hiding.png", series...); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/myqr"
+)
+
+const usage = `usage:
+  qr encode [-level M] [-scale 8] [-o file.png] text|-
+  qr decode file.png
+  qr seal -key K [-level M] [-scale 8] [-o file.png] message|-
+  qr open -key K file.png`
+
+// sealedPrefix помечает коды с сообщением, зашифрованным AES-GCM (base64 от nonce || ct || tag)
+const sealedPrefix = "sealed1:"
+
+func parseLevel(s string) myqr.Level {
+	i := strings.Index("LMQH", strings.ToUpper(s))
+	if len(s) != 1 || i < 0 {
+		log.Fatalf("unknown level %q, expected L, M, Q or H", s)
+	}
+	return myqr.Level(i)
+}
+
+// payload возвращает аргумент или стандартный ввод, если аргумент "-"
+func payload(fs *flag.FlagSet) []byte {
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if fs.Arg(0) == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return data
+	}
+	return []byte(fs.Arg(0))
+}
+
+// output пишет код в PNG-файл или, если файл не задан, рисует его в терминале
+func output(c *myqr.Code, file string, scale int) {
+	fmt.Fprintf(os.Stderr, "version %d-%s, mask %d, %dx%d modules\n", c.Version, c.Level, c.Mask, c.Size, c.Size)
+	if file == "" {
+		fmt.Print(c)
+		return
+	}
+	data, err := c.PNG(scale)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func decodeFile(path string) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		log.Fatalf("%s: %v", path, err)
+	}
+	data, err := myqr.Decode(img)
+	if err != nil {
+		log.Fatalf("%s: %v", path, err)
+	}
+	return data
+}
+
+func newGCM(key string) *mycrypto.MyCipher {
+	k, err := mycrypto.ParseKey(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(k); err != nil {
+		log.Fatal(err)
+	}
+	if err := mc.SetMode(mycrypto.ModeGCM); err != nil {
+		log.Fatal(err)
+	}
+	return mc
+}
+
+func main() {
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet(flag.Arg(0), flag.ExitOnError)
+	level := fs.String("level", "M", "error correction level (L, M, Q, H)")
+	scale := fs.Int("scale", 8, "pixels per module")
+	out := fs.String("o", "", "output PNG file (default: draw in the terminal)")
+	key := fs.String("key", "", "AES key for seal/open (hex:, base64:, file:, ...)")
+	fs.Parse(flag.Args()[1:])
+
+	switch flag.Arg(0) {
+	case "encode":
+		c, err := myqr.Encode(payload(fs), parseLevel(*level))
+		if err != nil {
+			log.Fatal(err)
+		}
+		output(c, *out, *scale)
+	case "decode":
+		if fs.NArg() != 1 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		os.Stdout.Write(decodeFile(fs.Arg(0)))
+		fmt.Println()
+	case "seal":
+		sealed, err := newGCM(*key).Encrypt(payload(fs), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		c, err := myqr.Encode([]byte(sealedPrefix+base64.StdEncoding.EncodeToString(sealed)), parseLevel(*level))
+		if err != nil {
+			log.Fatal(err)
+		}
+		output(c, *out, *scale)
+	case "open":
+		if fs.NArg() != 1 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		text, ok := strings.CutPrefix(string(decodeFile(fs.Arg(0))), sealedPrefix)
+		if !ok {
+			log.Fatalf("%s: not a sealed message", fs.Arg(0))
+		}
+		sealed, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			log.Fatal(err)
+		}
+		msg, err := newGCM(*key).Decrypt(sealed, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Stdout.Write(msg)
+		fmt.Println()
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sagilyp/lab1/myconfig"
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+const usage = `usage:
+  secretcfg encrypt -key K [-keys a.b,c | -match regexp] [-i] file.{json,yaml}
+  secretcfg decrypt -key K [-keys a.b,c | -match regexp] [-i] file.{json,yaml}
+  secretcfg show [-key K] file.{json,yaml}`
+
+// defaultMatch выбирает поля для шифрования, если -keys и -match не заданы
+const defaultMatch = `(?i)(password|passwd|secret|token|api_?key|private_?key)$`
+
+// selector строит регулярное выражение выбора полей по -keys (точные пути) или -match
+func selector(keys, match, fallback string) *regexp.Regexp {
+	if keys != "" && match != "" {
+		log.Fatal("use either -keys or -match")
+	}
+	if keys != "" {
+		var quoted []string
+		for _, k := range strings.Split(keys, ",") {
+			quoted = append(quoted, regexp.QuoteMeta(strings.TrimSpace(k)))
+		}
+		return regexp.MustCompile("^(" + strings.Join(quoted, "|") + ")$")
+	}
+	if match == "" {
+		match = fallback
+	}
+	if match == "" {
+		return nil
+	}
+	re, err := regexp.Compile(match)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return re
+}
+
+// writeBack записывает документ на место файла атомарно через временный файл
+func writeBack(path string, doc []byte) error {
+	mode := os.FileMode(0o600)
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, doc, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func main() {
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	verb := flag.Arg(0)
+	fs := flag.NewFlagSet(verb, flag.ExitOnError)
+	keyFlag := fs.String("key", "", "AES key (hex:, base64:, file:, ...)")
+	keys := fs.String("keys", "", "comma-separated field paths, e.g. db.password,api.token")
+	match := fs.String("match", "", "regexp over field paths (encrypt default: "+defaultMatch+")")
+	inPlace := fs.Bool("i", false, "rewrite the file in place instead of printing it")
+	fs.Parse(flag.Args()[1:])
+	if fs.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := fs.Arg(0)
+
+	var key []byte
+	if *keyFlag != "" {
+		var err error
+		if key, err = mycrypto.ParseKey(*keyFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if verb == "show" {
+		values, err := myconfig.Load(path, key)
+		if err != nil {
+			log.Fatal(err)
+		}
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s = %s\n", name, values[name])
+		}
+		return
+	}
+
+	format, err := myconfig.FormatFromPath(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var out []byte
+	var n int
+	switch verb {
+	case "encrypt":
+		out, n, err = myconfig.Encrypt(doc, format, key, selector(*keys, *match, defaultMatch))
+	case "decrypt":
+		out, n, err = myconfig.Decrypt(doc, format, key, selector(*keys, *match, ""))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", path, err)
+	}
+	if n == 0 {
+		fmt.Fprintf(os.Stderr, "%s: no fields to %s\n", path, verb)
+	}
+	if !*inPlace {
+		os.Stdout.Write(out)
+		return
+	}
+	if err := writeBack(path, out); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "%s: %sed %d fields\n", path, verb, n)
+}
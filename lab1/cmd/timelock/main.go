@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/sagilyp/lab1/mytimelock"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+func main() {
+	bits := flag.Int("bits", 1024, "RSA modulus size")
+	flag.Parse()
+
+	msg := []byte("open after the deadline")
+	var genPts, solvePts, provePts, verifyPts plotter.XYs
+	for _, t := range []uint64{50000, 100000, 250000, 500000, 1000000} {
+		start := time.Now()
+		pz, err := mytimelock.NewPuzzle(*bits, t, msg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		gen := time.Since(start)
+
+		start = time.Now()
+		got, y, err := pz.Solve()
+		if err != nil {
+			log.Fatal(err)
+		}
+		solve := time.Since(start)
+
+		start = time.Now()
+		pi := mytimelock.Prove(pz.N, pz.X, y, t)
+		prove := time.Since(start)
+
+		start = time.Now()
+		ok, err := mytimelock.Verify(pz.N, pz.X, y, pi, t)
+		if err != nil {
+			log.Fatal(err)
+		}
+		verify := time.Since(start)
+
+		// доказательство не подходит к другому значению y
+		forged := new(big.Int).Add(y, big.NewInt(1))
+		if bad, _ := mytimelock.Verify(pz.N, pz.X, forged, pi, t); bad {
+			log.Fatal("proof accepted for a wrong output")
+		}
+
+		fmt.Printf("T=%-7d gen %-12v solve %-12v prove %-12v verify %-12v proof ok: %v, message: %q\n",
+			t, gen, solve, prove, verify, ok, got)
+		x := float64(t)
+		genPts = append(genPts, plotter.XY{X: x, Y: gen.Seconds()})
+		solvePts = append(solvePts, plotter.XY{X: x, Y: solve.Seconds()})
+		provePts = append(provePts, plotter.XY{X: x, Y: prove.Seconds()})
+		verifyPts = append(verifyPts, plotter.XY{X: x, Y: verify.Seconds()})
+	}
+	if err := plotResults(fmt.Sprintf("RSW time-lock puzzle, %d-bit modulus", *bits), "Squarings T", "Time (s)",
+		"graphs/timelock.png",
+		"Generate (trapdoor)", genPts, "Solve", solvePts, "Prove (Wesolowski)", provePts, "Verify", verifyPts); err != nil {
+		log.Fatal(err)
+	}
+}
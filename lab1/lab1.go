@@ -1,21 +1,77 @@
 package main
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/des"
 	"crypto/rand"
 	"encoding/hex"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
 
+	"github.com/sagilyp/lab1/mybench"
+	"github.com/sagilyp/lab1/mycamellia"
 	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/mygost"
+	"github.com/sagilyp/lab1/mykdf"
+	"github.com/sagilyp/lab1/myrand"
+	"github.com/sagilyp/lab1/mysecret"
 )
 
+// setupRand подменяет mycrypto.Rand для записи или воспроизведения всех случайных выборок прогона
+func setupRand(recordFile, replayFile string) (func(), error) {
+	switch {
+	case recordFile != "" && replayFile != "":
+		return nil, fmt.Errorf("-record and -replay are mutually exclusive")
+	case recordFile != "":
+		f, err := os.Create(recordFile)
+		if err != nil {
+			return nil, err
+		}
+		mycrypto.Rand = myrand.NewRecorder(rand.Reader, f)
+		return func() { f.Close() }, nil
+	case replayFile != "":
+		f, err := os.Open(replayFile)
+		if err != nil {
+			return nil, err
+		}
+		mycrypto.Rand = myrand.NewReplayer(f)
+		return func() { f.Close() }, nil
+	}
+	return func() {}, nil
+}
+
 func main() {
+	recordFile := flag.String("record", "", "record all random draws to this file")
+	replayFile := flag.String("replay", "", "replay random draws from a file written by -record")
+	flag.Parse()
+	closeRand, err := setupRand(*recordFile, *replayFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeRand()
+
+	// Самопроверка по векторам NIST SP 800-38A до всех остальных примеров
+	fmt.Println("<<<--- NIST SP 800-38A self-test --->>>")
+	results := mycrypto.SelfTest()
+	if err := mycrypto.SelfTestError(results); err != nil {
+		for _, r := range results {
+			if !r.Passed() {
+				fmt.Println(r)
+			}
+		}
+		log.Fatal(err)
+	}
+	fmt.Printf("ECB, CBC, CFB1/8/128, OFB, CTR with 128/192/256-bit keys: %d known-answer tests passed\n", len(results))
+
 	// Валидация моей реализации CBC
-	fmt.Println("<<<---CBC Validation--->>>")
+	fmt.Println("\n<<<---CBC Validation--->>>")
 	plaintext := []byte("London Bridge is Down!")
-	key, err := hex.DecodeString("140b41b22a29beb4061bda66b6747e14")
+	key, err := mycrypto.ParseKey("140b41b22a29beb4061bda66b6747e14")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -24,7 +80,7 @@ func main() {
 		log.Fatal(err)
 	}
 	iv := make([]byte, aesBlock.BlockSize())
-	if _, err := rand.Read(iv); err != nil {
+	if _, err := mycrypto.Rand.Read(iv); err != nil {
 		log.Fatal(err)
 	}
 	padded := mycrypto.Pkcs7Pad(plaintext, aesBlock.BlockSize())
@@ -48,7 +104,10 @@ func main() {
 
 	// Расшифровка шифротекстов в режиме CTR и CBC
 	fmt.Printf("\nCBC Decryption Test:\n")
-	cbcKey, _ := hex.DecodeString("140b41b22a29beb4061bda66b6747e14")
+	cbcKey, err := mycrypto.ParseKey("140b41b22a29beb4061bda66b6747e14")
+	if err != nil {
+		log.Fatal(err)
+	}
 	cbcCiphertext1, _ := hex.DecodeString("4ca00ff4c898d61e1edbf1800618fb2828a226d160dad07883d04e008a7897ee2e4b7465d5290d0c0e6c6822236e1daafb94ffe0c5da05d9476be028ad7c1d81")
 	cbcCiphertext2, _ := hex.DecodeString("5b68629feb8606f9a6667670b75b38a5b4832d0f26e1ab7da33249de7d4afc48e713ac646ace36e872ad5fb8a512428a6e21364b0c374df45503473c5242a253")
 	cipherCBC := &mycrypto.MyCipher{}
@@ -71,7 +130,10 @@ func main() {
 	fmt.Println("CBC Decrypted:", string(cbcPlaintext2))
 
 	fmt.Printf("\nCTR Decryption Test:\n")
-	ctrKey, _ := hex.DecodeString("36f18357be4dbd77f050515c73fcf9f2")
+	ctrKey, err := mycrypto.ParseKey("36f18357be4dbd77f050515c73fcf9f2")
+	if err != nil {
+		log.Fatal(err)
+	}
 	ctrCiphertext1, _ := hex.DecodeString("69dda8455c7dd4254bf353b773304eec0ec7702330098ce7f7520d1cbbb20fc388d1b0adb5054dbd7370849dbf0b88d393f252e764f1f5f7ad97ef79d59ce29f5f51eeca32eabedd9afa9329")
 	ctrCiphertext2, _ := hex.DecodeString("770b80259ec33beb2561358a9f2dc617e46218c0a53cbeca695ae45faa8952aa0e311bde9d4e01726d3184c34451")
 	cipherCTR := &mycrypto.MyCipher{}
@@ -102,9 +164,12 @@ func main() {
 		mc := &mycrypto.MyCipher{}
 		var key []byte
 		if mode == mycrypto.ModeCTR {
-			key, _ = hex.DecodeString("36f18357be4dbd77f050515c73fcf9f2")
+			key, err = mycrypto.ParseKey("36f18357be4dbd77f050515c73fcf9f2")
 		} else {
-			key, _ = hex.DecodeString("140b41b22a29beb4061bda66b6747e14")
+			key, err = mycrypto.ParseKey("base64:FAtBsiopvrQGG9pmtnR+FA==")
+		}
+		if err != nil {
+			log.Fatal(err)
 		}
 		if err := mc.SetKey(key); err != nil {
 			log.Fatal(err)
@@ -125,4 +190,304 @@ func main() {
 		fmt.Printf("Original: %s\n", secretText)
 		fmt.Printf("Decrypted: %s\n", plainText)
 	}
+
+	// Шифрование конвейером io.Writer/io.Reader: сообщение целиком в памяти не нужно
+	fmt.Println("\n<<<--- io.Writer/io.Reader streaming --->>>")
+	for _, mode := range []string{mycrypto.ModeCBC, mycrypto.ModeCTR} {
+		mc := &mycrypto.MyCipher{}
+		if err := mc.SetKey(key); err != nil {
+			log.Fatal(err)
+		}
+		if err := mc.SetMode(mode); err != nil {
+			log.Fatal(err)
+		}
+		src := bytes.Repeat([]byte(secretText), 1000)
+		var sealed bytes.Buffer
+		w, err := mc.NewEncryptWriter(&sealed)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := io.Copy(w, bytes.NewReader(src)); err != nil {
+			log.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			log.Fatal(err)
+		}
+		r, err := mc.NewDecryptReader(&sealed)
+		if err != nil {
+			log.Fatal(err)
+		}
+		plain, err := io.ReadAll(r)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %d bytes piped, round trip ok: %v\n", mode, len(src), bytes.Equal(plain, src))
+	}
+
+	// Аутентифицированное шифрование на дуплексной губке Keccak
+	fmt.Println("\n<<<--- Duplex sponge AEAD --->>>")
+	spongeKey := make([]byte, mycrypto.AESKeySize16)
+	spongeNonce := make([]byte, mycrypto.DuplexNonceSize)
+	if _, err := mycrypto.Rand.Read(spongeKey); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := mycrypto.Rand.Read(spongeNonce); err != nil {
+		log.Fatal(err)
+	}
+	aead, err := mycrypto.NewDuplexAEAD(spongeKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	header := []byte("lab1 header")
+	sealed, err := aead.Seal(spongeNonce, []byte(secretText), header)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opened, err := aead.Open(spongeNonce, sealed, header)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Sealed: %s\n", hex.EncodeToString(sealed))
+	fmt.Printf("Opened: %s\n", opened)
+	sealed[0] ^= 0x01
+	if _, err := aead.Open(spongeNonce, sealed, header); err != nil {
+		fmt.Println("Tampered ciphertext rejected:", err)
+	}
+
+	// CBC с кражей шифротекста: шифротекст не длиннее сообщения (плюс IV)
+	fmt.Println("\n<<<--- CBC ciphertext stealing (CS3) --->>>")
+	cts := &mycrypto.MyCipher{}
+	if err := cts.SetKey(spongeKey); err != nil {
+		log.Fatal(err)
+	}
+	if err := cts.SetMode(mycrypto.ModeCTS); err != nil {
+		log.Fatal(err)
+	}
+	ctsSealed, err := cts.Encrypt([]byte(secretText), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctsOpened, err := cts.Decrypt(ctsSealed, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Message %d bytes, IV || ciphertext %d bytes\n", len(secretText), len(ctsSealed))
+	fmt.Printf("Decrypted: %s\n", ctsOpened)
+
+	// Аутентифицированный режим GCM: заголовок защищён тегом, но не шифруется
+	fmt.Println("\n<<<--- AES-GCM --->>>")
+	gcm := &mycrypto.MyCipher{}
+	if err := gcm.SetKey(spongeKey); err != nil {
+		log.Fatal(err)
+	}
+	if err := gcm.SetMode(mycrypto.ModeGCM); err != nil {
+		log.Fatal(err)
+	}
+	gcm.SetAAD(header)
+	gcmSealed, err := gcm.Encrypt([]byte(secretText), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	gcmOpened, err := gcm.Decrypt(gcmSealed, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Sealed: %s\n", hex.EncodeToString(gcmSealed))
+	fmt.Printf("Opened: %s\n", gcmOpened)
+	gcm.SetAAD([]byte("another header"))
+	if _, err := gcm.Decrypt(gcmSealed, nil); err != nil {
+		fmt.Println("Modified header rejected:", err)
+	}
+
+	// Обёртывание ключа GCM ключом шифрования ключей (RFC 3394)
+	fmt.Println("\n<<<--- AES Key Wrap --->>>")
+	kek := make([]byte, mycrypto.AESKeySize32)
+	if _, err := mycrypto.Rand.Read(kek); err != nil {
+		log.Fatal(err)
+	}
+	wrapped, err := mycrypto.WrapKey(kek, spongeKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	unwrapped, err := mycrypto.UnwrapKey(kek, wrapped)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrapped key: %s\n", hex.EncodeToString(wrapped))
+	fmt.Printf("Unwrapped key matches: %v\n", bytes.Equal(unwrapped, spongeKey))
+	wrapped[0] ^= 0x01
+	if _, err := mycrypto.UnwrapKey(kek, wrapped); err != nil {
+		fmt.Println("Tampered wrapped key rejected:", err)
+	}
+
+	// Режимы поверх другого блочного шифра: 3DES с 64-битным блоком
+	fmt.Println("\n<<<--- Pluggable block cipher: 3DES --->>>")
+	desKey := make([]byte, 24)
+	if _, err := rand.Read(desKey); err != nil {
+		log.Fatal(err)
+	}
+	tdes, err := des.NewTripleDESCipher(desKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, mode := range []string{mycrypto.ModeCBC, mycrypto.ModeCTR, mycrypto.ModeCTS, mycrypto.ModeGCM} {
+		mc := &mycrypto.MyCipher{}
+		if err := mc.SetBlockCipher(tdes); err != nil {
+			log.Fatal(err)
+		}
+		if err := mc.SetMode(mode); err != nil {
+			log.Fatal(err)
+		}
+		ct, err := mc.Encrypt([]byte(secretText), nil)
+		if err != nil {
+			fmt.Printf("%s: %v\n", mode, err)
+			continue
+		}
+		pt, err := mc.Decrypt(ct, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %d-byte ciphertext, round trip ok: %v\n", mode, len(ct), string(pt) == secretText)
+	}
+
+	// ГОСТ Р 34.12-2015: контрольные примеры и режимы ГОСТ Р 34.13-2015 поверх MyCipher
+	fmt.Println("\n<<<--- GOST Kuznyechik and Magma --->>>")
+	kuzKey, _ := hex.DecodeString("8899aabbccddeeff0011223344556677fedcba98765432100123456789abcdef")
+	kuzPT, _ := hex.DecodeString("1122334455667700ffeeddccbbaa9988")
+	kuz, err := mygost.NewKuznyechik(kuzKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	kuzCT := make([]byte, mygost.KuznyechikBlockSize)
+	kuz.Encrypt(kuzCT, kuzPT)
+	fmt.Printf("Kuznyechik: %x (expected 7f679d90bebc24305a468d42b9d4edcd)\n", kuzCT)
+	magmaKey, _ := hex.DecodeString("ffeeddccbbaa99887766554433221100f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	magmaPT, _ := hex.DecodeString("fedcba9876543210")
+	magma, err := mygost.NewMagma(magmaKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	magmaCT := make([]byte, mygost.MagmaBlockSize)
+	magma.Encrypt(magmaCT, magmaPT)
+	fmt.Printf("Magma:      %x (expected 4ee901e5c2d8ca3d)\n", magmaCT)
+	for _, b := range []mycrypto.BlockCipher{kuz, magma} {
+		mc := &mycrypto.MyCipher{}
+		if err := mc.SetBlockCipher(b); err != nil {
+			log.Fatal(err)
+		}
+		if err := mc.SetMode(mycrypto.ModeCTR); err != nil {
+			log.Fatal(err)
+		}
+		ctr, err := mygost.CounterBlock(b, make([]byte, b.BlockSize()/2))
+		if err != nil {
+			log.Fatal(err)
+		}
+		ct, err := mc.Encrypt([]byte(secretText), ctr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tag, err := mygost.MAC(b, []byte(secretText), b.BlockSize()/2)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d-bit block: CTR %x..., MAC %x\n", b.BlockSize()*8, ct[len(ctr):len(ctr)+8], tag)
+	}
+
+	// Camellia (RFC 3713): контрольный пример и GCM поверх MyCipher; сравнение скорости с AES - cmd/ciphercmp
+	fmt.Println("\n<<<--- Camellia --->>>")
+	camKey, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	cam, err := mycamellia.NewCipher(camKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	camCT := make([]byte, mycamellia.BlockSize)
+	cam.Encrypt(camCT, camKey)
+	fmt.Printf("Camellia-128: %x (expected 67673138549669730857065648eabe43)\n", camCT)
+	camMC := &mycrypto.MyCipher{}
+	if err := camMC.SetBlockCipher(cam); err != nil {
+		log.Fatal(err)
+	}
+	if err := camMC.SetMode(mycrypto.ModeGCM); err != nil {
+		log.Fatal(err)
+	}
+	camSealed, err := camMC.Encrypt([]byte(secretText), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	camOpened, err := camMC.Decrypt(camSealed, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Camellia-GCM round trip ok: %v\n", string(camOpened) == secretText)
+
+	// Хранилище секретов: в памяти лежат только шифротексты GCM
+	fmt.Println("\n<<<--- Encrypted secret cache --->>>")
+	cache, err := mysecret.NewSecretCache()
+	if err != nil {
+		log.Fatal(err)
+	}
+	secret := []byte("database password: hunter2")
+	if err := cache.Put("db", secret, true); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Caller copy after Put is wiped: %v\n", bytes.Equal(secret, make([]byte, len(secret))))
+	var leaked []byte
+	err = cache.With("db", func(value []byte) error {
+		fmt.Printf("Inside With: %s\n", value)
+		leaked = value // ссылка сохранена только для демонстрации затирания
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Slice after With is wiped: %v\n", bytes.Equal(leaked, make([]byte, len(leaked))))
+	cache.Close()
+
+	// Ключ AES из пароля; контрольные примеры KDF - в тестах mykdf, сравнение стоимости - cmd/kdfbench
+	fmt.Println("\n<<<--- Key derivation --->>>")
+	pwKey, pwSalt, err := mykdf.PasswordKey([]byte("correct horse battery staple"), mycrypto.AESKeySize16, mykdf.DefaultIterations)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pwMC := &mycrypto.MyCipher{}
+	if err := pwMC.SetKey(pwKey); err != nil {
+		log.Fatal(err)
+	}
+	if err := pwMC.SetMode(mycrypto.ModeGCM); err != nil {
+		log.Fatal(err)
+	}
+	pwSealed, err := pwMC.Encrypt([]byte(secretText), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Salt %x, %d iterations, GCM ciphertext %x...\n", pwSalt, mykdf.DefaultIterations, pwSealed[:16])
+
+	// Сравнение скорости AES-режимов и дуплексной губки на сообщении в 1 МБ
+	fmt.Println("\n<<<--- Throughput, 1 MB message --->>>")
+	const bigMsgSize = 1 << 20
+	for _, mode := range append(modes, mycrypto.ModeGCM) {
+		mc := &mycrypto.MyCipher{}
+		if err := mc.SetKey(spongeKey); err != nil {
+			log.Fatal(err)
+		}
+		if err := mc.SetMode(mode); err != nil {
+			log.Fatal(err)
+		}
+		res, err := mybench.DefaultConfig.Run("AES-"+mode, bigMsgSize, func(msg []byte) error {
+			_, err := mc.Encrypt(msg, nil)
+			return err
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %8.2f MB/s, %.4g allocs/op, %.4g B/op\n", res.Name, res.MBPerSec, res.AllocsPerOp, res.BytesPerOp)
+	}
+	res, err := mybench.DefaultConfig.Run("Duplex", bigMsgSize, func(msg []byte) error {
+		_, err := aead.Seal(spongeNonce, msg, nil)
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %8.2f MB/s, %.4g allocs/op, %.4g B/op\n", res.Name, res.MBPerSec, res.AllocsPerOp, res.BytesPerOp)
 }
@@ -0,0 +1,250 @@
+package myaes
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// Программная табличная реализация AES (T-таблицы), как в классических реализациях на C.
+// Она медленнее и, главное, НЕ константна по времени: индексы обращений к таблицам
+// зависят от ключа, что и демонстрируют атаки по кэшу. Обращения к таблицам можно
+// отслеживать через поле Trace.
+
+const BlockSize = 16
+
+// Номера таблиц, передаваемые в Trace: 0..3 - T-таблицы раундов, TableSbox - S-блок последнего раунда
+const (
+	TableT0 = iota
+	TableT1
+	TableT2
+	TableT3
+	TableSbox
+)
+
+var (
+	sbox    [256]byte
+	invSbox [256]byte
+	te      [4][256]uint32
+)
+
+// gfMul умножает два элемента GF(2^8) по модулю x^8 + x^4 + x^3 + x + 1
+func gfMul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// init строит S-блок (обращение в GF(2^8) + аффинное преобразование) и T-таблицы
+func init() {
+	for i := 0; i < 256; i++ {
+		var inv byte
+		if i != 0 {
+			for j := 1; j < 256; j++ {
+				if gfMul(byte(i), byte(j)) == 1 {
+					inv = byte(j)
+					break
+				}
+			}
+		}
+		s := inv ^ rotl8(inv, 1) ^ rotl8(inv, 2) ^ rotl8(inv, 3) ^ rotl8(inv, 4) ^ 0x63
+		sbox[i] = s
+		invSbox[s] = byte(i)
+	}
+	for i := 0; i < 256; i++ {
+		s := sbox[i]
+		w := uint32(gfMul(s, 2))<<24 | uint32(s)<<16 | uint32(s)<<8 | uint32(gfMul(s, 3))
+		for t := 0; t < 4; t++ {
+			te[t][i] = w>>(8*uint(t)) | w<<(32-8*uint(t))
+		}
+	}
+}
+
+func rotl8(x byte, n uint) byte {
+	return x<<n | x>>(8-n)
+}
+
+// Cipher - табличный AES, реализующий интерфейс cipher.Block
+type Cipher struct {
+	rk []uint32 // раундовые ключи
+	nr int      // число раундов
+
+	// Trace, если задан, вызывается при каждом обращении к таблице (номер таблицы и индекс)
+	Trace func(table int, index byte)
+	// Fault, если задан, вызывается перед каждым раундом 1..nr с текущим состоянием;
+	// изменения state попадают в дальнейшие вычисления (моделирование внесения сбоев)
+	Fault func(round int, state []byte)
+}
+
+// NewCipher создаёт шифр для ключа длиной 16, 24 или 32 байта
+func NewCipher(key []byte) (*Cipher, error) {
+	nk := len(key) / 4
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("myaes: invalid key length %d", len(key))
+	}
+	nr := nk + 6
+	rk := make([]uint32, 4*(nr+1))
+	for i := 0; i < nk; i++ {
+		rk[i] = binary.BigEndian.Uint32(key[4*i:])
+	}
+	rcon := byte(1)
+	for i := nk; i < len(rk); i++ {
+		t := rk[i-1]
+		if i%nk == 0 {
+			t = subWord(t<<8|t>>24) ^ uint32(rcon)<<24
+			rcon = gfMul(rcon, 2)
+		} else if nk > 6 && i%nk == 4 {
+			t = subWord(t)
+		}
+		rk[i] = rk[i-nk] ^ t
+	}
+	return &Cipher{rk: rk, nr: nr}, nil
+}
+
+func subWord(w uint32) uint32 {
+	mycrypto.AuditPoint("myaes.NewCipher/Sbox")
+	return uint32(sbox[w>>24])<<24 | uint32(sbox[w>>16&0xff])<<16 | uint32(sbox[w>>8&0xff])<<8 | uint32(sbox[w&0xff])
+}
+
+// BlockSize возвращает размер блока AES
+func (c *Cipher) BlockSize() int { return BlockSize }
+
+// lookup выполняет обращение к T-таблице с уведомлением трассировщика
+func (c *Cipher) lookup(table int, idx byte) uint32 {
+	if c.Trace != nil {
+		c.Trace(table, idx)
+	}
+	mycrypto.AuditPoint("myaes.Encrypt/Ttable")
+	return te[table][idx]
+}
+
+// sub выполняет обращение к S-блоку последнего раунда
+func (c *Cipher) sub(idx byte) uint32 {
+	if c.Trace != nil {
+		c.Trace(TableSbox, idx)
+	}
+	mycrypto.AuditPoint("myaes.Encrypt/Sbox")
+	return uint32(sbox[idx])
+}
+
+// Encrypt шифрует один блок src в dst
+func (c *Cipher) Encrypt(dst, src []byte) {
+	if len(src) < BlockSize || len(dst) < BlockSize {
+		panic("myaes: input not full block")
+	}
+	rk := c.rk
+	s0 := binary.BigEndian.Uint32(src[0:]) ^ rk[0]
+	s1 := binary.BigEndian.Uint32(src[4:]) ^ rk[1]
+	s2 := binary.BigEndian.Uint32(src[8:]) ^ rk[2]
+	s3 := binary.BigEndian.Uint32(src[12:]) ^ rk[3]
+	k := 4
+	for r := 1; r < c.nr; r++ {
+		if c.Fault != nil {
+			s0, s1, s2, s3 = c.inject(r, s0, s1, s2, s3)
+		}
+		t0 := c.lookup(TableT0, byte(s0>>24)) ^ c.lookup(TableT1, byte(s1>>16)) ^ c.lookup(TableT2, byte(s2>>8)) ^ c.lookup(TableT3, byte(s3)) ^ rk[k]
+		t1 := c.lookup(TableT0, byte(s1>>24)) ^ c.lookup(TableT1, byte(s2>>16)) ^ c.lookup(TableT2, byte(s3>>8)) ^ c.lookup(TableT3, byte(s0)) ^ rk[k+1]
+		t2 := c.lookup(TableT0, byte(s2>>24)) ^ c.lookup(TableT1, byte(s3>>16)) ^ c.lookup(TableT2, byte(s0>>8)) ^ c.lookup(TableT3, byte(s1)) ^ rk[k+2]
+		t3 := c.lookup(TableT0, byte(s3>>24)) ^ c.lookup(TableT1, byte(s0>>16)) ^ c.lookup(TableT2, byte(s1>>8)) ^ c.lookup(TableT3, byte(s2)) ^ rk[k+3]
+		s0, s1, s2, s3 = t0, t1, t2, t3
+		k += 4
+	}
+	// последний раунд без MixColumns
+	if c.Fault != nil {
+		s0, s1, s2, s3 = c.inject(c.nr, s0, s1, s2, s3)
+	}
+	t0 := c.sub(byte(s0>>24))<<24 | c.sub(byte(s1>>16))<<16 | c.sub(byte(s2>>8))<<8 | c.sub(byte(s3))
+	t1 := c.sub(byte(s1>>24))<<24 | c.sub(byte(s2>>16))<<16 | c.sub(byte(s3>>8))<<8 | c.sub(byte(s0))
+	t2 := c.sub(byte(s2>>24))<<24 | c.sub(byte(s3>>16))<<16 | c.sub(byte(s0>>8))<<8 | c.sub(byte(s1))
+	t3 := c.sub(byte(s3>>24))<<24 | c.sub(byte(s0>>16))<<16 | c.sub(byte(s1>>8))<<8 | c.sub(byte(s2))
+	binary.BigEndian.PutUint32(dst[0:], t0^rk[k])
+	binary.BigEndian.PutUint32(dst[4:], t1^rk[k+1])
+	binary.BigEndian.PutUint32(dst[8:], t2^rk[k+2])
+	binary.BigEndian.PutUint32(dst[12:], t3^rk[k+3])
+}
+
+// inject передаёт состояние в обработчик Fault и возвращает, возможно, изменённые слова
+func (c *Cipher) inject(round int, s0, s1, s2, s3 uint32) (uint32, uint32, uint32, uint32) {
+	var st [BlockSize]byte
+	binary.BigEndian.PutUint32(st[0:], s0)
+	binary.BigEndian.PutUint32(st[4:], s1)
+	binary.BigEndian.PutUint32(st[8:], s2)
+	binary.BigEndian.PutUint32(st[12:], s3)
+	c.Fault(round, st[:])
+	return binary.BigEndian.Uint32(st[0:]), binary.BigEndian.Uint32(st[4:]),
+		binary.BigEndian.Uint32(st[8:]), binary.BigEndian.Uint32(st[12:])
+}
+
+// Rounds возвращает число раундов шифра
+func (c *Cipher) Rounds() int { return c.nr }
+
+// Sbox возвращает значение S-блока AES
+func Sbox(b byte) byte { return sbox[b] }
+
+// InvSbox возвращает значение обратного S-блока AES
+func InvSbox(b byte) byte { return invSbox[b] }
+
+// Decrypt расшифровывает один блок src в dst (побайтовая обратная схема, без трассировки)
+func (c *Cipher) Decrypt(dst, src []byte) {
+	if len(src) < BlockSize || len(dst) < BlockSize {
+		panic("myaes: input not full block")
+	}
+	var st [BlockSize]byte
+	copy(st[:], src)
+	addRoundKey(&st, c.rk[4*c.nr:])
+	for r := c.nr - 1; r >= 0; r-- {
+		invShiftRows(&st)
+		mycrypto.AuditPoint("myaes.Decrypt/InvSbox")
+		for i := range st {
+			st[i] = invSbox[st[i]]
+		}
+		addRoundKey(&st, c.rk[4*r:])
+		if r > 0 {
+			invMixColumns(&st)
+		}
+	}
+	copy(dst, st[:])
+}
+
+func addRoundKey(st *[BlockSize]byte, rk []uint32) {
+	for i := 0; i < 4; i++ {
+		st[4*i] ^= byte(rk[i] >> 24)
+		st[4*i+1] ^= byte(rk[i] >> 16)
+		st[4*i+2] ^= byte(rk[i] >> 8)
+		st[4*i+3] ^= byte(rk[i])
+	}
+}
+
+// invShiftRows сдвигает строку r состояния (байты r, r+4, r+8, r+12) вправо на r позиций
+func invShiftRows(st *[BlockSize]byte) {
+	var tmp [BlockSize]byte
+	for c := 0; c < 4; c++ {
+		for r := 0; r < 4; r++ {
+			tmp[4*((c+r)%4)+r] = st[4*c+r]
+		}
+	}
+	*st = tmp
+}
+
+func invMixColumns(st *[BlockSize]byte) {
+	for c := 0; c < 4; c++ {
+		a0, a1, a2, a3 := st[4*c], st[4*c+1], st[4*c+2], st[4*c+3]
+		st[4*c] = gfMul(a0, 14) ^ gfMul(a1, 11) ^ gfMul(a2, 13) ^ gfMul(a3, 9)
+		st[4*c+1] = gfMul(a0, 9) ^ gfMul(a1, 14) ^ gfMul(a2, 11) ^ gfMul(a3, 13)
+		st[4*c+2] = gfMul(a0, 13) ^ gfMul(a1, 9) ^ gfMul(a2, 14) ^ gfMul(a3, 11)
+		st[4*c+3] = gfMul(a0, 11) ^ gfMul(a1, 13) ^ gfMul(a2, 9) ^ gfMul(a3, 14)
+	}
+}
@@ -0,0 +1,53 @@
+package myanalysis
+
+// ----- Обнаружение режима ECB по повторяющимся блокам -----
+
+// ECBScore - результат поиска одинаковых блоков шифротекста.
+// У случайных 16-байтовых блоков совпадения практически невозможны (вероятность порядка n^2/2^129),
+// поэтому даже один повтор в шифротексте - сильный признак ECB: одинаковые блоки открытого текста
+// дают одинаковые блоки шифротекста.
+type ECBScore struct {
+	Offset   int     // сдвиг начала блоков, при котором повторов больше всего
+	Blocks   int     // число полных блоков при этом сдвиге
+	Repeated int     // блоков, совпадающих с каким-либо более ранним
+	Ratio    float64 // Repeated / Blocks
+}
+
+// LikelyECB сообщает, найден ли хотя бы один повторяющийся блок
+func (s ECBScore) LikelyECB() bool {
+	return s.Repeated > 0
+}
+
+// repeatedBlocks считает повторы среди полных блоков data, начиная с offset
+func repeatedBlocks(data []byte, blockSize, offset int) (blocks, repeated int) {
+	seen := make(map[string]bool)
+	for i := offset; i+blockSize <= len(data); i += blockSize {
+		b := string(data[i : i+blockSize])
+		if seen[b] {
+			repeated++
+		}
+		seen[b] = true
+		blocks++
+	}
+	return blocks, repeated
+}
+
+// DetectECB оценивает, зашифрованы ли data в режиме ECB с блоком blockSize.
+// Перебираются все сдвиги от 0 до blockSize-1, так что заголовок или IV произвольной длины
+// перед шифротекстом не мешает обнаружению.
+func DetectECB(data []byte, blockSize int) ECBScore {
+	var best ECBScore
+	if blockSize <= 0 {
+		return best
+	}
+	for off := 0; off < blockSize && off+blockSize <= len(data); off++ {
+		blocks, repeated := repeatedBlocks(data, blockSize, off)
+		if off == 0 || repeated > best.Repeated {
+			best = ECBScore{Offset: off, Blocks: blocks, Repeated: repeated}
+		}
+	}
+	if best.Blocks > 0 {
+		best.Ratio = float64(best.Repeated) / float64(best.Blocks)
+	}
+	return best
+}
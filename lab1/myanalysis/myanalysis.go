@@ -0,0 +1,45 @@
+package myanalysis
+
+import "errors"
+
+// FlipBit возвращает копию data с инвертированным битом номер bit (нумерация с 0, старший бит байта первый)
+func FlipBit(data []byte, bit int) ([]byte, error) {
+	if bit < 0 || bit >= len(data)*8 {
+		return nil, errors.New("FlipBit: bit index out of range")
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	out[bit/8] ^= 0x80 >> uint(bit%8)
+	return out, nil
+}
+
+// CorruptedBytes считает, сколько байтов got отличаются от want.
+// Недостающие или лишние байты в got тоже считаются испорченными.
+func CorruptedBytes(want, got []byte) int {
+	n := 0
+	for i := range want {
+		if i >= len(got) || want[i] != got[i] {
+			n++
+		}
+	}
+	if len(got) > len(want) {
+		n += len(got) - len(want)
+	}
+	return n
+}
+
+// BlockDiff возвращает число отличающихся байтов в каждом блоке размера blockSize
+func BlockDiff(want, got []byte, blockSize int) []int {
+	length := len(want)
+	if len(got) > length {
+		length = len(got)
+	}
+	blocks := (length + blockSize - 1) / blockSize
+	diff := make([]int, blocks)
+	for i := 0; i < length; i++ {
+		if i >= len(want) || i >= len(got) || want[i] != got[i] {
+			diff[i/blockSize]++
+		}
+	}
+	return diff
+}
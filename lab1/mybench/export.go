@@ -0,0 +1,143 @@
+package mybench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----- Выгрузка результатов в CSV и JSON -----
+
+// csvHeader - столбцы CSV; в JSON у записи результата те же имена полей
+var csvHeader = []string{"algorithm", "size_bytes", "runs", "ns_per_op", "mb_per_sec", "allocs_per_op", "bytes_per_op",
+	"median_ns", "stddev_ns", "ci_low_ns", "ci_high_ns", "confidence", "outliers"}
+
+// jsonResult - запись результата в JSON
+type jsonResult struct {
+	Algorithm   string  `json:"algorithm"`
+	SizeBytes   int     `json:"size_bytes"`
+	Runs        int     `json:"runs"`
+	NsPerOp     int64   `json:"ns_per_op"`
+	MBPerSec    float64 `json:"mb_per_sec"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+	MedianNs    int64   `json:"median_ns"`
+	StdDevNs    int64   `json:"stddev_ns"`
+	CILowNs     int64   `json:"ci_low_ns"`
+	CIHighNs    int64   `json:"ci_high_ns"`
+	Confidence  float64 `json:"confidence"`
+	Outliers    int     `json:"outliers"`
+}
+
+// MarshalJSON записывает результат с полями csvHeader: время - в наносекундах на прогон
+func (r Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonResult{r.Name, r.Size, r.Runs, r.PerOp.Nanoseconds(), r.MBPerSec, r.AllocsPerOp, r.BytesPerOp,
+		r.Median.Nanoseconds(), r.StdDev.Nanoseconds(), r.CILow.Nanoseconds(), r.CIHigh.Nanoseconds(), r.Confidence, r.Outliers})
+}
+
+// UnmarshalJSON читает результат, записанный MarshalJSON; Total восстанавливается как PerOp * Runs
+// (при отброшенных выбросах - приближённо)
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var j jsonResult
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*r = Result{Name: j.Algorithm, Size: j.SizeBytes, Runs: j.Runs, PerOp: time.Duration(j.NsPerOp),
+		Total: time.Duration(j.NsPerOp) * time.Duration(j.Runs), MBPerSec: j.MBPerSec, AllocsPerOp: j.AllocsPerOp, BytesPerOp: j.BytesPerOp,
+		Median: time.Duration(j.MedianNs), StdDev: time.Duration(j.StdDevNs), CILow: time.Duration(j.CILowNs),
+		CIHigh: time.Duration(j.CIHighNs), Confidence: j.Confidence, Outliers: j.Outliers}
+	return nil
+}
+
+// Report - результаты вместе с окружением, в котором они получены: по нему прогоны
+// на разных коммитах и машинах можно сравнивать между собой
+type Report struct {
+	Commit    string    `json:"commit,omitempty"`
+	Dirty     bool      `json:"dirty,omitempty"` // были незафиксированные изменения
+	Time      time.Time `json:"time"`
+	GoVersion string    `json:"go_version"`
+	GOOS      string    `json:"goos"`
+	GOARCH    string    `json:"goarch"`
+	CPUs      int       `json:"cpus"`
+	Results   []Result  `json:"results"`
+}
+
+// NewReport описывает окружение текущего процесса; коммит берётся из git в рабочем каталоге,
+// вне репозитория он остаётся пустым
+func NewReport(results []Result) Report {
+	r := Report{Time: time.Now().UTC(), GoVersion: runtime.Version(), GOOS: runtime.GOOS, GOARCH: runtime.GOARCH,
+		CPUs: runtime.NumCPU(), Results: results}
+	if out, err := exec.Command("git", "rev-parse", "HEAD").Output(); err == nil {
+		r.Commit = strings.TrimSpace(string(out))
+		status, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output()
+		r.Dirty = err == nil && len(strings.TrimSpace(string(status))) > 0
+	}
+	return r
+}
+
+// WriteCSV записывает результаты таблицей со столбцами csvHeader, по строке на результат
+func WriteCSV(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range results {
+		row := []string{
+			r.Name,
+			strconv.Itoa(r.Size),
+			strconv.Itoa(r.Runs),
+			strconv.FormatInt(r.PerOp.Nanoseconds(), 10),
+			strconv.FormatFloat(r.MBPerSec, 'f', 3, 64),
+			strconv.FormatFloat(r.AllocsPerOp, 'f', 2, 64),
+			strconv.FormatFloat(r.BytesPerOp, 'f', 0, 64),
+			strconv.FormatInt(r.Median.Nanoseconds(), 10),
+			strconv.FormatInt(r.StdDev.Nanoseconds(), 10),
+			strconv.FormatInt(r.CILow.Nanoseconds(), 10),
+			strconv.FormatInt(r.CIHigh.Nanoseconds(), 10),
+			strconv.FormatFloat(r.Confidence, 'f', 3, 64),
+			strconv.Itoa(r.Outliers),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON записывает отчёт с отступами
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Export записывает результаты в base.csv и отчёт с окружением в base.json,
+// например рядом с графиком: Export("graphs/time_cmp", results)
+func Export(base string, results []Result) error {
+	f, err := os.Create(base + ".csv")
+	if err != nil {
+		return err
+	}
+	if err := WriteCSV(f, results); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if f, err = os.Create(base + ".json"); err != nil {
+		return err
+	}
+	if err := NewReport(results).WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
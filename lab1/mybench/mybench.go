@@ -0,0 +1,222 @@
+package mybench
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Измерение скорости: прогрев, среднее на прогон, пропускная способность -----
+
+// Op - измеряемая операция над сообщением
+type Op func(msg []byte) error
+
+// Config - параметры измерения
+type Config struct {
+	Warmup int  // прогоны до измерения: прогрев кэшей, пулов и расписаний ключей, в результат не входят
+	Runs   int  // измеряемые прогоны
+	Fresh  bool // новое случайное сообщение на каждый прогон; генерация не входит в измерение
+
+	Confidence   float64 // доверительная вероятность интервала среднего (0 - 0.95)
+	KeepOutliers bool    // не отбрасывать выбросы (по умолчанию отбрасываются по правилу Тьюки)
+
+	Profile Profile // профили и трассировки измеряемых прогонов; по умолчанию выключены
+}
+
+// DefaultConfig - 3 прогона прогрева и 20 измеряемых прогонов на одном сообщении, 95% интервал
+var DefaultConfig = Config{Warmup: 3, Runs: 20, Confidence: 0.95}
+
+// Validate проверяет параметры измерения
+func (c Config) Validate() error {
+	if c.Warmup < 0 {
+		return fmt.Errorf("mybench: negative warm-up %d", c.Warmup)
+	}
+	if c.Runs < 1 {
+		return fmt.Errorf("mybench: at least one run is required, got %d", c.Runs)
+	}
+	if c.Confidence < 0 || c.Confidence >= 1 {
+		return fmt.Errorf("mybench: confidence must be in (0, 1), got %v", c.Confidence)
+	}
+	return nil
+}
+
+// confidence возвращает доверительную вероятность с учётом значения по умолчанию
+func (c Config) confidence() float64 {
+	if c.Confidence == 0 {
+		return DefaultConfig.Confidence
+	}
+	return c.Confidence
+}
+
+// Result - результат измерения алгоритма Name на сообщениях длиной Size байт. Выбросы (Outliers из Runs
+// прогонов) в среднее, медиану, разброс и интервал не входят; Total - сумма всех измеренных прогонов.
+type Result struct {
+	Name        string
+	Size        int
+	Runs        int
+	Total       time.Duration // суммарное время измеренных прогонов
+	PerOp       time.Duration // среднее время прогона без выбросов
+	MBPerSec    float64       // МБ (2^20 байт) в секунду по PerOp
+	AllocsPerOp float64       // выделений памяти за прогон (по runtime.MemStats, во всех горутинах)
+	BytesPerOp  float64       // байт, выделенных за прогон (по runtime.MemStats.TotalAlloc)
+
+	Median     time.Duration
+	StdDev     time.Duration // выборочное стандартное отклонение
+	CILow      time.Duration // доверительный интервал среднего с вероятностью Confidence
+	CIHigh     time.Duration
+	Confidence float64
+	Outliers   int // отброшено прогонов
+}
+
+// NewResult вычисляет среднее время прогона и пропускную способность по суммарному времени runs прогонов,
+// когда отдельные прогоны неизвестны (статистики разброса остаются нулевыми)
+func NewResult(name string, size, runs int, total time.Duration) Result {
+	r := Result{Name: name, Size: size, Runs: runs, Total: total}
+	if runs > 0 {
+		r.PerOp = total / time.Duration(runs)
+	}
+	r.setThroughput()
+	return r
+}
+
+// setThroughput пересчитывает MBPerSec по PerOp
+func (r *Result) setThroughput() {
+	r.MBPerSec = 0
+	if r.PerOp > 0 {
+		r.MBPerSec = float64(r.Size) / r.PerOp.Seconds() / (1 << 20)
+	}
+}
+
+// String возвращает строку вида
+// "OMAC, 1024 KB: 3.1ms/op (median 3.05ms, 95% CI 3.02ms..3.18ms), 322.58 MB/s, 2 allocs/op, 1.1e+06 B/op over 20 runs, 1 outliers"
+func (r Result) String() string {
+	return fmt.Sprintf("%s, %s: %v/op (median %v, %.0f%% CI %v..%v), %.2f MB/s, %.4g allocs/op, %.4g B/op over %d runs, %d outliers",
+		r.Name, SizeLabel(r.Size), r.PerOp, r.Median, 100*r.Confidence, r.CILow, r.CIHigh, r.MBPerSec, r.AllocsPerOp, r.BytesPerOp,
+		r.Runs, r.Outliers)
+}
+
+// SizeLabel возвращает размер сообщения в байтах или КБ
+func SizeLabel(size int) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%g KB", float64(size)/1024)
+}
+
+// Timer измеряет отдельные прогоны Op на сообщении фиксированной длины. Сообщение берётся из
+// mycrypto.Rand до начала отсчёта; при Fresh оно перезаписывается перед каждым прогоном, тоже вне отсчёта.
+// Timer нужен, когда число прогонов определяет внешний план (например, mystats.Plan из lab3);
+// итог измеренных прогонов возвращает Result.
+type Timer struct {
+	cfg Config
+	op  Op
+	msg []byte
+
+	samples []time.Duration
+	mallocs uint64
+	bytes   uint64
+}
+
+// NewTimer создаёт сообщение длиной size и выполняет c.Warmup прогонов прогрева; Runs не используется
+func (c Config) NewTimer(size int, op Op) (*Timer, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("mybench: negative message size %d", size)
+	}
+	if op == nil {
+		return nil, errors.New("mybench: nil operation")
+	}
+	t := &Timer{cfg: c, op: op, msg: make([]byte, size)}
+	if err := t.fill(); err != nil {
+		return nil, err
+	}
+	for i := 0; i < c.Warmup; i++ {
+		if err := op(t.msg); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// fill заполняет сообщение случайными байтами
+func (t *Timer) fill() error {
+	if _, err := mycrypto.Rand.Read(t.msg); err != nil {
+		return fmt.Errorf("mybench: message generation: %v", err)
+	}
+	return nil
+}
+
+// Run выполняет один прогон и возвращает его время без времени генерации сообщения.
+// Счётчики выделений памяти читаются до и после отсчёта времени, в само время не входят.
+func (t *Timer) Run() (time.Duration, error) {
+	if t.cfg.Fresh {
+		if err := t.fill(); err != nil {
+			return 0, err
+		}
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	err := t.op(t.msg)
+	d := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		return 0, err
+	}
+	t.samples = append(t.samples, d)
+	t.mallocs += after.Mallocs - before.Mallocs
+	t.bytes += after.TotalAlloc - before.TotalAlloc
+	return d, nil
+}
+
+// Result возвращает итог всех успешных прогонов Run: среднее, медиану, разброс и доверительный
+// интервал после отбрасывания выбросов (если Config.KeepOutliers не задан)
+func (t *Timer) Result(name string) Result {
+	r := Result{Name: name, Size: len(t.msg), Runs: len(t.samples), Confidence: t.cfg.confidence()}
+	for _, d := range t.samples {
+		r.Total += d
+	}
+	if len(t.samples) == 0 {
+		return r
+	}
+	r.AllocsPerOp = float64(t.mallocs) / float64(len(t.samples))
+	r.BytesPerOp = float64(t.bytes) / float64(len(t.samples))
+	kept := t.samples
+	if !t.cfg.KeepOutliers {
+		kept = rejectOutliers(t.samples)
+		r.Outliers = len(t.samples) - len(kept)
+	}
+	s := summarize(kept, r.Confidence)
+	r.PerOp, r.Median, r.StdDev, r.CILow, r.CIHigh = s.mean, s.median, s.stddev, s.low, s.high
+	r.setThroughput()
+	return r
+}
+
+// Run измеряет op на сообщениях длиной size: Warmup прогонов прогрева, затем Runs измеряемых.
+// Время прогонов измеряется по отдельности, поэтому генерация сообщения (при Fresh) в него не входит.
+// Профили Profile охватывают только измеряемые прогоны.
+func (c Config) Run(name string, size int, op Op) (Result, error) {
+	if err := c.Validate(); err != nil {
+		return Result{}, err
+	}
+	t, err := c.NewTimer(size, op)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %v", name, err)
+	}
+	stop, err := c.Profile.Start(name, size)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %v", name, err)
+	}
+	for i := 0; i < c.Runs; i++ {
+		if _, err := t.Run(); err != nil {
+			stop()
+			return Result{}, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	if err := stop(); err != nil {
+		return Result{}, fmt.Errorf("%s: %v", name, err)
+	}
+	return t.Result(name), nil
+}
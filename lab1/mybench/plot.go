@@ -0,0 +1,55 @@
+package mybench
+
+import (
+	"time"
+
+	"gonum.org/v1/plot/plotter"
+)
+
+// ----- Точки графиков времени и памяти -----
+
+// ErrorPoints - точки графика времени с доверительными интервалами: реализует plotter.XYer
+// для plotutil.AddLinePoints и plotter.YErrorer для plotter.NewYErrorBars
+type ErrorPoints struct {
+	XYs  plotter.XYs
+	Errs plotter.YErrors
+}
+
+// Len возвращает число точек
+func (p ErrorPoints) Len() int { return len(p.XYs) }
+
+// XY возвращает координаты точки i
+func (p ErrorPoints) XY(i int) (float64, float64) { return p.XYs.XY(i) }
+
+// YError возвращает расстояния от точки i до нижней и верхней границ интервала
+func (p ErrorPoints) YError(i int) (float64, float64) { return p.Errs.YError(i) }
+
+// TimePoints отбирает результаты алгоритма name: X - размер сообщения в КБ, Y - среднее время в единицах unit,
+// планки погрешностей - доверительный интервал среднего (у результатов NewResult он нулевой)
+func TimePoints(results []Result, name string, unit time.Duration) ErrorPoints {
+	var p ErrorPoints
+	for _, r := range results {
+		if r.Name != name {
+			continue
+		}
+		lo, hi := r.PerOp-r.CILow, r.CIHigh-r.PerOp
+		if r.CILow == 0 && r.CIHigh == 0 {
+			lo, hi = 0, 0
+		}
+		p.XYs = append(p.XYs, plotter.XY{X: float64(r.Size) / 1024, Y: float64(r.PerOp) / float64(unit)})
+		p.Errs = append(p.Errs, struct{ Low, High float64 }{float64(lo) / float64(unit), float64(hi) / float64(unit)})
+	}
+	return p
+}
+
+// MemPoints отбирает результаты алгоритма name: X - размер сообщения в КБ, Y - байт, выделенных за прогон,
+// в единицах unit (например, 1024 - КБ)
+func MemPoints(results []Result, name string, unit float64) plotter.XYs {
+	var pts plotter.XYs
+	for _, r := range results {
+		if r.Name == name {
+			pts = append(pts, plotter.XY{X: float64(r.Size) / 1024, Y: r.BytesPerOp / unit})
+		}
+	}
+	return pts
+}
@@ -0,0 +1,106 @@
+package mybench
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+)
+
+// ----- Профили pprof и трассировки измеряемых прогонов -----
+
+// Profile - профили и трассировки измеряемых прогонов (прогрев в них не входит), по файлу на алгоритм
+// и размер сообщения: <Dir>/<имя>_<размер>.cpu.pprof, .heap.pprof и .trace. Файлы смотрят через
+// "go tool pprof" и "go tool trace", например чтобы найти время в xor или в росте срезов через append.
+type Profile struct {
+	Dir   string // каталог файлов; пустой - профилирование выключено
+	CPU   bool   // профиль CPU
+	Heap  bool   // профиль кучи после прогонов (вместе с выделениями за время работы программы)
+	Trace bool   // трассировка выполнения runtime/trace
+}
+
+// Enabled сообщает, записывает ли Profile хотя бы один файл
+func (p Profile) Enabled() bool {
+	return p.Dir != "" && (p.CPU || p.Heap || p.Trace)
+}
+
+// fileName возвращает имя файла профиля: символы, неудобные в именах файлов, заменяются на '_'
+func (p Profile) fileName(name string, size int, ext string) string {
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+	return filepath.Join(p.Dir, fmt.Sprintf("%s_%d%s", clean, size, ext))
+}
+
+// Start включает выбранные профили для прогонов name на сообщениях длиной size.
+// Возвращённая stop останавливает их и записывает профиль кучи; её нужно вызвать и при ошибке прогонов.
+// Профиль CPU и трассировка в процессе бывают только одни, поэтому измерения с Profile не запускают параллельно.
+func (p Profile) Start(name string, size int) (stop func() error, err error) {
+	if !p.Enabled() {
+		return func() error { return nil }, nil
+	}
+	if err := os.MkdirAll(p.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	var files []*os.File
+	closeAll := func() error {
+		var errs []error
+		for _, f := range files {
+			errs = append(errs, f.Close())
+		}
+		return errors.Join(errs...)
+	}
+	create := func(ext string) (*os.File, error) {
+		f, err := os.Create(p.fileName(name, size, ext))
+		if err == nil {
+			files = append(files, f)
+		}
+		return f, err
+	}
+	if p.CPU {
+		f, err := create(".cpu.pprof")
+		if err != nil {
+			return nil, errors.Join(err, closeAll())
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return nil, errors.Join(fmt.Errorf("mybench: CPU profile: %v", err), closeAll())
+		}
+	}
+	if p.Trace {
+		f, err := create(".trace")
+		if err == nil {
+			err = trace.Start(f)
+		}
+		if err != nil {
+			if p.CPU {
+				pprof.StopCPUProfile()
+			}
+			return nil, errors.Join(fmt.Errorf("mybench: trace: %v", err), closeAll())
+		}
+	}
+	return func() error {
+		if p.CPU {
+			pprof.StopCPUProfile()
+		}
+		if p.Trace {
+			trace.Stop()
+		}
+		var heapErr error
+		if p.Heap {
+			var f *os.File
+			if f, heapErr = create(".heap.pprof"); heapErr == nil {
+				runtime.GC() // профиль кучи отражает состояние на момент последней сборки
+				heapErr = pprof.WriteHeapProfile(f)
+			}
+		}
+		return errors.Join(heapErr, closeAll())
+	}, nil
+}
@@ -0,0 +1,73 @@
+package mybench
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// ----- Статистика прогонов: выбросы, медиана, доверительный интервал -----
+
+// quantile возвращает квантиль уровня q отсортированной выборки с линейной интерполяцией
+func quantile(sorted []time.Duration, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return float64(sorted[len(sorted)-1])
+	}
+	return float64(sorted[i]) + (pos-float64(i))*float64(sorted[i+1]-sorted[i])
+}
+
+// rejectOutliers отбрасывает прогоны вне заборов Тьюки [Q1 - 1.5 IQR, Q3 + 1.5 IQR]. На времени
+// прогонов это в основном медленные прогоны, попавшие на сборку мусора, вытеснение или прерывание.
+// Меньше четырёх прогонов не фильтруются: квартили по ним не оценить.
+func rejectOutliers(samples []time.Duration) []time.Duration {
+	if len(samples) < 4 {
+		return samples
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	q1, q3 := quantile(sorted, 0.25), quantile(sorted, 0.75)
+	lo, hi := q1-1.5*(q3-q1), q3+1.5*(q3-q1)
+	kept := make([]time.Duration, 0, len(samples))
+	for _, d := range sorted {
+		if float64(d) >= lo && float64(d) <= hi {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// summary - среднее, медиана, стандартное отклонение и доверительный интервал среднего
+type summary struct {
+	mean, median, stddev, low, high time.Duration
+}
+
+// summarize вычисляет статистики выборки. Интервал - нормальное приближение mean ± z·s/√n,
+// z = √2·erfinv(confidence); при 20 и более прогонах оно отличается от интервала Стьюдента на единицы процентов.
+func summarize(samples []time.Duration, confidence float64) summary {
+	n := float64(len(samples))
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	var sum float64
+	for _, d := range samples {
+		sum += float64(d)
+	}
+	mean := sum / n
+	var ss float64
+	for _, d := range samples {
+		ss += (float64(d) - mean) * (float64(d) - mean)
+	}
+	var sd float64
+	if n > 1 {
+		sd = math.Sqrt(ss / (n - 1))
+	}
+	half := math.Sqrt2 * math.Erfinv(confidence) * sd / math.Sqrt(n)
+	return summary{
+		mean:   time.Duration(mean),
+		median: time.Duration(quantile(sorted, 0.5)),
+		stddev: time.Duration(sd),
+		low:    time.Duration(mean - half),
+		high:   time.Duration(mean + half),
+	}
+}
@@ -0,0 +1,234 @@
+package mycamellia
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Camellia (RFC 3713): 128-битный блок, ключ 128, 192 или 256 бит -----
+
+const BlockSize = 16
+
+// sbox1 - подстановка s1; s2, s3 и s4 получаются из неё циклическими сдвигами
+var sbox1 = [256]byte{
+	112, 130, 44, 236, 179, 39, 192, 229, 228, 133, 87, 53, 234, 12, 174, 65,
+	35, 239, 107, 147, 69, 25, 165, 33, 237, 14, 79, 78, 29, 101, 146, 189,
+	134, 184, 175, 143, 124, 235, 31, 206, 62, 48, 220, 95, 94, 197, 11, 26,
+	166, 225, 57, 202, 213, 71, 93, 61, 217, 1, 90, 214, 81, 86, 108, 77,
+	139, 13, 154, 102, 251, 204, 176, 45, 116, 18, 43, 32, 240, 177, 132, 153,
+	223, 76, 203, 194, 52, 126, 118, 5, 109, 183, 169, 49, 209, 23, 4, 215,
+	20, 88, 58, 97, 222, 27, 17, 28, 50, 15, 156, 22, 83, 24, 242, 34,
+	254, 68, 207, 178, 195, 181, 122, 145, 36, 8, 232, 168, 96, 252, 105, 80,
+	170, 208, 160, 125, 161, 137, 98, 151, 84, 91, 30, 149, 224, 255, 100, 210,
+	16, 196, 0, 72, 163, 247, 117, 219, 138, 3, 230, 218, 9, 63, 221, 148,
+	135, 92, 131, 2, 205, 74, 144, 51, 115, 103, 246, 243, 157, 127, 191, 226,
+	82, 155, 216, 38, 200, 55, 198, 59, 129, 150, 111, 75, 19, 190, 99, 46,
+	233, 121, 167, 140, 159, 110, 188, 142, 41, 245, 249, 182, 47, 253, 180, 89,
+	120, 152, 6, 106, 231, 70, 113, 186, 212, 37, 171, 66, 136, 162, 141, 250,
+	114, 7, 185, 85, 248, 238, 172, 10, 54, 73, 42, 104, 60, 56, 241, 164,
+	64, 40, 211, 123, 187, 201, 67, 193, 21, 227, 173, 244, 119, 199, 128, 158,
+}
+
+// sigma - константы Σ1..Σ6 ключевого расписания
+var sigma = [6]uint64{
+	0xa09e667f3bcc908b, 0xb67ae8584caa73b2, 0xc6ef372fe94f82be,
+	0x54ff53a5f1d36f1c, 0x10e527fade682d1d, 0xb05688c2b3e6c1fd,
+}
+
+// sp[i] - таблица, объединяющая подстановку i-го байта с линейным слоем P
+var sp [8][256]uint64
+
+func init() {
+	s := [4]func(byte) byte{
+		func(x byte) byte { return sbox1[x] },
+		func(x byte) byte { return bits.RotateLeft8(sbox1[x], 1) },
+		func(x byte) byte { return bits.RotateLeft8(sbox1[x], 7) },
+		func(x byte) byte { return sbox1[bits.RotateLeft8(x, 1)] },
+	}
+	// Подстановка для байтов t1..t8 и выходы y1..y8, в которые входит t_i
+	box := [8]int{0, 1, 2, 3, 1, 2, 3, 0}
+	outs := [8][]int{
+		{1, 2, 3, 5, 8},
+		{2, 3, 4, 5, 6},
+		{1, 3, 4, 6, 7},
+		{1, 2, 4, 7, 8},
+		{2, 3, 4, 6, 7, 8},
+		{1, 3, 4, 5, 7, 8},
+		{1, 2, 4, 5, 6, 8},
+		{1, 2, 3, 5, 6, 7},
+	}
+	for i := 0; i < 8; i++ {
+		for x := 0; x < 256; x++ {
+			t := uint64(s[box[i]](byte(x)))
+			var v uint64
+			for _, j := range outs[i] {
+				v |= t << (8 * uint(8-j))
+			}
+			sp[i][x] = v
+		}
+	}
+}
+
+// f - раундовая функция F(x, k) = P(S(x ^ k))
+func f(x, k uint64) uint64 {
+	x ^= k
+	mycrypto.AuditPoint("mycamellia.f/SP")
+	return sp[0][x>>56] ^ sp[1][x>>48&0xff] ^ sp[2][x>>40&0xff] ^ sp[3][x>>32&0xff] ^
+		sp[4][x>>24&0xff] ^ sp[5][x>>16&0xff] ^ sp[6][x>>8&0xff] ^ sp[7][x&0xff]
+}
+
+// fl и flInv - функции FL и FL^-1, вставляемые через каждые 6 раундов
+func fl(x, k uint64) uint64 {
+	x1, x2 := uint32(x>>32), uint32(x)
+	k1, k2 := uint32(k>>32), uint32(k)
+	x2 ^= bits.RotateLeft32(x1&k1, 1)
+	x1 ^= x2 | k2
+	return uint64(x1)<<32 | uint64(x2)
+}
+
+func flInv(y, k uint64) uint64 {
+	y1, y2 := uint32(y>>32), uint32(y)
+	k1, k2 := uint32(k>>32), uint32(k)
+	y1 ^= y2 | k2
+	y2 ^= bits.RotateLeft32(y1&k1, 1)
+	return uint64(y1)<<32 | uint64(y2)
+}
+
+// rot128 возвращает 128-битное значение hi||lo, циклически сдвинутое влево на n бит
+func rot128(hi, lo uint64, n uint) (uint64, uint64) {
+	if n >= 64 {
+		hi, lo = lo, hi
+		n -= 64
+	}
+	if n == 0 {
+		return hi, lo
+	}
+	return hi<<n | lo>>(64-n), lo<<n | hi>>(64-n)
+}
+
+// Cipher - блочный шифр Camellia; реализует mycrypto.BlockCipher
+type Cipher struct {
+	kw [4]uint64  // kw1..kw4 - отбеливание
+	k  [24]uint64 // ключи раундов (18 для 128-битного ключа, 24 иначе)
+	ke [6]uint64  // ключи FL/FL^-1
+	nr int
+}
+
+// NewCipher строит расписание ключей для ключа длиной 16, 24 или 32 байта
+func NewCipher(key []byte) (*Cipher, error) {
+	var klH, klL, krH, krL uint64
+	switch len(key) {
+	case 16:
+	case 24:
+		krH = binary.BigEndian.Uint64(key[16:])
+		krL = ^krH
+	case 32:
+		krH = binary.BigEndian.Uint64(key[16:])
+		krL = binary.BigEndian.Uint64(key[24:])
+	default:
+		return nil, fmt.Errorf("mycamellia: invalid key length %d", len(key))
+	}
+	klH = binary.BigEndian.Uint64(key)
+	klL = binary.BigEndian.Uint64(key[8:])
+
+	d1, d2 := klH^krH, klL^krL
+	d2 ^= f(d1, sigma[0])
+	d1 ^= f(d2, sigma[1])
+	d1 ^= klH
+	d2 ^= klL
+	d2 ^= f(d1, sigma[2])
+	d1 ^= f(d2, sigma[3])
+	kaH, kaL := d1, d2
+
+	c := &Cipher{}
+	// set записывает половины (K <<< n) в пару подключей
+	set := func(dstH, dstL *uint64, hi, lo uint64, n uint) {
+		h, l := rot128(hi, lo, n)
+		if dstH != nil {
+			*dstH = h
+		}
+		if dstL != nil {
+			*dstL = l
+		}
+	}
+	if len(key) == 16 {
+		c.nr = 18
+		set(&c.kw[0], &c.kw[1], klH, klL, 0)
+		set(&c.k[0], &c.k[1], kaH, kaL, 0)
+		set(&c.k[2], &c.k[3], klH, klL, 15)
+		set(&c.k[4], &c.k[5], kaH, kaL, 15)
+		set(&c.ke[0], &c.ke[1], kaH, kaL, 30)
+		set(&c.k[6], &c.k[7], klH, klL, 45)
+		set(&c.k[8], nil, kaH, kaL, 45)
+		set(nil, &c.k[9], klH, klL, 60)
+		set(&c.k[10], &c.k[11], kaH, kaL, 60)
+		set(&c.ke[2], &c.ke[3], klH, klL, 77)
+		set(&c.k[12], &c.k[13], klH, klL, 94)
+		set(&c.k[14], &c.k[15], kaH, kaL, 94)
+		set(&c.k[16], &c.k[17], klH, klL, 111)
+		set(&c.kw[2], &c.kw[3], kaH, kaL, 111)
+		return c, nil
+	}
+
+	d1, d2 = kaH^krH, kaL^krL
+	d2 ^= f(d1, sigma[4])
+	d1 ^= f(d2, sigma[5])
+	kbH, kbL := d1, d2
+
+	c.nr = 24
+	set(&c.kw[0], &c.kw[1], klH, klL, 0)
+	set(&c.k[0], &c.k[1], kbH, kbL, 0)
+	set(&c.k[2], &c.k[3], krH, krL, 15)
+	set(&c.k[4], &c.k[5], kaH, kaL, 15)
+	set(&c.ke[0], &c.ke[1], krH, krL, 30)
+	set(&c.k[6], &c.k[7], kbH, kbL, 30)
+	set(&c.k[8], &c.k[9], klH, klL, 45)
+	set(&c.k[10], &c.k[11], kaH, kaL, 45)
+	set(&c.ke[2], &c.ke[3], klH, klL, 60)
+	set(&c.k[12], &c.k[13], krH, krL, 60)
+	set(&c.k[14], &c.k[15], kbH, kbL, 60)
+	set(&c.k[16], &c.k[17], klH, klL, 77)
+	set(&c.ke[4], &c.ke[5], kaH, kaL, 77)
+	set(&c.k[18], &c.k[19], krH, krL, 94)
+	set(&c.k[20], &c.k[21], kaH, kaL, 94)
+	set(&c.k[22], &c.k[23], klH, klL, 111)
+	set(&c.kw[2], &c.kw[3], kbH, kbL, 111)
+	return c, nil
+}
+
+func (c *Cipher) BlockSize() int { return BlockSize }
+
+// Encrypt зашифровывает один блок: 6 раундов Фейстеля, затем FL/FL^-1, и так nr/6 раз
+func (c *Cipher) Encrypt(dst, src []byte) {
+	d1 := binary.BigEndian.Uint64(src) ^ c.kw[0]
+	d2 := binary.BigEndian.Uint64(src[8:]) ^ c.kw[1]
+	for i := 0; i < c.nr; i += 2 {
+		if i > 0 && i%6 == 0 {
+			d1 = fl(d1, c.ke[i/3-2])
+			d2 = flInv(d2, c.ke[i/3-1])
+		}
+		d2 ^= f(d1, c.k[i])
+		d1 ^= f(d2, c.k[i+1])
+	}
+	binary.BigEndian.PutUint64(dst, d2^c.kw[2])
+	binary.BigEndian.PutUint64(dst[8:], d1^c.kw[3])
+}
+
+// Decrypt расшифровывает один блок той же схемой с подключами в обратном порядке
+func (c *Cipher) Decrypt(dst, src []byte) {
+	d1 := binary.BigEndian.Uint64(src) ^ c.kw[2]
+	d2 := binary.BigEndian.Uint64(src[8:]) ^ c.kw[3]
+	for i := c.nr - 1; i > 0; i -= 2 {
+		if i < c.nr-1 && (i+1)%6 == 0 {
+			d1 = fl(d1, c.ke[(i+1)/3-1])
+			d2 = flInv(d2, c.ke[(i+1)/3-2])
+		}
+		d2 ^= f(d1, c.k[i])
+		d1 ^= f(d2, c.k[i-1])
+	}
+	binary.BigEndian.PutUint64(dst, d2^c.kw[0])
+	binary.BigEndian.PutUint64(dst[8:], d1^c.kw[1])
+}
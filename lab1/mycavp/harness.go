@@ -0,0 +1,345 @@
+package mycavp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Прогон файлов ответов на MyCipher, включая процедуру Monte Carlo из AESAVS -----
+
+// mctInner - число внутренних итераций одной записи Monte Carlo
+const mctInner = 1000
+
+// Failure - непройденная запись
+type Failure struct {
+	Section string
+	Count   int
+	Line    int
+	Reason  string
+}
+
+// Report - итоги прогона файла
+type Report struct {
+	Info     Info
+	Total    int
+	Passed   int
+	Failed   int
+	Failures []Failure
+}
+
+// Run прогоняет все записи файла. Записи KAT и MMT проверяются независимо: шифрование в секции
+// ENCRYPT, расшифрование в секции DECRYPT. В Monte Carlo каждая запись прогоняется от собственных
+// KEY, IV и входа, а ключ, IV и вход, выработанные из неё по AESAVS, сверяются со следующей записью,
+// так что ошибка в цепочке указывает на первую расходящуюся запись, а не на все после неё.
+func Run(f *File) (*Report, error) {
+	info, err := ParseName(f.Name)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Info: info}
+	for _, sec := range f.Sections {
+		var decrypt bool
+		switch sec.Name {
+		case SectionEncrypt:
+		case SectionDecrypt:
+			decrypt = true
+		default:
+			return nil, fmt.Errorf("mycavp: %s: unknown section [%s]", f.Name, sec.Name)
+		}
+		var next *mctState
+		for _, rec := range sec.Records {
+			r.Total++
+			if info.Kind == KindMCT {
+				next, err = runMCT(info, rec, decrypt, next)
+			} else {
+				err = runRecord(info, rec, decrypt)
+			}
+			if err != nil {
+				r.Failed++
+				r.Failures = append(r.Failures, Failure{Section: sec.Name, Count: rec.Count, Line: rec.Line, Reason: err.Error()})
+			} else {
+				r.Passed++
+			}
+		}
+	}
+	return r, nil
+}
+
+// record - разобранная запись: in - вход операции (PLAINTEXT при шифровании), out - ожидаемый выход
+type record struct {
+	key, iv []byte
+	in, out bitString
+}
+
+// parseRecord разбирает поля записи; IV нет только у ECB
+func parseRecord(info Info, rec Record, decrypt bool) (*record, error) {
+	bits := info.Segment == 1
+	p := &record{}
+	s, err := rec.field("KEY")
+	if err != nil {
+		return nil, err
+	}
+	if p.key, err = hex.DecodeString(s); err != nil {
+		return nil, fmt.Errorf("KEY: %v", err)
+	}
+	if 8*len(p.key) != info.KeyBits {
+		return nil, fmt.Errorf("KEY of %d bits in an AES-%d file", 8*len(p.key), info.KeyBits)
+	}
+	if info.Mode != mycrypto.ModeECB {
+		if s, err = rec.field("IV"); err != nil {
+			return nil, err
+		}
+		if p.iv, err = hex.DecodeString(s); err != nil {
+			return nil, fmt.Errorf("IV: %v", err)
+		}
+		if len(p.iv) != mycrypto.AESBlockSize {
+			return nil, fmt.Errorf("IV of %d bytes", len(p.iv))
+		}
+	}
+	inName, outName := "PLAINTEXT", "CIPHERTEXT"
+	if decrypt {
+		inName, outName = outName, inName
+	}
+	if s, err = rec.field(inName); err != nil {
+		return nil, err
+	}
+	if p.in, err = decodeData(s, bits); err != nil {
+		return nil, fmt.Errorf("%s: %v", inName, err)
+	}
+	if s, err = rec.field(outName); err != nil {
+		return nil, err
+	}
+	if p.out, err = decodeData(s, bits); err != nil {
+		return nil, fmt.Errorf("%s: %v", outName, err)
+	}
+	if p.in.n != p.out.n {
+		return nil, fmt.Errorf("%s has %d bits, %s has %d", inName, p.in.n, outName, p.out.n)
+	}
+	if !bits && (info.Mode == mycrypto.ModeECB || info.Mode == mycrypto.ModeCBC) && len(p.in.b)%mycrypto.AESBlockSize != 0 {
+		return nil, fmt.Errorf("%s is not a multiple of the block size", inName)
+	}
+	return p, nil
+}
+
+// newCipher создаёт MyCipher (AES) для режима файла
+func newCipher(info Info, key []byte) (*mycrypto.MyCipher, error) {
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(info.Mode); err != nil {
+		return nil, err
+	}
+	if info.Segment != 0 {
+		if err := mc.SetSegmentSize(info.Segment); err != nil {
+			return nil, err
+		}
+	}
+	return mc, nil
+}
+
+// process шифрует или расшифровывает сообщение целиком. Файлы CAVP не используют паддинг:
+// у ECB и CBC при шифровании отбрасывается блок PKCS7, расшифрование идёт поблочно через
+// ProcessBlockDecrypt (IV - первым блоком). Сообщение CFB1 дополняется нулевыми битами до байта;
+// биты выхода зависят только от предыдущих, поэтому лишние биты отбрасываются.
+func process(mc *mycrypto.MyCipher, info Info, iv, in []byte, decrypt bool) ([]byte, error) {
+	if !decrypt {
+		out, err := mc.Encrypt(in, iv)
+		if err != nil {
+			return nil, err
+		}
+		return out[len(iv) : len(iv)+len(in)], nil
+	}
+	if info.Mode != mycrypto.ModeECB && info.Mode != mycrypto.ModeCBC {
+		return mc.Decrypt(in, iv)
+	}
+	mc.Reset()
+	if len(iv) != 0 {
+		if _, err := mc.ProcessBlockDecrypt(iv, false, mycrypto.PaddingNON); err != nil {
+			return nil, err
+		}
+	}
+	var out []byte
+	for i := 0; i < len(in); i += mycrypto.AESBlockSize {
+		blk, err := mc.ProcessBlockDecrypt(in[i:i+mycrypto.AESBlockSize], i+mycrypto.AESBlockSize == len(in), mycrypto.PaddingNON)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, blk...)
+	}
+	return out, nil
+}
+
+// truncBits обнуляет биты за n-м в последнем байте
+func truncBits(b []byte, n int) []byte {
+	if n%8 != 0 {
+		b[n/8] &= 0xff << (8 - n%8)
+	}
+	return b[:(n+7)/8]
+}
+
+// runRecord проверяет запись KAT или MMT
+func runRecord(info Info, rec Record, decrypt bool) error {
+	p, err := parseRecord(info, rec, decrypt)
+	if err != nil {
+		return err
+	}
+	mc, err := newCipher(info, p.key)
+	if err != nil {
+		return err
+	}
+	out, err := process(mc, info, p.iv, p.in.b, decrypt)
+	if err != nil {
+		return err
+	}
+	got := bitString{truncBits(out, p.in.n), p.in.n}
+	if !bytes.Equal(got.b, p.out.b) {
+		bits := info.Segment == 1
+		return fmt.Errorf("got %s, want %s", got.String(bits), p.out.String(bits))
+	}
+	return nil
+}
+
+// mctState - ключ, IV и вход следующей записи Monte Carlo
+type mctState struct {
+	key, iv, in []byte
+}
+
+// runMCT проверяет запись Monte Carlo: сверяет её KEY, IV и вход с выработанными предыдущей записью
+// (prev, nil для первой) и прогоняет mctRound. Возвращает состояние для следующей записи.
+func runMCT(info Info, rec Record, decrypt bool, prev *mctState) (*mctState, error) {
+	p, err := parseRecord(info, rec, decrypt)
+	if err != nil {
+		return nil, err
+	}
+	if p.in.n != segmentBits(info) {
+		return nil, fmt.Errorf("Monte Carlo input of %d bits, want one segment of %d", p.in.n, segmentBits(info))
+	}
+	next, out, err := mctRound(info, p.key, p.iv, p.in.b, decrypt)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		switch {
+		case !bytes.Equal(prev.key, p.key):
+			err = fmt.Errorf("chained KEY %x, record has %x", prev.key, p.key)
+		case !bytes.Equal(prev.iv, p.iv):
+			err = fmt.Errorf("chained IV %x, record has %x", prev.iv, p.iv)
+		case !bytes.Equal(prev.in, p.in.b):
+			err = fmt.Errorf("chained input %x, record has %x", prev.in, p.in.b)
+		}
+	}
+	if err == nil && !bytes.Equal(out, p.out.b) {
+		bits := info.Segment == 1
+		err = fmt.Errorf("got %s, want %s", bitString{out, p.out.n}.String(bits), p.out.String(bits))
+	}
+	return next, err
+}
+
+// segmentBits - размер сегмента режима: бит у CFB1, байт у CFB8, блок у остальных
+func segmentBits(info Info) int {
+	if info.Segment != 0 {
+		return info.Segment
+	}
+	return 8 * mycrypto.AESBlockSize
+}
+
+// window - последние 384 бита потока IV || OUT[0] || OUT[1] || ... (у ECB вместо IV нули).
+// Его хватает и на вход следующей итерации (сегмент перед последними 128 битами),
+// и на обновление ключа (последние 128, 192 или 256 бит).
+type window [48]byte
+
+// push дописывает сегмент seg длиной s бит (у CFB1 бит - старший в байте)
+func (w *window) push(seg []byte, s int) {
+	if s%8 == 0 {
+		copy(w[:], w[len(seg):])
+		copy(w[len(w)-len(seg):], seg)
+		return
+	}
+	for i := 0; i < len(w)-1; i++ {
+		w[i] = w[i]<<1 | w[i+1]>>7
+	}
+	w[len(w)-1] = w[len(w)-1]<<1 | seg[0]>>7
+}
+
+// beforeIV возвращает сегмент длиной s бит, предшествующий последним 128 битам потока
+func (w *window) beforeIV(s int) []byte {
+	end := len(w) - mycrypto.AESBlockSize
+	if s%8 == 0 {
+		return append([]byte{}, w[end-s/8:end]...)
+	}
+	return []byte{w[end-1] << 7}
+}
+
+// mctRound выполняет одну запись Monte Carlo AESAVS (разделы 6.4.x): 1000 итераций, в которых
+// вход итерации j+1 - j-й сегмент потока IV || OUT[0] || OUT[1] || ... (у ECB - OUT[j]),
+// каждая итерация - отдельное сообщение из одного сегмента с IV, равным регистру режима
+// (предыдущий блок шифротекста у CBC и CFB, предыдущий выход блочного шифра у OFB).
+// После итераций ключ складывается с последними битами выхода, IV - последние 128 бит выхода,
+// вход следующей записи - сегмент перед ними. Расшифрование симметрично с заменой PT и CT.
+func mctRound(info Info, key, iv, in []byte, decrypt bool) (next *mctState, out []byte, err error) {
+	mc, err := newCipher(info, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := segmentBits(info)
+	var w window
+	reg := append([]byte{}, iv...)
+	if iv != nil {
+		w.push(iv, 8*len(iv))
+	}
+	cur := append([]byte{}, in...)
+	for j := 0; j < mctInner; j++ {
+		if out, err = process(mc, info, reg, cur, decrypt); err != nil {
+			return nil, nil, err
+		}
+		if s == 1 {
+			out[0] &= 0x80
+		}
+		ct := out
+		if decrypt {
+			ct = cur
+		}
+		switch {
+		case info.Mode == mycrypto.ModeCBC:
+			reg = append(reg[:0], ct...)
+		case info.Mode == mycrypto.ModeOFB:
+			if reg, err = mycrypto.XORBytes(cur, out); err != nil {
+				return nil, nil, err
+			}
+		case info.Mode == mycrypto.ModeCFB && s == 8*mycrypto.AESBlockSize:
+			reg = append(reg[:0], ct...)
+		case info.Mode == mycrypto.ModeCFB:
+			reg = shiftRegister(reg, ct, s)
+		}
+		w.push(out, s)
+		if info.Mode == mycrypto.ModeECB {
+			cur = append(cur[:0], out...)
+		} else {
+			cur = w.beforeIV(s)
+		}
+	}
+	next = &mctState{in: cur}
+	if next.key, err = mycrypto.XORBytes(key, w[len(w)-len(key):]); err != nil {
+		return nil, nil, err
+	}
+	if info.Mode != mycrypto.ModeECB {
+		next.iv = append([]byte{}, w[len(w)-mycrypto.AESBlockSize:]...)
+	}
+	return next, out, nil
+}
+
+// shiftRegister сдвигает регистр CFB на s бит влево и дописывает сегмент шифротекста
+func shiftRegister(reg, seg []byte, s int) []byte {
+	if s%8 == 0 {
+		return append(reg[len(seg):], seg...)
+	}
+	for i := 0; i < len(reg)-1; i++ {
+		reg[i] = reg[i]<<1 | reg[i+1]>>7
+	}
+	reg[len(reg)-1] = reg[len(reg)-1]<<1 | seg[0]>>7
+	return reg
+}
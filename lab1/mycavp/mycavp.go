@@ -0,0 +1,201 @@
+package mycavp
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Файлы ответов NIST CAVP (.rsp) для AES: разбор -----
+
+// Виды тестов AESAVS: известные ответы (GFSbox, KeySbox, VarKey, VarTxt),
+// сообщения из нескольких блоков и Monte Carlo
+const (
+	KindKAT = "KAT"
+	KindMMT = "MMT"
+	KindMCT = "MCT"
+)
+
+// Секции файла ответов
+const (
+	SectionEncrypt = "ENCRYPT"
+	SectionDecrypt = "DECRYPT"
+)
+
+// Record - запись теста: поля KEY, IV, PLAINTEXT, CIPHERTEXT в исходном виде
+// (hex, у CFB1 открытый текст и шифротекст - строки бит). Line - строка COUNT в файле.
+type Record struct {
+	Count  int
+	Line   int
+	Fields map[string]string
+}
+
+// Section - секция файла, например [ENCRYPT], с записями в порядке файла
+type Section struct {
+	Name    string
+	Records []Record
+}
+
+// File - разобранный файл ответов. Name - имя файла, по нему Run определяет режим и вид теста.
+type File struct {
+	Name     string
+	Comments []string
+	Sections []Section
+}
+
+// Parse читает файл ответов: строки "# ..." - комментарии, "[NAME]" открывает секцию,
+// "COUNT = n" - запись, следующие строки "KEY = value" относятся к ней
+func Parse(r io.Reader) (*File, error) {
+	f := &File{}
+	var sec *Section
+	var rec *Record
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "":
+			rec = nil
+		case strings.HasPrefix(line, "#"):
+			f.Comments = append(f.Comments, strings.TrimSpace(line[1:]))
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			f.Sections = append(f.Sections, Section{Name: strings.TrimSpace(line[1 : len(line)-1])})
+			sec, rec = &f.Sections[len(f.Sections)-1], nil
+		default:
+			name, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected NAME = value, got %q", n, line)
+			}
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+			if name == "COUNT" {
+				if sec == nil {
+					return nil, fmt.Errorf("line %d: record outside a section", n)
+				}
+				count, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: bad COUNT %q", n, value)
+				}
+				sec.Records = append(sec.Records, Record{Count: count, Line: n, Fields: map[string]string{}})
+				rec = &sec.Records[len(sec.Records)-1]
+				continue
+			}
+			if rec == nil {
+				return nil, fmt.Errorf("line %d: field %s outside a record", n, name)
+			}
+			if _, dup := rec.Fields[name]; dup {
+				return nil, fmt.Errorf("line %d: duplicate field %s", n, name)
+			}
+			rec.Fields[name] = value
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Load читает и разбирает файл ответов
+func Load(path string) (*File, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	f, err := Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	f.Name = filepath.Base(path)
+	return f, nil
+}
+
+// Info - режим, вид теста и длина ключа, записанные в имени файла, например CFB8MMT192.rsp
+type Info struct {
+	Mode    string // режим MyCipher
+	Segment int    // размер сегмента CFB в битах, 0 для остальных режимов
+	Kind    string
+	KeyBits int
+}
+
+// String возвращает имя теста вида "CFB8-AES192 MMT"
+func (i Info) String() string {
+	mode := i.Mode
+	if i.Segment != 0 {
+		mode = fmt.Sprintf("%s%d", i.Mode, i.Segment)
+	}
+	return fmt.Sprintf("%s-AES%d %s", mode, i.KeyBits, i.Kind)
+}
+
+var nameRe = regexp.MustCompile(`^(ECB|CBC|OFB|CFB1|CFB8|CFB128)(GFSbox|KeySbox|VarKey|VarTxt|MMT|MCT)(128|192|256)\.rsp$`)
+
+// ParseName определяет режим, вид теста и длину ключа по имени файла из архивов AESAVS
+func ParseName(name string) (Info, error) {
+	m := nameRe.FindStringSubmatch(filepath.Base(name))
+	if m == nil {
+		return Info{}, fmt.Errorf("mycavp: unrecognized file name %q", name)
+	}
+	i := Info{Mode: m[1], Kind: KindKAT}
+	if seg, ok := strings.CutPrefix(m[1], mycrypto.ModeCFB); ok {
+		i.Mode = mycrypto.ModeCFB
+		i.Segment, _ = strconv.Atoi(seg)
+	}
+	if m[2] == KindMMT || m[2] == KindMCT {
+		i.Kind = m[2]
+	}
+	i.KeyBits, _ = strconv.Atoi(m[3])
+	return i, nil
+}
+
+// bitString - открытый текст или шифротекст CFB1: биты упакованы в байты старшим битом вперёд,
+// n - число бит
+type bitString struct {
+	b []byte
+	n int
+}
+
+// decodeData разбирает поле PLAINTEXT или CIPHERTEXT: строку бит у CFB1, hex у остальных режимов
+func decodeData(s string, bits bool) (bitString, error) {
+	if !bits {
+		b, err := hex.DecodeString(s)
+		return bitString{b, 8 * len(b)}, err
+	}
+	v := bitString{b: make([]byte, (len(s)+7)/8), n: len(s)}
+	for i, c := range s {
+		switch c {
+		case '1':
+			v.b[i/8] |= 0x80 >> (i % 8)
+		case '0':
+		default:
+			return bitString{}, fmt.Errorf("bad bit %q", c)
+		}
+	}
+	return v, nil
+}
+
+// String возвращает значение в записи файла: hex или строку бит
+func (v bitString) String(bits bool) string {
+	if !bits {
+		return hex.EncodeToString(v.b)
+	}
+	var sb strings.Builder
+	for i := 0; i < v.n; i++ {
+		sb.WriteByte('0' + v.b[i/8]>>(7-i%8)&1)
+	}
+	return sb.String()
+}
+
+// field возвращает поле записи или ошибку, если его нет
+func (r Record) field(name string) (string, error) {
+	v, ok := r.Fields[name]
+	if !ok {
+		return "", fmt.Errorf("missing %s", name)
+	}
+	return v, nil
+}
@@ -0,0 +1,81 @@
+package myconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// jsonFields перечисляет скалярные значения JSON-документа с их смещениями в тексте.
+// Decoder.InputOffset после предыдущего токена указывает на разделители перед значением,
+// поэтому начало значения находится пропуском пробелов, ':' и ','.
+func jsonFields(doc []byte) ([]field, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var fs []field
+	var walk func(path string) error
+	walk = func(path string) error {
+		start := int(dec.InputOffset())
+		for start < len(doc) && bytes.IndexByte([]byte(" \t\r\n:,"), doc[start]) >= 0 {
+			start++
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := walk(join(path, key.(string))); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+			return err
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(join(path, strconv.Itoa(i))); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+			return err
+		}
+		end := int(dec.InputOffset())
+		fs = append(fs, field{path: path, start: start, end: end, raw: string(doc[start:end])})
+		return nil
+	}
+	if err := walk(""); err != nil {
+		return nil, fmt.Errorf("myconfig: invalid JSON: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("myconfig: invalid JSON: trailing data after the document")
+	}
+	return fs, nil
+}
+
+// join добавляет к пути ключ через точку
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// unquote возвращает значение скаляра без кавычек и экранирования
+func unquote(raw string, format Format) string {
+	if format == YAML {
+		return yamlUnquote(raw)
+	}
+	var s string
+	if len(raw) > 0 && raw[0] == '"' && json.Unmarshal([]byte(raw), &s) == nil {
+		return s
+	}
+	return raw
+}
@@ -0,0 +1,206 @@
+package myconfig
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Файлы конфигурации с зашифрованными полями (в духе SOPS) -----
+
+// Format - формат файла конфигурации
+type Format int
+
+const (
+	JSON Format = iota
+	YAML
+)
+
+func (f Format) String() string {
+	if f == YAML {
+		return "yaml"
+	}
+	return "json"
+}
+
+// Зашифрованное значение: ENC[GCM,base64(nonce || ciphertext || tag)]
+const (
+	encPrefix = "ENC[GCM,"
+	encSuffix = "]"
+)
+
+// field - скалярное значение документа: путь из ключей через точку (индексы массивов - числа)
+// и байтовый диапазон [start, end) исходного текста значения вместе с кавычками
+type field struct {
+	path       string
+	start, end int
+	raw        string
+}
+
+// FormatFromPath определяет формат по расширению файла
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON, nil
+	case ".yaml", ".yml":
+		return YAML, nil
+	}
+	return 0, fmt.Errorf("myconfig: unknown config format of %s, expected .json, .yaml or .yml", path)
+}
+
+func fields(doc []byte, format Format) ([]field, error) {
+	if format == YAML {
+		return yamlFields(doc)
+	}
+	return jsonFields(doc)
+}
+
+// newGCM создаёт AES-GCM из mycrypto на ключе key
+func newGCM(key []byte) (*mycrypto.MyCipher, error) {
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(mycrypto.ModeGCM); err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
+
+// encrypted возвращает содержимое ENC[...] скалярного значения, если оно зашифровано
+func encrypted(f field, format Format) (string, bool) {
+	v := unquote(f.raw, format)
+	if !strings.HasPrefix(v, encPrefix) || !strings.HasSuffix(v, encSuffix) {
+		return "", false
+	}
+	return v[len(encPrefix) : len(v)-len(encSuffix)], true
+}
+
+// rewrite заменяет значения выбранных полей результатом fn, сохраняя остальной текст
+// (комментарии, порядок ключей, отступы) без изменений
+func rewrite(doc []byte, format Format, match *regexp.Regexp, fn func(f field) (string, bool, error)) ([]byte, int, error) {
+	fs, err := fields(doc, format)
+	if err != nil {
+		return nil, 0, err
+	}
+	var out []byte
+	last, n := 0, 0
+	for _, f := range fs {
+		if match != nil && !match.MatchString(f.path) {
+			continue
+		}
+		repl, ok, err := fn(f)
+		if err != nil {
+			return nil, 0, fmt.Errorf("field %s: %v", f.path, err)
+		}
+		if !ok {
+			continue
+		}
+		out = append(out, doc[last:f.start]...)
+		out = append(out, repl...)
+		last = f.end
+		n++
+	}
+	return append(out, doc[last:]...), n, nil
+}
+
+// Encrypt шифрует значения полей, путь которых подходит под match (nil - все поля).
+// Исходный текст значения (с кавычками) шифруется AES-GCM с путём поля в AAD, поэтому
+// шифротексты нельзя незаметно переставить между полями. Уже зашифрованные поля пропускаются.
+// Возвращает новый документ и число зашифрованных полей.
+func Encrypt(doc []byte, format Format, key []byte, match *regexp.Regexp) ([]byte, int, error) {
+	mc, err := newGCM(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return rewrite(doc, format, match, func(f field) (string, bool, error) {
+		if _, ok := encrypted(f, format); ok {
+			return "", false, nil
+		}
+		mc.SetAAD([]byte(f.path))
+		sealed, err := mc.Encrypt([]byte(f.raw), nil)
+		if err != nil {
+			return "", false, err
+		}
+		v := encPrefix + base64.StdEncoding.EncodeToString(sealed) + encSuffix
+		if format == JSON {
+			v = `"` + v + `"`
+		}
+		return v, true, nil
+	})
+}
+
+// Decrypt расшифровывает зашифрованные поля, подходящие под match (nil - все),
+// и возвращает документ в исходном виде. Неверный ключ или подмена значения дают ошибку.
+func Decrypt(doc []byte, format Format, key []byte, match *regexp.Regexp) ([]byte, int, error) {
+	mc, err := newGCM(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return rewrite(doc, format, match, func(f field) (string, bool, error) {
+		enc, ok := encrypted(f, format)
+		if !ok {
+			return "", false, nil
+		}
+		sealed, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid base64: %v", err)
+		}
+		mc.SetAAD([]byte(f.path))
+		raw, err := mc.Decrypt(sealed, nil)
+		if err != nil {
+			return "", false, err
+		}
+		return string(raw), true, nil
+	})
+}
+
+// Values возвращает все скалярные поля документа в виде путь -> значение без кавычек.
+// Зашифрованные поля расшифровываются; key может быть nil, если их нет.
+func Values(doc []byte, format Format, key []byte) (map[string]string, error) {
+	fs, err := fields(doc, format)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fs {
+		if _, ok := encrypted(f, format); ok {
+			if key == nil {
+				return nil, fmt.Errorf("field %s is encrypted, a key is required", f.path)
+			}
+			if doc, _, err = Decrypt(doc, format, key, nil); err != nil {
+				return nil, err
+			}
+			if fs, err = fields(doc, format); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	values := make(map[string]string, len(fs))
+	for _, f := range fs {
+		values[f.path] = unquote(f.raw, format)
+	}
+	return values, nil
+}
+
+// Load читает файл конфигурации (формат по расширению) и возвращает его поля с расшифрованными секретами
+func Load(path string, key []byte) (map[string]string, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := Values(doc, format, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,156 @@
+package myconfig
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlFields перечисляет скалярные значения YAML-документа. Поддерживается подмножество,
+// типичное для конфигураций: вложенные блочные отображения "key: value" с отступами пробелами,
+// простые и закавыченные однострочные скаляры, комментарии. Последовательности, блочные
+// скаляры (| и >), потоковые коллекции ([...], {...}), якоря и теги пропускаются целиком:
+// их значения не перечисляются и не шифруются.
+func yamlFields(doc []byte) ([]field, error) {
+	type level struct {
+		indent int
+		key    string
+	}
+	var fs []field
+	var stack []level
+	skipIndent := -1 // пропуск содержимого последовательности или блочного скаляра
+	skipSeq := false
+	offset := 0
+	for n, line := range bytes.SplitAfter(doc, []byte("\n")) {
+		lineStart := offset
+		offset += len(line)
+		text := strings.TrimRight(string(line), "\r\n")
+		trimmed := strings.TrimLeft(text, " ")
+		indent := len(text) - len(trimmed)
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		if skipIndent >= 0 {
+			if indent > skipIndent || (skipSeq && indent == skipIndent && isSeqItem(trimmed)) {
+				continue
+			}
+			skipIndent = -1
+		}
+		if indent == 0 && (strings.HasPrefix(trimmed, "---") || strings.HasPrefix(trimmed, "...") || trimmed[0] == '%') {
+			stack = stack[:0]
+			continue
+		}
+		if trimmed[0] == '\t' {
+			return nil, fmt.Errorf("myconfig: YAML line %d: tabs are not allowed in indentation", n+1)
+		}
+		if isSeqItem(trimmed) {
+			skipIndent, skipSeq = indent, true
+			continue
+		}
+		key, rest, err := yamlKey(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("myconfig: YAML line %d: %v", n+1, err)
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		path := ""
+		for _, l := range stack {
+			path = join(path, l.key)
+		}
+		path = join(path, key)
+
+		value := strings.TrimLeft(rest, " ")
+		valueStart := lineStart + len(text) - len(value)
+		if value == "" || value[0] == '#' {
+			stack = append(stack, level{indent, key})
+			continue
+		}
+		switch value[0] {
+		case '|', '>':
+			skipIndent, skipSeq = indent, false
+			continue
+		case '[', '{', '&', '*', '!':
+			continue
+		}
+		token, err := yamlScalar(value)
+		if err != nil {
+			return nil, fmt.Errorf("myconfig: YAML line %d: %v", n+1, err)
+		}
+		fs = append(fs, field{path: path, start: valueStart, end: valueStart + len(token), raw: token})
+	}
+	return fs, nil
+}
+
+func isSeqItem(s string) bool {
+	return s == "-" || strings.HasPrefix(s, "- ")
+}
+
+// yamlKey отделяет ключ отображения от остатка строки после двоеточия
+func yamlKey(s string) (key, rest string, err error) {
+	if s[0] == '"' || s[0] == '\'' {
+		token, err := yamlScalar(s)
+		if err != nil {
+			return "", "", err
+		}
+		after := s[len(token):]
+		if !strings.HasPrefix(after, ":") {
+			return "", "", fmt.Errorf("expected ':' after key %s", token)
+		}
+		return yamlUnquote(token), after[1:], nil
+	}
+	i := strings.Index(s, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(s, ":") {
+			return "", "", fmt.Errorf("expected 'key: value', got %q", s)
+		}
+		i = len(s) - 1
+	}
+	return strings.TrimRight(s[:i], " "), s[i+1:], nil
+}
+
+// yamlScalar возвращает текст однострочного скаляра в начале s без хвостового комментария
+func yamlScalar(s string) (string, error) {
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				return s[:i+1], nil
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted scalar")
+	case '\'':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\'' {
+				if i+1 < len(s) && s[i+1] == '\'' {
+					i++
+					continue
+				}
+				return s[:i+1], nil
+			}
+		}
+		return "", fmt.Errorf("unterminated single-quoted scalar")
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimRight(s, " "), nil
+}
+
+// yamlUnquote снимает кавычки с однострочного скаляра
+func yamlUnquote(raw string) string {
+	if len(raw) >= 2 && raw[0] == '"' {
+		if s, err := strconv.Unquote(raw); err == nil {
+			return s
+		}
+		return raw[1 : len(raw)-1]
+	}
+	if len(raw) >= 2 && raw[0] == '\'' {
+		return strings.ReplaceAll(raw[1:len(raw)-1], "''", "'")
+	}
+	return raw
+}
@@ -0,0 +1,184 @@
+package mycrypto
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ----- Рекомендации по выбору режима и проверка конфигурации на типичные ошибки -----
+
+// MinTagSize - минимальная длина тега (байт), при которой подделка перебором считается непрактичной
+const MinTagSize = 12
+
+// Severity - важность предупреждения
+type Severity int
+
+const (
+	SeverityInfo     Severity = iota // стоит знать, но безопасность не нарушена
+	SeverityWarning                  // уязвимо при определённых условиях использования
+	SeverityCritical                 // конфиденциальность или целостность нарушаются напрямую
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Коды предупреждений Analyze
+const (
+	WarnUnknownMode   = "unknown-mode"
+	WarnECB           = "ecb"
+	WarnNoIntegrity   = "no-integrity"
+	WarnPaddingOracle = "padding-oracle"
+	WarnShortTag      = "short-tag"
+	WarnStaticIV      = "static-iv"
+	WarnPredictableIV = "predictable-iv"
+	WarnIVLength      = "iv-length"
+	WarnShortKey      = "short-key"
+	WarnBirthdayBound = "birthday-bound"
+)
+
+// Config - описание предполагаемого использования MyCipher; нулевые поля означают «не задано»
+type Config struct {
+	Mode      string // режим MyCipher (ModeECB, ..., ModeOCB)
+	BlockSize int    // размер блока шифра в байтах (0 - AES, 16)
+	KeySize   int    // длина ключа в байтах (0 - не проверять)
+	MAC       string // внешний MAC поверх шифротекста для режимов без аутентификации ("" - нет)
+	TagSize   int    // длина тега в байтах (0 - полный тег режима или MAC)
+	IV        []byte // IV/nonce, который вызывающий передаёт в Encrypt (nil - генерируется)
+	StaticIV  bool   // один и тот же IV используется для нескольких сообщений
+	DataBytes int64  // объём данных под одним ключом (0 - не проверять)
+}
+
+// Warning - структурированное предупреждение о конфигурации
+type Warning struct {
+	Code       string
+	Severity   Severity
+	Message    string
+	Suggestion string
+}
+
+func (w Warning) String() string {
+	s := fmt.Sprintf("%s [%s]: %s", w.Severity, w.Code, w.Message)
+	if w.Suggestion != "" {
+		s += "; " + w.Suggestion
+	}
+	return s
+}
+
+func isAEAD(mode string) bool {
+	return mode == ModeGCM || mode == ModeOCB
+}
+
+// RecommendMode возвращает режим, который следует использовать вместо заданного:
+// AEAD для 128-битного блока и CTR с внешним MAC для 64-битного (GCM и OCB требуют 16-байтный блок)
+func RecommendMode(cfg Config) string {
+	if cfg.BlockSize != 0 && cfg.BlockSize != AESBlockSize {
+		return ModeCTR
+	}
+	if cfg.Mode == ModeOCB {
+		return ModeOCB
+	}
+	return ModeGCM
+}
+
+// Analyze проверяет конфигурацию на ошибки, разобранные в лабораторных (ECB, отсутствие
+// целостности, короткий тег, повтор IV и т.д.), и возвращает предупреждения в порядке
+// убывания важности. Пустой результат не доказывает безопасность, а лишь означает,
+// что известных проверке ошибок не найдено.
+func Analyze(cfg Config) []Warning {
+	var ws []Warning
+	add := func(code string, sev Severity, msg, suggestion string) {
+		ws = append(ws, Warning{Code: code, Severity: sev, Message: msg, Suggestion: suggestion})
+	}
+	blockSize := cfg.BlockSize
+	if blockSize == 0 {
+		blockSize = AESBlockSize
+	}
+	recommended := "use " + RecommendMode(cfg)
+	if RecommendMode(cfg) == ModeCTR {
+		recommended += " with a MAC (GCM and OCB need a 128-bit block)"
+	}
+
+	switch cfg.Mode {
+	case ModeECB:
+		add(WarnECB, SeverityCritical, "ECB encrypts equal blocks to equal ciphertext blocks and leaks message structure", recommended)
+	case ModeCBC, ModeCTS, ModeCFB, ModeOFB, ModeCTR, ModeGCM, ModeOCB:
+	default:
+		add(WarnUnknownMode, SeverityCritical, fmt.Sprintf("mode %q is not supported by MyCipher", cfg.Mode), recommended)
+		return ws
+	}
+
+	// Целостность: режимы без тега допускают подмену битов, а CBC с PKCS7 ещё и оракул паддинга
+	if !isAEAD(cfg.Mode) && cfg.MAC == "" {
+		switch cfg.Mode {
+		case ModeCBC:
+			add(WarnPaddingOracle, SeverityCritical, "CBC with PKCS7 and no MAC is open to padding oracle attacks that decrypt any ciphertext", recommended+", or encrypt-then-MAC")
+		case ModeCTR, ModeOFB, ModeCFB:
+			add(WarnNoIntegrity, SeverityWarning, cfg.Mode+" is malleable: flipping a ciphertext bit flips the same plaintext bit undetected", recommended+", or encrypt-then-MAC")
+		case ModeCTS:
+			add(WarnNoIntegrity, SeverityWarning, "CTS provides no integrity: modified ciphertext decrypts to garbage without an error", recommended+", or encrypt-then-MAC")
+		}
+	}
+	if cfg.TagSize > 0 && cfg.TagSize < MinTagSize && (isAEAD(cfg.Mode) || cfg.MAC != "") {
+		add(WarnShortTag, SeverityWarning,
+			fmt.Sprintf("%d-byte tag can be forged with about 2^%d attempts", cfg.TagSize, 8*cfg.TagSize),
+			fmt.Sprintf("use a tag of at least %d bytes", MinTagSize))
+	}
+
+	// IV, заданный вызывающим: длина и предсказуемость
+	if cfg.Mode != ModeECB && cfg.IV != nil {
+		want := blockSize
+		switch cfg.Mode {
+		case ModeGCM:
+			want = GCMNonceSize
+		case ModeOCB:
+			want = OCBNonceSize
+		}
+		if len(cfg.IV) != want && !(cfg.Mode == ModeOCB && len(cfg.IV) >= 1 && len(cfg.IV) <= OCBMaxNonceSize) {
+			add(WarnIVLength, SeverityWarning,
+				fmt.Sprintf("%d-byte IV for %s, expected %d", len(cfg.IV), cfg.Mode, want),
+				"pass nil to let Encrypt generate a random IV")
+		}
+		if !cfg.StaticIV && (cfg.Mode == ModeCBC || cfg.Mode == ModeCTS) {
+			add(WarnPredictableIV, SeverityInfo, "a caller-chosen CBC IV must be unpredictable to the attacker before encryption", "pass nil IV unless interoperability requires a fixed one")
+		}
+	}
+
+	// Повтор IV в потоковых режимах и GCM раскрывает XOR открытых текстов, в CBC - общие префиксы
+	if cfg.StaticIV && cfg.Mode != ModeECB {
+		switch cfg.Mode {
+		case ModeCTR, ModeOFB, ModeCFB, ModeGCM, ModeOCB:
+			msg := "reusing an IV repeats the keystream, so the XOR of two ciphertexts is the XOR of the plaintexts"
+			if cfg.Mode == ModeGCM {
+				msg += ", and GCM also leaks the authentication key H"
+			}
+			add(WarnStaticIV, SeverityCritical, msg, "pass nil IV or a fresh unique nonce per message")
+		default:
+			add(WarnStaticIV, SeverityWarning, "reusing a CBC IV reveals which messages share a prefix", "pass nil IV to get a fresh random one per message")
+		}
+	}
+
+	if cfg.KeySize > 0 && cfg.KeySize < AESKeySize16 {
+		add(WarnShortKey, SeverityCritical, fmt.Sprintf("%d-bit key is within reach of exhaustive search", 8*cfg.KeySize), "use a key of at least 128 bits")
+	}
+	// Граница дней рождения: после 2^(n/2) блоков под одним ключом коллизии блоков становятся вероятны (Sweet32)
+	if cfg.DataBytes > 0 && blockSize < AESBlockSize {
+		limit := int64(blockSize) << (4 * blockSize)
+		if cfg.DataBytes >= limit/64 {
+			add(WarnBirthdayBound, SeverityWarning,
+				fmt.Sprintf("%d bytes under one key approach the birthday bound of %d bytes for a %d-bit block", cfg.DataBytes, limit, 8*blockSize),
+				"rekey well before that or use a 128-bit block cipher")
+		}
+	}
+
+	sort.SliceStable(ws, func(i, j int) bool { return ws[i].Severity > ws[j].Severity })
+	return ws
+}
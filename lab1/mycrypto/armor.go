@@ -0,0 +1,272 @@
+package mycrypto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ----- ASCII-обёртка шифротекста для текстовых отчётов -----
+
+// Кодировки тела ASCII-обёртки
+const (
+	ArmorBase64 = "base64"
+	ArmorHex    = "hex"
+)
+
+// ArmorHeaderEncoding - заголовок с кодировкой тела; пишется всегда, при чтении без него считается base64
+const ArmorHeaderEncoding = "Encoding"
+
+// armorLineLen - число символов тела в строке
+const armorLineLen = 64
+
+const armorDashes = "-----"
+
+// checkArmorLabel проверяет, что метку можно вписать в строки BEGIN/END
+func checkArmorLabel(label string) error {
+	if label == "" || strings.ContainsAny(label, "\r\n") || strings.Contains(label, armorDashes) {
+		return fmt.Errorf("invalid armor label %q", label)
+	}
+	return nil
+}
+
+// lineWriter вставляет перевод строки через каждые armorLineLen символов
+type lineWriter struct {
+	w   io.Writer
+	col int
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), armorLineLen-lw.col)
+		if _, err := lw.w.Write(p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		lw.col += n
+		p = p[n:]
+		if lw.col == armorLineLen {
+			if _, err := io.WriteString(lw.w, "\n"); err != nil {
+				return written, err
+			}
+			lw.col = 0
+		}
+	}
+	return written, nil
+}
+
+type armorWriter struct {
+	w      io.Writer
+	lines  *lineWriter
+	enc    io.Writer
+	label  string
+	closed bool
+}
+
+// NewArmorWriter возвращает поток, который пишет в w строку "-----BEGIN label-----", заголовки
+// "Ключ: значение" (по алфавиту, вместе с Encoding), пустую строку и тело в кодировке encoding
+// (ArmorBase64 или ArmorHex) по 64 символа в строке. Строку END дописывает Close; w он не закрывает.
+func NewArmorWriter(w io.Writer, label, encoding string, headers map[string]string) (io.WriteCloser, error) {
+	if err := checkArmorLabel(label); err != nil {
+		return nil, err
+	}
+	if encoding != ArmorBase64 && encoding != ArmorHex {
+		return nil, fmt.Errorf("unsupported armor encoding %q", encoding)
+	}
+	all := map[string]string{ArmorHeaderEncoding: encoding}
+	for k, v := range headers {
+		if k == "" || strings.ContainsAny(k, ":\r\n") || strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("invalid armor header %q: %q", k, v)
+		}
+		if k != ArmorHeaderEncoding {
+			all[k] = v
+		}
+	}
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var head strings.Builder
+	fmt.Fprintf(&head, "%sBEGIN %s%s\n", armorDashes, label, armorDashes)
+	for _, k := range keys {
+		fmt.Fprintf(&head, "%s: %s\n", k, all[k])
+	}
+	head.WriteString("\n")
+	if _, err := io.WriteString(w, head.String()); err != nil {
+		return nil, err
+	}
+	aw := &armorWriter{w: w, lines: &lineWriter{w: w}, label: label}
+	if encoding == ArmorHex {
+		aw.enc = hex.NewEncoder(aw.lines)
+	} else {
+		aw.enc = base64.NewEncoder(base64.StdEncoding, aw.lines)
+	}
+	return aw, nil
+}
+
+func (aw *armorWriter) Write(p []byte) (int, error) {
+	if aw.closed {
+		return 0, ErrClosedWriter
+	}
+	return aw.enc.Write(p)
+}
+
+// Close дописывает остаток тела и строку "-----END label-----"
+func (aw *armorWriter) Close() error {
+	if aw.closed {
+		return nil
+	}
+	aw.closed = true
+	if c, ok := aw.enc.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+	if aw.lines.col > 0 {
+		if _, err := io.WriteString(aw.w, "\n"); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(aw.w, "%sEND %s%s\n", armorDashes, aw.label, armorDashes)
+	return err
+}
+
+// ArmorReader читает тело ASCII-обёртки, записанной NewArmorWriter или Armor
+type ArmorReader struct {
+	Label   string
+	Headers map[string]string
+
+	dec io.Reader
+}
+
+// NewArmorReader пропускает текст до строки BEGIN (обёртку можно вставить в отчёт целиком),
+// разбирает заголовки и возвращает поток декодированного тела. Пробелы и пустые строки в теле
+// игнорируются; если строки END нет, чтение завершается ошибкой.
+func NewArmorReader(r io.Reader) (*ArmorReader, error) {
+	br := bufio.NewReader(r)
+	ar := &ArmorReader{Headers: map[string]string{}}
+	for {
+		line, err := br.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, armorDashes+"BEGIN ") && strings.HasSuffix(line, armorDashes) && len(line) > 2*len(armorDashes)+6 {
+			ar.Label = line[len(armorDashes)+6 : len(line)-len(armorDashes)]
+			break
+		}
+		if err == io.EOF {
+			return nil, errors.New("armor: BEGIN line not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	body := &armorBody{br: br, end: armorDashes + "END " + ar.Label + armorDashes}
+	// заголовки идут до пустой строки; строка без двоеточия - уже тело (base64 и hex двоеточий не содержат)
+	for {
+		line, err := body.readLine()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line == "" {
+			break // конец заголовков или сразу END (пустое тело)
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			body.pending = []byte(strings.Join(strings.Fields(line), ""))
+			break
+		}
+		ar.Headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	switch enc := ar.Headers[ArmorHeaderEncoding]; enc {
+	case "", ArmorBase64:
+		ar.dec = base64.NewDecoder(base64.StdEncoding, body)
+	case ArmorHex:
+		ar.dec = hex.NewDecoder(body)
+	default:
+		return nil, fmt.Errorf("armor: unsupported encoding %q", enc)
+	}
+	return ar, nil
+}
+
+func (ar *ArmorReader) Read(p []byte) (int, error) {
+	return ar.dec.Read(p)
+}
+
+// armorBody отдаёт символы тела без пробелов до строки END
+type armorBody struct {
+	br      *bufio.Reader
+	end     string
+	pending []byte
+	done    bool
+}
+
+func (b *armorBody) readLine() (string, error) {
+	line, err := b.br.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err == io.EOF {
+		return "", fmt.Errorf("armor: missing %q line: %w", b.end, io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	if line == b.end {
+		b.done = true
+		return "", io.EOF
+	}
+	return line, nil
+}
+
+func (b *armorBody) Read(p []byte) (int, error) {
+	for len(b.pending) == 0 {
+		if b.done {
+			return 0, io.EOF
+		}
+		line, err := b.readLine()
+		if err != nil {
+			return 0, err
+		}
+		b.pending = []byte(strings.Join(strings.Fields(line), ""))
+	}
+	n := copy(p, b.pending)
+	b.pending = b.pending[n:]
+	return n, nil
+}
+
+// Armor оборачивает data целиком; см. NewArmorWriter
+func Armor(data []byte, label, encoding string, headers map[string]string) (string, error) {
+	var buf bytes.Buffer
+	aw, err := NewArmorWriter(&buf, label, encoding, headers)
+	if err != nil {
+		return "", err
+	}
+	if _, err := aw.Write(data); err != nil {
+		return "", err
+	}
+	if err := aw.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Dearmor извлекает данные, метку и заголовки из первой ASCII-обёртки в text
+func Dearmor(text string) (data []byte, label string, headers map[string]string, err error) {
+	ar, err := NewArmorReader(strings.NewReader(text))
+	if err != nil {
+		return nil, "", nil, err
+	}
+	data, err = io.ReadAll(ar)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return data, ar.Label, ar.Headers, nil
+}
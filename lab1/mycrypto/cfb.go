@@ -0,0 +1,65 @@
+package mycrypto
+
+import "fmt"
+
+// ----- Размер сегмента CFB (NIST SP 800-38A, CFB-s) -----
+
+// SetSegmentSize задаёт размер сегмента CFB в битах: 1, 8, 64 или 128 (полный блок, по умолчанию).
+// За один вызов AES обрабатывается один сегмент, поэтому CFB8 в 16 раз, а CFB1 в 128 раз медленнее CFB128.
+func (mc *MyCipher) SetSegmentSize(bits int) error {
+	switch bits {
+	case 1, 8, 64, 128:
+		mc.cfbSegment = bits
+		mc.resetStream()
+		return nil
+	default:
+		return fmt.Errorf("unsupported CFB segment size: %d bits, expected 1, 8, 64, or 128", bits)
+	}
+}
+
+// segmentLen возвращает длину сегмента гаммы в байтах: для CFB-s - s/8, для остальных режимов
+// и для сегмента не короче блока (CFB64 с 64-битным шифром) - блок
+func (mc *MyCipher) segmentLen() int {
+	if mc.mode == ModeCFB && mc.cfbSegment >= 8 && mc.cfbSegment/8 < mc.blockSize {
+		return mc.cfbSegment / 8
+	}
+	return mc.blockSize
+}
+
+// cfb1XOR шифрует или расшифровывает data побитно в режиме CFB1, начиная со старшего бита байта.
+// Регистр сдвигается на бит шифротекста после каждого бита, так что куски любой длины стыкуются.
+func (mc *MyCipher) cfb1XOR(data []byte, decrypt bool) ([]byte, error) {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		var reg []byte
+		if mc.hook != nil {
+			reg = append(reg, mc.lastBlock...)
+		}
+		for bit := 7; bit >= 0; bit-- {
+			ks, err := mc.BlockCipherEncrypt(mc.lastBlock)
+			if err != nil {
+				return nil, err
+			}
+			in := b >> uint(bit) & 1
+			o := in ^ ks[0]>>7
+			out[i] |= o << uint(bit)
+			c := o
+			if decrypt {
+				c = in
+			}
+			shiftLeft1(mc.lastBlock, c)
+		}
+		if mc.hook != nil {
+			mc.emit(decrypt, reg, data[i:i+1], out[i:i+1])
+		}
+	}
+	return out, nil
+}
+
+// shiftLeft1 сдвигает регистр на бит влево и дописывает bit в младший разряд
+func shiftLeft1(reg []byte, bit byte) {
+	for i := 0; i < len(reg)-1; i++ {
+		reg[i] = reg[i]<<1 | reg[i+1]>>7
+	}
+	reg[len(reg)-1] = reg[len(reg)-1]<<1 | bit
+}
@@ -0,0 +1,78 @@
+package mycrypto
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// ----- Поточный шифр ChaCha20 (RFC 8439) -----
+
+const (
+	ChaCha20KeySize   = 32
+	ChaCha20NonceSize = 12
+	chachaBlockSize   = 64
+)
+
+// chachaQuarter - четвертьраунд ChaCha
+func chachaQuarter(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	a += b
+	d = bits.RotateLeft32(d^a, 16)
+	c += d
+	b = bits.RotateLeft32(b^c, 12)
+	a += b
+	d = bits.RotateLeft32(d^a, 8)
+	c += d
+	b = bits.RotateLeft32(b^c, 7)
+	return a, b, c, d
+}
+
+// chachaBlock записывает в out 64-байтовый блок гаммы для заданного счётчика
+func chachaBlock(out []byte, key, nonce []byte, counter uint32) {
+	var s [16]uint32
+	s[0], s[1], s[2], s[3] = 0x61707865, 0x3320646e, 0x79622d32, 0x6b206574 // "expand 32-byte k"
+	for i := 0; i < 8; i++ {
+		s[4+i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	s[12] = counter
+	for i := 0; i < 3; i++ {
+		s[13+i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+	x := s
+	for i := 0; i < 10; i++ {
+		// столбцы
+		x[0], x[4], x[8], x[12] = chachaQuarter(x[0], x[4], x[8], x[12])
+		x[1], x[5], x[9], x[13] = chachaQuarter(x[1], x[5], x[9], x[13])
+		x[2], x[6], x[10], x[14] = chachaQuarter(x[2], x[6], x[10], x[14])
+		x[3], x[7], x[11], x[15] = chachaQuarter(x[3], x[7], x[11], x[15])
+		// диагонали
+		x[0], x[5], x[10], x[15] = chachaQuarter(x[0], x[5], x[10], x[15])
+		x[1], x[6], x[11], x[12] = chachaQuarter(x[1], x[6], x[11], x[12])
+		x[2], x[7], x[8], x[13] = chachaQuarter(x[2], x[7], x[8], x[13])
+		x[3], x[4], x[9], x[14] = chachaQuarter(x[3], x[4], x[9], x[14])
+	}
+	for i := range x {
+		binary.LittleEndian.PutUint32(out[4*i:], x[i]+s[i])
+	}
+}
+
+// ChaCha20XOR накладывает на data гамму ChaCha20, начиная с блока counter
+func ChaCha20XOR(key, nonce []byte, counter uint32, data []byte) ([]byte, error) {
+	if len(key) != ChaCha20KeySize {
+		return nil, fmt.Errorf("ChaCha20: invalid key length %d, expected %d", len(key), ChaCha20KeySize)
+	}
+	if len(nonce) != ChaCha20NonceSize {
+		return nil, fmt.Errorf("ChaCha20: invalid nonce length %d, expected %d", len(nonce), ChaCha20NonceSize)
+	}
+	if uint64(counter)+uint64(len(data)+chachaBlockSize-1)/chachaBlockSize > 1<<32 {
+		return nil, fmt.Errorf("ChaCha20: counter overflow")
+	}
+	out := make([]byte, len(data))
+	var ks [chachaBlockSize]byte
+	for off := 0; off < len(data); off += chachaBlockSize {
+		chachaBlock(ks[:], key, nonce, counter)
+		counter++
+		XORTo(out[off:], data[off:], ks[:])
+	}
+	return out, nil
+}
@@ -0,0 +1,128 @@
+package mycrypto
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ----- Обработка кусков произвольной длины -----
+
+// ErrStreamFinalized возвращается при вызове Update или Finalize после Finalize
+var ErrStreamFinalized = errors.New("stream already finalized")
+
+// ChunkStream шифрует или расшифровывает сообщение, поданное кусками любой длины:
+// неполные блоки накапливаются внутри, результат совпадает с Encrypt/Decrypt всего сообщения.
+// В ECB и CBC при расшифровании последний блок придерживается до Finalize ради проверки паддинга.
+// GCM, OCB и CTS не обрабатываются поблочно: сообщение накапливается и обрабатывается в Finalize,
+// а открытый текст GCM и OCB выдаётся только после проверки тега.
+type ChunkStream struct {
+	mc      *MyCipher
+	decrypt bool
+	buf     []byte // неполный блок (ECB, CBC) или всё сообщение (GCM, OCB, CTS)
+	done    bool
+	err     error
+}
+
+// NewEncryptStream начинает шифрование нового сообщения; IV или nonce генерируется и выдаётся первым.
+// Пока поток не завершён, mc нельзя использовать для других сообщений.
+func (mc *MyCipher) NewEncryptStream() (*ChunkStream, error) {
+	return mc.newChunkStream(false)
+}
+
+// NewDecryptStream начинает расшифрование сообщения, IV или nonce которого идёт в начале шифротекста
+func (mc *MyCipher) NewDecryptStream() (*ChunkStream, error) {
+	return mc.newChunkStream(true)
+}
+
+func (mc *MyCipher) newChunkStream(decrypt bool) (*ChunkStream, error) {
+	if mc.block == nil {
+		return nil, errors.New("key unsetted")
+	}
+	if err := mc.checkBlockSize(); err != nil {
+		return nil, err
+	}
+	switch mc.mode {
+	case ModeECB, ModeCBC, ModeCFB, ModeOFB, ModeCTR, ModeGCM, ModeOCB, ModeCTS:
+	default:
+		return nil, fmt.Errorf("unsupported mode: %s", mc.mode)
+	}
+	mc.Reset()
+	return &ChunkStream{mc: mc, decrypt: decrypt}, nil
+}
+
+// process передаёт блок в ProcessBlockEncrypt или ProcessBlockDecrypt
+func (s *ChunkStream) process(data []byte, final bool, padding string) ([]byte, error) {
+	if s.decrypt {
+		return s.mc.ProcessBlockDecrypt(data, final, padding)
+	}
+	return s.mc.ProcessBlockEncrypt(data, final, padding)
+}
+
+// Update обрабатывает очередной кусок и возвращает выход, который уже можно отдать.
+// После ошибки поток непригоден: все следующие вызовы возвращают её же.
+func (s *ChunkStream) Update(chunk []byte) ([]byte, error) {
+	if s.done {
+		return nil, ErrStreamFinalized
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	out, err := s.update(chunk)
+	s.err = err
+	return out, err
+}
+
+func (s *ChunkStream) update(chunk []byte) ([]byte, error) {
+	switch s.mc.mode {
+	case ModeGCM, ModeOCB, ModeCTS:
+		s.buf = append(s.buf, chunk...)
+		return nil, nil
+	case ModeCFB, ModeOFB, ModeCTR:
+		if len(chunk) == 0 {
+			return nil, nil
+		}
+		return s.process(chunk, false, PaddingNON)
+	}
+	bs := s.mc.blockSize
+	s.buf = append(s.buf, chunk...)
+	var out []byte
+	// полный блок при шифровании уходит сразу (паддинг займёт отдельный блок),
+	// при расшифровании последний блок ждёт Finalize
+	for len(s.buf) > bs || (!s.decrypt && len(s.buf) == bs) {
+		res, err := s.process(s.buf[:bs], false, PaddingPKCS7)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, res...)
+		s.buf = s.buf[bs:]
+	}
+	s.buf = append([]byte(nil), s.buf...)
+	return out, nil
+}
+
+// Finalize обрабатывает остаток сообщения: добавляет или снимает паддинг, дописывает или проверяет тег
+func (s *ChunkStream) Finalize() ([]byte, error) {
+	if s.done {
+		return nil, ErrStreamFinalized
+	}
+	s.done = true
+	if s.err != nil {
+		return nil, s.err
+	}
+	mc := s.mc
+	buf := s.buf
+	s.buf = nil
+	switch mc.mode {
+	case ModeGCM, ModeOCB, ModeCTS:
+		if s.decrypt {
+			return mc.Decrypt(buf, nil)
+		}
+		return mc.Encrypt(buf, nil)
+	case ModeCFB, ModeOFB, ModeCTR:
+		return s.process(nil, true, PaddingNON)
+	}
+	if s.decrypt && (len(buf) != mc.blockSize || (mc.mode == ModeCBC && mc.lastBlock == nil)) {
+		return nil, fmt.Errorf("%s: ciphertext length is not a multiple of the block size or too short", mc.mode)
+	}
+	return s.process(buf, true, PaddingPKCS7)
+}
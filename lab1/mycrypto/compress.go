@@ -0,0 +1,87 @@
+package mycrypto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ----- Функции сжатия на основе блочного шифра -----
+
+// CompressionFunc - функция сжатия: новое состояние по текущему состоянию и блоку сообщения
+type CompressionFunc func(state, block []byte) ([]byte, error)
+
+// DaviesMeyer - функция сжатия Дэвиса–Мейера: H_i = E_{m_i}(H_{i-1}) xor H_{i-1}.
+// Блок сообщения служит ключом AES-128, состояние - блоком открытого текста.
+func DaviesMeyer(state, block []byte) ([]byte, error) {
+	if len(state) != AESBlockSize || len(block) != AESKeySize16 {
+		return nil, fmt.Errorf("DaviesMeyer: state and block must be %d bytes", AESBlockSize)
+	}
+	mc := &MyCipher{}
+	if err := mc.SetKey(block); err != nil {
+		return nil, err
+	}
+	enc, err := mc.BlockCipherEncrypt(state)
+	if err != nil {
+		return nil, err
+	}
+	return XORBytes(enc, state)
+}
+
+// MatyasMeyerOseas - функция сжатия Матиаса–Мейера–Осеаса: H_i = E_{g(H_{i-1})}(m_i) xor m_i,
+// где g - тождественное отображение (длины состояния и ключа AES-128 совпадают)
+func MatyasMeyerOseas(state, block []byte) ([]byte, error) {
+	if len(state) != AESKeySize16 || len(block) != AESBlockSize {
+		return nil, fmt.Errorf("MatyasMeyerOseas: state and block must be %d bytes", AESBlockSize)
+	}
+	mc := &MyCipher{}
+	if err := mc.SetKey(state); err != nil {
+		return nil, err
+	}
+	enc, err := mc.BlockCipherEncrypt(block)
+	if err != nil {
+		return nil, err
+	}
+	return XORBytes(enc, block)
+}
+
+// MDHash - хэш-функция по схеме Меркла–Дамгора над функцией сжатия
+type MDHash struct {
+	compress  CompressionFunc
+	iv        []byte
+	blockSize int
+}
+
+// NewMDHash создаёт хэш-функцию; iv задаёт начальное состояние,
+// blockSize - длину блока сообщения, принимаемого функцией сжатия
+func NewMDHash(compress CompressionFunc, iv []byte, blockSize int) (*MDHash, error) {
+	if compress == nil {
+		return nil, errors.New("NewMDHash: compression function is nil")
+	}
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("NewMDHash: invalid block size %d", blockSize)
+	}
+	return &MDHash{compress: compress, iv: append([]byte{}, iv...), blockSize: blockSize}, nil
+}
+
+// Sum вычисляет хэш с MD-усилением: 0x80, нули и 8 байт длины сообщения в битах
+func (h *MDHash) Sum(msg []byte) ([]byte, error) {
+	padded := append(append([]byte{}, msg...), 0x80)
+	for (len(padded)+8)%h.blockSize != 0 {
+		padded = append(padded, 0)
+	}
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(msg))*8)
+	padded = append(padded, length[:]...)
+
+	state := append([]byte{}, h.iv...)
+	var err error
+	for len(padded) > 0 {
+		state, err = h.compress(state, padded[:h.blockSize])
+		if err != nil {
+			return nil, err
+		}
+		padded = padded[h.blockSize:]
+	}
+	return state, nil
+}
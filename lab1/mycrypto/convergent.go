@@ -0,0 +1,72 @@
+package mycrypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+// ----- Конвергентное шифрование (дедупликация зашифрованных данных) -----
+
+// convergentIVLabel разделяет хэши для ключа и для IV
+var convergentIVLabel = []byte("convergent-iv")
+
+// ErrConvergentMismatch возвращается, если расшифрованные данные не дают исходный ключ
+var ErrConvergentMismatch = errors.New("convergent decrypt: plaintext does not match key")
+
+// ConvergentKey вычисляет ключ сообщения K = SHA-256(secret || data).
+// При пустом secret ключ зависит только от данных, и одинаковые файлы разных пользователей
+// шифруются одинаково; общий секрет домена ограничивает дедупликацию (и атаки) этим доменом.
+func ConvergentKey(data, secret []byte) []byte {
+	h := sha256.New()
+	h.Write(secret)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// convergentCipher готовит AES-256-CTR на ключе key и детерминированный IV = SHA-256(label || key)[:16]
+func convergentCipher(key []byte) (*MyCipher, []byte, error) {
+	mc := &MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		return nil, nil, err
+	}
+	if err := mc.SetMode(ModeCTR); err != nil {
+		return nil, nil, err
+	}
+	iv := sha256.Sum256(append(append([]byte{}, convergentIVLabel...), key...))
+	return mc, iv[:AESBlockSize], nil
+}
+
+// ConvergentEncrypt шифрует data на ключе ConvergentKey(data, secret).
+// Шифротекст (IV || ciphertext) полностью определяется данными и secret.
+func ConvergentEncrypt(data, secret []byte) (key, ct []byte, err error) {
+	key = ConvergentKey(data, secret)
+	mc, iv, err := convergentCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	ct, err = mc.Encrypt(data, iv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, ct, nil
+}
+
+// ConvergentDecrypt расшифровывает ct и проверяет, что хэш открытого текста совпадает с key
+func ConvergentDecrypt(key, ct, secret []byte) ([]byte, error) {
+	mc, iv, err := convergentCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ct) < AESBlockSize || !bytes.Equal(ct[:AESBlockSize], iv) {
+		return nil, ErrConvergentMismatch
+	}
+	data, err := mc.Decrypt(ct, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(ConvergentKey(data, secret), key) {
+		return nil, ErrConvergentMismatch
+	}
+	return data, nil
+}
@@ -0,0 +1,210 @@
+package mycrypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ----- Дифференциальная сверка с crypto/cipher на случайных входах -----
+
+// crossMaxMsg - наибольшая длина обычного случайного сообщения CrossCheck. Каждое восьмое сообщение
+// длиннее: оно шифруется с SetParallelism(crossWorkers), чтобы проверить и параллельный путь CTR и ECB.
+const (
+	crossMaxMsg  = 1024
+	crossWorkers = 4
+)
+
+// crossCase - входные данные одного испытания; iv - nonce у GCM, пустой у ECB
+type crossCase struct {
+	key, iv, msg, aad []byte
+	workers           int
+}
+
+// String описывает испытание для сообщения о расхождении
+func (c crossCase) String() string {
+	return fmt.Sprintf("key=%x iv=%x aad=%x msg of %d bytes, %d workers", c.key, c.iv, c.aad, len(c.msg), c.workers)
+}
+
+// randInt возвращает случайное число из [0, n) по байтам Rand
+func randInt(n int) (int, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(Rand, b[:]); err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint32(b[:]) % uint32(n)), nil
+}
+
+// randBytes возвращает n случайных байт из Rand
+func randBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(Rand, b)
+	return b, err
+}
+
+// newCrossCase выбирает длину ключа, IV (у GCM - nonce, чаще 12 байт, иногда произвольной длины),
+// AAD и сообщение испытания trial
+func newCrossCase(mode string, trial int) (c crossCase, err error) {
+	sizes := []int{AESKeySize16, AESKeySize24, AESKeySize32}
+	k, err := randInt(len(sizes))
+	if err != nil {
+		return c, err
+	}
+	if c.key, err = randBytes(sizes[k]); err != nil {
+		return c, err
+	}
+	ivLen := AESBlockSize
+	switch mode {
+	case ModeECB:
+		ivLen = 0
+	case ModeGCM:
+		ivLen = GCMNonceSize
+		if trial%4 == 3 {
+			if ivLen, err = randInt(2 * AESBlockSize); err != nil {
+				return c, err
+			}
+			ivLen++
+		}
+		n, err := randInt(2 * AESBlockSize)
+		if err != nil {
+			return c, err
+		}
+		if c.aad, err = randBytes(n); err != nil {
+			return c, err
+		}
+	}
+	if c.iv, err = randBytes(ivLen); err != nil {
+		return c, err
+	}
+	if mode == ModeCTR {
+		// MyCipher считает блоки в младших 64 битах и не допускает их переполнения,
+		// crypto/cipher переносит в старшие; старший бит счётчика сброшен, чтобы переноса не было
+		c.iv[NonceSize+IVSize] &= 0x7f
+	}
+	msgMax := crossMaxMsg
+	if trial%8 == 7 {
+		msgMax, c.workers = 4*parallelMinBlocks*AESBlockSize, crossWorkers
+	}
+	n, err := randInt(msgMax + 1)
+	if err != nil {
+		return c, err
+	}
+	c.msg, err = randBytes(n)
+	return c, err
+}
+
+// stdEncrypt шифрует испытание через crypto/aes и crypto/cipher и возвращает результат
+// в формате MyCipher.Encrypt: IV || шифротекст, у ECB и CBC - с паддингом PKCS7
+func stdEncrypt(mode string, c crossCase) ([]byte, error) {
+	b, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte{}, c.iv...)
+	switch mode {
+	case ModeECB:
+		padded := Pkcs7Pad(c.msg, AESBlockSize)
+		for i := 0; i < len(padded); i += AESBlockSize {
+			b.Encrypt(padded[i:], padded[i:i+AESBlockSize])
+		}
+		return append(out, padded...), nil
+	case ModeCBC:
+		padded := Pkcs7Pad(c.msg, AESBlockSize)
+		cipher.NewCBCEncrypter(b, c.iv).CryptBlocks(padded, padded)
+		return append(out, padded...), nil
+	case ModeCFB, ModeOFB, ModeCTR:
+		var s cipher.Stream
+		switch mode {
+		case ModeCFB:
+			s = cipher.NewCFBEncrypter(b, c.iv)
+		case ModeOFB:
+			s = cipher.NewOFB(b, c.iv)
+		default:
+			s = cipher.NewCTR(b, c.iv)
+		}
+		ct := make([]byte, len(c.msg))
+		s.XORKeyStream(ct, c.msg)
+		return append(out, ct...), nil
+	case ModeGCM:
+		aead, err := cipher.NewGCMWithNonceSize(b, len(c.iv))
+		if err != nil {
+			return nil, err
+		}
+		return aead.Seal(out, c.iv, c.msg, c.aad), nil
+	}
+	return nil, fmt.Errorf("CrossCheck: unsupported mode %s", mode)
+}
+
+// crossTrial сравнивает MyCipher с crypto/cipher на одном испытании в обе стороны
+func crossTrial(mode string, c crossCase) error {
+	want, err := stdEncrypt(mode, c)
+	if err != nil {
+		return err
+	}
+	mc := &MyCipher{}
+	if err := mc.SetKey(c.key); err != nil {
+		return err
+	}
+	if err := mc.SetMode(mode); err != nil {
+		return err
+	}
+	if err := mc.SetParallelism(c.workers); err != nil {
+		return err
+	}
+	mc.SetAAD(c.aad)
+	iv := c.iv
+	if mode == ModeECB {
+		iv = nil
+	}
+	got, err := mc.Encrypt(c.msg, iv)
+	if err != nil {
+		return fmt.Errorf("encrypt: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		i := 0
+		for i < min(len(got), len(want)) && got[i] == want[i] {
+			i++
+		}
+		return fmt.Errorf("encrypt: %d bytes differ from crypto/cipher's %d starting at offset %d", len(got), len(want), i)
+	}
+	var pt []byte
+	if mode == ModeGCM {
+		// nonce произвольной длины из данных не извлечь, он передаётся отдельно
+		pt, err = mc.Decrypt(want[len(iv):], iv)
+	} else {
+		pt, err = mc.Decrypt(want, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("decrypt: %v", err)
+	}
+	if !bytes.Equal(pt, c.msg) {
+		return fmt.Errorf("decrypt of crypto/cipher ciphertext does not return the message")
+	}
+	return nil
+}
+
+// CrossCheck выполняет trials испытаний режима mode (ECB, CBC, CFB с сегментом 128, OFB, CTR, GCM):
+// случайные ключ AES-128/192/256, IV (nonce), AAD и сообщение шифруются через MyCipher и через
+// crypto/cipher, результаты должны совпасть побайтно, а шифротекст стандартной библиотеки -
+// расшифроваться MyCipher в исходное сообщение. Случайность берётся из Rand, так что расхождение,
+// записанное через myrand.NewRecorder, воспроизводится. Возвращает ошибку первого расхождения.
+func CrossCheck(mode string, trials int) error {
+	switch mode {
+	case ModeECB, ModeCBC, ModeCFB, ModeOFB, ModeCTR, ModeGCM:
+	default:
+		return fmt.Errorf("CrossCheck: mode %s has no crypto/cipher equivalent", mode)
+	}
+	for t := 0; t < trials; t++ {
+		c, err := newCrossCase(mode, t)
+		if err != nil {
+			return err
+		}
+		if err := crossTrial(mode, c); err != nil {
+			return fmt.Errorf("CrossCheck %s: trial %d (%s): %v", mode, t, c, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build ctaudit
+
+package mycrypto
+
+import "sync"
+
+// Режим аудита константного времени (сборка с тегом ctaudit).
+// Каждая точка ветвления или поиска, зависящая от секретных данных,
+// увеличивает свой счётчик, чтобы было видно, сколько раз она срабатывала.
+// Счётчики общие для всех пакетов lab1 и lab3: они вызывают AuditPoint отсюда.
+// Отмечены: PKCS7 (Pkcs7Unpad), сравнение тегов MAC (mymac.MacEqual), побитовое умножение
+// GF(2^128) (GFMul), табличные шифры (T-таблицы и S-блоки myaes, SP-таблицы mycamellia,
+// π и таблицы умножения mygost) и операции math/big над секретами (mytimelock, mykeys,
+// mysidechannel). Пути через crypto/aes, crypto/subtle и crypto/hmac не отмечаются:
+// они константны по времени в стандартной библиотеке.
+var (
+	auditMu       sync.Mutex
+	auditCounters = make(map[string]uint64)
+)
+
+// AuditEnabled сообщает, собран ли пакет с тегом ctaudit
+const AuditEnabled = true
+
+// AuditPoint отмечает срабатывание секретно-зависимой точки name: "Функция/точка",
+// в других пакетах - с именем пакета, например "myaes.Encrypt/Ttable"
+func AuditPoint(name string) {
+	auditMu.Lock()
+	auditCounters[name]++
+	auditMu.Unlock()
+}
+
+// AuditCounters возвращает копию счётчиков аудита
+func AuditCounters() map[string]uint64 {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	res := make(map[string]uint64, len(auditCounters))
+	for k, v := range auditCounters {
+		res[k] = v
+	}
+	return res
+}
+
+// ResetAuditCounters обнуляет счётчики аудита
+func ResetAuditCounters() {
+	auditMu.Lock()
+	auditCounters = make(map[string]uint64)
+	auditMu.Unlock()
+}
@@ -0,0 +1,15 @@
+//go:build !ctaudit
+
+package mycrypto
+
+// AuditEnabled сообщает, собран ли пакет с тегом ctaudit
+const AuditEnabled = false
+
+// AuditPoint в обычной сборке ничего не делает и встраивается без затрат
+func AuditPoint(string) {}
+
+// AuditCounters без тега ctaudit всегда возвращает nil
+func AuditCounters() map[string]uint64 { return nil }
+
+// ResetAuditCounters без тега ctaudit ничего не делает
+func ResetAuditCounters() {}
@@ -0,0 +1,109 @@
+package mycrypto
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ----- CBC с кражей шифротекста, вариант CS3 (NIST SP 800-38A Addendum) -----
+
+// ctsEncrypt шифрует data в режиме CBC-CS3 без расширения: длина шифротекста равна длине
+// открытого текста, а два последних блока переставлены (последний - усечённый).
+// Результат имеет вид IV || ciphertext.
+func (mc *MyCipher) ctsEncrypt(data, iv []byte) ([]byte, error) {
+	if len(data) < mc.blockSize {
+		return nil, fmt.Errorf("%s: message must be at least %d bytes", ModeCTS, mc.blockSize)
+	}
+	if len(iv) != mc.blockSize {
+		newIV, err := mc.generateIV()
+		if err != nil {
+			return nil, err
+		}
+		iv = newIV
+	}
+	if err := mc.useNonce(iv); err != nil {
+		return nil, err
+	}
+	bs := mc.blockSize
+	n := (len(data) + bs - 1) / bs
+	d := len(data) - (n-1)*bs // длина последнего блока, от 1 до bs
+	out := make([]byte, bs+len(data))
+	copy(out, iv)
+	prev := iv
+	block := make([]byte, bs)
+	blocks := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		// последний неполный блок дополняется нулями
+		for j := range block {
+			block[j] = 0
+		}
+		copy(block, data[i*bs:min((i+1)*bs, len(data))])
+		xored, err := XORBytes(block, prev)
+		if err != nil {
+			return nil, err
+		}
+		c, err := mc.BlockCipherEncrypt(xored)
+		if err != nil {
+			return nil, err
+		}
+		mc.emit(false, prev, block, c)
+		blocks[i] = c
+		prev = c
+	}
+	if n > 1 {
+		// CS3: C_n идёт на место предпоследнего блока, а от C_{n-1} остаются первые d байт
+		blocks[n-2], blocks[n-1] = blocks[n-1], blocks[n-2][:d]
+	}
+	pos := bs
+	for _, c := range blocks {
+		pos += copy(out[pos:], c)
+	}
+	return out, nil
+}
+
+// ctsDecrypt расшифровывает шифротекст CBC-CS3. Если iv не передан, он берётся из первого блока.
+func (mc *MyCipher) ctsDecrypt(data, iv []byte) ([]byte, error) {
+	bs := mc.blockSize
+	if len(iv) != bs {
+		if len(data) < bs {
+			return nil, errors.New("data too short to contain IV")
+		}
+		iv, data = data[:bs], data[bs:]
+	}
+	if len(data) < bs {
+		return nil, fmt.Errorf("%s: ciphertext must be at least %d bytes", ModeCTS, bs)
+	}
+	n := (len(data) + bs - 1) / bs
+	d := len(data) - (n-1)*bs
+	ct := append([]byte{}, data...)
+	if n > 1 {
+		// Z = D(C_n) = (P_n || 0) xor C_{n-1}: хвост Z восстанавливает украденные байты C_{n-1}
+		last := append([]byte{}, ct[(n-2)*bs:(n-1)*bs]...)
+		z, err := mc.BlockCipherDecrypt(last)
+		if err != nil {
+			return nil, err
+		}
+		stolen := ct[(n-1)*bs:]
+		full := append(append([]byte{}, stolen...), z[d:]...)
+		// возвращаем блоки в обычный порядок CBC: C_{n-1} (полный), затем C_n
+		ct = append(ct[:(n-2)*bs], full...)
+		ct = append(ct, last...)
+	}
+	out := make([]byte, 0, n*bs)
+	prev := iv
+	for i := 0; i < n; i++ {
+		c := ct[i*bs : (i+1)*bs]
+		p, err := mc.BlockCipherDecrypt(c)
+		if err != nil {
+			return nil, err
+		}
+		p, err = XORBytes(p, prev)
+		if err != nil {
+			return nil, err
+		}
+		mc.emit(true, prev, c, p)
+		out = append(out, p...)
+		prev = c
+	}
+	return out[:len(data)], nil
+}
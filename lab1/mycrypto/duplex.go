@@ -0,0 +1,136 @@
+package mycrypto
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+)
+
+// ----- Аутентифицированное шифрование на дуплексной губке (Keccak-f[1600]) -----
+
+// Параметры дуплексной конструкции: ёмкость 256 бит, скорость 168 байт.
+// В каждый вызов дуплекса поступает не более DuplexBlockSize байт, оставшиеся
+// два байта скорости занимает байт домена и завершающий бит дополнения.
+const (
+	DuplexRate      = 168
+	DuplexBlockSize = DuplexRate - 2
+	DuplexNonceSize = 16
+	DuplexTagSize   = 16
+)
+
+// байты разделения доменов для разных фаз дуплекса
+const (
+	domainInit     = 0x01
+	domainAAD      = 0x02
+	domainAADFinal = 0x03
+	domainMsg      = 0x04
+	domainMsgFinal = 0x05
+)
+
+// DuplexAEAD реализует AEAD со схемой Seal/Open без блочного шифра:
+// ключ и nonce поглощаются губкой, затем AAD, затем сообщение, а тег выжимается в конце
+type DuplexAEAD struct {
+	key []byte
+}
+
+// duplexState - состояние губки
+type duplexState struct {
+	a [25]uint64
+}
+
+// duplex поглощает блок in с байтом домена и применяет перестановку
+func (s *duplexState) duplex(in []byte, domain byte) {
+	keccakXorIn(&s.a, in)
+	keccakXorByte(&s.a, len(in), domain)
+	keccakXorByte(&s.a, DuplexRate-1, 0x80)
+	keccakF1600(&s.a)
+}
+
+// NewDuplexAEAD создаёт AEAD с ключом длиной 16, 24 или 32 байта
+func NewDuplexAEAD(key []byte) (*DuplexAEAD, error) {
+	if len(key) != AESKeySize16 && len(key) != AESKeySize24 && len(key) != AESKeySize32 {
+		return nil, fmt.Errorf("invalid key length: got %d, expected %d, %d, or %d", len(key), AESKeySize16, AESKeySize24, AESKeySize32)
+	}
+	return &DuplexAEAD{key: append([]byte{}, key...)}, nil
+}
+
+// start инициализирует губку ключом и nonce и поглощает AAD
+func (d *DuplexAEAD) start(nonce, aad []byte) (*duplexState, error) {
+	if len(nonce) != DuplexNonceSize {
+		return nil, fmt.Errorf("duplex: nonce length must be %d", DuplexNonceSize)
+	}
+	s := &duplexState{}
+	s.duplex(append(append([]byte{}, d.key...), nonce...), domainInit)
+	for len(aad) > DuplexBlockSize {
+		s.duplex(aad[:DuplexBlockSize], domainAAD)
+		aad = aad[DuplexBlockSize:]
+	}
+	s.duplex(aad, domainAADFinal)
+	return s, nil
+}
+
+// crypt шифрует или расшифровывает данные: байты гаммы берутся из состояния,
+// а в губку поглощается открытый текст
+func (s *duplexState) crypt(dst, src []byte, decrypt bool) {
+	for {
+		n := len(src)
+		domain := byte(domainMsgFinal)
+		if n > DuplexBlockSize {
+			n = DuplexBlockSize
+			domain = domainMsg
+		}
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ keccakByte(&s.a, i)
+		}
+		if decrypt {
+			s.duplex(dst[:n], domain)
+		} else {
+			s.duplex(src[:n], domain)
+		}
+		if domain == domainMsgFinal {
+			return
+		}
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// tag выжимает тег из состояния
+func (s *duplexState) tag() []byte {
+	t := make([]byte, DuplexTagSize)
+	for i := range t {
+		t[i] = keccakByte(&s.a, i)
+	}
+	return t
+}
+
+// Seal шифрует plaintext и возвращает ciphertext || tag
+func (d *DuplexAEAD) Seal(nonce, plaintext, aad []byte) ([]byte, error) {
+	s, err := d.start(nonce, aad)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(plaintext), len(plaintext)+DuplexTagSize)
+	s.crypt(out, plaintext, false)
+	return append(out, s.tag()...), nil
+}
+
+// Open проверяет тег и расшифровывает ciphertext || tag
+func (d *DuplexAEAD) Open(nonce, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < DuplexTagSize {
+		return nil, errors.New("duplex: ciphertext too short to contain tag")
+	}
+	s, err := d.start(nonce, aad)
+	if err != nil {
+		return nil, err
+	}
+	body, tag := ciphertext[:len(ciphertext)-DuplexTagSize], ciphertext[len(ciphertext)-DuplexTagSize:]
+	out := make([]byte, len(body))
+	s.crypt(out, body, true)
+	if subtle.ConstantTimeCompare(s.tag(), tag) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errors.New("duplex: message authentication failed")
+	}
+	return out, nil
+}
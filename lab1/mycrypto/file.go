@@ -0,0 +1,179 @@
+package mycrypto
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// ----- Шифрование больших файлов с ограниченной памятью -----
+
+// DefaultFileChunk - размер куска по умолчанию для EncryptStream и EncryptFile
+const DefaultFileChunk = 1 << 20
+
+// errMmapUnavailable означает, что файл нельзя отобразить в память и его нужно читать
+var errMmapUnavailable = errors.New("mmap unavailable")
+
+// FileOptions настраивает EncryptStream, DecryptStream, EncryptFile и DecryptFile
+type FileOptions struct {
+	ChunkSize int  // размер куска входа в байтах (0 - DefaultFileChunk)
+	Mmap      bool // читать входной файл через отображение в память; если ОС не позволяет - обычным чтением
+	// Progress вызывается после каждого куска с числом обработанных байт входа
+	// и полным размером входа (-1, если он неизвестен)
+	Progress func(done, total int64)
+}
+
+func (o FileOptions) chunkSize() int {
+	if o.ChunkSize <= 0 {
+		return DefaultFileChunk
+	}
+	return o.ChunkSize
+}
+
+// fileJob передаёт куски входа в ChunkStream и пишет результат в dst
+type fileJob struct {
+	cs      *ChunkStream
+	dst     io.Writer
+	opts    FileOptions
+	total   int64
+	done    int64
+	written int64
+}
+
+func (mc *MyCipher) newFileJob(dst io.Writer, total int64, opts FileOptions, decrypt bool) (*fileJob, error) {
+	// GCM, OCB и CTS накапливают сообщение целиком, что противоречит ограничению памяти
+	if err := mc.checkStreamMode(); err != nil {
+		return nil, err
+	}
+	cs, err := mc.newChunkStream(decrypt)
+	if err != nil {
+		return nil, err
+	}
+	return &fileJob{cs: cs, dst: dst, opts: opts, total: total}, nil
+}
+
+func (j *fileJob) write(out []byte) error {
+	n, err := j.dst.Write(out)
+	j.written += int64(n)
+	return err
+}
+
+func (j *fileJob) update(p []byte) error {
+	out, err := j.cs.Update(p)
+	if err != nil {
+		return err
+	}
+	if err := j.write(out); err != nil {
+		return err
+	}
+	j.done += int64(len(p))
+	if j.opts.Progress != nil {
+		j.opts.Progress(j.done, j.total)
+	}
+	return nil
+}
+
+func (j *fileJob) finish() (int64, error) {
+	out, err := j.cs.Finalize()
+	if err != nil {
+		return j.written, err
+	}
+	return j.written, j.write(out)
+}
+
+// readAll читает src кусками по ChunkSize и обрабатывает их; в памяти держится один кусок
+func (j *fileJob) readAll(src io.Reader) (int64, error) {
+	buf := make([]byte, j.opts.chunkSize())
+	for {
+		n, err := io.ReadFull(src, buf)
+		if n > 0 {
+			if err := j.update(buf[:n]); err != nil {
+				return j.written, err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return j.written, err
+		}
+	}
+	return j.finish()
+}
+
+// EncryptStream шифрует src в dst в формате Encrypt (ECB, CBC, CFB, OFB, CTR), держа в памяти
+// один кусок входа. total - размер входа для Progress (-1, если неизвестен). Возвращает число
+// записанных в dst байт.
+func (mc *MyCipher) EncryptStream(dst io.Writer, src io.Reader, total int64, opts FileOptions) (int64, error) {
+	j, err := mc.newFileJob(dst, total, opts, false)
+	if err != nil {
+		return 0, err
+	}
+	return j.readAll(src)
+}
+
+// DecryptStream расшифровывает src, записанный EncryptStream или Encrypt. Открытый текст пишется
+// в dst по мере расшифрования, поэтому при ошибке паддинга в конце dst уже содержит начало сообщения.
+func (mc *MyCipher) DecryptStream(dst io.Writer, src io.Reader, total int64, opts FileOptions) (int64, error) {
+	j, err := mc.newFileJob(dst, total, opts, true)
+	if err != nil {
+		return 0, err
+	}
+	return j.readAll(src)
+}
+
+// EncryptFile шифрует файл srcPath в dstPath; при ошибке dstPath удаляется
+func (mc *MyCipher) EncryptFile(dstPath, srcPath string, opts FileOptions) (int64, error) {
+	return mc.processFile(dstPath, srcPath, opts, false)
+}
+
+// DecryptFile расшифровывает файл srcPath в dstPath; при ошибке (в том числе неверном паддинге)
+// dstPath удаляется
+func (mc *MyCipher) DecryptFile(dstPath, srcPath string, opts FileOptions) (int64, error) {
+	return mc.processFile(dstPath, srcPath, opts, true)
+}
+
+func (mc *MyCipher) processFile(dstPath, srcPath string, opts FileOptions, decrypt bool) (n int64, err error) {
+	if err := mc.checkStreamMode(); err != nil {
+		return 0, err
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return 0, err
+	}
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cerr := dst.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(dstPath)
+		}
+	}()
+	j, err := mc.newFileJob(dst, info.Size(), opts, decrypt)
+	if err != nil {
+		return 0, err
+	}
+	if opts.Mmap {
+		data, unmap, merr := mapFile(src, info.Size())
+		if merr == nil {
+			defer unmap()
+			chunk := opts.chunkSize()
+			for i := 0; i < len(data); i += chunk {
+				if err := j.update(data[i:min(i+chunk, len(data))]); err != nil {
+					return j.written, err
+				}
+			}
+			return j.finish()
+		}
+	}
+	return j.readAll(src)
+}
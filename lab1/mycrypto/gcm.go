@@ -0,0 +1,121 @@
+package mycrypto
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ----- Режим GCM (NIST SP 800-38D) -----
+
+// Параметры GCM
+const (
+	GCMNonceSize = 12
+	GCMTagSize   = 16
+
+	// GCMMaxPlaintext - наибольшая длина открытого текста, 2^39 - 256 бит: счётчику inc32
+	// хватает 2^32 - 2 блоков после J0 и блока тега
+	GCMMaxPlaintext uint64 = 1<<36 - 32
+)
+
+// ErrAuthFailed возвращается при несовпадении тега аутентифицированного режима
+var ErrAuthFailed = errors.New("message authentication failed")
+
+// gcmR - старший байт многочлена приведения x^128 + x^7 + x^2 + x + 1
+// в отражённом порядке битов GCM (R = 11100001 || 0^120), см. GFMul
+const gcmR = 0xe1
+
+// inc32 увеличивает младшие 32 бита блока счётчика по модулю 2^32
+func inc32(counter []byte) {
+	c := binary.BigEndian.Uint32(counter[AESBlockSize-4:])
+	binary.BigEndian.PutUint32(counter[AESBlockSize-4:], c+1)
+}
+
+// gctr шифрует data гаммой E(icb), E(inc32(icb)), ...
+func (mc *MyCipher) gctr(icb, data []byte) []byte {
+	out := make([]byte, len(data))
+	cb := append([]byte{}, icb...)
+	ks := make([]byte, AESBlockSize)
+	for i := 0; i < len(data); i += AESBlockSize {
+		mc.block.Encrypt(ks, cb)
+		XORTo(out[i:], data[i:], ks)
+		inc32(cb)
+	}
+	return out
+}
+
+// gcmInit вычисляет ключ хэширования H = E(0) и начальный блок счётчика J0
+func (mc *MyCipher) gcmInit(nonce []byte) (h, j0 []byte) {
+	h = make([]byte, AESBlockSize)
+	mc.block.Encrypt(h, make([]byte, AESBlockSize))
+	if len(nonce) == GCMNonceSize {
+		j0 = make([]byte, AESBlockSize)
+		copy(j0, nonce)
+		j0[AESBlockSize-1] = 1
+	} else {
+		j0 = GHASH(h, nil, nonce)
+	}
+	return h, j0
+}
+
+// gcmSeal шифрует data и возвращает nonce || ciphertext || tag.
+// Если nonce не задан, генерируется случайный nonce длиной GCMNonceSize.
+func (mc *MyCipher) gcmSeal(data, nonce []byte) ([]byte, error) {
+	if uint64(len(data)) > GCMMaxPlaintext {
+		return nil, fmt.Errorf("GCM: message of %d bytes exceeds the %d-byte limit", len(data), GCMMaxPlaintext)
+	}
+	if len(nonce) == 0 {
+		nonce = make([]byte, GCMNonceSize)
+		if n, err := Rand.Read(nonce); err != nil || n != GCMNonceSize {
+			return nil, errors.New("failed to generate nonce")
+		}
+	}
+	if err := mc.useNonce(nonce); err != nil {
+		return nil, err
+	}
+	h, j0 := mc.gcmInit(nonce)
+	icb := append([]byte{}, j0...)
+	inc32(icb)
+	ct := mc.gctr(icb, data)
+	mc.emitGCTR(false, icb, data, ct)
+	tag := mc.gctr(j0, GHASH(h, mc.aad, ct))
+	result := make([]byte, 0, len(nonce)+len(ct)+GCMTagSize)
+	result = append(result, nonce...)
+	result = append(result, ct...)
+	return append(result, tag...), nil
+}
+
+// gcmOpen проверяет тег и расшифровывает nonce || ciphertext || tag
+// (или ciphertext || tag, если nonce передан отдельно)
+func (mc *MyCipher) gcmOpen(data, nonce []byte) ([]byte, error) {
+	if len(nonce) == 0 {
+		if len(data) < GCMNonceSize {
+			return nil, errors.New("data too short to contain nonce")
+		}
+		nonce, data = data[:GCMNonceSize], data[GCMNonceSize:]
+	}
+	if len(data) < GCMTagSize {
+		return nil, fmt.Errorf("GCM: ciphertext too short to contain %d-byte tag", GCMTagSize)
+	}
+	ct, tag := data[:len(data)-GCMTagSize], data[len(data)-GCMTagSize:]
+	if uint64(len(ct)) > GCMMaxPlaintext {
+		return nil, fmt.Errorf("GCM: ciphertext of %d bytes exceeds the %d-byte limit", len(ct), GCMMaxPlaintext)
+	}
+	h, j0 := mc.gcmInit(nonce)
+	expected := mc.gctr(j0, GHASH(h, mc.aad, ct))
+	if subtle.ConstantTimeCompare(expected, tag) != 1 {
+		return nil, ErrAuthFailed
+	}
+	icb := append([]byte{}, j0...)
+	inc32(icb)
+	pt := mc.gctr(icb, ct)
+	mc.emitGCTR(true, icb, ct, pt)
+	return pt, nil
+}
+
+// SetAAD задаёт дополнительные аутентифицируемые данные для режимов GCM и OCB.
+// Они не шифруются и не входят в результат, но защищены тегом.
+func (mc *MyCipher) SetAAD(aad []byte) {
+	mc.aad = append([]byte{}, aad...)
+}
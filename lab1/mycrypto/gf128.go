@@ -0,0 +1,154 @@
+package mycrypto
+
+import "fmt"
+
+// ----- Соглашения о порядке битов и байтов -----
+
+// BitOrder - соглашение о записи элемента GF(2^128) в 16-байтовый блок
+type BitOrder int
+
+const (
+	// BitOrderReflected - соглашение GCM: старший бит первого байта - коэффициент при x^0,
+	// младший бит последнего байта - при x^127
+	BitOrderReflected BitOrder = iota
+	// BitOrderNatural - соглашение OMAC/CMAC: блок - 128-битное число в big-endian,
+	// старший бит первого байта - коэффициент при x^127
+	BitOrderNatural
+)
+
+func (o BitOrder) String() string {
+	if o == BitOrderNatural {
+		return "natural"
+	}
+	return "reflected"
+}
+
+// Endian - порядок байтов счётчика
+type Endian int
+
+const (
+	EndianBig Endian = iota
+	EndianLittle
+)
+
+func (e Endian) String() string {
+	if e == EndianLittle {
+		return "little-endian"
+	}
+	return "big-endian"
+}
+
+// gfRb - младший байт многочлена приведения x^128 + x^7 + x^2 + x + 1 в естественном порядке
+const gfRb = 0x87
+
+// GFDouble умножает элемент GF(2^128) на x в заданном соглашении.
+// В естественном порядке это удвоение из генерации подключей OMAC (RFC 4493).
+func GFDouble(a []byte, order BitOrder) []byte {
+	out := make([]byte, AESBlockSize)
+	if order == BitOrderNatural {
+		for i := 0; i < AESBlockSize-1; i++ {
+			out[i] = a[i]<<1 | a[i+1]>>7
+		}
+		out[AESBlockSize-1] = a[AESBlockSize-1] << 1
+		if a[0]&0x80 != 0 {
+			out[AESBlockSize-1] ^= gfRb
+		}
+		return out
+	}
+	// в отражённом порядке умножение на x - сдвиг вправо
+	out[0] = a[0] >> 1
+	for i := 1; i < AESBlockSize; i++ {
+		out[i] = a[i]>>1 | a[i-1]<<7
+	}
+	if a[AESBlockSize-1]&1 != 0 {
+		out[0] ^= gcmR
+	}
+	return out
+}
+
+// GFMul умножает x на y в GF(2^128) по модулю x^128 + x^7 + x^2 + x + 1 в заданном соглашении
+func GFMul(x, y []byte, order BitOrder) []byte {
+	z := make([]byte, AESBlockSize)
+	v := append([]byte{}, y...)
+	for i := 0; i < 128; i++ {
+		// i-й коэффициент x, начиная с x^0
+		var bit byte
+		if order == BitOrderNatural {
+			bit = x[AESBlockSize-1-i/8] & (1 << uint(i%8))
+		} else {
+			bit = x[i/8] & (0x80 >> uint(i%8))
+		}
+		if bit != 0 {
+			AuditPoint("GFMul/bit")
+			XORInto(z, v)
+		}
+		v = GFDouble(v, order)
+	}
+	return z
+}
+
+// ConvertBitOrder переводит элемент GF(2^128) из одного соглашения в другое
+// (оба соглашения отличаются обращением порядка всех 128 бит)
+func ConvertBitOrder(a []byte) []byte {
+	out := make([]byte, len(a))
+	for i, b := range a {
+		var r byte
+		for j := 0; j < 8; j++ {
+			r |= (b >> uint(j) & 1) << uint(7-j)
+		}
+		out[len(a)-1-i] = r
+	}
+	return out
+}
+
+// incField увеличивает на единицу число, записанное в field в порядке байтов order.
+// Возвращает true, если поле переполнилось и стало нулевым.
+func incField(field []byte, order Endian) bool {
+	for k := 0; k < len(field); k++ {
+		i := len(field) - 1 - k
+		if order == EndianLittle {
+			i = k
+		}
+		field[i]++
+		if field[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// addField прибавляет k к числу, записанному в field в порядке байтов order (по модулю 2^(8*len(field)))
+func addField(field []byte, k uint64, order Endian) {
+	for j := 0; j < len(field) && k != 0; j++ {
+		i := len(field) - 1 - j
+		if order == EndianLittle {
+			i = j
+		}
+		sum := uint64(field[i]) + k&0xff
+		field[i] = byte(sum)
+		k = k>>8 + sum>>8
+	}
+}
+
+// fieldValue читает число из field (не длиннее 8 байт) в порядке байтов order
+func fieldValue(field []byte, order Endian) uint64 {
+	var v uint64
+	for k := 0; k < len(field); k++ {
+		i := k
+		if order == EndianLittle {
+			i = len(field) - 1 - k
+		}
+		v = v<<8 | uint64(field[i])
+	}
+	return v
+}
+
+// SetCounterEndian задаёт порядок байтов полей IV и counter блока счётчика CTR
+// (по умолчанию big-endian, как в NIST SP 800-38A)
+func (mc *MyCipher) SetCounterEndian(order Endian) error {
+	if order != EndianBig && order != EndianLittle {
+		return fmt.Errorf("unknown counter byte order %d", order)
+	}
+	mc.ctrEndian = order
+	return nil
+}
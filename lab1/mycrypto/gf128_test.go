@@ -0,0 +1,106 @@
+package mycrypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// mustHex декодирует hex-константу вектора
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestGFMulReflected: GHASH тестового примера 2 GCM (McGrew, Viega), H = AES_0(0), A пусто, C - один блок
+func TestGFMulReflected(t *testing.T) {
+	h := mustHex(t, "66e94bd4ef8a2c3b884cfa59ca342b2e")
+	c := mustHex(t, "0388dace60b6a392f328c2b971b2fe78")
+	lens := mustHex(t, "00000000000000000000000000000080") // len(A) = 0, len(C) = 128 бит
+	y := GFMul(c, h, BitOrderReflected)
+	if want := mustHex(t, "5e2ec746917062882c85b0685353deb7"); !bytes.Equal(y, want) {
+		t.Fatalf("C*H = %x, want %x", y, want)
+	}
+	XORInto(y, lens)
+	y = GFMul(y, h, BitOrderReflected)
+	if want := mustHex(t, "f38cbb1ad69223dcc3457ae5b6b0f885"); !bytes.Equal(y, want) {
+		t.Fatalf("GHASH = %x, want %x", y, want)
+	}
+}
+
+// TestGFMulNatural: подключи K1 = L*x и K2 = L*x^2 из RFC 4493, через GFMul и через GFDouble,
+// и они же в отражённом порядке после ConvertBitOrder
+func TestGFMulNatural(t *testing.T) {
+	l := mustHex(t, "7df76b0c1ab899b33e42f047b91b546f")
+	k := l
+	for i, want := range []string{"fbeed618357133667c85e08f7236a8de", "f7ddac306ae266ccf90bc11ee46d513b"} {
+		x := make([]byte, AESBlockSize)
+		x[15] = 2 << i // x^(i+1) в BitOrderNatural
+		if got := GFMul(l, x, BitOrderNatural); hex.EncodeToString(got) != want {
+			t.Fatalf("GFMul: K%d = %x, want %s", i+1, got, want)
+		}
+		if k = GFDouble(k, BitOrderNatural); hex.EncodeToString(k) != want {
+			t.Fatalf("GFDouble: K%d = %x, want %s", i+1, k, want)
+		}
+		got := GFMul(ConvertBitOrder(l), ConvertBitOrder(x), BitOrderReflected)
+		if got = ConvertBitOrder(got); hex.EncodeToString(got) != want {
+			t.Fatalf("GFMul %v: K%d = %x, want %s", BitOrderReflected, i+1, got, want)
+		}
+	}
+}
+
+// ctrVectors - блоки счётчика CTR трёх блоков одного сообщения, начиная с IV, и первый блок
+// следующего: перенос в поле counter и в поле IV при смене сообщения, nonce a0a1a2a3 не меняется
+var ctrVectors = []struct {
+	order  Endian
+	blocks []string
+	next   string
+}{
+	{EndianBig, []string{
+		"a0a1a2a3000000ff00000000000000fe",
+		"a0a1a2a3000000ff00000000000000ff",
+		"a0a1a2a3000000ff0000000000000100",
+	}, "a0a1a2a3000001000000000000000000"},
+	{EndianLittle, []string{
+		"a0a1a2a3ff000000fe00000000000000",
+		"a0a1a2a3ff000000ff00000000000000",
+		"a0a1a2a3ff0000000001000000000000",
+	}, "a0a1a2a3000100000000000000000000"},
+}
+
+// TestCounterEndian сверяет блоки счётчика, которые CTR шифрует при SetCounterEndian, с ctrVectors
+func TestCounterEndian(t *testing.T) {
+	for _, v := range ctrVectors {
+		mc := &MyCipher{}
+		if err := mc.SetKey(make([]byte, AESKeySize16)); err != nil {
+			t.Fatal(err)
+		}
+		if err := mc.SetMode(ModeCTR); err != nil {
+			t.Fatal(err)
+		}
+		if err := mc.SetCounterEndian(v.order); err != nil {
+			t.Fatal(err)
+		}
+		// гамма - шифротекст нулей, расшифровав её блоки, получаем сами блоки счётчика
+		out, err := mc.Encrypt(make([]byte, len(v.blocks)*AESBlockSize), mustHex(t, v.blocks[0]))
+		if err != nil {
+			t.Fatalf("%v: %v", v.order, err)
+		}
+		got := make([]byte, AESBlockSize)
+		for i, want := range v.blocks {
+			mc.block.Decrypt(got, out[(i+1)*AESBlockSize:])
+			if hex.EncodeToString(got) != want {
+				t.Fatalf("%v: counter block #%d = %x, want %s", v.order, i, got, want)
+			}
+		}
+		next := mustHex(t, v.blocks[0])
+		incMsgCTR(next, v.order)
+		if hex.EncodeToString(next) != v.next {
+			t.Fatalf("%v: next message starts at %x, want %s", v.order, next, v.next)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package mycrypto
+
+import "encoding/binary"
+
+// ----- Ускоренный GHASH -----
+
+// gcmElem - элемент GF(2^128) в отражённом порядке битов GCM: lo - первые 8 байт блока
+// (big-endian), hi - последние 8; младший бит hi - коэффициент при x^127
+type gcmElem struct {
+	lo, hi uint64
+}
+
+// double умножает элемент на x: в отражённом порядке - сдвиг вправо и приведение по gcmR
+func (e gcmElem) double() gcmElem {
+	d := gcmElem{lo: e.lo >> 1, hi: e.hi>>1 | e.lo<<63}
+	if e.hi&1 == 1 {
+		d.lo ^= gcmR << 56
+	}
+	return d
+}
+
+// ghashReduce[n] - приведение четырёх битов n, выдвинутых сдвигом на x^4, в старшие 16 бит lo
+var ghashReduce = [16]uint16{
+	0x0000, 0x1c20, 0x3840, 0x2460, 0x7080, 0x6ca0, 0x48c0, 0x54e0,
+	0xe100, 0xfd20, 0xd940, 0xc560, 0x9180, 0x8da0, 0xa9c0, 0xb5e0,
+}
+
+// reverse4 переставляет 4 младших бита i в обратном порядке: индекс таблицы - полубайт
+// блока, у которого старший бит - младшая степень x
+func reverse4(i int) int {
+	return (i&1)<<3 | (i&2)<<1 | (i&4)>>1 | (i&8)>>3
+}
+
+// ghashKey - ключ хэширования H с предвычислениями. На amd64 с PCLMULQDQ блоки умножает
+// ghashCLMUL (ghash_amd64.s), иначе - 4-битная таблица кратных H (метод Шоупа): 32 обращения
+// к таблице на блок вместо 128 шагов побитового GFMul
+type ghashKey struct {
+	h     [AESBlockSize]byte
+	table [16]gcmElem // table[reverse4(n)] = n*H
+}
+
+// newGHASHKey готовит ключ H. Таблица строится и при PCLMULQDQ: она дешевле одного блока
+// и позволяет сравнивать обе реализации на одном ключе
+func newGHASHKey(h []byte) *ghashKey {
+	k := &ghashKey{}
+	copy(k.h[:], h)
+	x := gcmElem{binary.BigEndian.Uint64(h[:8]), binary.BigEndian.Uint64(h[8:])}
+	k.table[reverse4(1)] = x
+	for i := 2; i < 16; i += 2 {
+		k.table[reverse4(i)] = k.table[reverse4(i/2)].double()
+		t := k.table[reverse4(i)]
+		k.table[reverse4(i+1)] = gcmElem{t.lo ^ x.lo, t.hi ^ x.hi}
+	}
+	return k
+}
+
+// blocksGeneric поглощает полные блоки data: y = (y xor block) * H по таблице.
+// Индексы таблицы зависят от данных и H, поэтому путь не постоянен по времени.
+func (k *ghashKey) blocksGeneric(y *[AESBlockSize]byte, data []byte) {
+	AuditPoint("ghash/table")
+	lo, hi := binary.BigEndian.Uint64(y[:8]), binary.BigEndian.Uint64(y[8:])
+	for ; len(data) >= AESBlockSize; data = data[AESBlockSize:] {
+		lo ^= binary.BigEndian.Uint64(data[:8])
+		hi ^= binary.BigEndian.Uint64(data[8:])
+		// схема Горнера по полубайтам от x^127 к x^0: z = z*x^4 + n*H
+		var z gcmElem
+		for _, word := range [2]uint64{hi, lo} {
+			for j := 0; j < 64; j += 4 {
+				out := z.hi & 0xf
+				z.hi = z.hi>>4 | z.lo<<60
+				z.lo = z.lo>>4 ^ uint64(ghashReduce[out])<<48
+				t := k.table[word&0xf]
+				z.lo ^= t.lo
+				z.hi ^= t.hi
+				word >>= 4
+			}
+		}
+		lo, hi = z.lo, z.hi
+	}
+	binary.BigEndian.PutUint64(y[:8], lo)
+	binary.BigEndian.PutUint64(y[8:], hi)
+}
+
+// update поглощает data, дополняя неполный последний блок нулями
+func (k *ghashKey) update(y *[AESBlockSize]byte, data []byte) {
+	full := len(data) &^ (AESBlockSize - 1)
+	if full > 0 {
+		k.blocks(y, data[:full])
+	}
+	if full < len(data) {
+		var last [AESBlockSize]byte
+		copy(last[:], data[full:])
+		k.blocks(y, last[:])
+	}
+}
+
+// GHASH вычисляет GHASH_H(aad || 0* || ct || 0* || [len(aad)]_64 || [len(ct)]_64) из NIST SP 800-38D.
+// Результат совпадает со сверткой блоков побитовым GFMul в BitOrderReflected.
+func GHASH(h, aad, ct []byte) []byte {
+	k := newGHASHKey(h)
+	var y, lens [AESBlockSize]byte
+	k.update(&y, aad)
+	k.update(&y, ct)
+	binary.BigEndian.PutUint64(lens[:8], uint64(len(aad))*8)
+	binary.BigEndian.PutUint64(lens[8:], uint64(len(ct))*8)
+	k.blocks(&y, lens[:])
+	return y[:]
+}
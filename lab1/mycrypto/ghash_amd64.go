@@ -0,0 +1,22 @@
+//go:build amd64 && !purego
+
+package mycrypto
+
+import "golang.org/x/sys/cpu"
+
+// useCLMUL - есть ли carry-less умножение (PCLMULQDQ) и PSHUFB (SSSE3) для ghashCLMUL
+var useCLMUL = cpu.X86.HasPCLMULQDQ && cpu.X86.HasSSSE3
+
+// ghashCLMUL поглощает полные блоки data: y = (y xor block) * h на PCLMULQDQ
+//
+//go:noescape
+func ghashCLMUL(y, h *[AESBlockSize]byte, data []byte)
+
+// blocks поглощает полные блоки data на PCLMULQDQ или, без него, по таблице
+func (k *ghashKey) blocks(y *[AESBlockSize]byte, data []byte) {
+	if useCLMUL {
+		ghashCLMUL(y, &k.h, data)
+		return
+	}
+	k.blocksGeneric(y, data)
+}
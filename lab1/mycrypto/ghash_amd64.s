@@ -0,0 +1,99 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// Умножение в GF(2^128) по схеме Intel "Carry-Less Multiplication Instruction and its Usage
+// for Computing the GCM Mode" (алгоритм 1 и 5): блоки переставляются по байтам PSHUFB,
+// произведение 4 умножениями PCLMULQDQ сдвигается на бит влево и приводится по x^128 + x^7 + x^2 + x + 1.
+
+// bswapMask - маска PSHUFB, переставляющая 16 байт в обратном порядке
+DATA bswapMask<>+0x00(SB)/8, $0x08090a0b0c0d0e0f
+DATA bswapMask<>+0x08(SB)/8, $0x0001020304050607
+GLOBL bswapMask<>(SB), (NOPTR+RODATA), $16
+
+// func ghashCLMUL(y, h *[16]byte, data []byte)
+TEXT ·ghashCLMUL(SB), NOSPLIT, $0-40
+	MOVQ  y+0(FP), DI
+	MOVQ  h+8(FP), SI
+	MOVQ  data_base+16(FP), DX
+	MOVQ  data_len+24(FP), CX
+	MOVOU bswapMask<>(SB), X10
+	MOVOU (DI), X0
+	PSHUFB X10, X0
+	MOVOU (SI), X1
+	PSHUFB X10, X1
+
+loop:
+	CMPQ CX, $16
+	JB   done
+	MOVOU (DX), X2
+	PSHUFB X10, X2
+	PXOR  X2, X0
+
+	// X6:X3 = X0 * X1
+	MOVOU     X0, X3
+	PCLMULQDQ $0x00, X1, X3
+	MOVOU     X0, X4
+	PCLMULQDQ $0x10, X1, X4
+	MOVOU     X0, X5
+	PCLMULQDQ $0x01, X1, X5
+	MOVOU     X0, X6
+	PCLMULQDQ $0x11, X1, X6
+	PXOR      X5, X4
+	MOVOU     X4, X5
+	PSRLO     $8, X4
+	PSLLO     $8, X5
+	PXOR      X5, X3
+	PXOR      X4, X6
+
+	// сдвиг X6:X3 на бит влево: отражённый порядок битов даёт произведение, сдвинутое вправо
+	MOVOU X3, X7
+	MOVOU X6, X8
+	PSLLL $1, X3
+	PSLLL $1, X6
+	PSRLL $31, X7
+	PSRLL $31, X8
+	MOVOU X7, X9
+	PSLLO $4, X8
+	PSLLO $4, X7
+	PSRLO $12, X9
+	POR   X7, X3
+	POR   X8, X6
+	POR   X9, X6
+
+	// приведение, первая фаза
+	MOVOU X3, X7
+	MOVOU X3, X8
+	MOVOU X3, X9
+	PSLLL $31, X7
+	PSLLL $30, X8
+	PSLLL $25, X9
+	PXOR  X8, X7
+	PXOR  X9, X7
+	MOVOU X7, X8
+	PSLLO $12, X7
+	PSRLO $4, X8
+	PXOR  X7, X3
+
+	// приведение, вторая фаза
+	MOVOU X3, X2
+	MOVOU X3, X4
+	MOVOU X3, X5
+	PSRLL $1, X2
+	PSRLL $2, X4
+	PSRLL $7, X5
+	PXOR  X4, X2
+	PXOR  X5, X2
+	PXOR  X8, X2
+	PXOR  X2, X3
+	PXOR  X3, X6
+	MOVOU X6, X0
+
+	ADDQ $16, DX
+	SUBQ $16, CX
+	JMP  loop
+
+done:
+	PSHUFB X10, X0
+	MOVOU  X0, (DI)
+	RET
@@ -0,0 +1,11 @@
+//go:build !amd64 || purego
+
+package mycrypto
+
+// useCLMUL ложно: вне amd64 и с тегом purego GHASH считается по таблице
+const useCLMUL = false
+
+// blocks поглощает полные блоки data по таблице
+func (k *ghashKey) blocks(y *[AESBlockSize]byte, data []byte) {
+	k.blocksGeneric(y, data)
+}
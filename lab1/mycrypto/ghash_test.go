@@ -0,0 +1,166 @@
+package mycrypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// ghashBitSerial - эталонный GHASH на побитовом GFMul
+func ghashBitSerial(h, aad, ct []byte) []byte {
+	y := make([]byte, AESBlockSize)
+	absorb := func(data []byte) {
+		for len(data) > 0 {
+			n := min(len(data), AESBlockSize)
+			XORInto(y, data[:n])
+			y = GFMul(y, h, BitOrderReflected)
+			data = data[n:]
+		}
+	}
+	absorb(aad)
+	absorb(ct)
+	lens := make([]byte, AESBlockSize)
+	lens[7], lens[6] = byte(len(aad)*8), byte(len(aad)*8>>8)
+	lens[15], lens[14] = byte(len(ct)*8), byte(len(ct)*8>>8)
+	absorb(lens)
+	return y
+}
+
+func testBytes(t testing.TB, n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestGHASHVectors(t *testing.T) {
+	// тестовые примеры 2 и 4 GCM (McGrew, Viega)
+	vectors := []struct{ h, aad, ct, want string }{
+		{"66e94bd4ef8a2c3b884cfa59ca342b2e", "", "0388dace60b6a392f328c2b971b2fe78",
+			"f38cbb1ad69223dcc3457ae5b6b0f885"},
+		{"b83b533708bf535d0aa6e52980d53b78", "feedfacedeadbeeffeedfacedeadbeefabaddad2",
+			"42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e" +
+				"21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e091",
+			"698e57f70e6ecc7fd9463b7260a9ae5f"},
+	}
+	for i, v := range vectors {
+		var in [4][]byte
+		for j, s := range []string{v.h, v.aad, v.ct, v.want} {
+			b, err := hex.DecodeString(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in[j] = b
+		}
+		if got := GHASH(in[0], in[1], in[2]); !bytes.Equal(got, in[3]) {
+			t.Fatalf("vector #%d: GHASH = %x, want %s", i, got, v.want)
+		}
+		if got := ghashBitSerial(in[0], in[1], in[2]); !bytes.Equal(got, in[3]) {
+			t.Fatalf("vector #%d: bit-serial GHASH = %x, want %s", i, got, v.want)
+		}
+	}
+}
+
+// TestGHASHPaths сверяет таблицу и, где есть, PCLMULQDQ с побитовым GFMul на случайных данных
+func TestGHASHPaths(t *testing.T) {
+	t.Logf("PCLMULQDQ path: %v", useCLMUL)
+	for n := 1; n <= 40; n++ {
+		h, data := testBytes(t, AESBlockSize), testBytes(t, n*AESBlockSize)
+		want := make([]byte, AESBlockSize)
+		for i := 0; i < len(data); i += AESBlockSize {
+			XORInto(want, data[i:i+AESBlockSize])
+			want = GFMul(want, h, BitOrderReflected)
+		}
+		k := newGHASHKey(h)
+		var y [AESBlockSize]byte
+		k.blocksGeneric(&y, data)
+		if !bytes.Equal(y[:], want) {
+			t.Fatalf("%d blocks: table %x, want %x", n, y, want)
+		}
+		y = [AESBlockSize]byte{}
+		k.blocks(&y, data)
+		if !bytes.Equal(y[:], want) {
+			t.Fatalf("%d blocks: blocks %x, want %x", n, y, want)
+		}
+	}
+}
+
+// TestGCMMatchesStdlib сверяет MyCipher в режиме GCM с crypto/cipher на разных длинах
+func TestGCMMatchesStdlib(t *testing.T) {
+	key, nonce := testBytes(t, 16), testBytes(t, GCMNonceSize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	std, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mc := &MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := mc.SetMode(ModeGCM); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 1, 15, 16, 17, 100, 1000} {
+		msg, aad := testBytes(t, n), testBytes(t, n/3)
+		mc.SetAAD(aad)
+		got, err := mc.Encrypt(msg, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := std.Seal(append([]byte{}, nonce...), nonce, msg, aad); !bytes.Equal(got, want) {
+			t.Fatalf("%d bytes: sealed %x, want %x", n, got, want)
+		}
+	}
+}
+
+var benchSizes = []int{1 << 10, 16 << 10}
+
+// BenchmarkGHASH - PCLMULQDQ на amd64; с -tags purego - таблица. Сравнение: benchstat по двум прогонам.
+func BenchmarkGHASH(b *testing.B) {
+	h := testBytes(b, AESBlockSize)
+	for _, size := range benchSizes {
+		data := testBytes(b, size)
+		b.Run(fmt.Sprintf("%dK", size>>10), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				GHASH(h, nil, data)
+			}
+		})
+	}
+}
+
+// BenchmarkGHASHBitSerial - прежний GHASH на побитовом GFMul, точка отсчёта для BenchmarkGHASH
+func BenchmarkGHASHBitSerial(b *testing.B) {
+	h := testBytes(b, AESBlockSize)
+	for _, size := range benchSizes {
+		data := testBytes(b, size)
+		b.Run(fmt.Sprintf("%dK", size>>10), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				ghashBitSerial(h, nil, data)
+			}
+		})
+	}
+}
+
+// BenchmarkXOR - crypto/subtle (SIMD); с -tags purego - цикл по 64-битным словам
+func BenchmarkXOR(b *testing.B) {
+	for _, size := range benchSizes {
+		x, y := testBytes(b, size), testBytes(b, size)
+		dst := make([]byte, size)
+		b.Run(fmt.Sprintf("%dK", size>>10), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				XORTo(dst, x, y)
+			}
+		})
+	}
+}
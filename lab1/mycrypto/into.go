@@ -0,0 +1,244 @@
+package mycrypto
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ----- Шифрование в буфер вызывающего без выделения памяти -----
+
+// EncryptedLen возвращает длину результата Encrypt(data, iv) для сообщения длины n
+func (mc *MyCipher) EncryptedLen(n int, iv []byte) int {
+	bs := mc.blockSize
+	switch mc.mode {
+	case ModeECB:
+		return (n/bs + 1) * bs
+	case ModeCBC:
+		return bs + (n/bs+1)*bs
+	case ModeGCM, ModeOCB:
+		if mc.lenPolicy != nil {
+			n = mc.lenPolicy(n)
+		}
+		nonceLen := len(iv)
+		if nonceLen == 0 {
+			nonceLen = GCMNonceSize // OCBNonceSize совпадает
+		}
+		return nonceLen + n + GCMTagSize
+	default:
+		return bs + n
+	}
+}
+
+// directMode сообщает, обрабатывает ли EncryptTo/DecryptTo текущий режим без выделений.
+// Остальные режимы и шифрование с BlockHook выполняются через Encrypt/Decrypt с копированием.
+func (mc *MyCipher) directMode() bool {
+	if mc.hook != nil {
+		return false
+	}
+	switch mc.mode {
+	case ModeECB, ModeCBC, ModeOFB, ModeCTR:
+		return true
+	case ModeCFB:
+		return mc.segmentLen() == mc.blockSize && mc.cfbSegment != 1
+	}
+	return false
+}
+
+// scratchBlocks возвращает переиспользуемые регистр режима и временный блок
+func (mc *MyCipher) scratchBlocks() (reg, tmp []byte) {
+	if len(mc.scratch) != 2*mc.blockSize {
+		mc.scratch = make([]byte, 2*mc.blockSize)
+	}
+	return mc.scratch[:mc.blockSize:mc.blockSize], mc.scratch[mc.blockSize:]
+}
+
+// EncryptTo шифрует src так же, как Encrypt(src, iv), но пишет результат в dst
+// и возвращает его длину. dst должен вмещать EncryptedLen(len(src), iv) байт и не пересекаться с src.
+// В режимах ECB, CBC, CFB (полный блок), OFB и CTR память не выделяется.
+func (mc *MyCipher) EncryptTo(dst, src, iv []byte) (int, error) {
+	if mc.block == nil {
+		return 0, errors.New("key unsetted")
+	}
+	if err := mc.checkBlockSize(); err != nil {
+		return 0, err
+	}
+	need := mc.EncryptedLen(len(src), iv)
+	if len(dst) < need {
+		return 0, fmt.Errorf("EncryptTo: dst too short: need %d bytes, got %d", need, len(dst))
+	}
+	if !mc.directMode() {
+		ct, err := mc.Encrypt(src, iv)
+		if err != nil {
+			return 0, err
+		}
+		return copy(dst, ct), nil
+	}
+	mc.hookIndex = 0
+	mc.resetStream()
+	bs := mc.blockSize
+	reg, tmp := mc.scratchBlocks()
+	out := dst
+	if mc.requiresIV() {
+		if len(iv) == bs {
+			copy(reg, iv)
+		} else if err := mc.generateIVTo(reg); err != nil {
+			return 0, err
+		}
+		if mc.mode == ModeCTR {
+			if err := mc.checkCTRLength(reg, len(src)); err != nil {
+				return 0, err
+			}
+		}
+		if err := mc.useNonce(reg); err != nil {
+			return 0, err
+		}
+		copy(out, reg)
+		out = out[bs:]
+	}
+	switch mc.mode {
+	case ModeECB, ModeCBC:
+		full := len(src) / bs * bs
+		start := 0
+		if w := mc.parallelWorkers(full / bs); w > 1 {
+			mc.ecbParallel(out[:full], src[:full], w, false)
+			start = full
+		}
+		// последний блок с паддингом PKCS7 (при len(src) кратной блоку - целый блок паддинга)
+		for i := start; i <= full; i += bs {
+			n := copy(tmp, src[i:min(i+bs, len(src))])
+			for j := n; j < bs; j++ {
+				tmp[j] = byte(bs - n)
+			}
+			if mc.mode == ModeCBC {
+				XORInto(tmp, reg)
+			}
+			mc.block.Encrypt(out[i:i+bs], tmp)
+			if mc.mode == ModeCBC {
+				copy(reg, out[i:i+bs])
+			}
+		}
+	default:
+		if w := mc.parallelWorkers((len(src) + bs - 1) / bs); w > 1 {
+			mc.ctrParallel(out, src, reg, w)
+			incMsgCTR(reg, mc.ctrEndian)
+			break
+		}
+		mc.streamTo(out, src, reg, tmp, false)
+	}
+	if mc.mode == ModeECB {
+		mc.lastBlock = nil
+	} else {
+		mc.lastBlock = reg
+	}
+	return need, nil
+}
+
+// DecryptTo расшифровывает src так же, как Decrypt(src, iv), но пишет открытый текст в dst
+// и возвращает его длину. dst должен вмещать шифротекст без IV и не пересекаться с src.
+// В режимах ECB, CBC, CFB (полный блок), OFB и CTR память не выделяется.
+func (mc *MyCipher) DecryptTo(dst, src, iv []byte) (int, error) {
+	if mc.block == nil {
+		return 0, errors.New("key unsetted")
+	}
+	if err := mc.checkBlockSize(); err != nil {
+		return 0, err
+	}
+	if !mc.directMode() {
+		pt, err := mc.Decrypt(src, iv)
+		if err != nil {
+			return 0, err
+		}
+		if len(dst) < len(pt) {
+			return 0, fmt.Errorf("DecryptTo: dst too short: need %d bytes, got %d", len(pt), len(dst))
+		}
+		return copy(dst, pt), nil
+	}
+	mc.hookIndex = 0
+	mc.resetStream()
+	bs := mc.blockSize
+	reg, tmp := mc.scratchBlocks()
+	if mc.requiresIV() {
+		if len(iv) != bs {
+			if len(src) < bs {
+				return 0, errors.New("data too short to contain IV")
+			}
+			iv, src = src[:bs], src[bs:]
+		}
+		copy(reg, iv)
+		if mc.mode == ModeCTR {
+			if err := mc.checkCTRLength(reg, len(src)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if len(dst) < len(src) {
+		return 0, fmt.Errorf("DecryptTo: dst too short: need %d bytes, got %d", len(src), len(dst))
+	}
+	n := len(src)
+	switch mc.mode {
+	case ModeECB, ModeCBC:
+		if len(src) == 0 || len(src)%bs != 0 {
+			return 0, fmt.Errorf("%s: ciphertext length must be a positive multiple of %d", mc.mode, bs)
+		}
+		if w := mc.parallelWorkers(len(src) / bs); w > 1 {
+			mc.ecbParallel(dst[:len(src)], src, w, true)
+		} else {
+			for i := 0; i < len(src); i += bs {
+				mc.block.Decrypt(dst[i:i+bs], src[i:i+bs])
+				if mc.mode == ModeCBC {
+					XORInto(dst[i:i+bs], reg)
+					copy(reg, src[i:i+bs])
+				}
+			}
+		}
+		pt, err := Pkcs7Unpad(dst[:len(src)], bs)
+		if err != nil {
+			return 0, err
+		}
+		n = len(pt)
+	default:
+		if w := mc.parallelWorkers((len(src) + bs - 1) / bs); w > 1 {
+			mc.ctrParallel(dst, src, reg, w)
+			incMsgCTR(reg, mc.ctrEndian)
+			break
+		}
+		mc.streamTo(dst, src, reg, tmp, true)
+	}
+	if mc.mode == ModeECB {
+		mc.lastBlock = nil
+	} else {
+		mc.lastBlock = reg
+	}
+	return n, nil
+}
+
+// streamTo накладывает гамму CFB, OFB или CTR на src с начальным заполнением reg.
+// Состояние после вызова совпадает с состоянием после Encrypt/Decrypt того же сообщения.
+func (mc *MyCipher) streamTo(dst, src, reg, ks []byte, decrypt bool) {
+	bs := mc.blockSize
+	for i := 0; i < len(src); i += bs {
+		mc.block.Encrypt(ks, reg)
+		n := min(bs, len(src)-i)
+		if n < bs {
+			XORTo(dst[i:i+n], src[i:i+n], ks)
+			break
+		}
+		if mc.mode == ModeCFB && decrypt {
+			copy(reg, src[i:i+bs]) // шифротекст нужен до того, как dst его перезапишет
+		}
+		XORTo(dst[i:i+bs], src[i:i+bs], ks)
+		switch mc.mode {
+		case ModeCFB:
+			if !decrypt {
+				copy(reg, dst[i:i+bs])
+			}
+		case ModeOFB:
+			copy(reg, ks)
+		case ModeCTR:
+			incBlockCTR(reg, mc.ctrEndian) // длина проверена checkCTRLength
+		}
+	}
+	if mc.mode == ModeCTR {
+		incMsgCTR(reg, mc.ctrEndian)
+	}
+}
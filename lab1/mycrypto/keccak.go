@@ -0,0 +1,73 @@
+package mycrypto
+
+import "math/bits"
+
+// ----- Перестановка Keccak-f[1600] -----
+
+// keccakRC - раундовые константы шага ι
+var keccakRC = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808A, 0x8000000080008000,
+	0x000000000000808B, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008A, 0x0000000000000088, 0x0000000080008009, 0x000000008000000A,
+	0x000000008000808B, 0x800000000000008B, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800A, 0x800000008000000A,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakRot - смещения циклического сдвига шага ρ для дорожки (x, y) с индексом x + 5y
+var keccakRot = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// keccakF1600 применяет 24 раунда перестановки Keccak к состоянию из 25 дорожек
+func keccakF1600(a *[25]uint64) {
+	var c [5]uint64
+	var b [25]uint64
+	for round := 0; round < 24; round++ {
+		// θ
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[x+y] ^= d
+			}
+		}
+		// ρ и π
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				b[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], keccakRot[x+5*y])
+			}
+		}
+		// χ
+		for y := 0; y < 25; y += 5 {
+			for x := 0; x < 5; x++ {
+				a[x+y] = b[x+y] ^ (^b[(x+1)%5+y] & b[(x+2)%5+y])
+			}
+		}
+		// ι
+		a[0] ^= keccakRC[round]
+	}
+}
+
+// keccakXorIn складывает по модулю 2 байты data с началом состояния (порядок little-endian)
+func keccakXorIn(a *[25]uint64, data []byte) {
+	for i, b := range data {
+		a[i/8] ^= uint64(b) << (8 * uint(i%8))
+	}
+}
+
+// keccakXorByte складывает по модулю 2 байт b с i-м байтом состояния
+func keccakXorByte(a *[25]uint64, i int, b byte) {
+	a[i/8] ^= uint64(b) << (8 * uint(i%8))
+}
+
+// keccakByte возвращает i-й байт состояния
+func keccakByte(a *[25]uint64, i int) byte {
+	return byte(a[i/8] >> (8 * uint(i%8)))
+}
@@ -0,0 +1,118 @@
+package mycrypto
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Префиксы, задающие формат строки с ключом, IV или nonce.
+// Без префикса строка считается шестнадцатеричной.
+const (
+	PrefixHex    = "hex:"
+	PrefixBase64 = "base64:"
+	PrefixFile   = "file:"
+	PrefixPEM    = "pem:"
+)
+
+// ParseBytes разбирает строку в одном из форматов:
+// "hex:00ff..." или просто "00ff...", "base64:AP8=", "file:path" (сырые байты или PEM),
+// "pem:-----BEGIN ...". Пробелы и двоеточия-разделители в hex допускаются.
+func ParseBytes(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, PrefixFile):
+		path := strings.TrimPrefix(s, PrefixFile)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %v", path, err)
+		}
+		if block, _ := pem.Decode(data); block != nil {
+			return block.Bytes, nil
+		}
+		return data, nil
+	case strings.HasPrefix(s, PrefixPEM):
+		block, _ := pem.Decode([]byte(strings.TrimPrefix(s, PrefixPEM)))
+		if block == nil {
+			return nil, fmt.Errorf("no PEM block found")
+		}
+		return block.Bytes, nil
+	case strings.HasPrefix(s, PrefixBase64):
+		enc := strings.TrimPrefix(s, PrefixBase64)
+		data, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			data, err = base64.RawStdEncoding.DecodeString(enc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64: %v", err)
+		}
+		return data, nil
+	default:
+		enc := strings.TrimPrefix(s, PrefixHex)
+		enc = strings.NewReplacer(" ", "", ":", "", "\n", "", "\t", "").Replace(enc)
+		enc = strings.TrimPrefix(strings.TrimPrefix(enc, "0x"), "0X")
+		if len(enc)%2 != 0 {
+			return nil, fmt.Errorf("invalid hex: odd number of digits (%d)", len(enc))
+		}
+		data, err := hex.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex: %v (use %q or %q prefix for other formats)", err, PrefixBase64, PrefixFile)
+		}
+		return data, nil
+	}
+}
+
+// ParseKey разбирает ключ AES и проверяет его длину
+func ParseKey(s string) ([]byte, error) {
+	key, err := ParseBytes(s)
+	if err != nil {
+		return nil, fmt.Errorf("key: %v", err)
+	}
+	if len(key) != AESKeySize16 && len(key) != AESKeySize24 && len(key) != AESKeySize32 {
+		return nil, fmt.Errorf("key: invalid length %d bytes (%d bits), expected %d, %d or %d bytes",
+			len(key), len(key)*8, AESKeySize16, AESKeySize24, AESKeySize32)
+	}
+	return key, nil
+}
+
+// ParseIV разбирает IV для режима mode и проверяет его длину.
+// Пустая строка означает "сгенерировать IV автоматически" и возвращает nil.
+// Для CTR IV - это полный начальный блок счётчика nonce || IV || counter, для GCM и OCB - 12-байтовый nonce.
+func ParseIV(s string, mode string) ([]byte, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	if mode == ModeECB {
+		return nil, fmt.Errorf("iv: mode %s does not use an IV", mode)
+	}
+	iv, err := ParseBytes(s)
+	if err != nil {
+		return nil, fmt.Errorf("iv: %v", err)
+	}
+	size := AESBlockSize
+	switch mode {
+	case ModeGCM:
+		size = GCMNonceSize
+	case ModeOCB:
+		size = OCBNonceSize
+	}
+	if len(iv) != size {
+		return nil, fmt.Errorf("iv: invalid length %d bytes for mode %s, expected %d", len(iv), mode, size)
+	}
+	return iv, nil
+}
+
+// ParseNonce разбирает nonce режима CTR (старшие NonceSize байт блока счётчика)
+func ParseNonce(s string) ([]byte, error) {
+	nonce, err := ParseBytes(s)
+	if err != nil {
+		return nil, fmt.Errorf("nonce: %v", err)
+	}
+	if len(nonce) != NonceSize {
+		return nil, fmt.Errorf("nonce: invalid length %d bytes, expected %d", len(nonce), NonceSize)
+	}
+	return nonce, nil
+}
@@ -0,0 +1,159 @@
+package mycrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ----- Обёртывание ключей AES-KW (RFC 3394) и AES-KWP (RFC 5649) -----
+
+// Начальные значения проверки целостности
+var (
+	kwDefaultIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+	kwpPrefix   = []byte{0xa6, 0x59, 0x59, 0xa6}
+)
+
+// ErrUnwrap возвращается, если проверка целостности обёрнутого ключа не прошла
+var ErrUnwrap = errors.New("key unwrap: integrity check failed")
+
+// newKEK создаёт блочный шифр ключа обёртывания
+func newKEK(kek []byte) (cipher.Block, error) {
+	if len(kek) != AESKeySize16 && len(kek) != AESKeySize24 && len(kek) != AESKeySize32 {
+		return nil, fmt.Errorf("invalid KEK length: got %d, expected %d, %d, or %d", len(kek), AESKeySize16, AESKeySize24, AESKeySize32)
+	}
+	return aes.NewCipher(kek)
+}
+
+// wrapBlocks - функция W из RFC 3394: 6n раундов над A и полублоками R[1..n]
+func wrapBlocks(b cipher.Block, iv, plain []byte) []byte {
+	n := len(plain) / 8
+	out := make([]byte, 8+len(plain))
+	a := out[:8]
+	copy(a, iv)
+	copy(out[8:], plain)
+	buf := make([]byte, AESBlockSize)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			r := out[8*i : 8*i+8]
+			copy(buf, a)
+			copy(buf[8:], r)
+			b.Encrypt(buf, buf)
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(buf[:8])^t)
+			copy(r, buf[8:])
+		}
+	}
+	return out
+}
+
+// unwrapBlocks - обратная функция W^-1; возвращает A и полублоки R[1..n]
+func unwrapBlocks(b cipher.Block, wrapped []byte) (a, plain []byte) {
+	n := len(wrapped)/8 - 1
+	a = append([]byte{}, wrapped[:8]...)
+	plain = append([]byte{}, wrapped[8:]...)
+	buf := make([]byte, AESBlockSize)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			r := plain[8*(i-1) : 8*i]
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(a)^t)
+			copy(buf[8:], r)
+			b.Decrypt(buf, buf)
+			copy(a, buf[:8])
+			copy(r, buf[8:])
+		}
+	}
+	return a, plain
+}
+
+// WrapKey обёртывает ключ key на ключе kek по RFC 3394.
+// Длина key должна быть кратна 8 байтам и не меньше 16; результат длиннее на 8 байт.
+func WrapKey(kek, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, fmt.Errorf("WrapKey: key length must be a multiple of 8 and at least 16, got %d", len(key))
+	}
+	b, err := newKEK(kek)
+	if err != nil {
+		return nil, err
+	}
+	return wrapBlocks(b, kwDefaultIV, key), nil
+}
+
+// UnwrapKey разворачивает ключ, обёрнутый WrapKey, и проверяет значение целостности
+func UnwrapKey(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, fmt.Errorf("UnwrapKey: wrapped key length must be a multiple of 8 and at least 24, got %d", len(wrapped))
+	}
+	b, err := newKEK(kek)
+	if err != nil {
+		return nil, err
+	}
+	a, key := unwrapBlocks(b, wrapped)
+	if subtle.ConstantTimeCompare(a, kwDefaultIV) != 1 {
+		return nil, ErrUnwrap
+	}
+	return key, nil
+}
+
+// WrapKeyPadded обёртывает ключ произвольной длины (от 1 байта) по RFC 5649
+func WrapKeyPadded(kek, key []byte) ([]byte, error) {
+	if len(key) == 0 || uint64(len(key)) > 0xffffffff {
+		return nil, fmt.Errorf("WrapKeyPadded: invalid key length %d", len(key))
+	}
+	b, err := newKEK(kek)
+	if err != nil {
+		return nil, err
+	}
+	// AIV = A65959A6 || MLI (длина ключа в байтах, 32 бита)
+	aiv := binary.BigEndian.AppendUint32(append([]byte{}, kwpPrefix...), uint32(len(key)))
+	padded := make([]byte, (len(key)+7)/8*8)
+	copy(padded, key)
+	if len(padded) == 8 {
+		// один полублок шифруется одним вызовом AES
+		out := make([]byte, AESBlockSize)
+		copy(out, aiv)
+		copy(out[8:], padded)
+		b.Encrypt(out, out)
+		return out, nil
+	}
+	return wrapBlocks(b, aiv, padded), nil
+}
+
+// UnwrapKeyPadded разворачивает ключ, обёрнутый WrapKeyPadded, и проверяет AIV и нулевое дополнение
+func UnwrapKeyPadded(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 16 || len(wrapped)%8 != 0 {
+		return nil, fmt.Errorf("UnwrapKeyPadded: wrapped key length must be a multiple of 8 and at least 16, got %d", len(wrapped))
+	}
+	b, err := newKEK(kek)
+	if err != nil {
+		return nil, err
+	}
+	var a, padded []byte
+	if len(wrapped) == 16 {
+		out := make([]byte, AESBlockSize)
+		b.Decrypt(out, wrapped)
+		a, padded = out[:8], out[8:]
+	} else {
+		a, padded = unwrapBlocks(b, wrapped)
+	}
+	mli := int(binary.BigEndian.Uint32(a[4:]))
+	ok := subtle.ConstantTimeCompare(a[:4], kwpPrefix)
+	// длина должна попадать в последние 8 байт, а дополнение - состоять из нулей
+	if mli <= len(padded)-8 || mli > len(padded) {
+		ok = 0
+	} else {
+		var nz byte
+		for _, c := range padded[mli:] {
+			nz |= c
+		}
+		ok &= subtle.ConstantTimeByteEq(nz, 0)
+	}
+	if ok != 1 {
+		return nil, ErrUnwrap
+	}
+	return padded[:mli], nil
+}
@@ -0,0 +1,82 @@
+package mycrypto
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// ----- Дополнение до корзин для сокрытия длины -----
+
+// BucketPolicy возвращает длину, до которой дополняется открытый текст длины n.
+// Результат должен быть не меньше n+1: в дополнении всегда есть хотя бы байт 0x80.
+type BucketPolicy func(n int) int
+
+// BucketMultiple дополняет до ближайшего кратного size
+func BucketMultiple(size int) BucketPolicy {
+	return func(n int) int {
+		return (n/size + 1) * size
+	}
+}
+
+// BucketPow2 дополняет до ближайшей степени двойки, но не меньше min
+func BucketPow2(min int) BucketPolicy {
+	return func(n int) int {
+		p := 1 << bits.Len(uint(n))
+		if p < min {
+			p = min
+		}
+		return p
+	}
+}
+
+// BucketPadme реализует Padmé (Nikitin et al., PURBs): длина округляется так, что
+// обнулены младшие биты в количестве порядка log2(log2(L)); издержки не более ~12%
+func BucketPadme() BucketPolicy {
+	return func(n int) int {
+		l := n + 1
+		if l < 2 {
+			return 2
+		}
+		e := bits.Len(uint(l)) - 1 // floor(log2 L)
+		s := bits.Len(uint(e))     // floor(log2 E) + 1
+		zeroBits := e - s
+		if zeroBits < 0 {
+			zeroBits = 0
+		}
+		mask := (1 << uint(zeroBits)) - 1
+		return (l + mask) &^ mask
+	}
+}
+
+// PadToBucket дополняет data по схеме ISO/IEC 7816-4 (0x80, затем нули) до длины policy(len(data))
+func PadToBucket(data []byte, policy BucketPolicy) ([]byte, error) {
+	size := policy(len(data))
+	if size <= len(data) {
+		return nil, fmt.Errorf("bucket policy returned %d for %d-byte message", size, len(data))
+	}
+	out := make([]byte, size)
+	copy(out, data)
+	out[len(data)] = 0x80
+	return out, nil
+}
+
+// UnpadBucket снимает дополнение PadToBucket
+func UnpadBucket(data []byte) ([]byte, error) {
+	for i := len(data) - 1; i >= 0; i-- {
+		switch data[i] {
+		case 0x00:
+			continue
+		case 0x80:
+			return data[:i], nil
+		}
+		break
+	}
+	return nil, errors.New("invalid length-hiding padding")
+}
+
+// SetLengthPolicy включает сокрытие длины в режимах GCM и OCB: открытый текст дополняется
+// до корзины перед шифрованием, так что дополнение защищено тегом. nil отключает режим.
+func (mc *MyCipher) SetLengthPolicy(policy BucketPolicy) {
+	mc.lenPolicy = policy
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package mycrypto
+
+import "os"
+
+// mapFile на этой платформе недоступен: файл читается обычным образом
+func mapFile(*os.File, int64) ([]byte, func() error, error) {
+	return nil, nil, errMmapUnavailable
+}
@@ -3,12 +3,16 @@ package mycrypto
 import (
 	"bytes"
 	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"io"
 )
 
+// Rand - источник случайности для IV и nonce. По умолчанию crypto/rand;
+// для воспроизводимых прогонов его можно заменить на myrand.Recorder или myrand.Replayer.
+var Rand io.Reader = rand.Reader
+
 // --- Константы ---
 // Общие параметры для AES
 const (
@@ -32,6 +36,9 @@ const (
 	ModeCFB = "CFB"
 	ModeOFB = "OFB"
 	ModeCTR = "CTR"
+	ModeGCM = "GCM"
+	ModeOCB = "OCB"
+	ModeCTS = "CTS" // CBC с кражей шифротекста (CBC-CS3)
 
 	PaddingPKCS7 = "PKCS7"
 	PaddingNON   = "NON"
@@ -42,10 +49,59 @@ const (
 type MyCipher struct {
 	key       []byte
 	mode      string
-	aesBlock  cipher.Block
+	block     BlockCipher
 	lastBlock []byte
 	blockSize int
 	nonce     []byte
+
+	// Состояние потоковых режимов CFB/OFB/CTR при обработке кусков произвольной длины
+	keystream []byte // текущий блок гаммы
+	feedback  []byte // накопленные байты шифротекста текущего блока (CFB)
+	offset    int    // число использованных байтов текущего блока гаммы
+	ivBuf     []byte // начало IV, пришедшее при расшифровании не целиком
+	ctrWrap   bool   // счётчик блока CTR переполнился, следующий блок гаммы запрещён
+
+	aad        []byte        // дополнительные аутентифицируемые данные (GCM, OCB)
+	ctrEndian  Endian        // порядок байтов полей счётчика CTR
+	cfbSegment int           // размер сегмента CFB в битах (0 - полный блок)
+	lenPolicy  BucketPolicy  // политика сокрытия длины (GCM, OCB)
+	nonces     *NonceTracker // использованные с текущим ключом IV/nonce (noncetrack.go)
+	workers    int           // число горутин для CTR и ECB (parallel.go)
+
+	// Телеметрия по блокам (trace.go)
+	hook              BlockHook
+	hookIndex         int
+	traceIn, traceOut []byte // байты текущего сегмента потокового режима
+
+	scratch []byte // регистр и временный блок EncryptTo/DecryptTo (into.go)
+}
+
+// BlockCipher - блочный шифр, над которым работают режимы MyCipher.
+// Интерфейс совпадает с cipher.Block, поэтому подходят crypto/des, myaes и собственные шифры.
+type BlockCipher interface {
+	BlockSize() int
+	Encrypt(dst, src []byte)
+	Decrypt(dst, src []byte)
+}
+
+// SetBlockCipher подключает произвольный блочный шифр с блоком 64 или 128 бит вместо AES.
+// Режимы GCM и OCB определены только для 128-битного блока.
+func (mc *MyCipher) SetBlockCipher(b BlockCipher) error {
+	if b == nil {
+		return errors.New("SetBlockCipher: nil block cipher")
+	}
+	if bs := b.BlockSize(); bs != 8 && bs != AESBlockSize {
+		return fmt.Errorf("SetBlockCipher: unsupported block size %d, expected 8 or %d", bs, AESBlockSize)
+	}
+	mc.key = nil
+	mc.block = b
+	mc.blockSize = b.BlockSize()
+	mc.lastBlock = nil
+	mc.resetStream()
+	if mc.nonces != nil {
+		mc.nonces.Reset()
+	}
+	return nil
 }
 
 // SetKey устанавливает ключ и инициализирует AES‑блочный шифр
@@ -53,36 +109,65 @@ func (mc *MyCipher) SetKey(newkey []byte) error {
 	if len(newkey) != AESKeySize16 && len(newkey) != AESKeySize24 && len(newkey) != AESKeySize32 {
 		return fmt.Errorf("invalid key length: got %d, expected %d, %d, or %d", len(newkey), AESKeySize16, AESKeySize24, AESKeySize32)
 	}
-	var err error
-	mc.key = newkey
-	mc.aesBlock, err = aes.NewCipher(newkey)
+	block, err := aes.NewCipher(newkey)
 	if err != nil {
 		return err
 	}
-	mc.blockSize = mc.aesBlock.BlockSize() // всегда 16 байт для AES
+	mc.key = newkey
+	mc.block = block
+	mc.blockSize = block.BlockSize() // всегда 16 байт для AES
 	mc.lastBlock = nil
+	mc.resetStream()
+	if mc.nonces != nil {
+		mc.nonces.Reset()
+	}
 	return nil
 }
 
 // SetMode задает режим шифрования
 func (mc *MyCipher) SetMode(newmode string) error {
 	switch newmode {
-	case ModeECB, ModeCBC, ModeCFB, ModeOFB, ModeCTR:
+	case ModeECB, ModeCBC, ModeCFB, ModeOFB, ModeCTR, ModeGCM, ModeOCB, ModeCTS:
 		mc.mode = newmode
 		mc.lastBlock = nil
+		mc.resetStream()
 		return nil
 	default:
 		return fmt.Errorf("wrong mode [%s] detected", newmode)
 	}
 }
 
-// BlockCipherEncrypt выполняет одноблочное шифрование с помощью AES
+// Reset отбрасывает состояние незаконченного сообщения (IV, счётчик, позицию в гамме);
+// ключ, режим и прочие настройки сохраняются, и следующий вызов начинает новое сообщение
+func (mc *MyCipher) Reset() {
+	mc.lastBlock = nil
+	mc.resetStream()
+}
+
+// Clone возвращает независимую копию с тем же блочным шифром, настройками и состоянием
+// незаконченного сообщения, так что поток можно разветвить посреди сообщения.
+// Расписание ключа, обработчик блоков и трекер nonce копия разделяет с оригиналом;
+// копию и оригинал можно использовать в разных горутинах, если эти общие части это допускают.
+func (mc *MyCipher) Clone() *MyCipher {
+	c := *mc
+	c.lastBlock = bytes.Clone(mc.lastBlock)
+	c.nonce = bytes.Clone(mc.nonce)
+	c.keystream = bytes.Clone(mc.keystream)
+	c.feedback = bytes.Clone(mc.feedback)
+	c.ivBuf = bytes.Clone(mc.ivBuf)
+	c.aad = bytes.Clone(mc.aad)
+	c.traceIn, c.traceOut = bytes.Clone(mc.traceIn), bytes.Clone(mc.traceOut)
+	c.scratch = nil
+	return &c
+}
+
+// BlockCipherEncrypt выполняет одноблочное шифрование установленным блочным шифром
 func (mc *MyCipher) BlockCipherEncrypt(data []byte) ([]byte, error) {
 	if len(data) != mc.blockSize {
 		return nil, fmt.Errorf("BlockCipherEncrypt: data length must be %d", mc.blockSize)
 	}
 	out := make([]byte, mc.blockSize)
-	mc.aesBlock.Encrypt(out, data)
+	mc.block.Encrypt(out, data)
 	return out, nil
 }
 
@@ -92,7 +177,7 @@ func (mc *MyCipher) BlockCipherDecrypt(data []byte) ([]byte, error) {
 		return nil, fmt.Errorf("BlockCipherDecrypt: data length must be %d", mc.blockSize)
 	}
 	out := make([]byte, mc.blockSize)
-	mc.aesBlock.Decrypt(out, data)
+	mc.block.Decrypt(out, data)
 	return out, nil
 }
 
@@ -108,58 +193,73 @@ func Pkcs7Pad(data []byte, blockSize int) []byte {
 	return append(data, padding...)
 }
 
+// ErrInvalidPadding возвращается при неверном PKCS7-паддинге после расшифрования
+var ErrInvalidPadding = errors.New("invalid padding")
+
 // pkcs7Unpad удаляет PKCS7-паддинг.
 func Pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
 	if len(data) == 0 || len(data)%blockSize != 0 {
 		return nil, errors.New("invalid padded data")
 	}
 	padLen := int(data[len(data)-1])
+	AuditPoint("Pkcs7Unpad/padLen")
 	if padLen <= 0 || padLen > blockSize {
-		return nil, errors.New("invalid padding")
+		return nil, ErrInvalidPadding
 	}
 	// Проверяем корректность всех байтов паддинга.
 	for i := 0; i < padLen; i++ {
+		AuditPoint("Pkcs7Unpad/byteCheck")
 		if data[len(data)-1-i] != byte(padLen) {
-			return nil, errors.New("invalid padding")
+			return nil, ErrInvalidPadding
 		}
 	}
 	return data[:len(data)-padLen], nil
 }
 
-// xorBytes выполняет операцию XOR двух срезов байтов одинаковой длины.
-func xorBytes(a, b []byte) ([]byte, error) {
-	if len(a) != len(b) {
-		return nil, errors.New("xor: slices must have equal length")
-	}
-	res := make([]byte, len(a))
-	for i := 0; i < len(a); i++ {
-		res[i] = a[i] ^ b[i]
+// ctrFields возвращает начала полей IV (CTR_MSG) и счётчика блока (CTR_BLOCK) в блоке CTR.
+// Для 128-битного блока формат [nonce (4) || IV (4) || counter (8)], для 64-битного - [IV (4) || counter (4)].
+func ctrFields(blockSize int) (ivStart, ctrStart int) {
+	if blockSize < AESBlockSize {
+		return 0, blockSize / 2
 	}
-	return res, nil
+	return NonceSize, NonceSize + IVSize
 }
 
+// ErrCounterOverflow возвращается, если сообщению CTR не хватает значений счётчика блока:
+// после переполнения гамма повторилась бы с начала
+var ErrCounterOverflow = errors.New("CTR block counter overflow")
+
 // Функция инкремента для части CTR, отвечающей за блоковый счетчик (CTR_BLOCK).
-// Мы считаем, что CTR имеет формат: [nonce (4) || IV (4) || counter (8)]
-func incBlockCTR(counter []byte) {
-	// Инкрементируем последние 8 байт.
-	for i := AESBlockSize - 1; i >= NonceSize+IVSize; i-- {
-		counter[i]++
-		if counter[i] != 0 {
-			break
-		}
+// При переполнении счётчик обнуляется и возвращается ErrCounterOverflow.
+func incBlockCTR(counter []byte, order Endian) error {
+	_, ctrStart := ctrFields(len(counter))
+	if incField(counter[ctrStart:], order) {
+		return ErrCounterOverflow
 	}
+	return nil
 }
 
-// Функция INC_MSG для режима CTR – увеличивает поле IV (CTR_MSG) и сбрасывает счетчик блока.
-func incMsgCTR(counter []byte) {
-	for i := NonceSize + IVSize - 1; i >= NonceSize; i-- {
-		counter[i]++
-		if counter[i] != 0 {
-			break
-		}
+// checkCTRLength проверяет, что сообщению длиной n байт хватит значений счётчика блока,
+// начиная с начального блока iv
+func (mc *MyCipher) checkCTRLength(iv []byte, n int) error {
+	if n == 0 {
+		return nil
+	}
+	_, ctrStart := ctrFields(mc.blockSize)
+	field := iv[ctrStart:]
+	left := ^uint64(0)>>(64-8*len(field)) - fieldValue(field, mc.ctrEndian) // число доступных инкрементов
+	if blocks := uint64((n + mc.blockSize - 1) / mc.blockSize); blocks-1 > left {
+		return fmt.Errorf("%s: %d-byte message needs %d blocks, counter allows %d: %w", mc.mode, n, blocks, left+1, ErrCounterOverflow)
 	}
-	// Сбрасываем последние 8 байт (CTR_BLOCK) в ноль.
-	for i := NonceSize + IVSize; i < AESBlockSize; i++ {
+	return nil
+}
+
+// Функция INC_MSG для режима CTR – увеличивает поле IV (CTR_MSG) и сбрасывает счетчик блока.
+func incMsgCTR(counter []byte, order Endian) {
+	ivStart, ctrStart := ctrFields(len(counter))
+	incField(counter[ivStart:ctrStart], order)
+	// Сбрасываем CTR_BLOCK в ноль.
+	for i := ctrStart; i < len(counter); i++ {
 		counter[i] = 0
 	}
 }
@@ -176,6 +276,140 @@ func (mc *MyCipher) requiresIV() bool {
 	}
 }
 
+// generateIV создаёт начальное заполнение для режима: для CTR - блок nonce || IV || 0
+// (IV || 0 для 64-битного блока), для остальных режимов - случайный блок
+func (mc *MyCipher) generateIV() ([]byte, error) {
+	iv := make([]byte, mc.blockSize)
+	if err := mc.generateIVTo(iv); err != nil {
+		return nil, err
+	}
+	return iv, nil
+}
+
+// generateIVTo записывает новое начальное заполнение в iv длиной в блок
+func (mc *MyCipher) generateIVTo(iv []byte) error {
+	if mc.mode == ModeCTR && mc.blockSize < AESBlockSize {
+		_, ctrStart := ctrFields(mc.blockSize)
+		clear(iv[ctrStart:])
+		if n, err := Rand.Read(iv[:ctrStart]); err != nil || n != ctrStart {
+			return errors.New("failed to generate IV for CTR")
+		}
+		return nil
+	}
+	if mc.mode == ModeCTR {
+		if mc.nonce == nil {
+			nonce := make([]byte, NonceSize)
+			if n, err := Rand.Read(nonce); err != nil || n != NonceSize {
+				return errors.New("failed to generate nonce")
+			}
+			mc.nonce = nonce
+		}
+		copy(iv, mc.nonce)
+		if n, err := Rand.Read(iv[NonceSize : NonceSize+IVSize]); err != nil || n != IVSize {
+			return errors.New("failed to generate IV for CTR")
+		}
+		clear(iv[NonceSize+IVSize:]) // счётчик блока по умолчанию нулевой
+		return nil
+	}
+	if n, err := Rand.Read(iv); err != nil || n != mc.blockSize {
+		return errors.New("failed to generate IV")
+	}
+	return nil
+}
+
+// resetStream сбрасывает позицию в блоке гаммы потоковых режимов
+func (mc *MyCipher) resetStream() {
+	mc.keystream = nil
+	mc.feedback = nil
+	mc.offset = 0
+	mc.ivBuf = nil
+	mc.ctrWrap = false
+	mc.hookIndex = 0
+	mc.traceIn, mc.traceOut = nil, nil
+}
+
+// streamXOR накладывает гамму CFB/OFB/CTR на data произвольной длины.
+// mc.offset хранит позицию в текущем блоке гаммы, поэтому последовательность вызовов
+// с любыми длинами кусков даёт тот же результат, что и обработка сообщения целиком.
+// Новый блок гаммы вырабатывается, только когда использован сегмент предыдущего (для CFB-s - s/8 байт, иначе блок).
+func (mc *MyCipher) streamXOR(data []byte, decrypt bool, isFinalBlock bool) ([]byte, error) {
+	if mc.mode == ModeCFB && mc.cfbSegment == 1 {
+		return mc.cfb1XOR(data, decrypt)
+	}
+	seg := mc.segmentLen()
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); {
+		if mc.offset == 0 {
+			if mc.ctrWrap {
+				return nil, fmt.Errorf("%s: %w", mc.mode, ErrCounterOverflow)
+			}
+			ks, err := mc.BlockCipherEncrypt(mc.lastBlock)
+			if err != nil {
+				return nil, err
+			}
+			mc.keystream = ks
+			if mc.mode == ModeCFB {
+				mc.feedback = make([]byte, mc.blockSize)
+			}
+		}
+		// остаток текущего сегмента гаммы накладывается за один вызов
+		n := min(seg-mc.offset, len(data)-i)
+		in, res := data[i:i+n], out[i:i+n]
+		XORTo(res, in, mc.keystream[mc.offset:mc.offset+n])
+		if mc.hook != nil {
+			mc.traceIn = append(mc.traceIn, in...)
+			mc.traceOut = append(mc.traceOut, res...)
+		}
+		if mc.mode == ModeCFB {
+			// в регистр обратной связи CFB попадает шифротекст
+			if decrypt {
+				copy(mc.feedback[mc.offset:], in)
+			} else {
+				copy(mc.feedback[mc.offset:], res)
+			}
+		}
+		mc.offset += n
+		i += n
+		if mc.offset == seg {
+			mc.emitStream(decrypt)
+			mc.nextStreamBlock()
+		}
+	}
+	if isFinalBlock {
+		mc.emitStream(decrypt)
+		if mc.mode == ModeCTR {
+			incMsgCTR(mc.lastBlock, mc.ctrEndian)
+			mc.ctrWrap = false
+		}
+		mc.offset = 0
+	}
+	return out, nil
+}
+
+// nextStreamBlock обновляет заполнение после полностью использованного блока гаммы
+func (mc *MyCipher) nextStreamBlock() {
+	switch mc.mode {
+	case ModeCFB:
+		// сдвиг регистра на сегмент шифротекста; при полном блоке регистр - сам шифротекст
+		seg := mc.segmentLen()
+		reg := make([]byte, mc.blockSize)
+		copy(reg, mc.lastBlock[seg:])
+		copy(reg[mc.blockSize-seg:], mc.feedback[:seg])
+		mc.lastBlock = reg
+	case ModeOFB:
+		mc.lastBlock = mc.keystream
+	case ModeCTR:
+		counter := make([]byte, mc.blockSize)
+		copy(counter, mc.lastBlock)
+		// последний блок счётчика ещё допустим, ошибка - только если понадобится следующий
+		if err := incBlockCTR(counter, mc.ctrEndian); err != nil {
+			mc.ctrWrap = true
+		}
+		mc.lastBlock = counter
+	}
+	mc.offset = 0
+}
+
 // ----- Потоковый интерфейс -----
 
 // ProcessBlockEncrypt осуществляет шифрование одного блока (или части блока) с учётом режима и паддинга.
@@ -185,6 +419,13 @@ func (mc *MyCipher) ProcessBlockEncrypt(data []byte, isFinalBlock bool, padding
 	if padding != PaddingPKCS7 && padding != PaddingNON {
 		return nil, fmt.Errorf("unsupported padding: %s", padding)
 	}
+	if mc.block == nil {
+		return nil, errors.New("key unsetted")
+	}
+	// Длина проверяется до изменения состояния: отвергнутый вызов не должен съедать IV
+	if (mc.mode == ModeECB || mc.mode == ModeCBC) && isFinalBlock && padding == PaddingPKCS7 && len(data) >= mc.blockSize {
+		return nil, fmt.Errorf("%s: final block with PKCS7 padding must be shorter than %d bytes", mc.mode, mc.blockSize)
+	}
 	var result []byte
 	switch mc.mode {
 	case ModeECB:
@@ -198,26 +439,32 @@ func (mc *MyCipher) ProcessBlockEncrypt(data []byte, isFinalBlock bool, padding
 		if err != nil {
 			return nil, err
 		}
+		mc.emit(false, nil, data, enc)
 		result = append(result, enc...)
 		return result, nil
 
 	case ModeCBC:
+		if !(isFinalBlock && padding == PaddingPKCS7) && len(data) != mc.blockSize {
+			return nil, fmt.Errorf("CBC: data block length must be %d", mc.blockSize)
+		}
 		// Если lastBlock не задан, генерируем IV и сохраняем его.
 		if mc.lastBlock == nil {
 			iv := make([]byte, mc.blockSize)
-			if n, err := rand.Read(iv); err != nil || n != mc.blockSize {
+			if n, err := Rand.Read(iv); err != nil || n != mc.blockSize {
 				return nil, errors.New("Failed to generate IV")
 			}
+			if err := mc.useNonce(iv); err != nil {
+				return nil, err
+			}
 			mc.lastBlock = iv
+			mc.hookIndex = 0
 			// При шифровании IV прикрепляем в начало результата.
 			result = append(result, iv...)
 		}
 		if isFinalBlock && padding == PaddingPKCS7 {
 			data = Pkcs7Pad(data, mc.blockSize)
-		} else if len(data) != mc.blockSize {
-			return nil, fmt.Errorf("CBC: data block length must be %d", mc.blockSize)
 		}
-		xored, err := xorBytes(data, mc.lastBlock)
+		xored, err := XORBytes(data, mc.lastBlock)
 		if err != nil {
 			return nil, err
 		}
@@ -225,116 +472,37 @@ func (mc *MyCipher) ProcessBlockEncrypt(data []byte, isFinalBlock bool, padding
 		if err != nil {
 			return nil, err
 		}
+		mc.emit(false, mc.lastBlock, data, enc)
 		result = append(result, enc...)
 		mc.lastBlock = enc
 		return result, nil
 
-	case ModeCFB:
+	case ModeCFB, ModeOFB, ModeCTR:
 		// Для режимов CFB, OFB, CTR используется паддинг NON.
 		if padding != PaddingNON {
-			return nil, errors.New("CFB mode does not support padding")
+			return nil, fmt.Errorf("%s mode does not support padding", mc.mode)
 		}
 		if mc.lastBlock == nil {
-			iv := make([]byte, mc.blockSize)
-			if n, err := rand.Read(iv); err != nil || n != mc.blockSize {
-				return nil, errors.New("failed to generate IV")
+			iv, err := mc.generateIV()
+			if err != nil {
+				return nil, err
 			}
-			mc.lastBlock = iv
-			result = append(result, iv...)
-		}
-		encrypted, err := mc.BlockCipherEncrypt(mc.lastBlock)
-		if err != nil {
-			return nil, err
-		}
-		n := len(data)
-		if n < mc.blockSize {
-			encrypted = encrypted[:n]
-		}
-		xored, err := xorBytes(data, encrypted)
-		if err != nil {
-			return nil, err
-		}
-		result = append(result, xored...)
-		if len(data) == mc.blockSize {
-			mc.lastBlock = xored
-		}
-		return result, nil
-
-	case ModeOFB:
-		if padding != PaddingNON {
-			return nil, errors.New("OFB mode does not support padding")
-		}
-		if mc.lastBlock == nil {
-			iv := make([]byte, mc.blockSize)
-			if n, err := rand.Read(iv); err != nil || n != mc.blockSize {
-				return nil, errors.New("failed to generate IV")
+			if err := mc.useNonce(iv); err != nil {
+				return nil, err
 			}
 			mc.lastBlock = iv
+			mc.resetStream()
 			result = append(result, iv...)
 		}
-		encrypted, err := mc.BlockCipherEncrypt(mc.lastBlock)
-		if err != nil {
-			return nil, err
-		}
-		n := len(data)
-		if n < mc.blockSize {
-			encrypted = encrypted[:n]
-		}
-		xored, err := xorBytes(data, encrypted)
-		if err != nil {
-			return nil, err
-		}
-		result = append(result, xored...)
-		mc.lastBlock = encrypted
-		return result, nil
-
-	case ModeCTR:
-		if padding != PaddingNON {
-			return nil, errors.New("CTR mode does not support padding")
-		}
-		if mc.lastBlock == nil {
-			if mc.nonce == nil {
-				nonce := make([]byte, NonceSize)
-				if n, err := rand.Read(nonce); err != nil || n != NonceSize {
-					return nil, errors.New("failed to generate nonce")
-				}
-				mc.nonce = nonce
-			}
-			newIV := make([]byte, IVSize)
-			if n, err := rand.Read(newIV); err != nil || n != IVSize {
-				return nil, errors.New("failed to generate IV for CTR")
-			}
-			counterBlock := make([]byte, CounterSize) // по умолчанию нули
-			ctr := append(append(append([]byte{}, mc.nonce...), newIV...), counterBlock...)
-			if len(ctr) != mc.blockSize {
-				return nil, fmt.Errorf("CTR: invalid ctr length, got %d, expected %d", len(ctr), mc.blockSize)
-			}
-			mc.lastBlock = ctr
-			result = append(result, ctr...)
-		}
-		counter := make([]byte, mc.blockSize)
-		copy(counter, mc.lastBlock)
-		keystream, err := mc.BlockCipherEncrypt(counter)
-		if err != nil {
-			return nil, err
-		}
-		n := len(data)
-		if n < mc.blockSize {
-			keystream = keystream[:n]
-		}
-		encrypted, err := xorBytes(data, keystream)
+		encrypted, err := mc.streamXOR(data, false, isFinalBlock)
 		if err != nil {
 			return nil, err
 		}
 		result = append(result, encrypted...)
-		if isFinalBlock {
-			incMsgCTR(counter)
-		} else {
-			incBlockCTR(counter)
-		}
-		mc.lastBlock = counter
 		return result, nil
 
+	case ModeGCM, ModeOCB, ModeCTS:
+		return nil, fmt.Errorf("%s: block-wise processing is not supported, use Encrypt/Decrypt", mc.mode)
 	default:
 		return nil, fmt.Errorf("unsupported mode: %s", mc.mode)
 	}
@@ -346,6 +514,9 @@ func (mc *MyCipher) ProcessBlockDecrypt(data []byte, isFinalBlock bool, padding
 	if padding != PaddingPKCS7 && padding != PaddingNON {
 		return nil, fmt.Errorf("unsupported padding: %s", padding)
 	}
+	if mc.block == nil {
+		return nil, errors.New("key unsetted")
+	}
 	var result []byte
 	switch mc.mode {
 	case ModeECB:
@@ -356,6 +527,7 @@ func (mc *MyCipher) ProcessBlockDecrypt(data []byte, isFinalBlock bool, padding
 		if err != nil {
 			return nil, err
 		}
+		mc.emit(true, nil, data, decrypted)
 		result = append(result, decrypted...)
 		if isFinalBlock && padding == PaddingPKCS7 {
 			return Pkcs7Unpad(result, mc.blockSize)
@@ -372,16 +544,18 @@ func (mc *MyCipher) ProcessBlockDecrypt(data []byte, isFinalBlock bool, padding
 			}
 			mc.lastBlock = make([]byte, mc.blockSize)
 			copy(mc.lastBlock, data[:mc.blockSize])
+			mc.hookIndex = 0
 			return []byte{}, nil
 		}
 		decrypted, err := mc.BlockCipherDecrypt(data)
 		if err != nil {
 			return nil, err
 		}
-		plaintext, err := xorBytes(decrypted, mc.lastBlock)
+		plaintext, err := XORBytes(decrypted, mc.lastBlock)
 		if err != nil {
 			return nil, err
 		}
+		mc.emit(true, mc.lastBlock, data, plaintext)
 		result = append(result, plaintext...)
 		mc.lastBlock = make([]byte, mc.blockSize)
 		copy(mc.lastBlock, data)
@@ -390,110 +564,81 @@ func (mc *MyCipher) ProcessBlockDecrypt(data []byte, isFinalBlock bool, padding
 		}
 		return result, nil
 
-	case ModeCFB:
+	case ModeCFB, ModeOFB, ModeCTR:
 		if padding != PaddingNON {
-			return nil, errors.New("CFB mode does not support padding")
+			return nil, fmt.Errorf("%s mode does not support padding", mc.mode)
 		}
 		if mc.lastBlock == nil {
-			if len(data) < mc.blockSize {
-				return nil, errors.New("CFB Decrypt: ciphertext too short for IV")
-			}
-			mc.lastBlock = make([]byte, mc.blockSize)
-			copy(mc.lastBlock, data[:mc.blockSize])
-			return []byte{}, nil
-		}
-		keystream, err := mc.BlockCipherEncrypt(mc.lastBlock)
-		if err != nil {
-			return nil, err
-		}
-		n := len(data)
-		if n < mc.blockSize {
-			keystream = keystream[:n]
-		}
-		plaintext, err := xorBytes(data, keystream)
-		if err != nil {
-			return nil, err
-		}
-		if len(data) == mc.blockSize {
-			mc.lastBlock = data
-		}
-		return plaintext, nil
-
-	case ModeOFB:
-		if padding != PaddingNON {
-			return nil, errors.New("OFB mode does not support padding")
-		}
-		if mc.lastBlock == nil {
-			if len(data) < mc.blockSize {
-				return nil, errors.New("OFB Decrypt: ciphertext too short for IV")
+			// IV может прийти по частям: копим его, пока не наберётся целый блок
+			need := mc.blockSize - len(mc.ivBuf)
+			if len(data) < need {
+				if isFinalBlock {
+					return nil, fmt.Errorf("%s Decrypt: ciphertext too short for IV", mc.mode)
+				}
+				mc.ivBuf = append(mc.ivBuf, data...)
+				return []byte{}, nil
 			}
-			mc.lastBlock = make([]byte, mc.blockSize)
-			copy(mc.lastBlock, data[:mc.blockSize])
-			return []byte{}, nil
-		}
-		keystream, err := mc.BlockCipherEncrypt(mc.lastBlock)
-		if err != nil {
-			return nil, err
-		}
-		n := len(data)
-		if n < mc.blockSize {
-			keystream = keystream[:n]
-		}
-		plaintext, err := xorBytes(data, keystream)
-		if err != nil {
-			return nil, err
-		}
-		mc.lastBlock = keystream
-		return plaintext, nil
-
-	case ModeCTR:
-		if padding != PaddingNON {
-			return nil, errors.New("CTR mode does not support padding")
-		}
-		if mc.lastBlock == nil {
-			if len(data) < mc.blockSize {
-				return nil, errors.New("CTR Decrypt: ciphertext too short for IV")
+			mc.lastBlock = append(mc.ivBuf, data[:need]...)
+			mc.resetStream()
+			data = data[need:]
+			if len(data) == 0 && !isFinalBlock {
+				return []byte{}, nil
 			}
-			mc.lastBlock = make([]byte, mc.blockSize)
-			copy(mc.lastBlock, data[:mc.blockSize])
-			return []byte{}, nil
 		}
-		counter := make([]byte, mc.blockSize)
-		copy(counter, mc.lastBlock)
-		keystream, err := mc.BlockCipherEncrypt(counter)
-		if err != nil {
-			return nil, err
-		}
-		n := len(data)
-		if n < mc.blockSize {
-			keystream = keystream[:n]
-		}
-		decrypted, err := xorBytes(data, keystream)
-		if err != nil {
-			return nil, err
-		}
-		result = append(result, decrypted...)
-		if isFinalBlock {
-			incMsgCTR(counter)
-		} else {
-			incBlockCTR(counter)
-		}
-		mc.lastBlock = counter
-		return result, nil
+		return mc.streamXOR(data, true, isFinalBlock)
 
+	case ModeGCM, ModeOCB, ModeCTS:
+		return nil, fmt.Errorf("%s: block-wise processing is not supported, use Encrypt/Decrypt", mc.mode)
 	default:
 		return nil, fmt.Errorf("unsupported mode: %s", mc.mode)
 	}
 }
 
+// checkBlockSize проверяет, что режим определён для размера блока установленного шифра
+func (mc *MyCipher) checkBlockSize() error {
+	if (mc.mode == ModeGCM || mc.mode == ModeOCB) && mc.blockSize != AESBlockSize {
+		return fmt.Errorf("%s requires a %d-byte block cipher, got %d", mc.mode, AESBlockSize, mc.blockSize)
+	}
+	return nil
+}
+
 // --- Интерфейс Encrypt/Decrypt для всего сообщения ---
 // Encrypt шифрует всё сообщение. Если iv == nil или пустой и режим требует IV,
 // он генерируется автоматически и прикрепляется в начало результата.
 // Если iv передан, он используется как начальное заполнение (mc.lastBlock).
+// В режимах GCM и OCB iv - это nonce, а результат имеет вид nonce || ciphertext || tag.
+// В режиме CTS шифротекст не дополняется: IV || ciphertext длиннее сообщения ровно на блок.
 func (mc *MyCipher) Encrypt(data []byte, iv []byte) ([]byte, error) {
-	if mc.key == nil {
+	if mc.block == nil {
 		return nil, errors.New("key unsetted")
 	}
+	if err := mc.checkBlockSize(); err != nil {
+		return nil, err
+	}
+	mc.hookIndex = 0
+	if mc.parallelWorkers(len(data)/mc.blockSize) > 1 {
+		dst := make([]byte, mc.EncryptedLen(len(data), iv))
+		n, err := mc.EncryptTo(dst, data, iv)
+		if err != nil {
+			return nil, err
+		}
+		return dst[:n], nil
+	}
+	if (mc.mode == ModeGCM || mc.mode == ModeOCB) && mc.lenPolicy != nil {
+		padded, err := PadToBucket(data, mc.lenPolicy)
+		if err != nil {
+			return nil, err
+		}
+		data = padded
+	}
+	switch mc.mode {
+	case ModeGCM:
+		return mc.gcmSeal(data, iv)
+	case ModeOCB:
+		return mc.ocbSeal(data, iv)
+	case ModeCTS:
+		return mc.ctsEncrypt(data, iv)
+	}
 	var result []byte
 	var padding string
 	if mc.mode == ModeECB || mc.mode == ModeCBC {
@@ -502,46 +647,43 @@ func (mc *MyCipher) Encrypt(data []byte, iv []byte) ([]byte, error) {
 		padding = PaddingNON
 	}
 
+	mc.resetStream()
 	if mc.requiresIV() {
 		if iv != nil && len(iv) == mc.blockSize {
+			if mc.mode == ModeCTR {
+				if err := mc.checkCTRLength(iv, len(data)); err != nil {
+					return nil, err
+				}
+			}
+			if err := mc.useNonce(iv); err != nil {
+				return nil, err
+			}
 			mc.lastBlock = make([]byte, mc.blockSize)
 			copy(mc.lastBlock, iv)
 			result = append(result, iv...)
 		} else {
+			// Для CTR IV - блок nonce || IV || counter, для CBC, CFB, OFB - случайный блок.
+			newIV, err := mc.generateIV()
+			if err != nil {
+				return nil, err
+			}
 			if mc.mode == ModeCTR {
-				if mc.nonce == nil {
-					nonce := make([]byte, NonceSize)
-					if n, err := rand.Read(nonce); err != nil || n != NonceSize {
-						return nil, errors.New("failed to generate nonce")
-					}
-					mc.nonce = nonce
-				}
-				newIV := make([]byte, IVSize)
-				if n, err := rand.Read(newIV); err != nil || n != IVSize {
-					return nil, errors.New("failed to generate IV for CTR")
-				}
-				counterBlock := make([]byte, CounterSize) // zeros
-				ctr := append(append(append([]byte{}, mc.nonce...), newIV...), counterBlock...)
-				if len(ctr) != mc.blockSize {
-					return nil, fmt.Errorf("CTR: invalid ctr length, got %d, expected %d", len(ctr), mc.blockSize)
-				}
-				mc.lastBlock = ctr
-				result = append(result, ctr...)
-			} else {
-				// Для CBC, CFB, OFB: генерируем случайный IV размером blockSize.
-				newIV := make([]byte, mc.blockSize)
-				if n, err := rand.Read(newIV); err != nil || n != mc.blockSize {
-					return nil, errors.New("failed to generate IV")
+				if err := mc.checkCTRLength(newIV, len(data)); err != nil {
+					return nil, err
 				}
-				mc.lastBlock = newIV
-				result = append(result, newIV...)
 			}
+			if err := mc.useNonce(newIV); err != nil {
+				return nil, err
+			}
+			mc.lastBlock = newIV
+			result = append(result, newIV...)
 		}
 	} else {
 		mc.lastBlock = nil
 	}
 
-	for len(data) > mc.blockSize {
+	// При PKCS7 полный последний блок шифруется как обычный, а паддинг уходит в отдельный блок
+	for len(data) > mc.blockSize || (padding == PaddingPKCS7 && len(data) == mc.blockSize) {
 		block := data[:mc.blockSize]
 		encBlock, err := mc.ProcessBlockEncrypt(block, false, padding)
 		if err != nil {
@@ -560,10 +702,38 @@ func (mc *MyCipher) Encrypt(data []byte, iv []byte) ([]byte, error) {
 }
 
 // Decrypt дешифрует всё сообщение. Если iv не передан, то в режиме с IV первый блок считается вектором инициализации.
+// В режимах GCM и OCB сначала проверяется тег; при несовпадении возвращается ErrAuthFailed.
 func (mc *MyCipher) Decrypt(data []byte, iv []byte) ([]byte, error) {
-	if mc.key == nil {
+	if mc.block == nil {
 		return nil, errors.New("key unsetted")
 	}
+	if err := mc.checkBlockSize(); err != nil {
+		return nil, err
+	}
+	mc.hookIndex = 0
+	if mc.mode == ModeCTS {
+		return mc.ctsDecrypt(data, iv)
+	}
+	if mc.parallelWorkers(len(data)/mc.blockSize) > 1 {
+		dst := make([]byte, len(data))
+		n, err := mc.DecryptTo(dst, data, iv)
+		if err != nil {
+			return nil, err
+		}
+		return dst[:n], nil
+	}
+	if mc.mode == ModeGCM || mc.mode == ModeOCB {
+		open := mc.gcmOpen
+		if mc.mode == ModeOCB {
+			open = mc.ocbOpen
+		}
+		pt, err := open(data, iv)
+		if err != nil || mc.lenPolicy == nil {
+			return pt, err
+		}
+		return UnpadBucket(pt)
+	}
+	mc.resetStream()
 	if mc.requiresIV() {
 		if iv != nil && len(iv) == mc.blockSize {
 			mc.lastBlock = make([]byte, mc.blockSize)
@@ -576,6 +746,11 @@ func (mc *MyCipher) Decrypt(data []byte, iv []byte) ([]byte, error) {
 			copy(mc.lastBlock, data[:mc.blockSize])
 			data = data[mc.blockSize:]
 		}
+		if mc.mode == ModeCTR {
+			if err := mc.checkCTRLength(mc.lastBlock, len(data)); err != nil {
+				return nil, err
+			}
+		}
 	} else {
 		mc.lastBlock = nil
 	}
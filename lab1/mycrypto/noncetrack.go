@@ -0,0 +1,116 @@
+package mycrypto
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ----- Контроль повторного использования IV и nonce -----
+
+// ErrNonceReuse возвращается при шифровании, если IV или nonce уже использовался с текущим ключом
+var ErrNonceReuse = errors.New("nonce reuse")
+
+// NonceTracker запоминает последние capacity значений IV/nonce (вытесняется давно использованное).
+// Если Warn задан, повтор не прерывает шифрование: вместо ErrNonceReuse вызывается Warn.
+// Методы безопасны для одновременного вызова, так что трекер можно разделять между сессиями.
+type NonceTracker struct {
+	Warn func(mode string, nonce []byte)
+
+	mu       sync.Mutex
+	capacity int
+	order    *list.List               // от недавних к давним
+	seen     map[string]*list.Element // значение -> элемент order
+}
+
+// NewNonceTracker создаёт трекер на capacity последних значений
+func NewNonceTracker(capacity int) (*NonceTracker, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("NewNonceTracker: capacity must be positive, got %d", capacity)
+	}
+	return &NonceTracker{
+		capacity: capacity,
+		order:    list.New(),
+		seen:     make(map[string]*list.Element, capacity),
+	}, nil
+}
+
+// Seen сообщает, встречалось ли значение среди запомненных
+func (t *NonceTracker) Seen(nonce []byte) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.seen[string(nonce)]
+	return ok
+}
+
+// Add запоминает значение; при переполнении забывается самое давнее
+func (t *NonceTracker) Add(nonce []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(nonce)
+}
+
+// use атомарно проверяет и запоминает значение; возвращает true, если оно уже встречалось
+func (t *NonceTracker) use(nonce []byte) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, seen := t.seen[string(nonce)]
+	t.add(nonce)
+	return seen
+}
+
+func (t *NonceTracker) add(nonce []byte) {
+	if e, ok := t.seen[string(nonce)]; ok {
+		t.order.MoveToFront(e)
+		return
+	}
+	t.seen[string(nonce)] = t.order.PushFront(string(nonce))
+	if t.order.Len() > t.capacity {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.seen, oldest.Value.(string))
+	}
+}
+
+// Len возвращает число запомненных значений
+func (t *NonceTracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.order.Len()
+}
+
+// Reset забывает все значения
+func (t *NonceTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.order.Init()
+	clear(t.seen)
+}
+
+// SetNonceTracker включает контроль повторов IV/nonce при шифровании (nil - выключает).
+// Трекер очищается при смене ключа. ECB не использует IV и не контролируется.
+func (mc *MyCipher) SetNonceTracker(t *NonceTracker) {
+	mc.nonces = t
+}
+
+// useNonce проверяет и запоминает начальное заполнение очередного сообщения.
+// Для CTR сравнивается только nonce || IV: сообщения с одним префиксом и разными
+// начальными счётчиками всё равно перекрываются по гамме.
+func (mc *MyCipher) useNonce(iv []byte) error {
+	if mc.nonces == nil {
+		return nil
+	}
+	if mc.mode == ModeCTR {
+		_, ctrStart := ctrFields(mc.blockSize)
+		iv = iv[:min(ctrStart, len(iv))]
+	}
+	if mc.nonces.use(iv) {
+		if mc.nonces.Warn == nil {
+			return fmt.Errorf("%s: %w", mc.mode, ErrNonceReuse)
+		}
+		mc.nonces.Warn(mc.mode, bytes.Clone(iv))
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package mycrypto
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// ----- Режим OCB3 (RFC 7253) -----
+
+// Параметры OCB3: nonce по умолчанию 12 байт (допускается от 1 до 15), тег 128 бит
+const (
+	OCBNonceSize    = 12
+	OCBMaxNonceSize = 15
+	OCBTagSize      = 16
+)
+
+// ocbState - таблица L_*, L_$, L_0, L_1, ..., вычисляемая один раз на сообщение
+type ocbState struct {
+	mc     *MyCipher
+	lStar  []byte
+	lDolar []byte
+	l      [][]byte
+	blocks [][3][]byte // смещение, вход и выход блоков для BlockHook
+}
+
+// newOCBState вычисляет L_* = E(0), L_$ = double(L_*), L_0 = double(L_$)
+func (mc *MyCipher) newOCBState() *ocbState {
+	lStar := make([]byte, AESBlockSize)
+	mc.block.Encrypt(lStar, make([]byte, AESBlockSize))
+	lDolar := GFDouble(lStar, BitOrderNatural)
+	return &ocbState{mc: mc, lStar: lStar, lDolar: lDolar, l: [][]byte{GFDouble(lDolar, BitOrderNatural)}}
+}
+
+// lAt возвращает L_i, при необходимости достраивая таблицу удвоениями
+func (s *ocbState) lAt(i int) []byte {
+	for len(s.l) <= i {
+		s.l = append(s.l, GFDouble(s.l[len(s.l)-1], BitOrderNatural))
+	}
+	return s.l[i]
+}
+
+// offset0 вычисляет начальное смещение из nonce через Ktop и Stretch
+func (s *ocbState) offset0(nonce []byte) []byte {
+	block := make([]byte, AESBlockSize)
+	block[0] = byte(OCBTagSize*8%128) << 1
+	block[AESBlockSize-1-len(nonce)] |= 1
+	copy(block[AESBlockSize-len(nonce):], nonce)
+	bottom := int(block[AESBlockSize-1] & 0x3f)
+	block[AESBlockSize-1] &= 0xc0
+	ktop := make([]byte, AESBlockSize)
+	s.mc.block.Encrypt(ktop, block)
+	// Stretch = Ktop || (Ktop[1..64] xor Ktop[9..72])
+	stretch := make([]byte, AESBlockSize+8)
+	copy(stretch, ktop)
+	for i := 0; i < 8; i++ {
+		stretch[AESBlockSize+i] = ktop[i] ^ ktop[i+1]
+	}
+	// Offset_0 = Stretch[1+bottom..128+bottom]
+	off := make([]byte, AESBlockSize)
+	byteShift, bitShift := bottom/8, uint(bottom%8)
+	for i := range off {
+		off[i] = stretch[i+byteShift] << bitShift
+		if bitShift != 0 {
+			off[i] |= stretch[i+byteShift+1] >> (8 - bitShift)
+		}
+	}
+	return off
+}
+
+// hash вычисляет HASH(K, A) для дополнительных данных
+func (s *ocbState) hash(aad []byte) []byte {
+	sum := make([]byte, AESBlockSize)
+	off := make([]byte, AESBlockSize)
+	buf := make([]byte, AESBlockSize)
+	i := 1
+	for ; len(aad) >= AESBlockSize; i++ {
+		XORInto(off, s.lAt(bits.TrailingZeros(uint(i))))
+		copy(buf, aad[:AESBlockSize])
+		XORInto(buf, off)
+		s.mc.block.Encrypt(buf, buf)
+		XORInto(sum, buf)
+		aad = aad[AESBlockSize:]
+	}
+	if len(aad) > 0 {
+		XORInto(off, s.lStar)
+		for j := range buf {
+			buf[j] = 0
+		}
+		copy(buf, aad)
+		buf[len(aad)] = 0x80
+		XORInto(buf, off)
+		s.mc.block.Encrypt(buf, buf)
+		XORInto(sum, buf)
+	}
+	return sum
+}
+
+// trace запоминает блок для BlockHook: события отправляются только после проверки тега
+func (s *ocbState) trace(off, in, out []byte) {
+	if s.mc.hook != nil {
+		s.blocks = append(s.blocks, [3][]byte{append([]byte{}, off...), in, out})
+	}
+}
+
+// emit отправляет запомненные блоки обработчику MyCipher
+func (s *ocbState) emit(decrypt bool) {
+	for _, b := range s.blocks {
+		s.mc.emit(decrypt, b[0], b[1], b[2])
+	}
+}
+
+// crypt шифрует или расшифровывает данные и возвращает результат и тег
+func (s *ocbState) crypt(nonce, data []byte, decrypt bool) ([]byte, []byte) {
+	out := make([]byte, len(data))
+	off := s.offset0(nonce)
+	checksum := make([]byte, AESBlockSize)
+	buf := make([]byte, AESBlockSize)
+	n := 0
+	for i := 1; len(data)-n >= AESBlockSize; i++ {
+		XORInto(off, s.lAt(bits.TrailingZeros(uint(i))))
+		copy(buf, data[n:n+AESBlockSize])
+		XORInto(buf, off)
+		if decrypt {
+			s.mc.block.Decrypt(buf, buf)
+		} else {
+			XORInto(checksum, data[n:n+AESBlockSize])
+			s.mc.block.Encrypt(buf, buf)
+		}
+		XORInto(buf, off)
+		copy(out[n:], buf)
+		s.trace(off, data[n:n+AESBlockSize], out[n:n+AESBlockSize])
+		if decrypt {
+			XORInto(checksum, buf)
+		}
+		n += AESBlockSize
+	}
+	if rest := len(data) - n; rest > 0 {
+		XORInto(off, s.lStar)
+		pad := make([]byte, AESBlockSize)
+		s.mc.block.Encrypt(pad, off)
+		XORTo(out[n:], data[n:], pad)
+		s.trace(off, data[n:], out[n:])
+		plain := out[n:]
+		if !decrypt {
+			plain = data[n:]
+		}
+		XORInto(checksum, plain)
+		checksum[rest] ^= 0x80
+	}
+	// Tag = E(Checksum xor Offset xor L_$) xor HASH(K, A)
+	XORInto(checksum, off)
+	XORInto(checksum, s.lDolar)
+	tag := make([]byte, AESBlockSize)
+	s.mc.block.Encrypt(tag, checksum)
+	XORInto(tag, s.hash(s.mc.aad))
+	return out, tag[:OCBTagSize]
+}
+
+// ocbSeal шифрует data и возвращает nonce || ciphertext || tag.
+// Если nonce не задан, генерируется случайный nonce длиной OCBNonceSize.
+func (mc *MyCipher) ocbSeal(data, nonce []byte) ([]byte, error) {
+	if len(nonce) == 0 {
+		nonce = make([]byte, OCBNonceSize)
+		if n, err := Rand.Read(nonce); err != nil || n != OCBNonceSize {
+			return nil, errors.New("failed to generate nonce")
+		}
+	}
+	if len(nonce) > OCBMaxNonceSize {
+		return nil, fmt.Errorf("OCB: nonce must be at most %d bytes", OCBMaxNonceSize)
+	}
+	if err := mc.useNonce(nonce); err != nil {
+		return nil, err
+	}
+	s := mc.newOCBState()
+	ct, tag := s.crypt(nonce, data, false)
+	s.emit(false)
+	result := make([]byte, 0, len(nonce)+len(ct)+OCBTagSize)
+	result = append(result, nonce...)
+	result = append(result, ct...)
+	return append(result, tag...), nil
+}
+
+// ocbOpen проверяет тег и расшифровывает nonce || ciphertext || tag
+// (или ciphertext || tag, если nonce передан отдельно)
+func (mc *MyCipher) ocbOpen(data, nonce []byte) ([]byte, error) {
+	if len(nonce) == 0 {
+		if len(data) < OCBNonceSize {
+			return nil, errors.New("data too short to contain nonce")
+		}
+		nonce, data = data[:OCBNonceSize], data[OCBNonceSize:]
+	}
+	if len(nonce) > OCBMaxNonceSize {
+		return nil, fmt.Errorf("OCB: nonce must be at most %d bytes", OCBMaxNonceSize)
+	}
+	if len(data) < OCBTagSize {
+		return nil, fmt.Errorf("OCB: ciphertext too short to contain %d-byte tag", OCBTagSize)
+	}
+	ct, tag := data[:len(data)-OCBTagSize], data[len(data)-OCBTagSize:]
+	s := mc.newOCBState()
+	pt, expected := s.crypt(nonce, ct, true)
+	if subtle.ConstantTimeCompare(expected, tag) != 1 {
+		for i := range pt {
+			pt[i] = 0
+		}
+		return nil, ErrAuthFailed
+	}
+	s.emit(true)
+	return pt, nil
+}
@@ -0,0 +1,79 @@
+package mycrypto
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ----- Параллельное шифрование CTR и ECB -----
+
+// parallelMinBlocks - наименьшее число блоков на горутину: на коротких сообщениях
+// запуск горутин обходится дороже, чем выигрыш от параллелизма
+const parallelMinBlocks = 256
+
+// SetParallelism задаёт число горутин, между которыми делятся блоки сообщения в режимах CTR и ECB
+// (0 или 1 - последовательная обработка). Каждая горутина обрабатывает непрерывный диапазон блоков,
+// счётчик CTR для начала диапазона вычисляется сразу. Блочный шифр должен допускать
+// одновременные вызовы Encrypt/Decrypt (crypto/aes, myaes, mycamellia, mygost это допускают).
+// С BlockHook и в потоковом интерфейсе ProcessBlock* блоки обрабатываются последовательно.
+func (mc *MyCipher) SetParallelism(n int) error {
+	if n < 0 {
+		return fmt.Errorf("SetParallelism: negative number of workers %d", n)
+	}
+	mc.workers = n
+	return nil
+}
+
+// parallelWorkers возвращает число горутин для сообщения из blocks блоков
+func (mc *MyCipher) parallelWorkers(blocks int) int {
+	if mc.workers < 2 || mc.hook != nil || (mc.mode != ModeCTR && mc.mode != ModeECB) {
+		return 1
+	}
+	return max(1, min(mc.workers, blocks/parallelMinBlocks))
+}
+
+// forRanges делит blocks блоков на w непрерывных диапазонов и обрабатывает их в отдельных горутинах
+func forRanges(blocks, w int, f func(lo, hi int)) {
+	var wg sync.WaitGroup
+	for k := 0; k < w; k++ {
+		lo, hi := blocks*k/w, blocks*(k+1)/w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f(lo, hi)
+		}()
+	}
+	wg.Wait()
+}
+
+// ctrParallel накладывает на src гамму CTR с начальным блоком reg; reg не изменяется.
+// Диапазон с блока lo начинается со счётчика reg + lo.
+func (mc *MyCipher) ctrParallel(dst, src, reg []byte, w int) {
+	bs := mc.blockSize
+	_, ctrStart := ctrFields(bs)
+	forRanges((len(src)+bs-1)/bs, w, func(lo, hi int) {
+		cb := make([]byte, bs)
+		ks := make([]byte, bs)
+		copy(cb, reg)
+		addField(cb[ctrStart:], uint64(lo), mc.ctrEndian)
+		for j := lo; j < hi; j++ {
+			mc.block.Encrypt(ks, cb)
+			XORTo(dst[j*bs:], src[j*bs:min((j+1)*bs, len(src))], ks)
+			incField(cb[ctrStart:], mc.ctrEndian)
+		}
+	})
+}
+
+// ecbParallel шифрует или расшифровывает src из целых блоков в режиме ECB
+func (mc *MyCipher) ecbParallel(dst, src []byte, w int, decrypt bool) {
+	bs := mc.blockSize
+	f := mc.block.Encrypt
+	if decrypt {
+		f = mc.block.Decrypt
+	}
+	forRanges(len(src)/bs, w, func(lo, hi int) {
+		for j := lo; j < hi; j++ {
+			f(dst[j*bs:(j+1)*bs], src[j*bs:(j+1)*bs])
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package mycrypto
+
+import (
+	"bytes"
+	"sync"
+)
+
+// ----- Пул настроенных MyCipher -----
+
+type cipherPoolKey struct {
+	mode string
+	key  string
+}
+
+// CipherPool хранит готовые MyCipher по паре (режим, ключ), чтобы сервер, шифрующий много коротких
+// сообщений, не пересчитывал расписание ключа AES на каждое сообщение. Нулевое значение готово к работе,
+// методы безопасны для одновременного вызова. Экземпляры хранятся в sync.Pool и освобождаются сборщиком
+// мусора, а записи о парах (режим, ключ) остаются, поэтому пул рассчитан на ограниченный набор ключей.
+type CipherPool struct {
+	mu    sync.Mutex
+	pools map[cipherPoolKey]*sync.Pool
+}
+
+// pool возвращает sync.Pool для пары (mode, key); если его нет, создаёт при create, иначе возвращает nil
+func (p *CipherPool) pool(mode string, key []byte, create bool) *sync.Pool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k := cipherPoolKey{mode: mode, key: string(key)}
+	sp, ok := p.pools[k]
+	if !ok && create {
+		if p.pools == nil {
+			p.pools = make(map[cipherPoolKey]*sync.Pool)
+		}
+		sp = &sync.Pool{}
+		p.pools[k] = sp
+	}
+	return sp
+}
+
+// Get возвращает MyCipher с ключом key (AES) в режиме mode: из пула или новый.
+// Экземпляр принадлежит вызывающему, пока он не вернёт его через Put.
+func (p *CipherPool) Get(mode string, key []byte) (*MyCipher, error) {
+	if sp := p.pool(mode, key, false); sp != nil {
+		if mc, ok := sp.Get().(*MyCipher); ok {
+			return mc, nil
+		}
+	}
+	mc := &MyCipher{}
+	if err := mc.SetKey(bytes.Clone(key)); err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(mode); err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
+
+// Put сбрасывает состояние сообщения и настройки экземпляра (AAD, обработчик блоков, трекер nonce,
+// политику длины, сегмент CFB, порядок байтов счётчика, параллелизм) и возвращает его в пул
+// по текущим режиму и ключу. Экземпляры с блочным шифром из SetBlockCipher не пулируются.
+func (p *CipherPool) Put(mc *MyCipher) {
+	if mc == nil || mc.key == nil || mc.mode == "" {
+		return
+	}
+	mc.Reset()
+	mc.aad = nil
+	mc.hook = nil
+	mc.nonces = nil
+	mc.lenPolicy = nil
+	mc.cfbSegment = 0
+	mc.ctrEndian = EndianBig
+	mc.workers = 0
+	p.pool(mc.mode, mc.key, true).Put(mc)
+}
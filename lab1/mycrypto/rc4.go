@@ -0,0 +1,40 @@
+package mycrypto
+
+import "fmt"
+
+// ----- Поточный шифр RC4 (только для демонстрации атак: гамма заметно смещена) -----
+
+// RC4 - состояние RC4: перестановка S и индексы i, j
+type RC4 struct {
+	s    [256]byte
+	i, j byte
+}
+
+// NewRC4 выполняет расписание ключа (KSA) для ключа длиной от 1 до 256 байт
+func NewRC4(key []byte) (*RC4, error) {
+	if len(key) < 1 || len(key) > 256 {
+		return nil, fmt.Errorf("RC4: invalid key length %d, expected 1..256", len(key))
+	}
+	c := &RC4{}
+	for i := range c.s {
+		c.s[i] = byte(i)
+	}
+	var j byte
+	for i := 0; i < 256; i++ {
+		j += c.s[i] + key[i%len(key)]
+		c.s[i], c.s[j] = c.s[j], c.s[i]
+	}
+	return c, nil
+}
+
+// XORKeyStream складывает src с очередными байтами гаммы (PRGA) и записывает результат в dst
+func (c *RC4) XORKeyStream(dst, src []byte) {
+	i, j := c.i, c.j
+	for k, b := range src {
+		i++
+		j += c.s[i]
+		c.s[i], c.s[j] = c.s[j], c.s[i]
+		dst[k] = b ^ c.s[c.s[i]+c.s[j]]
+	}
+	c.i, c.j = i, j
+}
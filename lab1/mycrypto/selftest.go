@@ -0,0 +1,215 @@
+package mycrypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ----- Самопроверка по векторам NIST SP 800-38A (приложение F) -----
+
+// Ключи и сообщение приложения F; IV общий для CBC, CFB и OFB, для CTR - начальный блок счётчика
+const (
+	katKey128 = "2b7e151628aed2a6abf7158809cf4f3c"
+	katKey192 = "8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b"
+	katKey256 = "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4"
+	katIV     = "000102030405060708090a0b0c0d0e0f"
+	katCTR    = "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff"
+	katPlain  = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51" +
+		"30c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+)
+
+// katVector - пример приложения F. Section - номер примера шифрования (F.x.y),
+// пример расшифрования на тех же данных имеет номер F.x.(y+1). CFB1 шифрует 16 бит, CFB8 - 18 байт.
+type katVector struct {
+	Section string
+	Mode    string
+	Segment int
+	Key     string
+	CT      string
+}
+
+var katVectors = []katVector{
+	{"F.1.1", ModeECB, 0, katKey128, "3ad77bb40d7a3660a89ecaf32466ef97f5d3d58503b9699de785895a96fdbaaf43b1cd7f598ece23881b00e3ed0306887b0c785e27e8ad3f8223207104725dd4"},
+	{"F.1.3", ModeECB, 0, katKey192, "bd334f1d6e45f25ff712a214571fa5cc974104846d0ad3ad7734ecb3ecee4eefef7afd2270e2e60adce0ba2face6444e9a4b41ba738d6c72fb16691603c18e0e"},
+	{"F.1.5", ModeECB, 0, katKey256, "f3eed1bdb5d2a03c064b5a7e3db181f8591ccb10d410ed26dc5ba74a31362870b6ed21b99ca6f4f9f153e7b1beafed1d23304b7a39f9f3ff067d8d8f9e24ecc7"},
+	{"F.2.1", ModeCBC, 0, katKey128, "7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b273bed6b8e3c1743b7116e69e222295163ff1caa1681fac09120eca307586e1a7"},
+	{"F.2.3", ModeCBC, 0, katKey192, "4f021db243bc633d7178183a9fa071e8b4d9ada9ad7dedf4e5e738763f69145a571b242012fb7ae07fa9baac3df102e008b0e27988598881d920a9e64f5615cd"},
+	{"F.2.5", ModeCBC, 0, katKey256, "f58c4c04d6e5f1ba779eabfb5f7bfbd69cfc4e967edb808d679f777bc6702c7d39f23369a9d9bacfa530e26304231461b2eb05e2c39be9fcda6c19078c6a9d1b"},
+	{"F.3.1", ModeCFB, 1, katKey128, "68b3"},
+	{"F.3.3", ModeCFB, 1, katKey192, "9359"},
+	{"F.3.5", ModeCFB, 1, katKey256, "9029"},
+	{"F.3.7", ModeCFB, 8, katKey128, "3b79424c9c0dd436bace9e0ed4586a4f32b9"},
+	{"F.3.9", ModeCFB, 8, katKey192, "cda2521ef0a905ca44cd057cbf0d47a0678a"},
+	{"F.3.11", ModeCFB, 8, katKey256, "dc1f1a8520a64db55fcc8ac554844e889700"},
+	{"F.3.13", ModeCFB, 128, katKey128, "3b3fd92eb72dad20333449f8e83cfb4ac8a64537a0b3a93fcde3cdad9f1ce58b26751f67a3cbb140b1808cf187a4f4dfc04b05357c5d1c0eeac4c66f9ff7f2e6"},
+	{"F.3.15", ModeCFB, 128, katKey192, "cdc80d6fddf18cab34c25909c99a417467ce7f7f81173621961a2b70171d3d7a2e1e8a1dd59b88b1c8e60fed1efac4c9c05f9f9ca9834fa042ae8fba584b09ff"},
+	{"F.3.17", ModeCFB, 128, katKey256, "dc7e84bfda79164b7ecd8486985d386039ffed143b28b1c832113c6331e5407bdf10132415e54b92a13ed0a8267ae2f975a385741ab9cef82031623d55b1e471"},
+	{"F.4.1", ModeOFB, 0, katKey128, "3b3fd92eb72dad20333449f8e83cfb4a7789508d16918f03f53c52dac54ed8259740051e9c5fecf64344f7a82260edcc304c6528f659c77866a510d9c1d6ae5e"},
+	{"F.4.3", ModeOFB, 0, katKey192, "cdc80d6fddf18cab34c25909c99a4174fcc28b8d4c63837c09e81700c11004018d9a9aeac0f6596f559c6d4daf59a5f26d9f200857ca6c3e9cac524bd9acc92a"},
+	{"F.4.5", ModeOFB, 0, katKey256, "dc7e84bfda79164b7ecd8486985d38604febdc6740d20b3ac88f6ad82a4fb08d71ab47a086e86eedf39d1c5bba97c4080126141d67f37be8538f5a8be740e484"},
+	{"F.5.1", ModeCTR, 0, katKey128, "874d6191b620e3261bef6864990db6ce9806f66b7970fdff8617187bb9fffdff5ae4df3edbd5d35e5b4f09020db03eab1e031dda2fbe03d1792170a0f3009cee"},
+	{"F.5.3", ModeCTR, 0, katKey192, "1abc932417521ca24f2b0459fe7e6e0b090339ec0aa6faefd5ccc2c6f4ce8e941e36b26bd1ebc670d1bd1d665620abf74f78a7f6d29809585a97daec58c6b050"},
+	{"F.5.5", ModeCTR, 0, katKey256, "601ec313775789a5b7a7f504bbf3d228f443e3ca4d62b59aca84e990cacaf5c52b0930daa23de94ce87017ba2d84988ddfc9c58db67aada613c2dd08457941a6"},
+}
+
+// SelfTestResult - результат одного примера приложения F
+type SelfTestResult struct {
+	Section string // номер примера, например "F.2.1" (шифрование) или "F.2.2" (расшифрование)
+	Mode    string
+	Segment int // размер сегмента CFB в битах, 0 для остальных режимов
+	KeyBits int
+	Decrypt bool
+	Err     error // nil - пример пройден
+}
+
+// Passed сообщает, пройден ли пример
+func (r SelfTestResult) Passed() bool {
+	return r.Err == nil
+}
+
+// String возвращает строку вида "F.2.1 CBC-AES128.Encrypt: ok"
+func (r SelfTestResult) String() string {
+	mode := r.Mode
+	if r.Segment != 0 {
+		mode = fmt.Sprintf("%s%d", r.Mode, r.Segment)
+	}
+	dir := "Encrypt"
+	if r.Decrypt {
+		dir = "Decrypt"
+	}
+	status := "ok"
+	if r.Err != nil {
+		status = "FAIL: " + r.Err.Error()
+	}
+	return fmt.Sprintf("%s %s-AES%d.%s: %s", r.Section, mode, r.KeyBits, dir, status)
+}
+
+// katCipher создаёт MyCipher для примера
+func katCipher(v katVector, key []byte) (*MyCipher, error) {
+	mc := &MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(v.Mode); err != nil {
+		return nil, err
+	}
+	if v.Segment != 0 {
+		if err := mc.SetSegmentSize(v.Segment); err != nil {
+			return nil, err
+		}
+	}
+	return mc, nil
+}
+
+// katEncrypt шифрует сообщение примера через Encrypt. В приложении F нет паддинга, поэтому
+// у ECB и CBC сравниваются блоки до дополнительного блока PKCS7.
+func katEncrypt(v katVector, key, iv, pt, want []byte) error {
+	mc, err := katCipher(v, key)
+	if err != nil {
+		return err
+	}
+	out, err := mc.Encrypt(pt, iv)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(out[:len(iv)], iv) {
+		return fmt.Errorf("IV is not placed at the start of the ciphertext")
+	}
+	out = out[len(iv):]
+	if v.Mode == ModeECB || v.Mode == ModeCBC {
+		if len(out) != len(want)+AESBlockSize {
+			return fmt.Errorf("got %d bytes, want %d and a padding block", len(out), len(want))
+		}
+		out = out[:len(want)]
+	}
+	if !bytes.Equal(out, want) {
+		return fmt.Errorf("got %x, want %x", out, want)
+	}
+	return nil
+}
+
+// katDecrypt расшифровывает шифротекст примера: ECB и CBC поблочно через ProcessBlockDecrypt
+// без паддинга (IV передаётся первым блоком), остальные режимы - через Decrypt
+func katDecrypt(v katVector, key, iv, ct, want []byte) error {
+	mc, err := katCipher(v, key)
+	if err != nil {
+		return err
+	}
+	var out []byte
+	if v.Mode == ModeECB || v.Mode == ModeCBC {
+		if iv != nil {
+			if _, err := mc.ProcessBlockDecrypt(iv, false, PaddingNON); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < len(ct); i += AESBlockSize {
+			blk, err := mc.ProcessBlockDecrypt(ct[i:i+AESBlockSize], i+AESBlockSize == len(ct), PaddingNON)
+			if err != nil {
+				return err
+			}
+			out = append(out, blk...)
+		}
+	} else if out, err = mc.Decrypt(ct, iv); err != nil {
+		return err
+	}
+	if !bytes.Equal(out, want) {
+		return fmt.Errorf("got %x, want %x", out, want)
+	}
+	return nil
+}
+
+// decryptSection возвращает номер примера расшифрования: F.x.y -> F.x.(y+1)
+func decryptSection(section string) string {
+	i := strings.LastIndexByte(section, '.')
+	n, _ := strconv.Atoi(section[i+1:])
+	return section[:i+1] + strconv.Itoa(n+1)
+}
+
+// SelfTest прогоняет MyCipher (AES) по всем примерам NIST SP 800-38A для ECB, CBC, CFB1, CFB8,
+// CFB128, OFB и CTR с ключами 128, 192 и 256 бит, в обе стороны. Каждый пример выполняется
+// на новом MyCipher, поэтому вызов не зависит от состояния других объектов; его можно сделать
+// при запуске программы (см. SelfTestError) или по запросу.
+func SelfTest() []SelfTestResult {
+	pt, _ := hex.DecodeString(katPlain)
+	var results []SelfTestResult
+	for _, v := range katVectors {
+		key, _ := hex.DecodeString(v.Key)
+		ct, _ := hex.DecodeString(v.CT)
+		var iv []byte
+		switch v.Mode {
+		case ModeCTR:
+			iv, _ = hex.DecodeString(katCTR)
+		case ModeCBC, ModeCFB, ModeOFB:
+			iv, _ = hex.DecodeString(katIV)
+		}
+		msg := pt[:len(ct)]
+		enc := SelfTestResult{Section: v.Section, Mode: v.Mode, Segment: v.Segment, KeyBits: 8 * len(key)}
+		enc.Err = katEncrypt(v, key, iv, msg, ct)
+		dec := enc
+		dec.Section, dec.Decrypt = decryptSection(v.Section), true
+		dec.Err = katDecrypt(v, key, iv, ct, msg)
+		results = append(results, enc, dec)
+	}
+	return results
+}
+
+// SelfTestError возвращает ошибку с первым непройденным примером или nil, если пройдены все
+func SelfTestError(results []SelfTestResult) error {
+	failed := 0
+	var first SelfTestResult
+	for _, r := range results {
+		if !r.Passed() {
+			if failed == 0 {
+				first = r
+			}
+			failed++
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("SelfTest: %d of %d known-answer tests failed, first: %s", failed, len(results), first)
+}
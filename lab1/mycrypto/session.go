@@ -0,0 +1,16 @@
+package mycrypto
+
+// ----- Одновременное шифрование разных сообщений под одним ключом -----
+
+// NewSession возвращает независимый MyCipher для одного сообщения: копию ключа и настроек mc
+// без состояния незаконченного сообщения. MyCipher хранит IV и позицию в гамме в самом объекте,
+// поэтому один экземпляр нельзя использовать из нескольких горутин; вместо этого mc настраивается
+// один раз и служит шаблоном, а каждая горутина шифрует или расшифровывает своё сообщение в сессии.
+// NewSession можно вызывать одновременно, пока сам шаблон не перенастраивают и не используют для
+// шифрования. С шаблоном сессии разделяют блочный шифр (он должен допускать одновременные вызовы,
+// как crypto/aes и шифры lab1), трекер nonce (потокобезопасен) и обработчик блоков.
+func (mc *MyCipher) NewSession() *MyCipher {
+	s := mc.Clone()
+	s.Reset()
+	return s
+}
@@ -0,0 +1,145 @@
+package mycrypto
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ----- Шифрование потоков через io.Writer и io.Reader -----
+
+// streamChunk - размер куска, который читает decryptReader из источника
+const streamChunk = 32 * 1024
+
+// ErrClosedWriter возвращается при записи в закрытый шифрующий поток
+var ErrClosedWriter = errors.New("write to closed encrypt writer")
+
+// checkStreamMode проверяет, что режим поддерживает поблочную обработку без накопления сообщения
+func (mc *MyCipher) checkStreamMode() error {
+	if mc.block == nil {
+		return errors.New("key unsetted")
+	}
+	switch mc.mode {
+	case ModeECB, ModeCBC, ModeCFB, ModeOFB, ModeCTR:
+		return nil
+	default:
+		return fmt.Errorf("%s: streaming is not supported, use Encrypt/Decrypt", mc.mode)
+	}
+}
+
+type encryptWriter struct {
+	cs     *ChunkStream
+	w      io.Writer
+	closed bool
+	err    error
+}
+
+// NewEncryptWriter возвращает поток, шифрующий всё записанное в w в формате Encrypt:
+// IV генерируется и пишется первым, а паддинг PKCS7 (ECB, CBC) добавляется при Close.
+// Close не закрывает w. Пока поток открыт, mc нельзя использовать для других сообщений.
+func (mc *MyCipher) NewEncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	if err := mc.checkStreamMode(); err != nil {
+		return nil, err
+	}
+	cs, err := mc.NewEncryptStream()
+	if err != nil {
+		return nil, err
+	}
+	return &encryptWriter{cs: cs, w: w}, nil
+}
+
+func (ew *encryptWriter) Write(p []byte) (int, error) {
+	if ew.closed {
+		return 0, ErrClosedWriter
+	}
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	out, err := ew.cs.Update(p)
+	if err == nil {
+		_, err = ew.w.Write(out)
+	}
+	if err != nil {
+		ew.err = err
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close шифрует остаток с паддингом; для потоковых режимов без данных выдаёт только IV
+func (ew *encryptWriter) Close() error {
+	if ew.closed {
+		return ew.err
+	}
+	ew.closed = true
+	if ew.err != nil {
+		return ew.err
+	}
+	out, err := ew.cs.Finalize()
+	if err == nil {
+		_, err = ew.w.Write(out)
+	}
+	ew.err = err
+	return err
+}
+
+type decryptReader struct {
+	cs    *ChunkStream
+	r     io.Reader
+	chunk []byte
+	out   []byte // расшифрованные, но ещё не отданные байты
+	eof   bool
+	err   error
+}
+
+// NewDecryptReader возвращает поток, расшифровывающий данные из r, записанные NewEncryptWriter
+// или Encrypt: IV читается из начала потока, паддинг снимается и проверяется в конце.
+func (mc *MyCipher) NewDecryptReader(r io.Reader) (io.Reader, error) {
+	if err := mc.checkStreamMode(); err != nil {
+		return nil, err
+	}
+	cs, err := mc.NewDecryptStream()
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{cs: cs, r: r, chunk: make([]byte, streamChunk)}, nil
+}
+
+// fill читает следующий кусок шифротекста и расшифровывает всё, что уже можно отдать
+func (dr *decryptReader) fill() error {
+	n, err := dr.r.Read(dr.chunk)
+	if err == io.EOF {
+		dr.eof = true
+	} else if err != nil {
+		return err
+	}
+	out, err := dr.cs.Update(dr.chunk[:n])
+	if err != nil {
+		return err
+	}
+	dr.out = append(dr.out, out...)
+	if !dr.eof {
+		return nil
+	}
+	out, err = dr.cs.Finalize()
+	if err != nil {
+		return err
+	}
+	dr.out = append(dr.out, out...)
+	return nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.out) == 0 && dr.err == nil && !dr.eof {
+		dr.err = dr.fill()
+	}
+	if len(dr.out) > 0 {
+		n := copy(p, dr.out)
+		dr.out = dr.out[n:]
+		return n, nil
+	}
+	if dr.err != nil {
+		return 0, dr.err
+	}
+	return 0, io.EOF
+}
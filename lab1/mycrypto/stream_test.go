@@ -0,0 +1,105 @@
+package mycrypto
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// randomChunks режет data на куски случайной длины от 0 до maxLen в случайном порядке
+func randomChunks(r *rand.Rand, data []byte, maxLen int) [][]byte {
+	var chunks [][]byte
+	for len(data) > 0 {
+		n := min(r.IntN(maxLen+1), len(data))
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	return chunks
+}
+
+// streamCipher создаёт MyCipher режима mode с сегментом CFB segment бит (0 - по умолчанию)
+func streamCipher(t *testing.T, key []byte, mode string, segment int) *MyCipher {
+	t.Helper()
+	mc := &MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := mc.SetMode(mode); err != nil {
+		t.Fatal(err)
+	}
+	if segment != 0 {
+		if err := mc.SetSegmentSize(segment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return mc
+}
+
+// processChunks пропускает куски через ProcessBlockEncrypt или ProcessBlockDecrypt;
+// последний вызов - с isFinalBlock (пустой, если кусков нет)
+func processChunks(t *testing.T, mc *MyCipher, chunks [][]byte, decrypt bool) []byte {
+	t.Helper()
+	if len(chunks) == 0 {
+		chunks = [][]byte{{}}
+	}
+	var out []byte
+	for i, c := range chunks {
+		final := i == len(chunks)-1
+		var part []byte
+		var err error
+		if decrypt {
+			part, err = mc.ProcessBlockDecrypt(c, final, PaddingNON)
+		} else {
+			part, err = mc.ProcessBlockEncrypt(c, final, PaddingNON)
+		}
+		if err != nil {
+			t.Fatalf("chunk %d of %d (%d bytes): %v", i, len(chunks), len(c), err)
+		}
+		out = append(out, part...)
+	}
+	return out
+}
+
+// TestStreamChunking: шифрование и расшифрование кусками случайной длины, перемешанными
+// как угодно (включая пустые), совпадает с Encrypt/Decrypt всего сообщения
+func TestStreamChunking(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	key := testBytes(t, 16)
+	modes := []struct {
+		mode    string
+		segment int
+	}{{ModeCFB, 0}, {ModeCFB, 1}, {ModeCFB, 8}, {ModeCFB, 64}, {ModeOFB, 0}, {ModeCTR, 0}}
+	for _, m := range modes {
+		t.Run(fmt.Sprintf("%s%d", m.mode, m.segment), func(t *testing.T) {
+			for trial := 0; trial < 300; trial++ {
+				msg := testBytes(t, 1+r.IntN(200))
+				maxLen := []int{1, 3, 17, 40}[trial%4]
+
+				enc := streamCipher(t, key, m.mode, m.segment)
+				streamed := processChunks(t, enc, randomChunks(r, msg, maxLen), false)
+				iv := streamed[:AESBlockSize]
+				oneShot, err := streamCipher(t, key, m.mode, m.segment).Encrypt(msg, iv)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(streamed, oneShot) {
+					t.Fatalf("trial %d, %d bytes, chunks up to %d: chunked encryption differs from Encrypt", trial, len(msg), maxLen)
+				}
+
+				dec := streamCipher(t, key, m.mode, m.segment)
+				plain := processChunks(t, dec, randomChunks(r, oneShot, maxLen), true)
+				if !bytes.Equal(plain, msg) {
+					t.Fatalf("trial %d, %d bytes, chunks up to %d: chunked decryption differs from the message", trial, len(msg), maxLen)
+				}
+				whole, err := streamCipher(t, key, m.mode, m.segment).Decrypt(oneShot, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(whole, msg) {
+					t.Fatalf("trial %d: Decrypt differs from the message", trial)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package mycrypto
+
+import "errors"
+
+// ----- XOR по машинным словам -----
+
+// XORBytes возвращает a xor b для срезов одинаковой длины.
+// xorBytes - crypto/subtle.XORBytes (SSE2 на amd64, NEON на arm64, слова на остальных)
+// или, с тегом purego, переносимый цикл по 64-битным словам (xor_generic.go).
+func XORBytes(a, b []byte) ([]byte, error) {
+	if len(a) != len(b) {
+		return nil, errors.New("xor: slices must have equal length")
+	}
+	res := make([]byte, len(a))
+	xorBytes(res, a, b)
+	return res, nil
+}
+
+// XORInto выполняет dst ^= src на длине src без выделения памяти; dst должен быть не короче src
+func XORInto(dst, src []byte) {
+	xorBytes(dst[:len(src)], dst[:len(src)], src)
+}
+
+// XORTo записывает a xor b в dst на длине более короткого из a и b и возвращает её
+func XORTo(dst, a, b []byte) int {
+	return xorBytes(dst, a, b)
+}
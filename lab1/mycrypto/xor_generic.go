@@ -0,0 +1,21 @@
+//go:build purego
+
+package mycrypto
+
+import "encoding/binary"
+
+// xorBytes записывает a xor b в dst на длине более короткого из a и b по 64-битным словам
+func xorBytes(dst, a, b []byte) int {
+	n := min(len(a), len(b))
+	if len(dst) < n {
+		panic("xor: dst too short")
+	}
+	i := 0
+	for ; i+8 <= n; i += 8 {
+		binary.LittleEndian.PutUint64(dst[i:], binary.LittleEndian.Uint64(a[i:])^binary.LittleEndian.Uint64(b[i:]))
+	}
+	for ; i < n; i++ {
+		dst[i] = a[i] ^ b[i]
+	}
+	return n
+}
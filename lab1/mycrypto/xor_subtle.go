@@ -0,0 +1,10 @@
+//go:build !purego
+
+package mycrypto
+
+import "crypto/subtle"
+
+// xorBytes записывает a xor b в dst на длине более короткого из a и b; SIMD-путь crypto/subtle
+func xorBytes(dst, a, b []byte) int {
+	return subtle.XORBytes(dst, a, b)
+}
@@ -0,0 +1,26 @@
+package mycrypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestXOR сверяет xorBytes с побайтовым XOR на всех длинах до трёх слов и с разными сдвигами
+func TestXOR(t *testing.T) {
+	a, b := testBytes(t, 64), testBytes(t, 64)
+	for off := 0; off < 8; off++ {
+		for n := 0; n <= 24; n++ {
+			want := make([]byte, n)
+			for i := range want {
+				want[i] = a[off+i] ^ b[i]
+			}
+			dst := make([]byte, n)
+			if got := XORTo(dst, a[off:off+n], b[:n]); got != n || !bytes.Equal(dst, want) {
+				t.Fatalf("offset %d, %d bytes: %x (%d), want %x", off, n, dst, got, want)
+			}
+		}
+	}
+	if _, err := XORBytes(a, b[:1]); err == nil {
+		t.Fatal("XORBytes accepted slices of different lengths")
+	}
+}
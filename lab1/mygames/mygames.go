@@ -0,0 +1,232 @@
+package mygames
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// Scheme - схема шифрования, против которой играет противник
+type Scheme interface {
+	Encrypt(msg []byte) ([]byte, error)
+	Decrypt(ct []byte) ([]byte, error)
+}
+
+// Oracles - оракулы, доступные противнику. В игре IND-CPA Decrypt равен nil,
+// в IND-CCA оракул расшифрования отказывается расшифровывать вызов.
+type Oracles struct {
+	Encrypt func(msg []byte) ([]byte, error)
+	Decrypt func(ct []byte) ([]byte, error)
+}
+
+// LeftRight - оракул "левый-правый": возвращает шифртекст m_b для секретного бита b
+type LeftRight func(m0, m1 []byte) ([]byte, error)
+
+// Adversary - противник в игре на неразличимость; Play возвращает догадку о бите b
+type Adversary interface {
+	Name() string
+	Play(o Oracles, lr LeftRight) (int, error)
+}
+
+// Game - тип игры
+type Game int
+
+const (
+	INDCPA Game = iota
+	INDCCA
+)
+
+func (g Game) String() string {
+	if g == INDCCA {
+		return "IND-CCA"
+	}
+	return "IND-CPA"
+}
+
+// Result - итог серии игр
+type Result struct {
+	Game      Game
+	Adversary string
+	Trials    int
+	Wins      int
+	Advantage float64 // |2 * Pr[win] - 1|
+}
+
+// ErrChallengeQuery возвращается оракулом расшифрования при попытке расшифровать вызов
+var ErrChallengeQuery = errors.New("decryption of the challenge ciphertext is not allowed")
+
+// Play проводит trials независимых игр: для каждой создаётся новая схема (новый ключ)
+// и выбирается случайный бит b. Преимущество считается по доле угаданных битов.
+func Play(game Game, newScheme func() (Scheme, error), adv Adversary, trials int) (Result, error) {
+	if trials <= 0 {
+		return Result{}, errors.New("Play: trials must be positive")
+	}
+	res := Result{Game: game, Adversary: adv.Name(), Trials: trials}
+	for t := 0; t < trials; t++ {
+		scheme, err := newScheme()
+		if err != nil {
+			return Result{}, err
+		}
+		var rb [1]byte
+		if _, err := rand.Read(rb[:]); err != nil {
+			return Result{}, errors.New("failed to generate challenge bit")
+		}
+		b := int(rb[0] & 1)
+		var challenge []byte
+		used := false
+		lr := func(m0, m1 []byte) ([]byte, error) {
+			if used {
+				return nil, errors.New("left-right oracle may be queried only once")
+			}
+			if len(m0) != len(m1) {
+				return nil, errors.New("left-right messages must have equal length")
+			}
+			used = true
+			m := m0
+			if b == 1 {
+				m = m1
+			}
+			ct, err := scheme.Encrypt(m)
+			if err != nil {
+				return nil, err
+			}
+			challenge = append([]byte{}, ct...)
+			return ct, nil
+		}
+		o := Oracles{Encrypt: scheme.Encrypt}
+		if game == INDCCA {
+			o.Decrypt = func(ct []byte) ([]byte, error) {
+				if challenge != nil && bytes.Equal(ct, challenge) {
+					return nil, ErrChallengeQuery
+				}
+				return scheme.Decrypt(ct)
+			}
+		}
+		guess, err := adv.Play(o, lr)
+		if err != nil {
+			return Result{}, err
+		}
+		if guess == b {
+			res.Wins++
+		}
+	}
+	res.Advantage = math.Abs(2*float64(res.Wins)/float64(trials) - 1)
+	return res, nil
+}
+
+// ----- Схемы на основе MyCipher -----
+
+// cipherScheme шифрует MyCipher в заданном режиме; если iv задан, он используется в каждом сообщении
+type cipherScheme struct {
+	mc *mycrypto.MyCipher
+	iv []byte
+}
+
+func (s *cipherScheme) Encrypt(msg []byte) ([]byte, error) {
+	return s.mc.Encrypt(msg, s.iv)
+}
+
+func (s *cipherScheme) Decrypt(ct []byte) ([]byte, error) {
+	return s.mc.Decrypt(ct, nil)
+}
+
+// NewCipherScheme возвращает фабрику схем MyCipher со случайным ключом AES-128.
+// Если fixedIV задан, все сообщения шифруются с одним и тем же IV (детерминированная схема).
+func NewCipherScheme(mode string, fixedIV []byte) func() (Scheme, error) {
+	return func() (Scheme, error) {
+		key := make([]byte, mycrypto.AESKeySize16)
+		if _, err := rand.Read(key); err != nil {
+			return nil, errors.New("failed to generate key")
+		}
+		mc := &mycrypto.MyCipher{}
+		if err := mc.SetKey(key); err != nil {
+			return nil, err
+		}
+		if err := mc.SetMode(mode); err != nil {
+			return nil, err
+		}
+		return &cipherScheme{mc: mc, iv: fixedIV}, nil
+	}
+}
+
+// ----- Встроенные противники -----
+
+// RepeatedBlocks выигрывает у ECB: m0 состоит из двух одинаковых блоков,
+// m1 - из разных; в ECB одинаковые блоки дают одинаковые блоки шифртекста
+type RepeatedBlocks struct{}
+
+func (RepeatedBlocks) Name() string { return "repeated blocks" }
+
+func (RepeatedBlocks) Play(o Oracles, lr LeftRight) (int, error) {
+	bs := mycrypto.AESBlockSize
+	m0 := make([]byte, 2*bs)
+	m1 := make([]byte, 2*bs)
+	m1[bs] = 1
+	ct, err := lr(m0, m1)
+	if err != nil {
+		return 0, err
+	}
+	// у режимов с IV блоки сдвинуты на один, проверяем все пары соседних блоков
+	for i := 0; i+2*bs <= len(ct); i += bs {
+		if bytes.Equal(ct[i:i+bs], ct[i+bs:i+2*bs]) {
+			return 0, nil
+		}
+	}
+	return 1, nil
+}
+
+// ReplayEncryption выигрывает у любой детерминированной схемы: заранее шифрует m0
+// через оракул шифрования и сравнивает результат с вызовом
+type ReplayEncryption struct{}
+
+func (ReplayEncryption) Name() string { return "replay encryption" }
+
+func (ReplayEncryption) Play(o Oracles, lr LeftRight) (int, error) {
+	m0 := bytes.Repeat([]byte{0x00}, mycrypto.AESBlockSize)
+	m1 := bytes.Repeat([]byte{0xff}, mycrypto.AESBlockSize)
+	seen, err := o.Encrypt(m0)
+	if err != nil {
+		return 0, err
+	}
+	ct, err := lr(m0, m1)
+	if err != nil {
+		return 0, err
+	}
+	if bytes.Equal(seen, ct) {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// FlipAndDecrypt использует податливость потоковых режимов в игре IND-CCA:
+// инвертирует бит вызова, расшифровывает изменённый шифртекст и инвертирует бит обратно
+type FlipAndDecrypt struct{}
+
+func (FlipAndDecrypt) Name() string { return "flip and decrypt" }
+
+func (FlipAndDecrypt) Play(o Oracles, lr LeftRight) (int, error) {
+	if o.Decrypt == nil {
+		return 0, errors.New("FlipAndDecrypt needs a decryption oracle")
+	}
+	m0 := bytes.Repeat([]byte{0x00}, mycrypto.AESBlockSize)
+	m1 := bytes.Repeat([]byte{0xff}, mycrypto.AESBlockSize)
+	ct, err := lr(m0, m1)
+	if err != nil {
+		return 0, err
+	}
+	modified := append([]byte{}, ct...)
+	modified[len(modified)-1] ^= 0x01
+	pt, err := o.Decrypt(modified)
+	if err != nil || len(pt) == 0 {
+		// схема отвергла изменённый шифртекст - остаётся угадывать
+		return int(ct[0] & 1), nil
+	}
+	pt[len(pt)-1] ^= 0x01
+	if bytes.Equal(pt, m0) {
+		return 0, nil
+	}
+	return 1, nil
+}
@@ -0,0 +1,185 @@
+package mygost
+
+import (
+	"fmt"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Кузнечик (ГОСТ Р 34.12-2015, RFC 7801): 128-битный блок, 256-битный ключ -----
+
+const (
+	KuznyechikBlockSize = 16
+	KeySize             = 32
+)
+
+// kuzPi - нелинейная биекция π
+var kuzPi = [256]byte{
+	252, 238, 221, 17, 207, 110, 49, 22, 251, 196, 250, 218, 35, 197, 4, 77,
+	233, 119, 240, 219, 147, 46, 153, 186, 23, 54, 241, 187, 20, 205, 95, 193,
+	249, 24, 101, 90, 226, 92, 239, 33, 129, 28, 60, 66, 139, 1, 142, 79,
+	5, 132, 2, 174, 227, 106, 143, 160, 6, 11, 237, 152, 127, 212, 211, 31,
+	235, 52, 44, 81, 234, 200, 72, 171, 242, 42, 104, 162, 253, 58, 206, 204,
+	181, 112, 14, 86, 8, 12, 118, 18, 191, 114, 19, 71, 156, 183, 93, 135,
+	21, 161, 150, 41, 16, 123, 154, 199, 243, 145, 120, 111, 157, 158, 178, 177,
+	50, 117, 25, 61, 255, 53, 138, 126, 109, 84, 198, 128, 195, 189, 13, 87,
+	223, 245, 36, 169, 62, 168, 67, 201, 215, 121, 214, 246, 124, 34, 185, 3,
+	224, 15, 236, 222, 122, 148, 176, 188, 220, 232, 40, 80, 78, 51, 10, 74,
+	167, 151, 96, 115, 30, 0, 98, 68, 26, 184, 56, 130, 100, 159, 38, 65,
+	173, 69, 70, 146, 39, 94, 85, 47, 140, 163, 165, 125, 105, 213, 149, 59,
+	7, 88, 179, 64, 134, 172, 29, 247, 48, 55, 107, 228, 136, 217, 231, 137,
+	225, 27, 131, 73, 76, 63, 248, 254, 141, 83, 170, 144, 202, 216, 133, 97,
+	32, 113, 103, 164, 45, 43, 9, 91, 203, 155, 37, 208, 190, 229, 108, 82,
+	89, 166, 116, 210, 230, 244, 180, 192, 209, 102, 175, 194, 57, 75, 99, 182,
+}
+
+// kuzLVec - коэффициенты линейного преобразования ℓ для байтов a15..a0
+var kuzLVec = [16]byte{148, 32, 133, 16, 194, 192, 1, 251, 1, 192, 194, 16, 133, 32, 148, 1}
+
+var (
+	kuzPiInv [256]byte
+	// kuzMul[i][x] = kuzLVec[i] * x в поле GF(2^8) по модулю x^8 + x^7 + x^6 + x + 1
+	kuzMul [16][256]byte
+	// kuzC - итерационные константы развёртывания ключа C_i = L(Vec128(i))
+	kuzC [32][KuznyechikBlockSize]byte
+)
+
+// kuzGFMul умножает элементы GF(2^8) по модулю 0x1c3
+func kuzGFMul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0xc3
+		}
+		b >>= 1
+	}
+	return p
+}
+
+func init() {
+	for i, v := range kuzPi {
+		kuzPiInv[v] = byte(i)
+	}
+	for i, c := range kuzLVec {
+		for x := 0; x < 256; x++ {
+			kuzMul[i][x] = kuzGFMul(c, byte(x))
+		}
+	}
+	for i := range kuzC {
+		kuzC[i][KuznyechikBlockSize-1] = byte(i + 1)
+		kuzL(&kuzC[i])
+	}
+}
+
+// kuzR - один такт регистра сдвига: a15..a0 -> ℓ(a15..a0) || a15..a1
+func kuzR(a *[KuznyechikBlockSize]byte) {
+	mycrypto.AuditPoint("mygost.kuzR/Mul")
+	var x byte
+	for i, b := range a {
+		x ^= kuzMul[i][b]
+	}
+	copy(a[1:], a[:15])
+	a[0] = x
+}
+
+// kuzRInv - обратный такт: a15..a0 -> a14..a0 || ℓ(a14..a0, a15)
+func kuzRInv(a *[KuznyechikBlockSize]byte) {
+	first := a[0]
+	copy(a[:15], a[1:])
+	a[15] = first
+	mycrypto.AuditPoint("mygost.kuzRInv/Mul")
+	var x byte
+	for i, b := range a {
+		x ^= kuzMul[i][b]
+	}
+	a[15] = x
+}
+
+func kuzL(a *[KuznyechikBlockSize]byte) {
+	for i := 0; i < 16; i++ {
+		kuzR(a)
+	}
+}
+
+func kuzLInv(a *[KuznyechikBlockSize]byte) {
+	for i := 0; i < 16; i++ {
+		kuzRInv(a)
+	}
+}
+
+func kuzX(a *[KuznyechikBlockSize]byte, k *[KuznyechikBlockSize]byte) {
+	for i := range a {
+		a[i] ^= k[i]
+	}
+}
+
+// Kuznyechik - блочный шифр Кузнечик; реализует mycrypto.BlockCipher
+type Kuznyechik struct {
+	rk [10][KuznyechikBlockSize]byte
+}
+
+// NewKuznyechik развёртывает 256-битный ключ в 10 раундовых ключей
+func NewKuznyechik(key []byte) (*Kuznyechik, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("Kuznyechik: invalid key length %d, expected %d", len(key), KeySize)
+	}
+	c := &Kuznyechik{}
+	copy(c.rk[0][:], key[:16])
+	copy(c.rk[1][:], key[16:])
+	for i := 0; i < 4; i++ {
+		a1, a0 := c.rk[2*i], c.rk[2*i+1]
+		// восемь раундов сети Фейстеля F[C](a1, a0) = (LSX[C](a1) xor a0, a1)
+		for j := 0; j < 8; j++ {
+			t := a1
+			kuzX(&t, &kuzC[8*i+j])
+			mycrypto.AuditPoint("mygost.NewKuznyechik/Pi")
+			for b := range t {
+				t[b] = kuzPi[t[b]]
+			}
+			kuzL(&t)
+			kuzX(&t, &a0)
+			a1, a0 = t, a1
+		}
+		c.rk[2*i+2], c.rk[2*i+3] = a1, a0
+	}
+	return c, nil
+}
+
+func (c *Kuznyechik) BlockSize() int { return KuznyechikBlockSize }
+
+// Encrypt: девять раундов LSX и заключительное наложение ключа
+func (c *Kuznyechik) Encrypt(dst, src []byte) {
+	var a [KuznyechikBlockSize]byte
+	copy(a[:], src[:KuznyechikBlockSize])
+	for i := 0; i < 9; i++ {
+		kuzX(&a, &c.rk[i])
+		mycrypto.AuditPoint("mygost.Kuznyechik.Encrypt/Pi")
+		for b := range a {
+			a[b] = kuzPi[a[b]]
+		}
+		kuzL(&a)
+	}
+	kuzX(&a, &c.rk[9])
+	copy(dst, a[:])
+}
+
+// Decrypt применяет обратные преобразования в обратном порядке
+func (c *Kuznyechik) Decrypt(dst, src []byte) {
+	var a [KuznyechikBlockSize]byte
+	copy(a[:], src[:KuznyechikBlockSize])
+	kuzX(&a, &c.rk[9])
+	for i := 8; i >= 0; i-- {
+		kuzLInv(&a)
+		mycrypto.AuditPoint("mygost.Kuznyechik.Decrypt/PiInv")
+		for b := range a {
+			a[b] = kuzPiInv[a[b]]
+		}
+		kuzX(&a, &c.rk[i])
+	}
+	copy(dst, a[:])
+}
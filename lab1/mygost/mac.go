@@ -0,0 +1,87 @@
+package mygost
+
+import (
+	"crypto/cipher"
+	"errors"
+	"fmt"
+)
+
+// ----- Особенности режимов ГОСТ Р 34.13-2015 -----
+
+// Процедура дополнения 2 (байт 0x80 и нули до кратного блоку, всегда хотя бы один байт)
+// совпадает с mycrypto.PadToBucket(data, mycrypto.BucketMultiple(blockSize)).
+
+// Константы B_n для выработки вспомогательных ключей имитовставки (младший байт)
+const (
+	macB64  = 0x1b // x^64 + x^4 + x^3 + x + 1
+	macB128 = 0x87 // x^128 + x^7 + x^2 + x + 1
+)
+
+// CounterBlock строит начальное значение счётчика режима гаммирования (CTR) по ГОСТ: IV длиной
+// в половину блока, дополненный нулями. Результат передаётся в MyCipher.Encrypt как iv: поле
+// счётчика MyCipher совпадает с младшей половиной блока для 64- и 128-битных шифров.
+func CounterBlock(b cipher.Block, iv []byte) ([]byte, error) {
+	if len(iv) != b.BlockSize()/2 {
+		return nil, fmt.Errorf("CounterBlock: IV must be %d bytes, got %d", b.BlockSize()/2, len(iv))
+	}
+	ctr := make([]byte, b.BlockSize())
+	copy(ctr, iv)
+	return ctr, nil
+}
+
+// shift1 сдвигает блок на бит влево и при переносе складывает с константой B_n
+func shift1(in []byte, bn byte) []byte {
+	out := make([]byte, len(in))
+	for i := 0; i < len(in)-1; i++ {
+		out[i] = in[i]<<1 | in[i+1]>>7
+	}
+	out[len(in)-1] = in[len(in)-1] << 1
+	if in[0]&0x80 != 0 {
+		out[len(in)-1] ^= bn
+	}
+	return out
+}
+
+// MAC вычисляет имитовставку ГОСТ Р 34.13-2015 (OMAC1/CMAC) длиной tagSize байт.
+// Для 64-битного блока (Магма) константа B64 = 0x1b, для 128-битного (Кузнечик) B128 = 0x87.
+func MAC(b cipher.Block, msg []byte, tagSize int) ([]byte, error) {
+	bs := b.BlockSize()
+	var bn byte
+	switch bs {
+	case MagmaBlockSize:
+		bn = macB64
+	case KuznyechikBlockSize:
+		bn = macB128
+	default:
+		return nil, fmt.Errorf("MAC: unsupported block size %d", bs)
+	}
+	if tagSize <= 0 || tagSize > bs {
+		return nil, errors.New("MAC: invalid tag size")
+	}
+	r := make([]byte, bs)
+	b.Encrypt(r, r)
+	k1 := shift1(r, bn)
+	k2 := shift1(k1, bn)
+	state := make([]byte, bs)
+	// все блоки, кроме последнего, обрабатываются как в CBC
+	for len(msg) > bs {
+		for i := range state {
+			state[i] ^= msg[i]
+		}
+		b.Encrypt(state, state)
+		msg = msg[bs:]
+	}
+	// последний блок: полный - с K1, неполный дополняется 1 0..0 и берётся K2
+	last := make([]byte, bs)
+	copy(last, msg)
+	k := k1
+	if len(msg) < bs {
+		last[len(msg)] = 0x80
+		k = k2
+	}
+	for i := range state {
+		state[i] ^= last[i] ^ k[i]
+	}
+	b.Encrypt(state, state)
+	return state[:tagSize], nil
+}
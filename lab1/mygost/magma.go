@@ -0,0 +1,90 @@
+package mygost
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Магма (ГОСТ Р 34.12-2015, RFC 8891): 64-битный блок, 256-битный ключ -----
+
+const MagmaBlockSize = 8
+
+// magmaPi - подстановки π0..π7 (набор id-tc26-gost-28147-param-Z); πi действует на i-ю тетраду
+var magmaPi = [8][16]byte{
+	{0xc, 0x4, 0x6, 0x2, 0xa, 0x5, 0xb, 0x9, 0xe, 0x8, 0xd, 0x7, 0x0, 0x3, 0xf, 0x1},
+	{0x6, 0x8, 0x2, 0x3, 0x9, 0xa, 0x5, 0xc, 0x1, 0xe, 0x4, 0x7, 0xb, 0xd, 0x0, 0xf},
+	{0xb, 0x3, 0x5, 0x8, 0x2, 0xf, 0xa, 0xd, 0xe, 0x1, 0x7, 0x4, 0xc, 0x9, 0x6, 0x0},
+	{0xc, 0x8, 0x2, 0x1, 0xd, 0x4, 0xf, 0x6, 0x7, 0x0, 0xa, 0x5, 0x3, 0xe, 0x9, 0xb},
+	{0x7, 0xf, 0x5, 0xa, 0x8, 0x1, 0x6, 0xd, 0x0, 0x9, 0x3, 0xe, 0xb, 0x4, 0x2, 0xc},
+	{0x5, 0xd, 0xf, 0x6, 0x9, 0x2, 0xc, 0xa, 0xb, 0x7, 0x8, 0x1, 0x4, 0x3, 0xe, 0x0},
+	{0x8, 0xe, 0x2, 0x5, 0x6, 0x9, 0x1, 0xc, 0xf, 0x4, 0xb, 0x0, 0xd, 0xa, 0x3, 0x7},
+	{0x1, 0x7, 0xe, 0xd, 0x0, 0x5, 0x8, 0x3, 0x4, 0xf, 0xa, 0x6, 0x9, 0xc, 0xb, 0x2},
+}
+
+// magmaT - подстановка t над 32-битным словом
+func magmaT(a uint32) uint32 {
+	var r uint32
+	mycrypto.AuditPoint("mygost.magmaT/Pi")
+	for i := 0; i < 8; i++ {
+		r |= uint32(magmaPi[i][a>>(4*uint(i))&0xf]) << (4 * uint(i))
+	}
+	return r
+}
+
+// magmaG - раундовая функция g[k](a) = t(a + k) <<< 11
+func magmaG(k, a uint32) uint32 {
+	return bits.RotateLeft32(magmaT(a+k), 11)
+}
+
+// Magma - блочный шифр Магма; реализует mycrypto.BlockCipher
+type Magma struct {
+	k [8]uint32
+}
+
+// NewMagma делит 256-битный ключ на восемь 32-битных слов K1..K8 (big-endian)
+func NewMagma(key []byte) (*Magma, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("Magma: invalid key length %d, expected %d", len(key), KeySize)
+	}
+	c := &Magma{}
+	for i := range c.k {
+		c.k[i] = binary.BigEndian.Uint32(key[4*i:])
+	}
+	return c, nil
+}
+
+func (c *Magma) BlockSize() int { return MagmaBlockSize }
+
+// roundKey возвращает ключ раунда i (0..31): K1..K8 трижды, затем K8..K1
+func (c *Magma) roundKey(i int) uint32 {
+	if i < 24 {
+		return c.k[i%8]
+	}
+	return c.k[31-i]
+}
+
+// crypt выполняет 32 раунда сети Фейстеля; в последнем раунде половины не переставляются
+func (c *Magma) crypt(dst, src []byte, decrypt bool) {
+	a1 := binary.BigEndian.Uint32(src[0:])
+	a0 := binary.BigEndian.Uint32(src[4:])
+	for i := 0; i < 32; i++ {
+		k := c.roundKey(i)
+		if decrypt {
+			k = c.roundKey(31 - i)
+		}
+		if i == 31 {
+			a1 ^= magmaG(k, a0)
+		} else {
+			a1, a0 = a0, magmaG(k, a0)^a1
+		}
+	}
+	binary.BigEndian.PutUint32(dst[0:], a1)
+	binary.BigEndian.PutUint32(dst[4:], a0)
+}
+
+func (c *Magma) Encrypt(dst, src []byte) { c.crypt(dst, src, false) }
+
+func (c *Magma) Decrypt(dst, src []byte) { c.crypt(dst, src, true) }
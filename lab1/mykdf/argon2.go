@@ -0,0 +1,260 @@
+package mykdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sync"
+)
+
+// ----- Argon2id (RFC 9106) -----
+
+const (
+	argon2Version    = 0x13
+	argon2TypeID     = 2
+	argon2BlockWords = 128 // блок памяти - 1024 байта
+	argon2SyncPoints = 4   // число срезов в проходе
+)
+
+// Argon2Params - параметры Argon2id. Memory задаётся в КиБ (блоках по 1024 байта) и округляется
+// вниз до кратного 4*Threads; Threads - число полос, которые заполняются параллельно.
+// Secret (ключ K) и AD (данные X) необязательны.
+type Argon2Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+	Secret  []byte
+	AD      []byte
+}
+
+// DefaultArgon2Params - второй рекомендованный набор RFC 9106: 3 прохода по 64 МиБ в 4 полосах
+var DefaultArgon2Params = Argon2Params{Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: 32}
+
+type argon2Block [argon2BlockWords]uint64
+
+// argon2Hash - функция переменной длины H' из RFC 9106, раздел 3.3
+func argon2Hash(out []byte, parts ...[]byte) {
+	var t [4]byte
+	binary.LittleEndian.PutUint32(t[:], uint32(len(out)))
+	if len(out) <= blake2bSize {
+		copy(out, blake2bSum(len(out), append([][]byte{t[:]}, parts...)...))
+		return
+	}
+	v := blake2bSum(blake2bSize, append([][]byte{t[:]}, parts...)...)
+	for len(out) > blake2bSize {
+		copy(out, v[:blake2bSize/2])
+		out = out[blake2bSize/2:]
+		if len(out) > blake2bSize {
+			v = blake2bSum(blake2bSize, v)
+		} else {
+			v = blake2bSum(len(out), v)
+		}
+	}
+	copy(out, v)
+}
+
+// fBlaMka - сложение с умножением младших половин из перестановки P
+func fBlaMka(x, y uint64) uint64 {
+	return x + y + 2*uint64(uint32(x))*uint64(uint32(y))
+}
+
+func argon2GB(v *argon2Block, a, b, c, d int) {
+	v[a] = fBlaMka(v[a], v[b])
+	v[d] = bits.RotateLeft64(v[d]^v[a], -32)
+	v[c] = fBlaMka(v[c], v[d])
+	v[b] = bits.RotateLeft64(v[b]^v[c], -24)
+	v[a] = fBlaMka(v[a], v[b])
+	v[d] = bits.RotateLeft64(v[d]^v[a], -16)
+	v[c] = fBlaMka(v[c], v[d])
+	v[b] = bits.RotateLeft64(v[b]^v[c], -63)
+}
+
+// argon2Round - перестановка P над 16 словами с индексами i[0..15]
+func argon2Round(v *argon2Block, i [16]int) {
+	argon2GB(v, i[0], i[4], i[8], i[12])
+	argon2GB(v, i[1], i[5], i[9], i[13])
+	argon2GB(v, i[2], i[6], i[10], i[14])
+	argon2GB(v, i[3], i[7], i[11], i[15])
+	argon2GB(v, i[0], i[5], i[10], i[15])
+	argon2GB(v, i[1], i[6], i[11], i[12])
+	argon2GB(v, i[2], i[7], i[8], i[13])
+	argon2GB(v, i[3], i[4], i[9], i[14])
+}
+
+// argon2G - функция сжатия G(x, y): R = x xor y, P по строкам и столбцам матрицы 8x8 из 16-байтных
+// регистров, результат P(R) xor R; при xor результат ещё складывается с прежним out (проходы после первого)
+func argon2G(out, x, y *argon2Block, xor bool) {
+	var r, z argon2Block
+	for i := range r {
+		r[i] = x[i] ^ y[i]
+	}
+	z = r
+	for row := 0; row < 8; row++ {
+		var idx [16]int
+		for k := range idx {
+			idx[k] = 16*row + k
+		}
+		argon2Round(&z, idx)
+	}
+	for col := 0; col < 8; col++ {
+		var idx [16]int
+		for k := 0; k < 8; k++ {
+			idx[2*k] = 2*col + 16*k
+			idx[2*k+1] = 2*col + 16*k + 1
+		}
+		argon2Round(&z, idx)
+	}
+	for i := range out {
+		if xor {
+			out[i] ^= z[i] ^ r[i]
+		} else {
+			out[i] = z[i] ^ r[i]
+		}
+	}
+}
+
+// Argon2id вычисляет ключ длины p.KeyLen. Полосы одного среза заполняются в отдельных горутинах.
+func Argon2id(password, salt []byte, p Argon2Params) ([]byte, error) {
+	if p.Time < 1 {
+		return nil, fmt.Errorf("Argon2id: time must be at least 1")
+	}
+	if p.Threads < 1 {
+		return nil, fmt.Errorf("Argon2id: threads must be at least 1")
+	}
+	if p.KeyLen < 4 {
+		return nil, fmt.Errorf("Argon2id: key length must be at least 4 bytes, got %d", p.KeyLen)
+	}
+	if len(salt) < 8 {
+		return nil, fmt.Errorf("Argon2id: salt must be at least 8 bytes, got %d", len(salt))
+	}
+	lanes := uint32(p.Threads)
+	if p.Memory < 8*lanes {
+		return nil, fmt.Errorf("Argon2id: memory must be at least %d KiB for %d threads", 8*lanes, lanes)
+	}
+	memory := p.Memory / (argon2SyncPoints * lanes) * (argon2SyncPoints * lanes)
+	laneLen := memory / lanes
+	segLen := laneLen / argon2SyncPoints
+
+	// H0 из всех параметров и входов
+	le32 := func(x uint32) []byte { return binary.LittleEndian.AppendUint32(nil, x) }
+	h0 := blake2bSum(blake2bSize,
+		le32(lanes), le32(p.KeyLen), le32(p.Memory), le32(p.Time), le32(argon2Version), le32(argon2TypeID),
+		le32(uint32(len(password))), password, le32(uint32(len(salt))), salt,
+		le32(uint32(len(p.Secret))), p.Secret, le32(uint32(len(p.AD))), p.AD)
+
+	mem := make([]argon2Block, memory)
+	var buf [8 * argon2BlockWords]byte
+	for l := uint32(0); l < lanes; l++ {
+		for j := uint32(0); j < 2; j++ {
+			argon2Hash(buf[:], h0, le32(j), le32(l))
+			b := &mem[l*laneLen+j]
+			for k := range b {
+				b[k] = binary.LittleEndian.Uint64(buf[8*k:])
+			}
+		}
+	}
+
+	for pass := uint32(0); pass < p.Time; pass++ {
+		for slice := uint32(0); slice < argon2SyncPoints; slice++ {
+			var wg sync.WaitGroup
+			for l := uint32(0); l < lanes; l++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					argon2Segment(mem, pass, slice, l, lanes, laneLen, segLen, memory, p.Time)
+				}()
+			}
+			wg.Wait()
+		}
+	}
+
+	final := mem[laneLen-1]
+	for l := uint32(1); l < lanes; l++ {
+		last := &mem[l*laneLen+laneLen-1]
+		for k := range final {
+			final[k] ^= last[k]
+		}
+	}
+	for k, w := range final {
+		binary.LittleEndian.PutUint64(buf[8*k:], w)
+	}
+	tag := make([]byte, p.KeyLen)
+	argon2Hash(tag, buf[:])
+	return tag, nil
+}
+
+// argon2Segment заполняет сегмент (pass, slice) полосы lane. В первой половине первого прохода
+// индексы опорных блоков не зависят от данных (как в Argon2i), дальше - зависят (как в Argon2d).
+func argon2Segment(mem []argon2Block, pass, slice, lane, lanes, laneLen, segLen, memory, time uint32) {
+	independent := pass == 0 && slice < argon2SyncPoints/2
+	var input, addr, zero argon2Block
+	if independent {
+		input[0], input[1], input[2] = uint64(pass), uint64(lane), uint64(slice)
+		input[3], input[4], input[5] = uint64(memory), uint64(time), argon2TypeID
+	}
+	nextAddresses := func() {
+		input[6]++
+		argon2G(&addr, &zero, &input, false)
+		argon2G(&addr, &zero, &addr, false)
+	}
+	start := uint32(0)
+	if pass == 0 && slice == 0 {
+		start = 2
+		if independent {
+			nextAddresses()
+		}
+	}
+	for i := start; i < segLen; i++ {
+		cur := lane*laneLen + slice*segLen + i
+		prev := cur - 1
+		if slice == 0 && i == 0 {
+			prev = lane*laneLen + laneLen - 1
+		}
+		var rnd uint64
+		if independent {
+			if i%argon2BlockWords == 0 {
+				nextAddresses()
+			}
+			rnd = addr[i%argon2BlockWords]
+		} else {
+			rnd = mem[prev][0]
+		}
+		refLane := uint32(rnd>>32) % lanes
+		if pass == 0 && slice == 0 {
+			refLane = lane
+		}
+		ref := refLane*laneLen + argon2RefIndex(pass, slice, i, uint32(rnd), laneLen, segLen, refLane == lane)
+		argon2G(&mem[cur], &mem[prev], &mem[ref], pass > 0)
+	}
+}
+
+// argon2RefIndex выбирает опорный блок в полосе по 32 младшим битам rnd (RFC 9106, раздел 3.4.2)
+func argon2RefIndex(pass, slice, index, j1, laneLen, segLen uint32, sameLane bool) uint32 {
+	var area uint32
+	switch {
+	case pass == 0 && slice == 0:
+		area = index - 1
+	case pass == 0 && sameLane:
+		area = slice*segLen + index - 1
+	case pass == 0:
+		area = slice * segLen
+		if index == 0 {
+			area--
+		}
+	case sameLane:
+		area = laneLen - segLen + index - 1
+	default:
+		area = laneLen - segLen
+		if index == 0 {
+			area--
+		}
+	}
+	x := uint64(j1) * uint64(j1) >> 32
+	rel := area - 1 - uint32(uint64(area)*x>>32)
+	startPos := uint32(0)
+	if pass != 0 && slice != argon2SyncPoints-1 {
+		startPos = (slice + 1) * segLen
+	}
+	return (startPos + rel) % laneLen
+}
@@ -0,0 +1,36 @@
+package mykdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+// argon2Vectors - пример из RFC 9106, раздел 5.3; значения в hex
+var argon2Vectors = []struct {
+	password, salt, secret, ad string
+	params                     Argon2Params
+	tag                        string
+}{
+	{
+		password: "0101010101010101010101010101010101010101010101010101010101010101",
+		salt:     "02020202020202020202020202020202",
+		secret:   "0303030303030303",
+		ad:       "040404040404040404040404",
+		params:   Argon2Params{Time: 3, Memory: 32, Threads: 4, KeyLen: 32},
+		tag:      "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659",
+	},
+}
+
+func TestArgon2id(t *testing.T) {
+	for i, v := range argon2Vectors {
+		p := v.params
+		p.Secret, p.AD = mustHex(t, v.secret), mustHex(t, v.ad)
+		tag, err := Argon2id(mustHex(t, v.password), mustHex(t, v.salt), p)
+		if err != nil {
+			t.Fatalf("vector #%d: %v", i, err)
+		}
+		if want := mustHex(t, v.tag); !bytes.Equal(tag, want) {
+			t.Fatalf("vector #%d: got %x, want %s", i, tag, v.tag)
+		}
+	}
+}
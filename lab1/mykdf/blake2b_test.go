@@ -0,0 +1,56 @@
+package mykdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// blake2bTestMsg возвращает n байт сообщения для векторов с длинными входами
+func blake2bTestMsg(n int) []byte {
+	m := make([]byte, n)
+	for i := range m {
+		m[i] = byte(i*7 + 3)
+	}
+	return m
+}
+
+// blake2bVectors - BLAKE2b без ключа: "abc" для BLAKE2b-512 из RFC 7693, приложение A, остальные
+// получены hashlib.blake2b из Python; длины 127..129 и 256 - вокруг границ блока в 128 байт,
+// где Write придерживает последний блок до Sum
+var blake2bVectors = []struct {
+	size int
+	msg  []byte
+	sum  string
+}{
+	{64, []byte(""), "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+	{64, []byte("abc"), "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+	{32, []byte("abc"), "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319"},
+	{1, []byte("abc"), "6b"},
+	{64, blake2bTestMsg(127), "71546bbf9110ad184cc60f2eb120fcfd9b4dbbca7a7f1270045b8a23a6a4f4330f65c1f030dd2f5fabc6c57617242c37cf427bd90407fac5b9deffd3ae888c39"},
+	{64, blake2bTestMsg(128), "2d9e329f42afa3601d646692b81c13e87fcaff5bf15972e9813d7373cb6d181f9599f4d513d4af4fd6ebd37497aceb29aba5ee23ed764d8510b552bd088814fb"},
+	{64, blake2bTestMsg(129), "47889df9eb4d717afc5019df5c6a83df00a0b8677395e078cd5778ace0f338a618e68b7d9afb065d9e6a01ccd31d109447e7fae771c3ee3e105709194122ba2b"},
+	{64, blake2bTestMsg(256), "91019c558584980249ca43eceed27e19f1c3c24161b93eed1eee2a6a774f60bf8a81b43750870bee1698feac9c5336ae4d5c842e7ead159bf3916387e8ded9ae"},
+	{64, blake2bTestMsg(1000), "4bdd2c9cf31d797a81d245c989ffb7515143ca345c66f73087dd5c58bf642bf083ba16894eab79e3b08d5126404d833e7510271b50be36a7b7cbbb46f5c89fac"},
+}
+
+func TestBlake2b(t *testing.T) {
+	for i, v := range blake2bVectors {
+		if sum := hex.EncodeToString(blake2bSum(v.size, v.msg)); sum != v.sum {
+			t.Fatalf("vector #%d (%d bytes, size %d): %s, want %s", i, len(v.msg), v.size, sum, v.sum)
+		}
+	}
+}
+
+// TestBlake2bSplit проверяет, что хеш не зависит от того, как сообщение разбито на части Write
+func TestBlake2bSplit(t *testing.T) {
+	msg := blake2bTestMsg(300)
+	want := blake2bSum(blake2bSize, msg)
+	for i := 0; i <= len(msg); i++ {
+		for _, j := range []int{i, min(i+1, len(msg)), min(i+blake2bBlockSize, len(msg)), len(msg)} {
+			if got := blake2bSum(blake2bSize, msg[:i], msg[i:j], msg[j:]); !bytes.Equal(got, want) {
+				t.Fatalf("split at %d and %d: %x, want %x", i, j, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package mykdf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+)
+
+// ----- HKDF-SHA256 (RFC 5869) -----
+
+// HKDFMaxLen - наибольшая длина выхода HKDF-Expand: 255 блоков HMAC-SHA256
+const HKDFMaxLen = 255 * sha256.Size
+
+// Метки info для ключей композиции "шифрование, затем MAC"
+const (
+	LabelEncryption = "mykdf etm encryption key"
+	LabelMAC        = "mykdf etm mac key"
+)
+
+// HKDFExtract извлекает из входного материала ikm псевдослучайный ключ PRK = HMAC(salt, ikm).
+// Пустая соль заменяется нулевой строкой длины хеша, как требует RFC 5869.
+func HKDFExtract(salt, ikm []byte) []byte {
+	if len(salt) == 0 {
+		salt = make([]byte, sha256.Size)
+	}
+	m := hmac.New(sha256.New, salt)
+	m.Write(ikm)
+	return m.Sum(nil)
+}
+
+// HKDFExpand расширяет prk до n байт: T(i) = HMAC(prk, T(i-1) || info || i), результат - T(1) || T(2) || ...
+func HKDFExpand(prk, info []byte, n int) ([]byte, error) {
+	if len(prk) < sha256.Size {
+		return nil, fmt.Errorf("HKDFExpand: PRK must be at least %d bytes, got %d", sha256.Size, len(prk))
+	}
+	if n < 0 || n > HKDFMaxLen {
+		return nil, fmt.Errorf("HKDFExpand: output length %d out of range [0, %d]", n, HKDFMaxLen)
+	}
+	m := hmac.New(sha256.New, prk)
+	out := make([]byte, 0, n+sha256.Size)
+	var t []byte
+	for i := byte(1); len(out) < n; i++ {
+		m.Reset()
+		m.Write(t)
+		m.Write(info)
+		m.Write([]byte{i})
+		t = m.Sum(out[len(out):len(out)])
+		out = out[:len(out)+len(t)]
+	}
+	return out[:n], nil
+}
+
+// HKDF выполняет Extract и Expand
+func HKDF(ikm, salt, info []byte, n int) ([]byte, error) {
+	return HKDFExpand(HKDFExtract(salt, ikm), info, n)
+}
+
+// contextInfo составляет info из метки и контекста: label || 0x00 || context
+func contextInfo(label, context string) []byte {
+	info := make([]byte, 0, len(label)+1+len(context))
+	info = append(info, label...)
+	info = append(info, 0)
+	return append(info, context...)
+}
+
+// EtMKeys выводит из одного главного секрета независимые ключи шифрования и MAC длины encLen и macLen.
+// Ключи различаются меткой в info (LabelEncryption, LabelMAC); context (например, имя протокола
+// или файла) разделяет ключи разных применений одного секрета. PRK вычисляется один раз.
+func EtMKeys(master, salt []byte, context string, encLen, macLen int) (encKey, macKey []byte, err error) {
+	prk := HKDFExtract(salt, master)
+	if encKey, err = HKDFExpand(prk, contextInfo(LabelEncryption, context), encLen); err != nil {
+		return nil, nil, err
+	}
+	if macKey, err = HKDFExpand(prk, contextInfo(LabelMAC, context), macLen); err != nil {
+		return nil, nil, err
+	}
+	return encKey, macKey, nil
+}
@@ -0,0 +1,153 @@
+package mykdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Совместимость с "openssl enc -aes-*-cbc -salt" -----
+
+const (
+	// OpenSSLMagic - заголовок файла "openssl enc" с солью
+	OpenSSLMagic = "Salted__"
+	// OpenSSLSaltSize - длина соли после заголовка
+	OpenSSLSaltSize = 8
+	// OpenSSLIterations - число итераций "openssl enc -pbkdf2" по умолчанию
+	OpenSSLIterations = 10000
+)
+
+// Дайджесты EVP_BytesToKey (параметр -md); OpenSSL до 1.1.0 по умолчанию использовал MD5
+const (
+	DigestMD5    = "md5"
+	DigestSHA256 = "sha256"
+)
+
+// OpenSSLOptions повторяет параметры "openssl enc". Нулевое значение соответствует
+// "openssl enc -aes-256-cbc -salt" в OpenSSL 1.1.0 и новее: EVP_BytesToKey на SHA-256 с одной итерацией.
+type OpenSSLOptions struct {
+	KeyLen     int    // 16, 24 или 32 - aes-128/192/256-cbc (0 - 32)
+	Digest     string // DigestMD5 или DigestSHA256 ("" - SHA-256)
+	PBKDF2     bool   // -pbkdf2: ключ и IV из PBKDF2-HMAC-SHA256
+	Iterations int    // -iter для PBKDF2 (0 - OpenSSLIterations)
+}
+
+// EVPBytesToKey - выработка ключа и IV из пароля в OpenSSL: D_1 = H^count(password || salt),
+// D_i = H^count(D_{i-1} || password || salt); ключ и IV берутся из D_1 || D_2 || ... подряд.
+// Функция нужна только для совместимости: одна итерация быстрого хеша не защищает слабый пароль.
+func EVPBytesToKey(newHash func() hash.Hash, password, salt []byte, count, keyLen, ivLen int) (key, iv []byte) {
+	h := newHash()
+	var out, d []byte
+	for len(out) < keyLen+ivLen {
+		h.Reset()
+		h.Write(d)
+		h.Write(password)
+		h.Write(salt)
+		d = h.Sum(nil)
+		for i := 1; i < count; i++ {
+			h.Reset()
+			h.Write(d)
+			d = h.Sum(nil)
+		}
+		out = append(out, d...)
+	}
+	return out[:keyLen], out[keyLen : keyLen+ivLen]
+}
+
+// OpenSSLKeyIV вырабатывает ключ AES и IV CBC так же, как "openssl enc" с параметрами o
+func OpenSSLKeyIV(password, salt []byte, o OpenSSLOptions) (key, iv []byte, err error) {
+	keyLen := o.KeyLen
+	if keyLen == 0 {
+		keyLen = mycrypto.AESKeySize32
+	}
+	switch keyLen {
+	case mycrypto.AESKeySize16, mycrypto.AESKeySize24, mycrypto.AESKeySize32:
+	default:
+		return nil, nil, fmt.Errorf("OpenSSL: invalid AES key length %d", keyLen)
+	}
+	var newHash func() hash.Hash
+	switch o.Digest {
+	case "", DigestSHA256:
+		newHash = sha256.New
+	case DigestMD5:
+		newHash = md5.New
+	default:
+		return nil, nil, fmt.Errorf("OpenSSL: unsupported digest %q", o.Digest)
+	}
+	if !o.PBKDF2 {
+		key, iv = EVPBytesToKey(newHash, password, salt, 1, keyLen, mycrypto.AESBlockSize)
+		return key, iv, nil
+	}
+	if o.Digest == DigestMD5 {
+		return nil, nil, errors.New("OpenSSL: -pbkdf2 is supported with SHA-256 only")
+	}
+	iter := o.Iterations
+	if iter == 0 {
+		iter = OpenSSLIterations
+	}
+	dk, err := PBKDF2(password, salt, iter, keyLen+mycrypto.AESBlockSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dk[:keyLen], dk[keyLen:], nil
+}
+
+// newOpenSSLCipher настраивает MyCipher в режиме CBC на ключе из пароля и соли
+func newOpenSSLCipher(password, salt []byte, o OpenSSLOptions) (*mycrypto.MyCipher, []byte, error) {
+	key, iv, err := OpenSSLKeyIV(password, salt, o)
+	if err != nil {
+		return nil, nil, err
+	}
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		return nil, nil, err
+	}
+	if err := mc.SetMode(mycrypto.ModeCBC); err != nil {
+		return nil, nil, err
+	}
+	return mc, iv, nil
+}
+
+// OpenSSLEncrypt шифрует plaintext в формате "openssl enc -salt": "Salted__" || соль || шифротекст CBC
+// с паддингом PKCS7 (IV не пишется, он выводится из пароля вместе с ключом). Соль берётся из mycrypto.Rand.
+func OpenSSLEncrypt(password, plaintext []byte, o OpenSSLOptions) ([]byte, error) {
+	salt, err := NewSalt(OpenSSLSaltSize)
+	if err != nil {
+		return nil, err
+	}
+	mc, iv, err := newOpenSSLCipher(password, salt, o)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := mc.Encrypt(plaintext, iv)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(OpenSSLMagic)+OpenSSLSaltSize+len(ct)-len(iv))
+	out = append(out, OpenSSLMagic...)
+	out = append(out, salt...)
+	return append(out, ct[len(iv):]...), nil
+}
+
+// OpenSSLDecrypt расшифровывает вывод "openssl enc -salt" или OpenSSLEncrypt с теми же параметрами.
+// Неверный пароль обычно обнаруживается как ошибка паддинга, но с вероятностью около 1/256 даёт мусор.
+func OpenSSLDecrypt(password, data []byte, o OpenSSLOptions) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(OpenSSLMagic)) {
+		return nil, fmt.Errorf("OpenSSL: missing %q header (file encrypted with -nosalt?)", OpenSSLMagic)
+	}
+	data = data[len(OpenSSLMagic):]
+	if len(data) < OpenSSLSaltSize {
+		return nil, errors.New("OpenSSL: truncated salt")
+	}
+	salt, ct := data[:OpenSSLSaltSize], data[OpenSSLSaltSize:]
+	mc, iv, err := newOpenSSLCipher(password, salt, o)
+	if err != nil {
+		return nil, err
+	}
+	return mc.Decrypt(ct, iv)
+}
@@ -0,0 +1,91 @@
+package mykdf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- PBKDF2-HMAC-SHA256 (RFC 8018, раздел 5.2) -----
+
+const (
+	// DefaultIterations - число итераций PBKDF2-HMAC-SHA256 по рекомендации OWASP (2023)
+	DefaultIterations = 600000
+	// SaltSize - длина соли, которую выдаёт NewSalt
+	SaltSize = 16
+)
+
+// NewSalt возвращает n случайных байт из mycrypto.Rand (0 - SaltSize)
+func NewSalt(n int) ([]byte, error) {
+	if n == 0 {
+		n = SaltSize
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("NewSalt: negative length %d", n)
+	}
+	salt := make([]byte, n)
+	if _, err := mycrypto.Rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("NewSalt: %v", err)
+	}
+	return salt, nil
+}
+
+// PBKDF2 вырабатывает keyLen байт из пароля и непустой соли (SP 800-132 требует соль от 128 бит,
+// короткие допускаются ради контрольных примеров): T_i = U_1 xor ... xor U_c,
+// U_1 = HMAC(P, S || INT(i)), U_j = HMAC(P, U_{j-1}); результат - T_1 || T_2 || ... до keyLen байт
+func PBKDF2(password, salt []byte, iterations, keyLen int) ([]byte, error) {
+	if iterations < 1 {
+		return nil, fmt.Errorf("PBKDF2: iterations must be positive, got %d", iterations)
+	}
+	if len(salt) == 0 {
+		return nil, fmt.Errorf("PBKDF2: empty salt")
+	}
+	if keyLen < 1 || uint64(keyLen) > (1<<32-1)*sha256.Size {
+		return nil, fmt.Errorf("PBKDF2: invalid key length %d", keyLen)
+	}
+	return pbkdf2(password, salt, iterations, keyLen), nil
+}
+
+// pbkdf2 - PBKDF2 без проверки параметров (scrypt вызывает его и с пустой солью)
+func pbkdf2(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	key := make([]byte, 0, (keyLen+sha256.Size-1)/sha256.Size*sha256.Size)
+	var ctr [4]byte
+	u := make([]byte, 0, sha256.Size)
+	for i := uint32(1); len(key) < keyLen; i++ {
+		binary.BigEndian.PutUint32(ctr[:], i)
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(ctr[:])
+		u = prf.Sum(u[:0])
+		t := append(key[len(key):], u...)
+		for j := 1; j < iterations; j++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			mycrypto.XORInto(t, u)
+		}
+		key = key[:len(key)+len(t)]
+	}
+	return key[:keyLen]
+}
+
+// PasswordKey вырабатывает ключ AES длины keyLen (16, 24 или 32 байта) для MyCipher.SetKey
+// со свежей солью; соль и число итераций нужно сохранить рядом с шифротекстом
+func PasswordKey(password []byte, keyLen, iterations int) (key, salt []byte, err error) {
+	switch keyLen {
+	case mycrypto.AESKeySize16, mycrypto.AESKeySize24, mycrypto.AESKeySize32:
+	default:
+		return nil, nil, fmt.Errorf("PasswordKey: invalid AES key length %d", keyLen)
+	}
+	if salt, err = NewSalt(SaltSize); err != nil {
+		return nil, nil, err
+	}
+	if key, err = PBKDF2(password, salt, iterations, keyLen); err != nil {
+		return nil, nil, err
+	}
+	return key, salt, nil
+}
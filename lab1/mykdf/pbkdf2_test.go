@@ -0,0 +1,49 @@
+package mykdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// pbkdf2Vectors - примеры в духе RFC 6070 (те же пароли и соли, но с SHA-256)
+// и два примера из RFC 7914, раздел 11; DK в hex
+var pbkdf2Vectors = []struct {
+	password, salt string
+	iterations     int
+	dk             string
+}{
+	{"password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+	{"password", "salt", 2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+	{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096,
+		"348c89dbcbd32b2f32d814b8116e84cf2b17347ebc1800181c4e2a1fb8dd53e1c635518c7dac47e9"},
+	{"pass\x00word", "sa\x00lt", 4096, "89b69d0516f829893c696226650a8687"},
+	{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+		"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+	{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56" +
+		"a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+}
+
+// mustHex декодирует hex-строку вектора
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestPBKDF2(t *testing.T) {
+	for i, v := range pbkdf2Vectors {
+		want := mustHex(t, v.dk)
+		got, err := PBKDF2([]byte(v.password), []byte(v.salt), v.iterations, len(want))
+		if err != nil {
+			t.Fatalf("vector #%d: %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("vector #%d (%q, %q, c=%d): got %x, want %s", i, v.password, v.salt, v.iterations, got, v.dk)
+		}
+	}
+}
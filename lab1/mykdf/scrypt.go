@@ -0,0 +1,116 @@
+package mykdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sync"
+)
+
+// ----- scrypt (RFC 7914) -----
+
+// ScryptParams - параметры scrypt: N - степень двойки (стоимость по памяти и времени, память 128*r*N байт),
+// r - размер блока, P - число независимых ROMix, которые выполняются параллельно
+type ScryptParams struct {
+	N, R, P int
+	KeyLen  int
+}
+
+// DefaultScryptParams - рекомендованные для интерактивного входа параметры: N = 2^15, r = 8 (32 МиБ)
+var DefaultScryptParams = ScryptParams{N: 1 << 15, R: 8, P: 1, KeyLen: 32}
+
+// salsa208 применяет Salsa20/8 к блоку из 16 слов на месте
+func salsa208(blk *[16]uint32) {
+	x := *blk
+	qr := func(a, b, c, d int) {
+		x[b] ^= bits.RotateLeft32(x[a]+x[d], 7)
+		x[c] ^= bits.RotateLeft32(x[b]+x[a], 9)
+		x[d] ^= bits.RotateLeft32(x[c]+x[b], 13)
+		x[a] ^= bits.RotateLeft32(x[d]+x[c], 18)
+	}
+	for i := 0; i < 8; i += 2 {
+		// столбцы
+		qr(0, 4, 8, 12)
+		qr(5, 9, 13, 1)
+		qr(10, 14, 2, 6)
+		qr(15, 3, 7, 11)
+		// строки
+		qr(0, 1, 2, 3)
+		qr(5, 6, 7, 4)
+		qr(10, 11, 8, 9)
+		qr(15, 12, 13, 14)
+	}
+	for i := range blk {
+		blk[i] += x[i]
+	}
+}
+
+// blockMix - scryptBlockMix над 2r блоками по 16 слов: Y_i = Salsa(X xor B_i), затем чётные Y, потом нечётные
+func blockMix(in, out []uint32, r int) {
+	var x [16]uint32
+	copy(x[:], in[(2*r-1)*16:])
+	for i := 0; i < 2*r; i++ {
+		for k := range x {
+			x[k] ^= in[i*16+k]
+		}
+		salsa208(&x)
+		dst := (i/2 + (i%2)*r) * 16
+		copy(out[dst:], x[:])
+	}
+}
+
+// roMix - scryptROMix: перемешивание блока b с таблицей из n предыдущих состояний
+func roMix(b []byte, r, n int) {
+	words := 32 * r
+	x := make([]uint32, words)
+	y := make([]uint32, words)
+	v := make([]uint32, words*n)
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	for i := 0; i < n; i++ {
+		copy(v[i*words:], x)
+		blockMix(x, y, r)
+		x, y = y, x
+	}
+	for i := 0; i < n; i++ {
+		j := int(x[(2*r-1)*16]) & (n - 1) // Integerify по модулю N; N - степень двойки
+		for k := range x {
+			x[k] ^= v[j*words+k]
+		}
+		blockMix(x, y, r)
+		x, y = y, x
+	}
+	for i, w := range x {
+		binary.LittleEndian.PutUint32(b[4*i:], w)
+	}
+}
+
+// Scrypt вычисляет ключ: B = PBKDF2(P, S, 1, p*128*r), каждый из p блоков проходит ROMix
+// в отдельной горутине, DK = PBKDF2(P, B, 1, KeyLen)
+func Scrypt(password, salt []byte, p ScryptParams) ([]byte, error) {
+	if p.N <= 1 || p.N&(p.N-1) != 0 {
+		return nil, fmt.Errorf("Scrypt: N must be a power of two greater than 1, got %d", p.N)
+	}
+	if p.R < 1 || p.P < 1 || uint64(p.R)*uint64(p.P) >= 1<<30 {
+		return nil, fmt.Errorf("Scrypt: invalid r=%d, p=%d", p.R, p.P)
+	}
+	if uint64(p.N) > (1<<62)/(128*uint64(p.R)) || p.N > 1<<31/(32*p.R) {
+		return nil, fmt.Errorf("Scrypt: N=%d, r=%d need too much memory", p.N, p.R)
+	}
+	if p.KeyLen < 1 {
+		return nil, fmt.Errorf("Scrypt: invalid key length %d", p.KeyLen)
+	}
+	blockLen := 128 * p.R
+	b := pbkdf2(password, salt, 1, p.P*blockLen)
+	var wg sync.WaitGroup
+	for i := 0; i < p.P; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			roMix(b[i*blockLen:(i+1)*blockLen], p.R, p.N)
+		}()
+	}
+	wg.Wait()
+	return pbkdf2(password, b, 1, p.KeyLen), nil
+}
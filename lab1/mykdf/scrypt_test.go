@@ -0,0 +1,35 @@
+package mykdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+// scryptVectors - примеры из RFC 7914, раздел 12 (кроме самого тяжёлого, N = 2^20); DK в hex
+var scryptVectors = []struct {
+	password, salt string
+	params         ScryptParams
+	dk             string
+}{
+	{"", "", ScryptParams{N: 16, R: 1, P: 1, KeyLen: 64},
+		"77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442" +
+			"fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+	{"password", "NaCl", ScryptParams{N: 1024, R: 8, P: 16, KeyLen: 64},
+		"fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b373162" +
+			"2eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+	{"pleaseletmein", "SodiumChloride", ScryptParams{N: 16384, R: 8, P: 1, KeyLen: 64},
+		"7023bdcb3afd7348461c06cd81fd38ebfda8fbba904f8e3ea9b543f6545da1f2" +
+			"d5432955613f0fcf62d49705242a9af9e61e85dc0d651e40dfcf017b45575887"},
+}
+
+func TestScrypt(t *testing.T) {
+	for i, v := range scryptVectors {
+		got, err := Scrypt([]byte(v.password), []byte(v.salt), v.params)
+		if err != nil {
+			t.Fatalf("vector #%d: %v", i, err)
+		}
+		if want := mustHex(t, v.dk); !bytes.Equal(got, want) {
+			t.Fatalf("vector #%d (%q, %q): got %x, want %s", i, v.password, v.salt, got, v.dk)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+//go:build detkeys
+
+package mykeys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/myrand"
+)
+
+// Enabled сообщает, собран ли пакет с тегом detkeys
+const Enabled = true
+
+// hkdfSalt разделяет зёрна этого пакета и любые другие применения тех же строк
+var hkdfSalt = []byte("mykeys deterministic keys v1")
+
+var warnOnce sync.Once
+
+// ----- Поток из зерна: HKDF-SHA256 -> HMAC_DRBG -----
+
+// hkdf - HKDF-SHA256 (RFC 5869): извлечение и расширение до n байт
+func hkdf(secret, salt, info []byte, n int) []byte {
+	m := hmac.New(sha256.New, salt)
+	m.Write(secret)
+	prk := m.Sum(nil)
+	var out, t []byte
+	for i := byte(1); len(out) < n; i++ {
+		m = hmac.New(sha256.New, prk)
+		m.Write(t)
+		m.Write(info)
+		m.Write([]byte{i})
+		t = m.Sum(nil)
+		out = append(out, t...)
+	}
+	return out[:n]
+}
+
+// NewReader возвращает детерминированный поток байт для зерна seed и метки label:
+// HKDF-SHA256 расширяет зерно в энтропию и nonce HMAC_DRBG, метка служит строкой
+// персонализации. Разные метки дают независимые потоки из одного зерна.
+func NewReader(seed []byte, label string) (io.Reader, error) {
+	if len(seed) == 0 {
+		return nil, errors.New("mykeys: empty seed")
+	}
+	warnOnce.Do(func() { fmt.Fprintln(os.Stderr, Warning) })
+	material := hkdf(seed, hkdfSalt, []byte(label), 32+16)
+	return myrand.NewHMACDRBG(material[:32], material[32:], []byte(label))
+}
+
+// ----- RSA -----
+
+// rsaE - открытая экспонента F4
+const rsaE = 65537
+
+// prime ищет простое длиной ровно bits бит с двумя старшими единичными битами
+// (произведение двух таких чисел имеет полную длину) и p-1, взаимно простым с rsaE.
+// ProbablyPrime детерминирован, поэтому результат зависит только от потока r.
+func prime(r io.Reader, bits int) (*big.Int, error) {
+	buf := make([]byte, (bits+7)/8)
+	top := uint(bits % 8)
+	if top == 0 {
+		top = 8
+	}
+	e := big.NewInt(rsaE)
+	p, rem := new(big.Int), new(big.Int)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		buf[0] &= byte(int(1<<top) - 1)
+		if top >= 2 {
+			buf[0] |= 3 << (top - 2)
+		} else {
+			buf[0] |= 1
+			buf[1] |= 0x80
+		}
+		buf[len(buf)-1] |= 1
+		p.SetBytes(buf)
+		// отбраковка и ProbablyPrime работают с секретным кандидатом за переменное время
+		mycrypto.AuditPoint("mykeys.prime/candidate")
+		if rem.Mod(p, e).Int64() == 1 {
+			continue
+		}
+		if p.ProbablyPrime(20) {
+			return p, nil
+		}
+	}
+}
+
+// GenerateRSA строит ключ RSA длиной bits с e = 65537 из потока r.
+// В отличие от rsa.GenerateKey, который намеренно не детерминирован при одинаковом
+// источнике, результат полностью определяется байтами r.
+func GenerateRSA(r io.Reader, bits int) (*rsa.PrivateKey, error) {
+	if bits < 64 {
+		return nil, fmt.Errorf("mykeys: RSA key of %d bits is too small", bits)
+	}
+	e := big.NewInt(rsaE)
+	one := big.NewInt(1)
+	for {
+		p, err := prime(r, (bits+1)/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := prime(r, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != bits {
+			continue
+		}
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		mycrypto.AuditPoint("mykeys.GenerateRSA/ModInverse")
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: rsaE},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		key.Precompute()
+		return key, key.Validate()
+	}
+}
+
+// RSA выводит ключ RSA длиной bits из зерна и метки
+func RSA(seed []byte, label string, bits int) (*rsa.PrivateKey, error) {
+	r, err := NewReader(seed, fmt.Sprintf("rsa-%d/%s", bits, label))
+	if err != nil {
+		return nil, err
+	}
+	return GenerateRSA(r, bits)
+}
+
+// ----- Эллиптические кривые -----
+
+// GenerateECDSA выбирает скаляр d из [1, n-1] отбраковкой: байты r обрезаются до длины
+// порядка n, значения вне диапазона отбрасываются, поэтому распределение равномерно.
+func GenerateECDSA(r io.Reader, curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	params := curve.Params()
+	bits := params.N.BitLen()
+	buf := make([]byte, (bits+7)/8)
+	d := new(big.Int)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if extra := uint(len(buf)*8 - bits); extra > 0 {
+			buf[0] &= 0xff >> extra
+		}
+		d.SetBytes(buf)
+		mycrypto.AuditPoint("mykeys.GenerateECDSA/rejection")
+		if d.Sign() > 0 && d.Cmp(params.N) < 0 {
+			break
+		}
+	}
+	key := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve}, D: d}
+	key.X, key.Y = curve.ScalarBaseMult(d.FillBytes(make([]byte, len(buf))))
+	return key, nil
+}
+
+// ECDSA выводит ключ ECDSA на кривой curve из зерна и метки
+func ECDSA(seed []byte, label string, curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	r, err := NewReader(seed, fmt.Sprintf("ecdsa-%s/%s", curve.Params().Name, label))
+	if err != nil {
+		return nil, err
+	}
+	return GenerateECDSA(r, curve)
+}
+
+// Ed25519 выводит ключ Ed25519 из зерна и метки: 32 байта потока служат его seed
+func Ed25519(seed []byte, label string) (ed25519.PrivateKey, error) {
+	r, err := NewReader(seed, "ed25519/"+label)
+	if err != nil {
+		return nil, err
+	}
+	s := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(r, s); err != nil {
+		return nil, err
+	}
+	return ed25519.NewKeyFromSeed(s), nil
+}
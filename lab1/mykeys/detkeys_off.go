@@ -0,0 +1,36 @@
+//go:build !detkeys
+
+package mykeys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"io"
+)
+
+// Enabled сообщает, собран ли пакет с тегом detkeys
+const Enabled = false
+
+// NewReader без тега detkeys всегда возвращает ErrDisabled
+func NewReader(seed []byte, label string) (io.Reader, error) { return nil, ErrDisabled }
+
+// GenerateRSA без тега detkeys всегда возвращает ErrDisabled
+func GenerateRSA(r io.Reader, bits int) (*rsa.PrivateKey, error) { return nil, ErrDisabled }
+
+// RSA без тега detkeys всегда возвращает ErrDisabled
+func RSA(seed []byte, label string, bits int) (*rsa.PrivateKey, error) { return nil, ErrDisabled }
+
+// GenerateECDSA без тега detkeys всегда возвращает ErrDisabled
+func GenerateECDSA(r io.Reader, curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	return nil, ErrDisabled
+}
+
+// ECDSA без тега detkeys всегда возвращает ErrDisabled
+func ECDSA(seed []byte, label string, curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	return nil, ErrDisabled
+}
+
+// Ed25519 без тега detkeys всегда возвращает ErrDisabled
+func Ed25519(seed []byte, label string) (ed25519.PrivateKey, error) { return nil, ErrDisabled }
@@ -0,0 +1,16 @@
+// Package mykeys - детерминированная выработка асимметричных ключей из зерна
+// для воспроизводимых лабораторных работ и тестовых фикстур.
+//
+// ВНИМАНИЕ: ключ, выведенный из зерна, ровно настолько секретен, насколько секретно зерно.
+// Зёрна вида "lab4-alice" перебираются мгновенно, поэтому такие ключи нельзя использовать
+// ни для чего, кроме учебных примеров и тестов. Пакет работает только в сборке с тегом
+// detkeys (go test -tags detkeys ...); в обычной сборке все функции возвращают ErrDisabled.
+package mykeys
+
+import "errors"
+
+// Warning печатается в stderr при первой выработке ключа из зерна
+const Warning = "mykeys: WARNING: deterministic keys derived from a seed are NOT secret; use them only in labs and test fixtures"
+
+// ErrDisabled возвращается в сборке без тега detkeys
+var ErrDisabled = errors.New("mykeys: deterministic keys are only available in builds with -tags detkeys")
@@ -0,0 +1,179 @@
+package myqr
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math/bits"
+)
+
+// ----- Декодирование: матрица модулей, затем изображение без поворота и перспективы -----
+
+// ErrNotFound - на изображении не найден QR-код
+var ErrNotFound = errors.New("myqr: no QR code found")
+
+// DecodeMatrix декодирует код по матрице модулей (modules[y][x], true - тёмный),
+// исправляя ошибки кодом Рида–Соломона
+func DecodeMatrix(modules [][]bool) ([]byte, error) {
+	size := len(modules)
+	version := (size - 17) / 4
+	if size < 21 || (size-17)%4 != 0 || version > MaxVersion {
+		return nil, fmt.Errorf("myqr: unsupported symbol size %d", size)
+	}
+	for _, row := range modules {
+		if len(row) != size {
+			return nil, errors.New("myqr: matrix is not square")
+		}
+	}
+
+	// Информация о формате: ближайшее по Хэммингу из 32 допустимых слов в любой из двух копий
+	var f1, f2 int
+	for i := 0; i < 15; i++ {
+		x1, y1, x2, y2 := formatPositions(size, i)
+		if modules[y1][x1] {
+			f1 |= 1 << uint(i)
+		}
+		if modules[y2][x2] {
+			f2 |= 1 << uint(i)
+		}
+	}
+	level, mask, bestDist := LevelL, 0, 16
+	for l := LevelL; l <= LevelH; l++ {
+		for m := 0; m < 8; m++ {
+			w := formatWord(l, m)
+			if d := min(bits.OnesCount(uint(w^f1)), bits.OnesCount(uint(w^f2))); d < bestDist {
+				level, mask, bestDist = l, m, d
+			}
+		}
+	}
+	if bestDist > 3 {
+		return nil, errors.New("myqr: unreadable format information")
+	}
+
+	// Кодовые слова в порядке размещения с учётом маски
+	_, function := newMatrix(version)
+	var raw bitWriter
+	zigzag(function, func(x, y int) {
+		b := 0
+		if modules[y][x] != maskBit(mask, x, y) {
+			b = 1
+		}
+		raw.write(b, 1)
+	})
+
+	// Обратное чередование и исправление ошибок в каждом блоке
+	lens, ecc := blockLayout(version, level)
+	blocks := make([][]byte, len(lens))
+	k := 0
+	for i := 0; i < lens[len(lens)-1]; i++ {
+		for b, n := range lens {
+			if i < n {
+				blocks[b] = append(blocks[b], raw.buf[k])
+				k++
+			}
+		}
+	}
+	for i := 0; i < ecc; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], raw.buf[k])
+			k++
+		}
+	}
+	var data []byte
+	for b, n := range lens {
+		if _, err := rsCorrect(blocks[b], ecc); err != nil {
+			return nil, err
+		}
+		data = append(data, blocks[b][:n]...)
+	}
+
+	// Сегменты: поддерживается только байтовый режим, 0000 - терминатор
+	r := bitReader{buf: data}
+	var out []byte
+	for r.left() >= 4 {
+		mode := r.read(4)
+		if mode == 0 {
+			break
+		}
+		if mode != 0b0100 {
+			return nil, fmt.Errorf("myqr: unsupported segment mode %04b", mode)
+		}
+		n := r.read(countBits(version))
+		if r.left() < 8*n {
+			return nil, errors.New("myqr: segment longer than data")
+		}
+		for i := 0; i < n; i++ {
+			out = append(out, byte(r.read(8)))
+		}
+	}
+	return out, nil
+}
+
+type bitReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *bitReader) left() int {
+	return len(r.buf)*8 - r.pos
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		v = v<<1 | int(r.buf[r.pos/8]>>uint(7-r.pos%8)&1)
+		r.pos++
+	}
+	return v
+}
+
+// Decode находит код на изображении и декодирует его. Изображение должно быть выровнено
+// по осям и равномерно масштабировано (как результат Code.Image или снимок экрана):
+// размер модуля определяется по верхней стороне левого верхнего поискового узора,
+// размер символа - по правому краю правого верхнего.
+func Decode(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	dark := func(x, y int) bool {
+		r, g, bl, _ := img.At(x, y).RGBA()
+		return (299*r+587*g+114*bl)/1000 < 0x8000
+	}
+	x0, y0 := -1, -1
+	for y := b.Min.Y; y < b.Max.Y && x0 < 0; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if dark(x, y) {
+				x0, y0 = x, y
+				break
+			}
+		}
+	}
+	if x0 < 0 {
+		return nil, ErrNotFound
+	}
+	run := 0
+	for x := x0; x < b.Max.X && dark(x, y0); x++ {
+		run++
+	}
+	x1 := x0
+	for x := b.Max.X - 1; x > x0; x-- {
+		if dark(x, y0) {
+			x1 = x
+			break
+		}
+	}
+	scale := float64(run) / 7
+	size := int(float64(x1-x0+1)/scale + 0.5)
+	if run < 7 || size < 21 || (size-17)%4 != 0 {
+		return nil, ErrNotFound
+	}
+	if y0+int(float64(size)*scale) > b.Max.Y {
+		return nil, ErrNotFound
+	}
+	modules := make([][]bool, size)
+	for my := range modules {
+		modules[my] = make([]bool, size)
+		for mx := range modules[my] {
+			modules[my][mx] = dark(x0+int((float64(mx)+0.5)*scale), y0+int((float64(my)+0.5)*scale))
+		}
+	}
+	return DecodeMatrix(modules)
+}
@@ -0,0 +1,500 @@
+package myqr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// ----- QR-код (ISO/IEC 18004), байтовый режим, версии 1-10 -----
+
+// MaxVersion - наибольшая поддерживаемая версия (57x57 модулей, до 271 байта при уровне L)
+const MaxVersion = 10
+
+// QuietZone - ширина свободного поля вокруг символа в модулях
+const QuietZone = 4
+
+// Level - уровень коррекции ошибок
+type Level int
+
+const (
+	LevelL Level = iota // ~7% кодовых слов
+	LevelM              // ~15%
+	LevelQ              // ~25%
+	LevelH              // ~30%
+)
+
+func (l Level) String() string {
+	if l < LevelL || l > LevelH {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return "LMQH"[l : l+1]
+}
+
+// formatBits - код уровня в служебной информации о формате
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// ecBlocks - разбиение кодовых слов версии на блоки: проверочных байтов на блок,
+// затем число блоков и длина данных в первой и второй группах
+type ecBlocks struct {
+	ecc, n1, d1, n2, d2 int
+}
+
+// ecTable[уровень][версия-1]
+var ecTable = [4][MaxVersion]ecBlocks{
+	{{7, 1, 19, 0, 0}, {10, 1, 34, 0, 0}, {15, 1, 55, 0, 0}, {20, 1, 80, 0, 0}, {26, 1, 108, 0, 0},
+		{18, 2, 68, 0, 0}, {20, 2, 78, 0, 0}, {24, 2, 97, 0, 0}, {30, 2, 116, 0, 0}, {18, 2, 68, 2, 69}},
+	{{10, 1, 16, 0, 0}, {16, 1, 28, 0, 0}, {26, 1, 44, 0, 0}, {18, 2, 32, 0, 0}, {24, 2, 43, 0, 0},
+		{16, 4, 27, 0, 0}, {18, 4, 31, 0, 0}, {22, 2, 38, 2, 39}, {22, 3, 36, 2, 37}, {26, 4, 43, 1, 44}},
+	{{13, 1, 13, 0, 0}, {22, 1, 22, 0, 0}, {18, 2, 17, 0, 0}, {26, 2, 24, 0, 0}, {18, 2, 15, 2, 16},
+		{24, 4, 19, 0, 0}, {18, 2, 14, 4, 15}, {22, 4, 18, 2, 19}, {20, 4, 16, 4, 17}, {24, 6, 19, 2, 20}},
+	{{17, 1, 9, 0, 0}, {28, 1, 16, 0, 0}, {22, 2, 13, 0, 0}, {16, 4, 9, 0, 0}, {22, 2, 11, 2, 12},
+		{28, 4, 15, 0, 0}, {26, 4, 13, 1, 14}, {26, 4, 14, 2, 15}, {24, 4, 12, 4, 13}, {28, 6, 15, 2, 16}},
+}
+
+// blockLayout возвращает длины данных всех блоков и число проверочных байтов на блок
+func blockLayout(version int, level Level) ([]int, int) {
+	e := ecTable[level][version-1]
+	var lens []int
+	for i := 0; i < e.n1; i++ {
+		lens = append(lens, e.d1)
+	}
+	for i := 0; i < e.n2; i++ {
+		lens = append(lens, e.d2)
+	}
+	return lens, e.ecc
+}
+
+func dataCapacity(version int, level Level) int {
+	e := ecTable[level][version-1]
+	return e.n1*e.d1 + e.n2*e.d2
+}
+
+// countBits - длина поля счётчика символов байтового режима
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// Capacity возвращает наибольшую длину данных (байт) для версии и уровня
+func Capacity(version int, level Level) int {
+	return (dataCapacity(version, level)*8 - 4 - countBits(version)) / 8
+}
+
+// Code - построенный QR-код
+type Code struct {
+	Version int
+	Level   Level
+	Mask    int
+	Size    int
+	modules [][]bool
+}
+
+// Dark сообщает, тёмный ли модуль в столбце x строки y
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// Encode кодирует данные в наименьшую подходящую версию для уровня коррекции level
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < LevelL || level > LevelH {
+		return nil, fmt.Errorf("myqr: invalid level %d", level)
+	}
+	version := 0
+	for v := 1; v <= MaxVersion; v++ {
+		if len(data) <= Capacity(v, level) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("myqr: %d bytes do not fit version %d-%s (at most %d bytes)", len(data), MaxVersion, level, Capacity(MaxVersion, level))
+	}
+
+	// Поток битов: режим 0100, длина, данные, терминатор и байты-заполнители 0xec, 0x11
+	var bits bitWriter
+	bits.write(0b0100, 4)
+	bits.write(len(data), countBits(version))
+	for _, b := range data {
+		bits.write(int(b), 8)
+	}
+	capBits := dataCapacity(version, level) * 8
+	bits.write(0, min(4, capBits-bits.n))
+	bits.write(0, (8-bits.n%8)%8)
+	for pad := 0xec; bits.n < capBits; pad ^= 0xec ^ 0x11 {
+		bits.write(pad, 8)
+	}
+
+	// Блоки с проверочными байтами, чередование кодовых слов
+	lens, ecc := blockLayout(version, level)
+	var blocks, checks [][]byte
+	off := 0
+	for _, n := range lens {
+		blocks = append(blocks, bits.buf[off:off+n])
+		checks = append(checks, rsEncode(bits.buf[off:off+n], ecc))
+		off += n
+	}
+	codewords := interleave(blocks)
+	codewords = append(codewords, interleave(checks)...)
+
+	c := &Code{Version: version, Level: level, Size: 17 + 4*version}
+	base, function := newMatrix(version)
+	placeData(base, function, codewords)
+
+	best := -1
+	for mask := 0; mask < 8; mask++ {
+		m := cloneMatrix(base)
+		applyMask(m, function, mask)
+		drawFormat(m, level, mask)
+		if p := penalty(m); best < 0 || p < best {
+			best, c.Mask, c.modules = p, mask, m
+		}
+	}
+	return c, nil
+}
+
+func interleave(blocks [][]byte) []byte {
+	var out []byte
+	for i := 0; ; i++ {
+		added := false
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+				added = true
+			}
+		}
+		if !added {
+			return out
+		}
+	}
+}
+
+type bitWriter struct {
+	buf []byte
+	n   int
+}
+
+func (w *bitWriter) write(v, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.buf[w.n/8] |= 0x80 >> uint(w.n%8)
+		}
+		w.n++
+	}
+}
+
+func cloneMatrix(m [][]bool) [][]bool {
+	out := make([][]bool, len(m))
+	for i := range m {
+		out[i] = append([]bool(nil), m[i]...)
+	}
+	return out
+}
+
+// alignmentPositions возвращает координаты центров выравнивающих узоров по одной оси
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, version*4+10; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// newMatrix строит служебные узоры версии; function отмечает модули, не несущие данных
+// (включая зарезервированные под информацию о формате и версии)
+func newMatrix(version int) (modules, function [][]bool) {
+	size := 17 + 4*version
+	modules = make([][]bool, size)
+	function = make([][]bool, size)
+	for i := range modules {
+		modules[i] = make([]bool, size)
+		function[i] = make([]bool, size)
+	}
+	set := func(x, y int, dark bool) {
+		modules[y][x] = dark
+		function[y][x] = true
+	}
+	// Синхронизирующие линии
+	for i := 0; i < size; i++ {
+		set(6, i, i%2 == 0)
+		set(i, 6, i%2 == 0)
+	}
+	// Поисковые узоры с разделителями
+	for _, c := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || x >= size || y < 0 || y >= size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				set(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+	// Выравнивающие узоры, кроме перекрывающихся с поисковыми
+	pos := alignmentPositions(version)
+	for i, py := range pos {
+		for j, px := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == len(pos)-1) || (i == len(pos)-1 && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					set(px+dx, py+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	// Резерв под формат (заполняется drawFormat) и тёмный модуль
+	for i := 0; i < 9; i++ {
+		function[8][i] = true
+		function[i][8] = true
+	}
+	for i := 0; i < 8; i++ {
+		function[8][size-1-i] = true
+		function[size-1-i][8] = true
+	}
+	set(8, size-8, true)
+	// Информация о версии (версии 7 и выше): BCH(18, 6)
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1f25
+		}
+		v := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			a, b := size-11+i%3, i/3
+			set(a, b, v>>uint(i)&1 == 1)
+			set(b, a, v>>uint(i)&1 == 1)
+		}
+	}
+	return modules, function
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// zigzag обходит модули данных в порядке размещения: пары столбцов справа налево, змейкой вверх и вниз
+func zigzag(function [][]bool, visit func(x, y int)) {
+	size := len(function)
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < size; vert++ {
+			y := vert
+			if upward {
+				y = size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !function[y][x] {
+					visit(x, y)
+				}
+			}
+		}
+	}
+}
+
+func placeData(modules, function [][]bool, codewords []byte) {
+	i := 0
+	zigzag(function, func(x, y int) {
+		if i < len(codewords)*8 {
+			modules[y][x] = codewords[i/8]>>uint(7-i%8)&1 == 1
+		}
+		i++ // оставшиеся биты-остатки светлые
+	})
+}
+
+// maskBit - условие инвертирования модуля для шаблона маски
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+func applyMask(modules, function [][]bool, mask int) {
+	for y := range modules {
+		for x := range modules[y] {
+			if !function[y][x] && maskBit(mask, x, y) {
+				modules[y][x] = !modules[y][x]
+			}
+		}
+	}
+}
+
+// formatWord - 15-битная информация о формате: уровень и маска, BCH(15, 5) и маска 0x5412
+func formatWord(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// formatPositions возвращает координаты бита i информации о формате в обеих копиях
+func formatPositions(size, i int) (x1, y1, x2, y2 int) {
+	switch {
+	case i < 6:
+		x1, y1 = 8, i
+	case i < 8:
+		x1, y1 = 8, i+1
+	case i == 8:
+		x1, y1 = 7, 8
+	default:
+		x1, y1 = 14-i, 8
+	}
+	if i < 8 {
+		x2, y2 = size-1-i, 8
+	} else {
+		x2, y2 = 8, size-15+i
+	}
+	return
+}
+
+func drawFormat(modules [][]bool, level Level, mask int) {
+	f := formatWord(level, mask)
+	for i := 0; i < 15; i++ {
+		x1, y1, x2, y2 := formatPositions(len(modules), i)
+		modules[y1][x1] = f>>uint(i)&1 == 1
+		modules[y2][x2] = f>>uint(i)&1 == 1
+	}
+}
+
+// penalty оценивает маску по четырём правилам стандарта: серии, квадраты 2x2,
+// узоры, похожие на поисковые, и отклонение доли тёмных модулей от 50%
+func penalty(m [][]bool) int {
+	size := len(m)
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return m[x][y]
+		}
+		return m[y][x]
+	}
+	score := 0
+	finder := []bool{true, false, true, true, true, false, true, false, false, false, false}
+	for _, tr := range []bool{false, true} {
+		for y := 0; y < size; y++ {
+			run := 1
+			for x := 1; x <= size; x++ {
+				if x < size && at(x, y, tr) == at(x-1, y, tr) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			for x := 0; x+len(finder) <= size; x++ {
+				fwd, back := true, true
+				for k, f := range finder {
+					fwd = fwd && at(x+k, y, tr) == f
+					back = back && at(x+len(finder)-1-k, y, tr) == f
+				}
+				if fwd {
+					score += 40
+				}
+				if back {
+					score += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if m[y][x] {
+				dark++
+			}
+			if x+1 < size && y+1 < size && m[y][x] == m[y][x+1] && m[y][x] == m[y+1][x] && m[y][x] == m[y+1][x+1] {
+				score += 3
+			}
+		}
+	}
+	score += abs(dark*100/(size*size)-50) / 5 * 10
+	return score
+}
+
+// Image возвращает изображение кода: scale пикселей на модуль и свободное поле QuietZone
+func (c *Code) Image(scale int) *image.Gray {
+	if scale < 1 {
+		scale = 1
+	}
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			mx, my := x/scale-QuietZone, y/scale-QuietZone
+			v := uint8(255)
+			if mx >= 0 && mx < c.Size && my >= 0 && my < c.Size && c.modules[my][mx] {
+				v = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+// PNG кодирует изображение кода в PNG
+func (c *Code) PNG(scale int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.Image(scale)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// String рисует код в терминале: два символа на модуль, светлые модули - сплошные блоки
+// (для тёмного фона терминала)
+func (c *Code) String() string {
+	var sb strings.Builder
+	for y := -QuietZone; y < c.Size+QuietZone; y++ {
+		for x := -QuietZone; x < c.Size+QuietZone; x++ {
+			if x >= 0 && x < c.Size && y >= 0 && y < c.Size && c.modules[y][x] {
+				sb.WriteString("  ")
+			} else {
+				sb.WriteString("██")
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,166 @@
+package myqr
+
+import "errors"
+
+// ----- Код Рида–Соломона над GF(2^8) с многочленом x^8 + x^4 + x^3 + x^2 + 1 (0x11d) -----
+
+// errUncorrectable - в блоке больше ошибок, чем может исправить код
+var errUncorrectable = errors.New("myqr: too many errors to correct")
+
+var gfExp [512]byte
+var gfLog [256]int
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+// gfPow возвращает α^e
+func gfPow(e int) byte {
+	e %= 255
+	if e < 0 {
+		e += 255
+	}
+	return gfExp[e]
+}
+
+// rsGenerator возвращает порождающий многочлен (x - α^0)...(x - α^(n-1)), старший коэффициент первым
+func rsGenerator(n int) []byte {
+	g := []byte{1}
+	for i := 0; i < n; i++ {
+		next := make([]byte, len(g)+1)
+		for j, c := range g {
+			next[j] ^= c
+			next[j+1] ^= gfMul(c, gfPow(i))
+		}
+		g = next
+	}
+	return g
+}
+
+// rsEncode возвращает n проверочных байтов для данных (остаток от деления data(x)·x^n на порождающий многочлен)
+func rsEncode(data []byte, n int) []byte {
+	g := rsGenerator(n)
+	rem := make([]byte, n)
+	for _, d := range data {
+		f := d ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for j := 0; j < n; j++ {
+			rem[j] ^= gfMul(g[j+1], f)
+		}
+	}
+	return rem
+}
+
+// rsCorrect исправляет на месте до n/2 ошибок в блоке data || ecc с n проверочными байтами
+// (Берлекэмп–Мэсси, поиск Ченя, формула Форни) и возвращает число исправленных байтов
+func rsCorrect(block []byte, n int) (int, error) {
+	// Синдромы S_j = R(α^j); блок - многочлен со старшим коэффициентом block[0]
+	synd := make([]byte, n)
+	clean := true
+	for j := 0; j < n; j++ {
+		var s byte
+		for _, c := range block {
+			s = gfMul(s, gfPow(j)) ^ c
+		}
+		synd[j] = s
+		clean = clean && s == 0
+	}
+	if clean {
+		return 0, nil
+	}
+
+	// Берлекэмп–Мэсси: многочлен локаторов ошибок Λ (младший коэффициент первым)
+	lambda, prev := []byte{1}, []byte{1}
+	l, m, b := 0, 1, byte(1)
+	for k := 0; k < n; k++ {
+		d := synd[k]
+		for i := 1; i <= l && i < len(lambda); i++ {
+			d ^= gfMul(lambda[i], synd[k-i])
+		}
+		if d == 0 {
+			m++
+			continue
+		}
+		coef := gfDiv(d, b)
+		next := make([]byte, max(len(lambda), len(prev)+m))
+		copy(next, lambda)
+		for i, c := range prev {
+			next[i+m] ^= gfMul(coef, c)
+		}
+		if 2*l <= k {
+			prev, l, b, m = lambda, k+1-l, d, 1
+		} else {
+			m++
+		}
+		lambda = next
+	}
+	if 2*l > n {
+		return 0, errUncorrectable
+	}
+
+	// Ω(x) = S(x)Λ(x) mod x^n
+	omega := make([]byte, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i && j < len(lambda); j++ {
+			omega[i] ^= gfMul(lambda[j], synd[i-j])
+		}
+	}
+	eval := func(p []byte, x byte) byte {
+		var y byte
+		for i := len(p) - 1; i >= 0; i-- {
+			y = gfMul(y, x) ^ p[i]
+		}
+		return y
+	}
+	// Λ'(x): в характеристике 2 остаются только нечётные степени
+	deriv := make([]byte, len(lambda))
+	for i := 1; i < len(lambda); i += 2 {
+		deriv[i-1] = lambda[i]
+	}
+
+	// Поиск Ченя: ошибка в степени p, если Λ(α^-p) = 0; величина по Форни Y = X·Ω(X^-1)/Λ'(X^-1)
+	found := 0
+	for idx := range block {
+		p := len(block) - 1 - idx
+		xInv := gfPow(-p)
+		if eval(lambda, xInv) != 0 {
+			continue
+		}
+		den := eval(deriv, xInv)
+		if den == 0 {
+			return 0, errUncorrectable
+		}
+		block[idx] ^= gfMul(gfPow(p), gfDiv(eval(omega, xInv), den))
+		found++
+	}
+	if found != l {
+		return 0, errUncorrectable
+	}
+	return found, nil
+}
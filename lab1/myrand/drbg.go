@@ -0,0 +1,233 @@
+package myrand
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// ----- Детерминированные генераторы NIST SP 800-90A -----
+
+const (
+	// DRBGMaxRequest - наибольший запрос Generate в байтах (2^19 бит)
+	DRBGMaxRequest = 1 << 16
+	// drbgReseedInterval - число запросов до обязательного пересева
+	drbgReseedInterval = 1 << 48
+	// CTRDRBGSeedSize - длина зерна CTR_DRBG на AES-256 без функции выработки: ключ и блок V
+	CTRDRBGSeedSize = 32 + aes.BlockSize
+)
+
+// ErrReseedRequired возвращается, когда генератор исчерпал интервал пересева
+var ErrReseedRequired = errors.New("DRBG: reseed required")
+
+// HMACDRBG - HMAC_DRBG на SHA-256 (SP 800-90A, раздел 10.1.2) без устойчивости к предсказанию
+type HMACDRBG struct {
+	k, v          []byte
+	reseedCounter uint64
+}
+
+// NewHMACDRBG инициализирует генератор энтропией (не меньше 32 байт), nonce и строкой персонализации
+func NewHMACDRBG(entropy, nonce, personalization []byte) (*HMACDRBG, error) {
+	if len(entropy) < sha256.Size {
+		return nil, fmt.Errorf("HMAC_DRBG: need at least %d bytes of entropy, got %d", sha256.Size, len(entropy))
+	}
+	d := &HMACDRBG{k: make([]byte, sha256.Size), v: make([]byte, sha256.Size)}
+	for i := range d.v {
+		d.v[i] = 0x01
+	}
+	d.update(entropy, nonce, personalization)
+	d.reseedCounter = 1
+	return d, nil
+}
+
+// hmacSum вычисляет HMAC-SHA256(key, parts...)
+func hmacSum(key []byte, parts ...[]byte) []byte {
+	m := hmac.New(sha256.New, key)
+	for _, p := range parts {
+		m.Write(p)
+	}
+	return m.Sum(nil)
+}
+
+// update - функция HMAC_DRBG_Update над конкатенацией data
+func (d *HMACDRBG) update(data ...[]byte) {
+	provided := 0
+	for _, p := range data {
+		provided += len(p)
+	}
+	for _, sep := range []byte{0x00, 0x01} {
+		if sep == 0x01 && provided == 0 {
+			return
+		}
+		d.k = hmacSum(d.k, append([][]byte{d.v, {sep}}, data...)...)
+		d.v = hmacSum(d.k, d.v)
+	}
+}
+
+// Reseed добавляет свежую энтропию
+func (d *HMACDRBG) Reseed(entropy, additional []byte) error {
+	if len(entropy) < sha256.Size {
+		return fmt.Errorf("HMAC_DRBG: need at least %d bytes of entropy, got %d", sha256.Size, len(entropy))
+	}
+	d.update(entropy, additional)
+	d.reseedCounter = 1
+	return nil
+}
+
+// Generate заполняет out (не длиннее DRBGMaxRequest) псевдослучайными байтами
+func (d *HMACDRBG) Generate(out, additional []byte) error {
+	if len(out) > DRBGMaxRequest {
+		return fmt.Errorf("HMAC_DRBG: request of %d bytes exceeds %d", len(out), DRBGMaxRequest)
+	}
+	if d.reseedCounter > drbgReseedInterval {
+		return ErrReseedRequired
+	}
+	if len(additional) > 0 {
+		d.update(additional)
+	}
+	for off := 0; off < len(out); off += len(d.v) {
+		d.v = hmacSum(d.k, d.v)
+		copy(out[off:], d.v)
+	}
+	d.update(additional)
+	d.reseedCounter++
+	return nil
+}
+
+// Read выдаёт поток генератора запросами по DRBGMaxRequest байт
+func (d *HMACDRBG) Read(p []byte) (int, error) {
+	return drbgRead(p, d.Generate)
+}
+
+// CTRDRBG - CTR_DRBG на AES-256 без функции выработки (SP 800-90A, раздел 10.2.1)
+type CTRDRBG struct {
+	block         cipher.Block
+	v             [aes.BlockSize]byte
+	reseedCounter uint64
+}
+
+// NewCTRDRBG инициализирует генератор зерном из ровно CTRDRBGSeedSize байт полной энтропии.
+// Строка персонализации (не длиннее зерна) складывается с ним по XOR.
+func NewCTRDRBG(entropy, personalization []byte) (*CTRDRBG, error) {
+	seed, err := ctrSeed(entropy, personalization)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(make([]byte, 32))
+	if err != nil {
+		return nil, err
+	}
+	d := &CTRDRBG{block: block}
+	if err := d.update(seed); err != nil {
+		return nil, err
+	}
+	d.reseedCounter = 1
+	return d, nil
+}
+
+// ctrSeed проверяет длины и возвращает entropy XOR additional (дополненный нулями)
+func ctrSeed(entropy, additional []byte) ([]byte, error) {
+	if len(entropy) != CTRDRBGSeedSize {
+		return nil, fmt.Errorf("CTR_DRBG: entropy must be %d bytes, got %d", CTRDRBGSeedSize, len(entropy))
+	}
+	if len(additional) > CTRDRBGSeedSize {
+		return nil, fmt.Errorf("CTR_DRBG: additional input longer than %d bytes", CTRDRBGSeedSize)
+	}
+	seed := append([]byte{}, entropy...)
+	for i, b := range additional {
+		seed[i] ^= b
+	}
+	return seed, nil
+}
+
+// incV увеличивает счётчик V на единицу (big-endian)
+func (d *CTRDRBG) incV() {
+	for i := len(d.v) - 1; i >= 0; i-- {
+		d.v[i]++
+		if d.v[i] != 0 {
+			return
+		}
+	}
+}
+
+// update - функция CTR_DRBG_Update: новые K и V - гамма на старых, сложенная с provided
+func (d *CTRDRBG) update(provided []byte) error {
+	temp := make([]byte, CTRDRBGSeedSize)
+	for off := 0; off < len(temp); off += aes.BlockSize {
+		d.incV()
+		d.block.Encrypt(temp[off:], d.v[:])
+	}
+	for i, b := range provided {
+		temp[i] ^= b
+	}
+	block, err := aes.NewCipher(temp[:32])
+	if err != nil {
+		return err
+	}
+	d.block = block
+	copy(d.v[:], temp[32:])
+	return nil
+}
+
+// Reseed добавляет свежую энтропию (CTRDRBGSeedSize байт)
+func (d *CTRDRBG) Reseed(entropy, additional []byte) error {
+	seed, err := ctrSeed(entropy, additional)
+	if err != nil {
+		return err
+	}
+	if err := d.update(seed); err != nil {
+		return err
+	}
+	d.reseedCounter = 1
+	return nil
+}
+
+// Generate заполняет out (не длиннее DRBGMaxRequest) псевдослучайными байтами
+func (d *CTRDRBG) Generate(out, additional []byte) error {
+	if len(out) > DRBGMaxRequest {
+		return fmt.Errorf("CTR_DRBG: request of %d bytes exceeds %d", len(out), DRBGMaxRequest)
+	}
+	if d.reseedCounter > drbgReseedInterval {
+		return ErrReseedRequired
+	}
+	add := make([]byte, CTRDRBGSeedSize)
+	if len(additional) > 0 {
+		if len(additional) > CTRDRBGSeedSize {
+			return fmt.Errorf("CTR_DRBG: additional input longer than %d bytes", CTRDRBGSeedSize)
+		}
+		copy(add, additional)
+		if err := d.update(add); err != nil {
+			return err
+		}
+	}
+	var buf [aes.BlockSize]byte
+	for off := 0; off < len(out); off += aes.BlockSize {
+		d.incV()
+		d.block.Encrypt(buf[:], d.v[:])
+		copy(out[off:], buf[:])
+	}
+	if err := d.update(add); err != nil {
+		return err
+	}
+	d.reseedCounter++
+	return nil
+}
+
+// Read выдаёт поток генератора запросами по DRBGMaxRequest байт
+func (d *CTRDRBG) Read(p []byte) (int, error) {
+	return drbgRead(p, d.Generate)
+}
+
+// drbgRead разбивает чтение на запросы Generate допустимой длины
+func drbgRead(p []byte, generate func(out, additional []byte) error) (int, error) {
+	for off := 0; off < len(p); off += DRBGMaxRequest {
+		end := min(off+DRBGMaxRequest, len(p))
+		if err := generate(p[off:end], nil); err != nil {
+			return off, err
+		}
+	}
+	return len(p), nil
+}
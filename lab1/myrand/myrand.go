@@ -0,0 +1,80 @@
+package myrand
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Recorder - источник случайности, который записывает каждую выдачу в журнал
+// (одна строка в hex на каждый вызов Read), чтобы прогон можно было воспроизвести
+type Recorder struct {
+	mu sync.Mutex
+	r  io.Reader
+	w  io.Writer
+}
+
+// NewRecorder оборачивает источник r и пишет журнал в w
+func NewRecorder(r io.Reader, w io.Writer) *Recorder {
+	return &Recorder{r: r, w: w}
+}
+
+// Read читает случайные байты из исходного источника и записывает их в журнал
+func (rec *Recorder) Read(p []byte) (int, error) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	n, err := io.ReadFull(rec.r, p)
+	if err != nil {
+		return n, err
+	}
+	if _, err := fmt.Fprintln(rec.w, hex.EncodeToString(p[:n])); err != nil {
+		return 0, fmt.Errorf("recorder: cannot write log: %v", err)
+	}
+	return n, nil
+}
+
+// Replayer воспроизводит выдачи, записанные Recorder, в том же порядке
+type Replayer struct {
+	mu    sync.Mutex
+	sc    *bufio.Scanner
+	draws int
+}
+
+// NewReplayer читает журнал из r
+func NewReplayer(r io.Reader) *Replayer {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &Replayer{sc: sc}
+}
+
+// Read возвращает очередную записанную выдачу. Если запрошено другое число байтов,
+// значит, прогон разошёлся с записанным, и возвращается ошибка.
+func (rp *Replayer) Read(p []byte) (int, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.draws++
+	if !rp.sc.Scan() {
+		if err := rp.sc.Err(); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("replay: log exhausted at draw %d", rp.draws)
+	}
+	data, err := hex.DecodeString(strings.TrimSpace(rp.sc.Text()))
+	if err != nil {
+		return 0, fmt.Errorf("replay: draw %d: %v", rp.draws, err)
+	}
+	if len(data) != len(p) {
+		return 0, fmt.Errorf("replay: draw %d: recorded %d bytes, requested %d", rp.draws, len(data), len(p))
+	}
+	return copy(p, data), nil
+}
+
+// Draws возвращает число уже воспроизведённых выдач
+func (rp *Replayer) Draws() int {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return rp.draws
+}
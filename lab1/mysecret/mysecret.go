@@ -0,0 +1,122 @@
+package mysecret
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ErrClosed возвращается при обращении к закрытому хранилищу
+var ErrClosed = errors.New("secret cache is closed")
+
+// SecretCache хранит значения в памяти только в зашифрованном виде (AES-GCM из mycrypto)
+// на эфемерном ключе процесса. Имя записи входит в AAD, поэтому шифротексты нельзя
+// незаметно переставить между записями. Открытые значения существуют только на время
+// вызова With и затираются сразу после него.
+type SecretCache struct {
+	mu      sync.Mutex
+	key     []byte
+	mc      *mycrypto.MyCipher
+	entries map[string][]byte
+}
+
+// Wipe затирает b нулями
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// NewSecretCache создаёт хранилище со случайным ключом AES-256, который нигде не сохраняется
+func NewSecretCache() (*SecretCache, error) {
+	key := make([]byte, mycrypto.AESKeySize32)
+	if _, err := mycrypto.Rand.Read(key); err != nil {
+		return nil, errors.New("failed to generate cache key")
+	}
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(mycrypto.ModeGCM); err != nil {
+		return nil, err
+	}
+	return &SecretCache{key: key, mc: mc, entries: make(map[string][]byte)}, nil
+}
+
+// Put шифрует value и сохраняет под именем name. Если wipe, исходное значение затирается.
+func (c *SecretCache) Put(name string, value []byte, wipe bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mc == nil {
+		return ErrClosed
+	}
+	c.mc.SetAAD([]byte(name))
+	sealed, err := c.mc.Encrypt(value, nil)
+	if err != nil {
+		return err
+	}
+	c.entries[name] = sealed
+	if wipe {
+		Wipe(value)
+	}
+	return nil
+}
+
+// With расшифровывает значение name, передаёт его fn и затирает после возврата.
+// fn не должна сохранять ссылку на срез.
+func (c *SecretCache) With(name string, fn func(value []byte) error) error {
+	c.mu.Lock()
+	if c.mc == nil {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	sealed, ok := c.entries[name]
+	if !ok {
+		c.mu.Unlock()
+		return fmt.Errorf("secret %q not found", name)
+	}
+	c.mc.SetAAD([]byte(name))
+	value, err := c.mc.Decrypt(sealed, nil)
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("secret %q: %v", name, err)
+	}
+	defer Wipe(value)
+	return fn(value)
+}
+
+// Delete удаляет запись name
+func (c *SecretCache) Delete(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if sealed, ok := c.entries[name]; ok {
+		Wipe(sealed)
+		delete(c.entries, name)
+	}
+}
+
+// Names возвращает имена сохранённых записей
+func (c *SecretCache) Names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.entries))
+	for name := range c.entries {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Close затирает все записи и ключ. Расписание ключей внутри crypto/aes затереть
+// нельзя, но без шифротекстов оно бесполезно.
+func (c *SecretCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, sealed := range c.entries {
+		Wipe(sealed)
+		delete(c.entries, name)
+	}
+	Wipe(c.key)
+	c.mc = nil
+}
@@ -0,0 +1,196 @@
+package myshamir
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Разделение секрета Шамира над GF(2^8) -----
+
+// Каждый байт секрета - свободный член своего многочлена степени k-1 над GF(2^8)
+// (поле AES, многочлен 0x11b). Доля с номером x - значения всех многочленов в точке x.
+
+// fingerprintSize - длина отпечатка секрета, по которому проверяется восстановление
+const fingerprintSize = 8
+
+// sharePrefix открывает текстовую запись доли
+const sharePrefix = "shamir1"
+
+var (
+	// ErrThreshold возвращается, если долей меньше порога
+	ErrThreshold = errors.New("not enough shares to reach threshold")
+	// ErrMismatch возвращается, если восстановленный секрет не совпадает с отпечатком
+	ErrMismatch = errors.New("reconstructed secret does not match fingerprint: wrong or corrupted share")
+)
+
+// Share - доля секрета. Threshold и Fingerprint (первые байты SHA-256 секрета) одинаковы
+// у всех долей одного разделения и позволяют проверить их совместимость и результат.
+type Share struct {
+	Threshold   int
+	X           byte
+	Y           []byte
+	Fingerprint []byte
+}
+
+// gfMul умножает элементы GF(2^8) по модулю x^8 + x^4 + x^3 + x + 1
+func gfMul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv возвращает обратный элемент как a^254
+func gfInv(a byte) byte {
+	r := byte(1)
+	for i := 0; i < 254; i++ {
+		r = gfMul(r, a)
+	}
+	return r
+}
+
+// fingerprint возвращает отпечаток секрета
+func fingerprint(secret []byte) []byte {
+	h := sha256.Sum256(secret)
+	return h[:fingerprintSize]
+}
+
+// Split делит secret на n долей, любые k из которых восстанавливают секрет
+func Split(secret []byte, k, n int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("Split: empty secret")
+	}
+	if k < 2 || k > n || n > 255 {
+		return nil, fmt.Errorf("Split: need 2 <= k <= n <= 255, got k=%d n=%d", k, n)
+	}
+	fp := fingerprint(secret)
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{Threshold: k, X: byte(i + 1), Y: make([]byte, len(secret)), Fingerprint: fp}
+	}
+	coeffs := make([]byte, k)
+	for j, s := range secret {
+		coeffs[0] = s
+		if _, err := mycrypto.Rand.Read(coeffs[1:]); err != nil {
+			return nil, errors.New("Split: failed to generate coefficients")
+		}
+		for i := range shares {
+			// схема Горнера в точке x
+			var y byte
+			for c := k - 1; c >= 0; c-- {
+				y = gfMul(y, shares[i].X) ^ coeffs[c]
+			}
+			shares[i].Y[j] = y
+		}
+	}
+	for i := range coeffs {
+		coeffs[i] = 0
+	}
+	return shares, nil
+}
+
+// Combine восстанавливает секрет интерполяцией Лагранжа в нуле. Доли проверяются на совместимость
+// (порог, отпечаток, длина, разные номера), а результат - по отпечатку.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, ErrThreshold
+	}
+	k := shares[0].Threshold
+	if len(shares) < k {
+		return nil, fmt.Errorf("%w: have %d, need %d", ErrThreshold, len(shares), k)
+	}
+	seen := make(map[byte]bool)
+	for _, s := range shares {
+		if s.Threshold != k || len(s.Y) != len(shares[0].Y) || subtle.ConstantTimeCompare(s.Fingerprint, shares[0].Fingerprint) != 1 {
+			return nil, errors.New("Combine: shares belong to different splits")
+		}
+		if s.X == 0 || seen[s.X] {
+			return nil, fmt.Errorf("Combine: invalid or duplicate share index %d", s.X)
+		}
+		seen[s.X] = true
+	}
+	// берутся первые k долей; лишние только проверены на совместимость
+	use := shares[:k]
+	secret := make([]byte, len(use[0].Y))
+	for i, si := range use {
+		// базисный многочлен Лагранжа в нуле: prod x_j / (x_j - x_i), вычитание в GF(2^8) - XOR
+		num, den := byte(1), byte(1)
+		for j, sj := range use {
+			if i != j {
+				num = gfMul(num, sj.X)
+				den = gfMul(den, sj.X^si.X)
+			}
+		}
+		l := gfMul(num, gfInv(den))
+		for b := range secret {
+			secret[b] ^= gfMul(l, si.Y[b])
+		}
+	}
+	if subtle.ConstantTimeCompare(fingerprint(secret), shares[0].Fingerprint) != 1 {
+		return nil, ErrMismatch
+	}
+	return secret, nil
+}
+
+// checksum - контрольная сумма текстовой записи доли для обнаружения опечаток
+func checksum(body string) string {
+	h := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(h[:4])
+}
+
+// String кодирует долю в строку shamir1-<k>-<x>-<fingerprint>-<y>-<checksum>,
+// которую можно распечатать, переписать вручную или передать генератору QR-кодов
+func (s Share) String() string {
+	body := fmt.Sprintf("%s-%d-%d-%x-%x", sharePrefix, s.Threshold, s.X, s.Fingerprint, s.Y)
+	return body + "-" + checksum(body)
+}
+
+// ParseShare разбирает строку, созданную Share.String, и проверяет контрольную сумму
+func ParseShare(text string) (Share, error) {
+	text = strings.TrimSpace(text)
+	cut := strings.LastIndexByte(text, '-')
+	if cut < 0 {
+		return Share{}, errors.New("ParseShare: malformed share")
+	}
+	body, sum := text[:cut], text[cut+1:]
+	if subtle.ConstantTimeCompare([]byte(checksum(body)), []byte(sum)) != 1 {
+		return Share{}, errors.New("ParseShare: checksum mismatch (share mistyped or damaged)")
+	}
+	parts := strings.Split(body, "-")
+	if len(parts) != 5 || parts[0] != sharePrefix {
+		return Share{}, errors.New("ParseShare: malformed share")
+	}
+	k, err := strconv.Atoi(parts[1])
+	if err != nil || k < 2 {
+		return Share{}, errors.New("ParseShare: invalid threshold")
+	}
+	x, err := strconv.ParseUint(parts[2], 10, 8)
+	if err != nil || x == 0 {
+		return Share{}, errors.New("ParseShare: invalid share index")
+	}
+	fp, err := hex.DecodeString(parts[3])
+	if err != nil || len(fp) != fingerprintSize {
+		return Share{}, errors.New("ParseShare: invalid fingerprint")
+	}
+	y, err := hex.DecodeString(parts[4])
+	if err != nil || len(y) == 0 {
+		return Share{}, errors.New("ParseShare: invalid share value")
+	}
+	return Share{Threshold: k, X: byte(x), Y: y, Fingerprint: fp}, nil
+}
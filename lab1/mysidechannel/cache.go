@@ -0,0 +1,85 @@
+package mysidechannel
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"github.com/sagilyp/lab1/myaes"
+)
+
+// Параметры моделируемого кэша: строка 64 байта вмещает 16 записей T-таблицы по 4 байта
+const (
+	CacheLineSize  = 64
+	EntriesPerLine = CacheLineSize / 4
+	LinesPerTable  = 256 / EntriesPerLine
+)
+
+// CacheObservation - результат prime+probe после одного шифрования:
+// для каждой T-таблицы битовая маска строк кэша, к которым было обращение
+type CacheObservation [4]uint16
+
+// ProbeEncrypt шифрует pt, моделируя prime+probe: вместо реального вытеснения строк
+// кэша обращения к таблицам перехватываются через Trace шифра
+func ProbeEncrypt(c *myaes.Cipher, pt []byte) ([]byte, CacheObservation) {
+	var obs CacheObservation
+	prev := c.Trace
+	c.Trace = func(table int, index byte) {
+		if table <= myaes.TableT3 {
+			obs[table] |= 1 << (index / EntriesPerLine)
+		}
+	}
+	ct := make([]byte, myaes.BlockSize)
+	c.Encrypt(ct, pt)
+	c.Trace = prev
+	return ct, obs
+}
+
+// FirstRoundAttack восстанавливает старшие 4 бита каждого байта ключа по обращениям
+// первого раунда: индекс в таблице T(i mod 4) равен pt[i] ^ key[i], поэтому строка
+// (pt[i] ^ key[i]) >> 4 затрагивается при каждом шифровании. Для каждого кандидата
+// старшего полубайта считается, сколько раз соответствующая строка была занята;
+// верный кандидат набирает максимум. Возвращает ключ, у которого младшие полубайты нулевые.
+func FirstRoundAttack(probe func(pt []byte) CacheObservation, samples int) ([]byte, error) {
+	if samples <= 0 {
+		return nil, errors.New("FirstRoundAttack: samples must be positive")
+	}
+	var scores [myaes.BlockSize][LinesPerTable]int
+	pt := make([]byte, myaes.BlockSize)
+	for s := 0; s < samples; s++ {
+		if _, err := rand.Read(pt); err != nil {
+			return nil, errors.New("failed to generate plaintext")
+		}
+		obs := probe(pt)
+		for i := 0; i < myaes.BlockSize; i++ {
+			mask := obs[i%4]
+			for h := 0; h < LinesPerTable; h++ {
+				line := int(pt[i]>>4) ^ h
+				if mask&(1<<line) != 0 {
+					scores[i][h]++
+				}
+			}
+		}
+	}
+	key := make([]byte, myaes.BlockSize)
+	for i := range key {
+		best := 0
+		for h := 1; h < LinesPerTable; h++ {
+			if scores[i][h] > scores[i][best] {
+				best = h
+			}
+		}
+		key[i] = byte(best << 4)
+	}
+	return key, nil
+}
+
+// CorrectNibbles считает, сколько старших полубайтов восстановленного ключа совпало с настоящим
+func CorrectNibbles(recovered, key []byte) int {
+	n := 0
+	for i := range recovered {
+		if i < len(key) && recovered[i]>>4 == key[i]>>4 {
+			n++
+		}
+	}
+	return n
+}
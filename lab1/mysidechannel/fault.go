@@ -0,0 +1,166 @@
+package mysidechannel
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+	"math/bits"
+
+	"github.com/sagilyp/lab1/myaes"
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- RSA-CRT и атака Bellcore -----
+
+// CRTSigner подписывает сообщения RSA с использованием китайской теоремы об остатках.
+// Fault, если задан, получает половину подписи по модулю p и может её испортить.
+type CRTSigner struct {
+	Key   *rsa.PrivateKey
+	Fault func(sp *big.Int)
+}
+
+// NewCRTSigner генерирует ключ RSA заданного размера
+func NewCRTSigner(bits int) (*CRTSigner, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+	key.Precompute()
+	return &CRTSigner{Key: key}, nil
+}
+
+// Sign вычисляет s = m^d mod N как комбинацию s_p = m^dp mod p и s_q = m^dq mod q (формула Гарнера)
+func (s *CRTSigner) Sign(m *big.Int) *big.Int {
+	p, q := s.Key.Primes[0], s.Key.Primes[1]
+	// math/big.Exp не константен по времени, а показатели dp и dq секретны
+	mycrypto.AuditPoint("mysidechannel.CRTSigner.Sign/Exp")
+	sp := new(big.Int).Exp(m, s.Key.Precomputed.Dp, p)
+	if s.Fault != nil {
+		s.Fault(sp)
+	}
+	mycrypto.AuditPoint("mysidechannel.CRTSigner.Sign/Exp")
+	sq := new(big.Int).Exp(m, s.Key.Precomputed.Dq, q)
+	h := new(big.Int).Sub(sp, sq)
+	h.Mul(h, s.Key.Precomputed.Qinv)
+	h.Mod(h, p)
+	return h.Mul(h, q).Add(h, sq)
+}
+
+// BellcoreAttack по одной ошибочной подписи восстанавливает множители модуля:
+// s'^e ≡ m (mod q), но не по модулю p, поэтому gcd(s'^e - m, N) = q
+func BellcoreAttack(pub *rsa.PublicKey, m, faultySig *big.Int) (*big.Int, *big.Int, error) {
+	e := big.NewInt(int64(pub.E))
+	diff := new(big.Int).Exp(faultySig, e, pub.N)
+	diff.Sub(diff, m)
+	diff.Mod(diff, pub.N)
+	q := new(big.Int).GCD(nil, nil, diff, pub.N)
+	if q.Cmp(big.NewInt(1)) == 0 || q.Cmp(pub.N) == 0 {
+		return nil, nil, errors.New("BellcoreAttack: signature is not faulty in exactly one half")
+	}
+	p := new(big.Int).Div(pub.N, q)
+	return p, q, nil
+}
+
+// ----- Дифференциальный анализ сбоев AES (упрощённый) -----
+
+// FaultPair - пара шифротекстов одного открытого текста: без сбоя и со сбоем
+type FaultPair struct {
+	Correct []byte
+	Faulty  []byte
+}
+
+// BitFlipBeforeLastRound возвращает обработчик Fault, инвертирующий случайный бит
+// случайного байта состояния перед последним раундом (модель одиночной инверсии бита)
+func BitFlipBeforeLastRound(rounds int) func(round int, state []byte) {
+	return func(round int, state []byte) {
+		if round != rounds {
+			return
+		}
+		var r [2]byte
+		if _, err := rand.Read(r[:]); err != nil {
+			return
+		}
+		state[r[0]%myaes.BlockSize] ^= 1 << (r[1] % 8)
+	}
+}
+
+// LastRoundDFA восстанавливает ключ последнего раунда по парам со сбоем в одном бите
+// перед последним SubBytes. Для выходного байта j, отличающегося в паре, кандидат k
+// остаётся, только если InvSbox(c^k) ^ InvSbox(c'^k) имеет ровно один единичный бит.
+// Возвращает ключ последнего раунда и число байтов, для которых кандидат определён однозначно.
+func LastRoundDFA(pairs []FaultPair) ([]byte, int, error) {
+	var candidates [myaes.BlockSize][256]bool
+	for j := range candidates {
+		for k := range candidates[j] {
+			candidates[j][k] = true
+		}
+	}
+	for _, pair := range pairs {
+		if len(pair.Correct) != myaes.BlockSize || len(pair.Faulty) != myaes.BlockSize {
+			return nil, 0, errors.New("LastRoundDFA: ciphertexts must be one block")
+		}
+		for j := 0; j < myaes.BlockSize; j++ {
+			c, cf := pair.Correct[j], pair.Faulty[j]
+			if c == cf {
+				continue
+			}
+			for k := 0; k < 256; k++ {
+				d := myaes.InvSbox(c^byte(k)) ^ myaes.InvSbox(cf^byte(k))
+				if bits.OnesCount8(d) != 1 {
+					candidates[j][k] = false
+				}
+			}
+		}
+	}
+	key := make([]byte, myaes.BlockSize)
+	solved := 0
+	for j := range candidates {
+		count := 0
+		for k, ok := range candidates[j] {
+			if ok {
+				key[j] = byte(k)
+				count++
+			}
+		}
+		if count == 0 {
+			return nil, 0, errors.New("LastRoundDFA: fault model does not match the pairs")
+		}
+		if count == 1 {
+			solved++
+		}
+	}
+	return key, solved, nil
+}
+
+// MasterKeyFromLastRoundKey128 обращает расписание ключей AES-128: из ключа 10-го раунда
+// последовательно восстанавливаются слова w[39]..w[0]
+func MasterKeyFromLastRoundKey128(last []byte) ([]byte, error) {
+	if len(last) != 16 {
+		return nil, errors.New("MasterKeyFromLastRoundKey128: round key must be 16 bytes")
+	}
+	var w [44][4]byte
+	for i := 0; i < 4; i++ {
+		copy(w[40+i][:], last[4*i:4*i+4])
+	}
+	rcon := [11]byte{0, 0x01, 0x02, 0x04, 0x08, 0x10, 0x20, 0x40, 0x80, 0x1b, 0x36}
+	for i := 43; i >= 4; i-- {
+		t := w[i-1]
+		if i%4 == 0 {
+			t = [4]byte{
+				myaes.Sbox(t[1]) ^ rcon[i/4],
+				myaes.Sbox(t[2]),
+				myaes.Sbox(t[3]),
+				myaes.Sbox(t[0]),
+			}
+		}
+		for b := 0; b < 4; b++ {
+			w[i-4][b] = w[i][b] ^ t[b]
+		}
+	}
+	key := make([]byte, 16)
+	for i := 0; i < 4; i++ {
+		copy(key[4*i:], w[i][:])
+	}
+	return key, nil
+}
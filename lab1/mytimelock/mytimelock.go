@@ -0,0 +1,139 @@
+package mytimelock
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Головоломка с временным замком RSW (Rivest, Shamir, Wagner, 1996) -----
+
+// Puzzle - головоломка: сообщение зашифровано AES-GCM на ключе SHA-256(y), где y = X^(2^T) mod N.
+// Создатель, знающий φ(N), вычисляет y за одно возведение в степень; остальным нужно
+// T последовательных возведений в квадрат, которые не распараллеливаются.
+type Puzzle struct {
+	N      *big.Int
+	X      *big.Int
+	T      uint64
+	Sealed []byte
+}
+
+var two = big.NewInt(2)
+
+// sealKey выводит ключ AES-256 из решения головоломки
+func sealKey(y *big.Int) []byte {
+	k := sha256.Sum256(y.Bytes())
+	return k[:]
+}
+
+// gcm возвращает MyCipher в режиме GCM на ключе, выведенном из y
+func gcm(y *big.Int) (*mycrypto.MyCipher, error) {
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(sealKey(y)); err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(mycrypto.ModeGCM); err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
+
+// NewPuzzle генерирует модуль RSA размера bits и запечатывает msg на T возведений в квадрат
+func NewPuzzle(bits int, t uint64, msg []byte) (*Puzzle, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+	n := key.N
+	p, q := key.Primes[0], key.Primes[1]
+	phi := new(big.Int).Mul(new(big.Int).Sub(p, big.NewInt(1)), new(big.Int).Sub(q, big.NewInt(1)))
+	x, err := rand.Int(rand.Reader, new(big.Int).Sub(n, two))
+	if err != nil {
+		return nil, err
+	}
+	x.Add(x, two)
+	// лазейка: 2^T сокращается по модулю φ(N); math/big.Exp не константен по времени, а φ(N) секретна
+	mycrypto.AuditPoint("mytimelock.NewPuzzle/ExpModPhi")
+	e := new(big.Int).Exp(two, new(big.Int).SetUint64(t), phi)
+	y := new(big.Int).Exp(x, e, n)
+	mc, err := gcm(y)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := mc.Encrypt(msg, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Puzzle{N: n, X: x, T: t, Sealed: sealed}, nil
+}
+
+// Eval вычисляет x^(2^t) mod n последовательными возведениями в квадрат
+func Eval(n, x *big.Int, t uint64) *big.Int {
+	y := new(big.Int).Set(x)
+	for i := uint64(0); i < t; i++ {
+		y.Mul(y, y).Mod(y, n)
+	}
+	return y
+}
+
+// Solve решает головоломку без лазейки и возвращает сообщение и y = X^(2^T) mod N
+func (pz *Puzzle) Solve() ([]byte, *big.Int, error) {
+	y := Eval(pz.N, pz.X, pz.T)
+	mc, err := gcm(y)
+	if err != nil {
+		return nil, nil, err
+	}
+	msg, err := mc.Decrypt(pz.Sealed, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return msg, y, nil
+}
+
+// ----- Проверяемая задержка: доказательство Весоловского -----
+
+// hashToPrime выводит 128-битное простое l из (N, x, y, T)
+func hashToPrime(n, x, y *big.Int, t uint64) *big.Int {
+	var ctr [8]byte
+	for i := uint64(0); ; i++ {
+		h := sha256.New()
+		h.Write(n.Bytes())
+		h.Write(x.Bytes())
+		h.Write(y.Bytes())
+		binary.BigEndian.PutUint64(ctr[:], t)
+		h.Write(ctr[:])
+		binary.BigEndian.PutUint64(ctr[:], i)
+		h.Write(ctr[:])
+		l := new(big.Int).SetBytes(h.Sum(nil)[:16])
+		l.SetBit(l, 0, 1)
+		if l.ProbablyPrime(20) {
+			return l
+		}
+	}
+}
+
+// Prove строит доказательство π = x^floor(2^t / l) того, что y = x^(2^t) mod n.
+// Стоимость сравнима с самим вычислением y, зато проверка занимает два коротких возведения в степень.
+func Prove(n, x, y *big.Int, t uint64) *big.Int {
+	l := hashToPrime(n, x, y, t)
+	q := new(big.Int).Lsh(big.NewInt(1), uint(t))
+	q.Quo(q, l)
+	return new(big.Int).Exp(x, q, n)
+}
+
+// Verify проверяет доказательство: π^l * x^(2^t mod l) = y (mod n)
+func Verify(n, x, y, pi *big.Int, t uint64) (bool, error) {
+	if pi.Sign() <= 0 || pi.Cmp(n) >= 0 {
+		return false, errors.New("Verify: proof out of range")
+	}
+	l := hashToPrime(n, x, y, t)
+	r := new(big.Int).Exp(two, new(big.Int).SetUint64(t), l)
+	lhs := new(big.Int).Exp(pi, l, n)
+	lhs.Mul(lhs, new(big.Int).Exp(x, r, n)).Mod(lhs, n)
+	return lhs.Cmp(y) == 0, nil
+}
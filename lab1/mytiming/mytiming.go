@@ -0,0 +1,107 @@
+package mytiming
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Порог |t|, начиная с которого считаем, что утечка по времени обнаружена (как в dudect)
+const LeakThreshold = 4.5
+
+// Result хранит итог статистического теста утечки по времени
+type Result struct {
+	Name    string
+	Samples int
+	MeanA   float64 // среднее время (нс) для класса с фиксированным входом
+	MeanB   float64 // среднее время (нс) для класса со случайным входом
+	T       float64 // статистика Уэлча
+	P       float64 // двусторонний p-value (нормальное приближение)
+	Leak    bool
+}
+
+// welford накапливает среднее и дисперсию в один проход
+type welford struct {
+	n    int
+	mean float64
+	m2   float64
+}
+
+func (w *welford) push(x float64) {
+	w.n++
+	delta := x - w.mean
+	w.mean += delta / float64(w.n)
+	w.m2 += delta * (x - w.mean)
+}
+
+func (w *welford) variance() float64 {
+	if w.n < 2 {
+		return 0
+	}
+	return w.m2 / float64(w.n-1)
+}
+
+// LeakageTest выполняет тест в стиле dudect: fn вызывается samples раз на входах
+// двух классов (фиксированный fixed и случайный от random), классы перемешиваются
+// случайно, а времена сравниваются t-тестом Уэлча. Верхние 5% измерений отбрасываются.
+func LeakageTest(name string, fn func([]byte), fixed []byte, random func() []byte, samples int) (Result, error) {
+	if samples < 2 {
+		return Result{}, errors.New("LeakageTest: need at least 2 samples")
+	}
+	classes := make([]bool, samples)
+	inputs := make([][]byte, samples)
+	for i := range classes {
+		classes[i] = rand.Intn(2) == 0
+		if classes[i] {
+			inputs[i] = fixed
+		} else {
+			inputs[i] = random()
+		}
+	}
+	times := make([]float64, samples)
+	for i := range inputs {
+		start := time.Now()
+		fn(inputs[i])
+		times[i] = float64(time.Since(start).Nanoseconds())
+	}
+	cutoff := percentile(times, 0.95)
+	var a, b welford
+	for i, t := range times {
+		if t > cutoff {
+			continue
+		}
+		if classes[i] {
+			a.push(t)
+		} else {
+			b.push(t)
+		}
+	}
+	if a.n < 2 || b.n < 2 {
+		return Result{}, errors.New("LeakageTest: not enough samples in one of the classes")
+	}
+	se := math.Sqrt(a.variance()/float64(a.n) + b.variance()/float64(b.n))
+	t := 0.0
+	if se > 0 {
+		t = (a.mean - b.mean) / se
+	}
+	p := math.Erfc(math.Abs(t) / math.Sqrt2)
+	return Result{
+		Name:    name,
+		Samples: a.n + b.n,
+		MeanA:   a.mean,
+		MeanB:   b.mean,
+		T:       t,
+		P:       p,
+		Leak:    math.Abs(t) > LeakThreshold,
+	}, nil
+}
+
+// percentile возвращает значение q-квантиля (0..1) без изменения исходного среза
+func percentile(data []float64, q float64) float64 {
+	sorted := make([]float64, len(data))
+	copy(sorted, data)
+	sort.Float64s(sorted)
+	return sorted[int(q*float64(len(sorted)-1))]
+}
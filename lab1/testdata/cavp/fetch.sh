@@ -0,0 +1,15 @@
+#!/bin/sh
+# Скачивает файлы ответов NIST CAVP для AES (AESAVS): известные ответы, сообщения из нескольких
+# блоков и Monte Carlo для ECB, CBC, CFB1, CFB8, CFB128 и OFB, которые прогоняет "go run ./cmd/cavp".
+# Запуск из каталога lab1: sh testdata/cavp/fetch.sh
+set -e
+
+base=https://csrc.nist.gov/CSRC/media/Projects/Cryptographic-Algorithm-Validation-Program/documents/aes
+dir=$(dirname "$0")
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+for name in KAT_AES aesmmt aesmct; do
+	curl -fsSL -o "$tmp/$name.zip" "$base/$name.zip"
+	unzip -o -j -q "$tmp/$name.zip" '*.rsp' -d "$dir"
+done
+ls "$dir"/*.rsp | wc -l
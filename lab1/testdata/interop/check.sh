@@ -0,0 +1,34 @@
+#!/bin/sh
+# Проверяет с помощью OpenSSL векторы, записанные "go run ./cmd/interop -golden file":
+# расшифровывает каждый шифротекст и сравнивает с исходным сообщением.
+# Запуск: sh check.sh golden.json
+set -e
+
+[ $# -eq 1 ] || { echo "usage: sh check.sh golden.json" >&2; exit 2; }
+
+field() {
+	printf '%s' "$1" | sed -n "s/.*\"$2\":\"\([0-9a-f]*\)\".*/\1/p"
+}
+
+total=0
+failed=0
+for v in $(tr -d ' \n\t' < "$1" | sed 's/},{/}\n{/g'); do
+	mode=$(printf '%s' "$v" | sed -n 's/.*"mode":"\([A-Z]*\)".*/\1/p')
+	key=$(field "$v" key)
+	iv=$(field "$v" iv)
+	msg=$(field "$v" msg)
+	out=$(field "$v" out)
+	segment=$(printf '%s' "$v" | sed -n 's/.*"segment":\([0-9]*\).*/\1/p')
+	alg=aes-$((${#key} * 4))-$(echo "$mode" | tr 'A-Z' 'a-z')
+	case $segment in
+	1 | 8) alg=$alg$segment ;;
+	esac
+	got=$(printf '%s' "$out" | xxd -r -p | openssl enc -d "-$alg" -K "$key" -iv "$iv" | od -An -tx1 | tr -d ' \n')
+	total=$((total + 1))
+	if [ "$got" != "$msg" ]; then
+		failed=$((failed + 1))
+		echo "FAIL $alg iv=$iv: got $got, want $msg"
+	fi
+done
+echo "$((total - failed))/$total golden vectors accepted by $(openssl version | cut -d' ' -f1-2)"
+[ $failed -eq 0 ]
@@ -0,0 +1,46 @@
+#!/bin/sh
+# Генерирует эталонные векторы AES-CBC/CTR/CFB (сегменты 1, 8 и 128 бит) с помощью OpenSSL (>= 3.0).
+# Запуск: sh gen.sh > vectors.json
+set -e
+
+KEY128=000102030405060708090a0b0c0d0e0f
+KEY256=603deb1015ca71be2b73aef0857d77811f352c073b6108d77d9810a3094f7e8f
+IV=f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff
+CTRIV=a1a2a3a4b1b2b3b40000000000000000
+
+# msg N - сообщение из байтов 00 01 02 ... длиной N
+msg() {
+	awk -v n="$1" 'BEGIN { for (i = 0; i < n; i++) printf "%02x", i % 256 }'
+}
+
+enc() {
+	printf '%s' "$4" | xxd -r -p | openssl enc "-$1" -K "$2" -iv "$3" | od -An -tx1 | tr -d ' \n'
+}
+
+echo "["
+first=1
+for alg in aes-128-cbc aes-256-cbc aes-128-ctr aes-256-ctr aes-128-cfb1 aes-128-cfb8 aes-128-cfb aes-256-cfb8; do
+	case $alg in
+	aes-128-*) key=$KEY128 ;;
+	*) key=$KEY256 ;;
+	esac
+	segment=
+	case $alg in
+	*-cbc) mode=CBC iv=$IV ;;
+	*-ctr) mode=CTR iv=$CTRIV ;;
+	*-cfb1) mode=CFB iv=$IV segment=1 ;;
+	*-cfb8) mode=CFB iv=$IV segment=8 ;;
+	*) mode=CFB iv=$IV segment=128 ;;
+	esac
+	for n in 0 1 15 16 17 32 33 64; do
+		m=$(msg "$n")
+		ct=$(enc "$alg" "$key" "$iv" "$m")
+		[ $first -eq 1 ] || echo ","
+		first=0
+		printf '  {"tool": "%s", "mode": "%s", ' "$(openssl version | cut -d' ' -f1-2)" "$mode"
+		[ -z "$segment" ] || printf '"segment": %s, ' "$segment"
+		printf '"key": "%s", "iv": "%s", "msg": "%s", "out": "%s"}' "$key" "$iv" "$m" "$ct"
+	done
+done
+echo
+echo "]"
@@ -0,0 +1,34 @@
+#!/bin/sh
+# Проверяет совместимость "filecrypt -openssl" с "openssl enc -aes-*-cbc -salt" в обе стороны:
+# для каждого размера ключа и способа выработки (EVP_BytesToKey на SHA-256 и MD5, PBKDF2)
+# файл шифруется одной программой и расшифровывается другой.
+# Запуск из каталога lab1: sh testdata/interop/salted.sh
+set -e
+
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+go build -o "$tmp/filecrypt" ./cmd/filecrypt
+head -c 100003 /dev/urandom > "$tmp/plain"
+
+total=0
+failed=0
+for bits in 128 192 256; do
+	for kdf in "-md sha256" "-md md5" "-pbkdf2" "-pbkdf2 -iter 1234"; do
+		# shellcheck disable=SC2086
+		openssl enc -aes-$bits-cbc -salt -pass pass:interop $kdf -in "$tmp/plain" -out "$tmp/a.enc" 2>/dev/null
+		"$tmp/filecrypt" decrypt -openssl -pass pass:interop -keybits $bits $kdf -in "$tmp/a.enc" -out "$tmp/a.dec" || true
+		"$tmp/filecrypt" encrypt -openssl -pass pass:interop -keybits $bits $kdf -in "$tmp/plain" -out "$tmp/b.enc"
+		openssl enc -d -aes-$bits-cbc -pass pass:interop $kdf -in "$tmp/b.enc" -out "$tmp/b.dec" 2>/dev/null || true
+		for dir in a b; do
+			total=$((total + 1))
+			if ! cmp -s "$tmp/plain" "$tmp/$dir.dec"; then
+				failed=$((failed + 1))
+				[ $dir = a ] && what="openssl -> filecrypt" || what="filecrypt -> openssl"
+				echo "FAIL aes-$bits-cbc $kdf: $what"
+			fi
+			rm -f "$tmp/$dir.dec"
+		done
+	done
+done
+echo "$((total - failed))/$total round trips with $(openssl version | cut -d' ' -f1-2)"
+[ $failed -eq 0 ]
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/sagilyp/lab2/myattacks"
+)
+
+func main() {
+	hash := flag.String("hash", "SHA-256", "attacked hash function")
+	bits := flag.Int("bits", 20, "truncated output bits")
+	dbits := flag.Int("dbits", myattacks.DistBits, "distinguished point bits")
+	n := flag.Int("n", 20, "collisions needed")
+	workers := flag.Int("workers", myattacks.NumWorkers, "number of chains")
+	out := flag.String("out", "chaintrace", "output directory")
+	flag.Parse()
+
+	h, err := myattacks.LookupHash(*hash)
+	if err != nil {
+		log.Fatal(err)
+	}
+	trace, err := myattacks.NewColumnarTrace(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	colls, _, _, _, err := myattacks.PollardAttackTraced(h, *bits, *dbits, *n, *workers, trace.Record)
+	if err != nil {
+		trace.Close()
+		log.Fatal(err)
+	}
+	if err := trace.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d collisions, %d events written to %s\n", len(colls), trace.Rows(), *out)
+	entries, err := os.ReadDir(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("  %-32s %d bytes\n", filepath.Join(*out, e.Name()), info.Size())
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab2/myattacks"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+// store - хранилище с дедупликацией: шифротексты адресуются своим хэшем
+type store struct {
+	blobs    map[[32]byte][]byte
+	uploaded int
+}
+
+func (s *store) put(ct []byte) [32]byte {
+	id := sha256.Sum256(ct)
+	s.uploaded += len(ct)
+	if _, ok := s.blobs[id]; !ok {
+		s.blobs[id] = ct
+	}
+	return id
+}
+
+func (s *store) size() int {
+	n := 0
+	for _, ct := range s.blobs {
+		n += len(ct)
+	}
+	return n
+}
+
+// letter - шаблон файла, в котором неизвестен только PIN из digits цифр
+func letter(digits int) func(i int) []byte {
+	return func(i int) []byte {
+		return []byte(fmt.Sprintf("Dear customer,\nyour new card PIN is %0*d.\nPlease keep it secret.\n", digits, i))
+	}
+}
+
+func pow10(n int) int {
+	r := 1
+	for i := 0; i < n; i++ {
+		r *= 10
+	}
+	return r
+}
+
+func main() {
+	// Дедупликация: одинаковые файлы разных пользователей дают один шифротекст
+	s := &store{blobs: make(map[[32]byte][]byte)}
+	files := [][]byte{
+		[]byte("quarterly report v1"),
+		[]byte("quarterly report v1"),
+		[]byte("holiday photos"),
+		[]byte("quarterly report v1"),
+	}
+	for _, f := range files {
+		key, ct, err := mycrypto.ConvergentEncrypt(f, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		id := s.put(ct)
+		pt, err := mycrypto.ConvergentDecrypt(key, s.blobs[id], nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("uploaded %-22q blob %x... decrypts to %q\n", f, id[:6], pt)
+	}
+	fmt.Printf("Uploaded %d bytes, stored %d bytes in %d blobs\n", s.uploaded, s.size(), len(s.blobs))
+
+	// Подтверждение файла
+	_, ct, err := mycrypto.ConvergentEncrypt(files[0], nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, guess := range []string{"quarterly report v1", "quarterly report v2"} {
+		ok, err := myattacks.ConfirmFile(ct, []byte(guess), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Confirm %q: %v\n", guess, ok)
+	}
+
+	// Узнать оставшееся: перебор PIN в известном шаблоне
+	var timePts, triesPts plotter.XYs
+	for digits := 1; digits <= 5; digits++ {
+		tmpl := letter(digits)
+		pin := pow10(digits) * 7 / 10
+		_, ct, err := mycrypto.ConvergentEncrypt(tmpl(pin), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		start := time.Now()
+		found, tries, err := myattacks.LearnRemaining(ct, nil, pow10(digits), tmpl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		elapsed := time.Since(start)
+		fmt.Printf("PIN of %d digits: found %0*d after %d tries in %v\n", digits, digits, found, tries, elapsed)
+		timePts = append(timePts, plotter.XY{X: float64(digits), Y: elapsed.Seconds() * 1000})
+		triesPts = append(triesPts, plotter.XY{X: float64(digits), Y: float64(tries)})
+	}
+
+	// Секрет домена: без него перебор ничего не находит
+	secret := []byte("per-tenant secret")
+	_, ct, err = mycrypto.ConvergentEncrypt(letter(4)(1234), secret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	found, tries, err := myattacks.LearnRemaining(ct, nil, pow10(4), letter(4))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("With domain secret: found %d after %d tries\n", found, tries)
+
+	if err := plotResults("Learn-the-remaining-information attack", "PIN digits", "Time (ms)",
+		"graphs/convergent_attack_time.png", "Time", timePts); err != nil {
+		log.Fatal(err)
+	}
+	if err := plotResults("Learn-the-remaining-information attack", "PIN digits", "Encryptions",
+		"graphs/convergent_attack_tries.png", "Tries", triesPts); err != nil {
+		log.Fatal(err)
+	}
+}
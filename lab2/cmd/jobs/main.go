@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/sagilyp/lab2/myjobs"
+)
+
+const usage = `usage:
+  jobs [-db file] submit -alg birthday|pollard [-hash name] -bits N -n collisions
+  jobs [-db file] run [-workers N]
+  jobs [-db file] list
+  jobs [-db file] status ID`
+
+func main() {
+	db := flag.String("db", "jobs.db", "job queue file (bbolt)")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	q, err := myjobs.Open(*db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "submit":
+		fs := flag.NewFlagSet("submit", flag.ExitOnError)
+		alg := fs.String("alg", myjobs.AlgPollard, "attack algorithm")
+		hash := fs.String("hash", "SHA-256", "attacked hash function")
+		bits := fs.Int("bits", 16, "truncated output bits")
+		n := fs.Int("n", 10, "collisions needed")
+		fs.Parse(args)
+		id, err := q.Submit(myjobs.Request{Algorithm: *alg, Hash: *hash, OutBits: *bits, Collisions: *n})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("job %d queued\n", id)
+	case "run":
+		fs := flag.NewFlagSet("run", flag.ExitOnError)
+		workers := fs.Int("workers", 2, "number of workers")
+		fs.Parse(args)
+		fmt.Printf("running %d queued jobs with %d workers\n", q.Pending(), *workers)
+		q.Start(*workers)
+		q.Drain()
+		if err := q.Err(); err != nil {
+			log.Fatalf("queue stopped: %v", err)
+		}
+	case "list":
+		for _, j := range q.List() {
+			fmt.Printf("%4d  %-8s %-8s %-12s %2d bits  %s\n", j.ID, j.Status, j.Request.Algorithm, j.Request.Hash, j.Request.OutBits, summary(j))
+		}
+	case "status":
+		if len(args) != 1 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		j, ok := q.Get(id)
+		if !ok {
+			log.Fatalf("job %d not found", id)
+		}
+		fmt.Printf("job %d: %s, %s\n", j.ID, j.Status, summary(j))
+		if j.Result != nil {
+			for i, c := range j.Result.Collisions {
+				fmt.Printf("  collision %d: %s = %s\n", i+1, c.X, c.Y)
+			}
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := q.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func summary(j myjobs.Job) string {
+	switch {
+	case j.Result != nil:
+		return fmt.Sprintf("%d collisions, %d iterations, %v", len(j.Result.Collisions), j.Result.Iterations, j.Result.Elapsed)
+	case j.Error != "":
+		return "error: " + j.Error
+	}
+	return ""
+}
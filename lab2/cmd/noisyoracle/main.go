@@ -0,0 +1,136 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab2/myattacks"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// Число шифротекстов, по которым оценивается точность ответов оракула
+const samples = 400
+
+// Число повторов при временных ошибках
+const attempts = 1000
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+// sample - шифротекст и истинный ответ оракула паддинга для него
+type sample struct {
+	ct    []byte
+	valid bool
+}
+
+// makeSamples шифрует случайные сообщения; у половины шифротекстов портится предпоследний блок,
+// что обычно ломает паддинг. Истинный ответ берётся у оракула без шума.
+func makeSamples(o *myattacks.CipherOracle) ([]sample, error) {
+	res := make([]sample, samples)
+	for i := range res {
+		msg := make([]byte, 20)
+		if _, err := rand.Read(msg); err != nil {
+			return nil, err
+		}
+		ct, err := o.Encrypt(msg)
+		if err != nil {
+			return nil, err
+		}
+		if i%2 == 1 {
+			ct[len(ct)-mycrypto.AESBlockSize-1] ^= 0x5a
+		}
+		valid, err := o.PaddingValid(ct)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = sample{ct: ct, valid: valid}
+	}
+	return res, nil
+}
+
+// accuracy возвращает долю верных ответов голосования и среднее число запросов на ответ
+func accuracy(cfg myattacks.NoiseConfig, inner *myattacks.CipherOracle, ss []sample, votes int) (float64, float64, error) {
+	o := myattacks.NewNoisyOracle(cfg, nil, nil, nil, inner)
+	correct := 0
+	for _, s := range ss {
+		ok, err := myattacks.Vote(votes, attempts, func() (bool, error) { return o.PaddingValid(s.ct) })
+		if err != nil {
+			return 0, 0, err
+		}
+		if ok == s.valid {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(ss)), float64(o.Queries()) / float64(len(ss)), nil
+}
+
+func main() {
+	key := make([]byte, mycrypto.AESKeySize16)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	inner, err := myattacks.NewCipherOracle(mycrypto.ModeCBC, key, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ss, err := makeSamples(inner)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Точность ответа большинства в зависимости от вероятности неверного ответа
+	flipRates := []float64{0, 0.05, 0.1, 0.15, 0.2, 0.25, 0.3, 0.35, 0.4}
+	accSeries := []interface{}{}
+	for _, votes := range []int{1, 3, 7, 15} {
+		pts := make(plotter.XYs, 0, len(flipRates))
+		for _, fr := range flipRates {
+			acc, _, err := accuracy(myattacks.NoiseConfig{FlipRate: fr}, inner, ss, votes)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("flip rate %.2f, %2d votes: accuracy %.3f\n", fr, votes, acc)
+			pts = append(pts, plotter.XY{X: fr, Y: acc})
+		}
+		accSeries = append(accSeries, fmt.Sprintf("%d votes", votes), pts)
+	}
+	if err := plotResults("Padding oracle accuracy vs noise", "Flip rate", "Accuracy", "graphs/noisy_oracle_accuracy.png", accSeries...); err != nil {
+		log.Fatal(err)
+	}
+
+	// Число запросов на один ответ при временных ошибках (ожидается votes / (1 - p))
+	errRates := []float64{0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8}
+	qSeries := []interface{}{}
+	for _, votes := range []int{1, 3, 7} {
+		pts := make(plotter.XYs, 0, len(errRates))
+		for _, er := range errRates {
+			_, q, err := accuracy(myattacks.NoiseConfig{ErrorRate: er}, inner, ss, votes)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("error rate %.1f, %d votes: %.2f queries per answer (expected %.2f)\n", er, votes, q, float64(votes)/(1-er))
+			pts = append(pts, plotter.XY{X: er, Y: q})
+		}
+		qSeries = append(qSeries, fmt.Sprintf("%d votes", votes), pts)
+	}
+	if err := plotResults("Oracle queries vs transient error rate", "Error rate", "Queries per answer", "graphs/noisy_oracle_queries.png", qSeries...); err != nil {
+		log.Fatal(err)
+	}
+
+	// Бюджет запросов: при его исчерпании атака получает ErrBudgetExhausted
+	budget := myattacks.NoiseConfig{FlipRate: 0.2, Budget: 3 * samples}
+	_, _, err = accuracy(budget, inner, ss, 7)
+	fmt.Printf("budget %d queries, 7 votes per answer: %v\n", budget.Budget, err)
+}
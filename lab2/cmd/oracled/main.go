@@ -0,0 +1,45 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab2/myattacks"
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// oracled - сетевая цель для атак: публикует оракулы шифрования, расшифрования,
+// проверки паддинга и MAC со случайными ключами, известными только серверу
+func main() {
+	addr := flag.String("addr", "localhost:8080", "listen address")
+	mode := flag.String("mode", mycrypto.ModeCBC, "cipher mode")
+	macMode := flag.String("mac", mymac.OMAC, "MAC algorithm")
+	flag.Parse()
+
+	key := make([]byte, mycrypto.AESKeySize16)
+	macKey := make([]byte, mymac.AESKeySize)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := rand.Read(macKey); err != nil {
+		log.Fatal(err)
+	}
+	// Оракулы уязвимы намеренно; предупреждения напоминают, что именно эксплуатируется
+	for _, w := range mycrypto.Analyze(mycrypto.Config{Mode: *mode, KeySize: len(key)}) {
+		log.Printf("config %s", w)
+	}
+	cipherOracle, err := myattacks.NewCipherOracle(*mode, key, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	macOracle, err := myattacks.NewMACOracle(*macMode, macKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("serving %s and %s oracles on http://%s", *mode, *macMode, *addr)
+	h := myattacks.OracleHandler(cipherOracle, cipherOracle, macOracle, cipherOracle)
+	log.Fatal(http.ListenAndServe(*addr, h))
+}
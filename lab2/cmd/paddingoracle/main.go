@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab2/myattacks"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// Число сообщений каждой длины, по которым усредняется число запросов
+const trials = 5
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+// oracle - цель атаки: шифрует сообщения и отвечает на вопросы о паддинге
+type oracle interface {
+	myattacks.EncryptionOracle
+	myattacks.PaddingOracle
+}
+
+// attack шифрует msg у оракула и восстанавливает его атакой на паддинг
+func attack(o oracle, msg []byte) (myattacks.PaddingOracleStats, error) {
+	ct, err := o.Encrypt(msg)
+	if err != nil {
+		return myattacks.PaddingOracleStats{}, err
+	}
+	got, st, err := myattacks.PaddingOracleAttack(o, ct, mycrypto.AESBlockSize)
+	if err != nil {
+		return st, err
+	}
+	if !bytes.Equal(got, msg) {
+		return st, fmt.Errorf("recovered %q, expected %q", got, msg)
+	}
+	return st, nil
+}
+
+func main() {
+	url := flag.String("url", "", "attack a remote oracle started by cmd/oracled (-mode CBC) instead of a local one")
+	flag.Parse()
+
+	var o oracle
+	if *url != "" {
+		o = myattacks.NewRemoteOracle(*url)
+	} else {
+		key := make([]byte, mycrypto.AESKeySize16)
+		if _, err := rand.Read(key); err != nil {
+			log.Fatal(err)
+		}
+		local, err := myattacks.NewCipherOracle(mycrypto.ModeCBC, key, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		o = local
+	}
+
+	// Демонстрация на одном сообщении
+	secret := []byte("attack at dawn, the password is hunter2")
+	st, err := attack(o, secret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("recovered %q: %d blocks, %d queries, %.1f queries per byte\n", secret, st.Blocks, st.Queries, st.QueriesPerByte())
+	if *url != "" {
+		return
+	}
+
+	// Число запросов в зависимости от длины сообщения
+	lengths := []int{16, 32, 64, 128, 256, 512}
+	var total, perByte plotter.XYs
+	for _, n := range lengths {
+		queries, bytesDone := 0, 0
+		for t := 0; t < trials; t++ {
+			msg := make([]byte, n)
+			if _, err := rand.Read(msg); err != nil {
+				log.Fatal(err)
+			}
+			st, err := attack(o, msg)
+			if err != nil {
+				log.Fatal(err)
+			}
+			queries += st.Queries
+			bytesDone += st.Bytes
+		}
+		avg := float64(queries) / trials
+		qpb := float64(queries) / float64(bytesDone)
+		fmt.Printf("message %4d bytes: %8.0f queries, %.1f queries per byte\n", n, avg, qpb)
+		total = append(total, plotter.XY{X: float64(n), Y: avg})
+		perByte = append(perByte, plotter.XY{X: float64(n), Y: qpb})
+	}
+	if err := plotResults("Padding oracle attack", "Message length, bytes", "Oracle queries", "graphs/padding_oracle_queries.png", "queries", total); err != nil {
+		log.Fatal(err)
+	}
+	if err := plotResults("Padding oracle attack", "Message length, bytes", "Queries per byte", "graphs/padding_oracle_per_byte.png", "queries per byte", perByte); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+
+	"github.com/sagilyp/lab2/myperf"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+const usage = `usage:
+  perftrack [-db file] run [-bench regexp]
+  perftrack [-db file] list
+  perftrack [-db file] check [-threshold 10] [-base commit] [-head commit]
+  perftrack [-db file] plot [-o graphs/perf_trend.png]`
+
+func short(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
+
+func main() {
+	dbPath := flag.String("db", "perf.json", "results database")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	db, err := myperf.Open(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "run":
+		fs := flag.NewFlagSet("run", flag.ExitOnError)
+		bench := fs.String("bench", "", "run only kernels matching this regexp")
+		fs.Parse(args)
+		var filter *regexp.Regexp
+		if *bench != "" {
+			if filter, err = regexp.Compile(*bench); err != nil {
+				log.Fatal(err)
+			}
+		}
+		rec, err := myperf.NewRecord(".", myperf.Suite(), filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if rec.Dirty {
+			fmt.Println("warning: working tree has uncommitted changes, the record may not match the commit")
+		}
+		for _, r := range rec.Results {
+			speed := "-"
+			if r.MBPerSec > 0 {
+				speed = fmt.Sprintf("%.2f", r.MBPerSec)
+			}
+			fmt.Printf("%-18s %14.0f ns/op %10s MB/s %6d allocs/op\n", r.Name, r.NsPerOp, speed, r.AllocsPerOp)
+		}
+		db.Add(rec)
+		if err := db.Save(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("recorded %s in %s (%d revisions)\n", short(rec.Commit), *dbPath, len(db.Records))
+	case "list":
+		for _, r := range db.Records {
+			dirty := ""
+			if r.Dirty {
+				dirty = " (dirty)"
+			}
+			fmt.Printf("%s  %s  %s  %d kernels%s\n", short(r.Commit), r.Time.Format("2006-01-02 15:04"), r.GoVersion, len(r.Results), dirty)
+		}
+	case "check":
+		fs := flag.NewFlagSet("check", flag.ExitOnError)
+		threshold := fs.Float64("threshold", 10, "allowed slowdown in percent")
+		base := fs.String("base", "", "baseline commit (default: the record before head)")
+		head := fs.String("head", "", "checked commit (default: the latest record)")
+		fs.Parse(args)
+		if len(db.Records) < 2 && (*base == "" || *head == "") {
+			log.Fatal("check: need at least two records")
+		}
+		headRec, baseRec := db.Records[len(db.Records)-1], db.Records[len(db.Records)-2]
+		if *head != "" {
+			if headRec, err = db.Find(*head); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if *base != "" {
+			if baseRec, err = db.Find(*base); err != nil {
+				log.Fatal(err)
+			}
+		}
+		regs, err := myperf.Compare(baseRec, headRec, *threshold)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s -> %s, threshold %.1f%%\n", short(baseRec.Commit), short(headRec.Commit), *threshold)
+		for _, r := range regs {
+			fmt.Printf("REGRESSION %-18s %14.0f -> %14.0f ns/op (%+.1f%%)\n", r.Name, r.Base, r.Head, r.ChangePct)
+		}
+		if len(regs) > 0 {
+			os.Exit(1)
+		}
+		fmt.Println("no regressions")
+	case "plot":
+		fs := flag.NewFlagSet("plot", flag.ExitOnError)
+		out := fs.String("o", "graphs/perf_trend.png", "output plot")
+		fs.Parse(args)
+		if len(db.Records) == 0 {
+			log.Fatal("plot: database is empty")
+		}
+		// Время каждого ядра относительно первой записи, в которой оно встречается
+		series := []interface{}{}
+		for _, name := range db.Names() {
+			pts := make(plotter.XYs, 0)
+			first := 0.0
+			for i, rec := range db.Records {
+				for _, r := range rec.Results {
+					if r.Name != name {
+						continue
+					}
+					if first == 0 {
+						first = r.NsPerOp
+					}
+					pts = append(pts, plotter.XY{X: float64(i), Y: r.NsPerOp / first})
+				}
+			}
+			series = append(series, name, pts)
+		}
+		p := plot.New()
+		p.Title.Text = "Performance by revision"
+		p.X.Label.Text = "Commit"
+		p.Y.Label.Text = "Time relative to first record"
+		ticks := make([]plot.Tick, len(db.Records))
+		for i, rec := range db.Records {
+			ticks[i] = plot.Tick{Value: float64(i), Label: short(rec.Commit)}
+		}
+		p.X.Tick.Marker = plot.ConstantTicks(ticks)
+		p.Legend.Top = true
+		p.Legend.Left = true
+		if err := plotutil.AddLinePoints(p, series...); err != nil {
+			log.Fatal(err)
+		}
+		if err := p.Save(8*vg.Inch, 4*vg.Inch, *out); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab2/myattacks"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+func plotResults(title, xLabel, yLabel, filename string, logX bool, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	p.Legend.Top = true
+	p.Legend.Left = true
+	if logX {
+		p.X.Scale = plot.LogScale{}
+		p.X.Tick.Marker = plot.LogTicks{Prec: -1}
+	}
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+// broadcast шифрует msg на n случайных 16-байтовых ключах RC4
+func broadcast(msg []byte, n int) ([][]byte, error) {
+	cts := make([][]byte, n)
+	key := make([]byte, 16)
+	for i := range cts {
+		if _, err := io.ReadFull(myattacks.Rand, key); err != nil {
+			return nil, err
+		}
+		c, err := mycrypto.NewRC4(key)
+		if err != nil {
+			return nil, err
+		}
+		cts[i] = make([]byte, len(msg))
+		c.XORKeyStream(cts[i], msg)
+	}
+	return cts, nil
+}
+
+func main() {
+	numKeys := flag.Int("keys", 1<<20, "random keys for the keystream statistics")
+	positions := flag.Int("positions", 16, "keystream positions to examine")
+	trials := flag.Int("trials", 100, "attack trials per number of ciphertexts")
+	flag.Parse()
+
+	// Распределение байтов гаммы по позициям: отношение частоты нуля к 1/256
+	counts, err := myattacks.RC4KeystreamCounts(*numKeys, 16, *positions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	expected := float64(*numKeys) / 256
+	zeroPts := make(plotter.XYs, 0, *positions)
+	fmt.Printf("%8s %14s %14s\n", "position", "Pr[Z=0]*256", "max other*256")
+	for r, c := range counts {
+		maxOther := uint64(0)
+		for v := 1; v < 256; v++ {
+			maxOther = max(maxOther, c[v])
+		}
+		fmt.Printf("%8d %14.3f %14.3f\n", r+1, float64(c[0])/expected, float64(maxOther)/expected)
+		zeroPts = append(zeroPts, plotter.XY{X: float64(r + 1), Y: float64(c[0]) / expected})
+	}
+	second := make(plotter.XYs, 256)
+	first := make(plotter.XYs, 256)
+	for v := 0; v < 256; v++ {
+		second[v] = plotter.XY{X: float64(v), Y: float64(counts[1][v]) / expected}
+		first[v] = plotter.XY{X: float64(v), Y: float64(counts[0][v]) / expected}
+	}
+	title := fmt.Sprintf("RC4 keystream byte distribution (%d keys)", *numKeys)
+	if err := plotResults(title, "Byte value", "Frequency × 256", "graphs/rc4_second_byte.png", false, "Z1", first, "Z2", second); err != nil {
+		log.Fatal(err)
+	}
+	if err := plotResults("Pr[Z_r = 0] relative to uniform", "Keystream position r", "Pr[Z_r = 0] × 256", "graphs/rc4_zero_bias.png", false, "RC4", zeroPts); err != nil {
+		log.Fatal(err)
+	}
+
+	// Широковещательная атака: доля верно восстановленных вторых байтов
+	msg := []byte("XSECRET MESSAGE")
+	success := make(plotter.XYs, 0)
+	fmt.Printf("\n%12s %10s\n", "ciphertexts", "success")
+	for n := 16; n <= 8192; n *= 2 {
+		ok := 0
+		for t := 0; t < *trials; t++ {
+			cts, err := broadcast(msg, n)
+			if err != nil {
+				log.Fatal(err)
+			}
+			b, _, err := myattacks.RecoverSecondByte(cts)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if b == msg[1] {
+				ok++
+			}
+		}
+		rate := float64(ok) / float64(*trials)
+		fmt.Printf("%12d %10.2f\n", n, rate)
+		success = append(success, plotter.XY{X: float64(n), Y: rate})
+	}
+	if err := plotResults("Broadcast attack on the second plaintext byte", "Ciphertexts", "Success rate", "graphs/rc4_broadcast.png", true, "RC4", success); err != nil {
+		log.Fatal(err)
+	}
+}
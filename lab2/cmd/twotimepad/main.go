@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab2/myattacks"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// Сообщения, зашифрованные на одном ключе с одним и тем же начальным блоком счётчика
+var messages = []string{
+	"The meeting with the supplier has been moved to Thursday morning.",
+	"Please send the quarterly report to the finance team before noon.",
+	"Our new office in the city center opens at the end of this month.",
+	"The server will be down for maintenance between two and four.",
+	"Remember to change the default password on every new router.",
+	"The budget for the next year was approved by the board today.",
+	"We need three more engineers to finish the project on time.",
+	"The train to the airport leaves every twenty minutes from here.",
+	"All visitors must sign in at the front desk and wear a badge.",
+	"The contract was signed and the first payment is due next week.",
+	"Backups are stored offsite and tested at least once a quarter.",
+	"The conference call is scheduled for three in the afternoon.",
+	"She asked whether the new policy applies to remote employees.",
+	"Shipping costs have risen sharply since the start of the year.",
+	"The audit found no problems with the accounts of the company.",
+	"He left the keys to the storage room on the table by the door.",
+	"The launch of the product was delayed because of a late part.",
+	"Customers can now track their orders online at any time.",
+	"The training session for new staff starts at nine tomorrow.",
+	"Please review the attached draft and send comments by Friday.",
+	"The weather forecast promises rain for most of the weekend.",
+	"A copy of the invoice was sent to the customer this morning.",
+	"The team celebrated the release with pizza in the kitchen.",
+	"Access to the data center is limited to authorized personnel.",
+}
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+// encryptAll шифрует сообщения оракулом CTR и отрезает IV: при повторе он у всех одинаков
+func encryptAll(o *myattacks.CipherOracle) [][]byte {
+	cts := make([][]byte, len(messages))
+	for i, m := range messages {
+		ct, err := o.Encrypt([]byte(m))
+		if err != nil {
+			log.Fatal(err)
+		}
+		cts[i] = ct[mycrypto.AESBlockSize:]
+	}
+	return cts
+}
+
+// accuracy - доля байтов сообщений, которые гамма ks расшифровывает верно
+func accuracy(cts [][]byte, ks []byte) float64 {
+	good, total := 0, 0
+	for i, ct := range cts {
+		for j, c := range ct {
+			if c^ks[j] == messages[i][j] {
+				good++
+			}
+			total++
+		}
+	}
+	return float64(good) / float64(total)
+}
+
+// printable заменяет непечатные символы точками
+func printable(b []byte) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r >= 0x7f {
+			return '.'
+		}
+		return r
+	}, string(b))
+}
+
+func main() {
+	key := make([]byte, mycrypto.AESKeySize16)
+	iv := make([]byte, mycrypto.AESBlockSize)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := rand.Read(iv[:mycrypto.NonceSize+mycrypto.IVSize]); err != nil {
+		log.Fatal(err)
+	}
+	// Ошибка, которую демонстрирует программа: один и тот же nonce || IV для всех сообщений
+	reused, err := myattacks.NewCipherOracle(mycrypto.ModeCTR, key, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cts := encryptAll(reused)
+
+	// 1. Два сообщения: C1 xor C2 = P1 xor P2, протаскиваем " the "
+	x := myattacks.XORBytes(cts[0], cts[1])
+	fmt.Println("crib dragging \" the \" over C1 xor C2:")
+	hits := myattacks.CribDrag(x, []byte(" the "))
+	for _, h := range hits[:min(5, len(hits))] {
+		fmt.Printf("  offset %2d: %q\n", h.Offset, h.Text)
+	}
+
+	// 2. Много сообщений: статистика по столбцам
+	ks, err := myattacks.RecoverKeystream(cts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\nstatistical recovery from %d ciphertexts, %.1f%% of bytes correct:\n", len(cts), 100*accuracy(cts, ks))
+	for _, ct := range cts[:4] {
+		fmt.Printf("  %s\n", printable(myattacks.XORBytes(ct, ks)))
+	}
+
+	// 3. Одна известная строка исправляет гамму на всю свою длину
+	if err := myattacks.ApplyCrib(ks, cts[0], 0, []byte(messages[0])); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\nafter one known message: %.1f%% of bytes correct\n", 100*accuracy(cts, ks))
+	for _, ct := range cts[:4] {
+		fmt.Printf("  %s\n", printable(myattacks.XORBytes(ct, ks)))
+	}
+
+	// 4. С новым IV для каждого сообщения гаммы разные и статистика ничего не даёт
+	fresh, err := myattacks.NewCipherOracle(mycrypto.ModeCTR, key, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	freshCts := encryptAll(fresh)
+	freshKs, err := myattacks.RecoverKeystream(freshCts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\nfresh IV per message: %.1f%% of bytes correct\n", 100*accuracy(freshCts, freshKs))
+
+	// 5. Трекер nonce отвергает повтор ещё до шифрования
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		log.Fatal(err)
+	}
+	if err := mc.SetMode(mycrypto.ModeCTR); err != nil {
+		log.Fatal(err)
+	}
+	tracker, err := mycrypto.NewNonceTracker(1024)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mc.SetNonceTracker(tracker)
+	for _, m := range messages[:2] {
+		_, err := mc.Encrypt([]byte(m), iv)
+		fmt.Printf("nonce tracker, encrypt with the same IV: err = %v\n", err)
+	}
+
+	// Точность в зависимости от числа шифротекстов с повторённой гаммой
+	var reusedPts, freshPts plotter.XYs
+	for n := 2; n <= len(cts); n++ {
+		ks, err := myattacks.RecoverKeystream(cts[:n])
+		if err != nil {
+			log.Fatal(err)
+		}
+		fks, err := myattacks.RecoverKeystream(freshCts[:n])
+		if err != nil {
+			log.Fatal(err)
+		}
+		reusedPts = append(reusedPts, plotter.XY{X: float64(n), Y: 100 * accuracy(cts[:n], ks)})
+		freshPts = append(freshPts, plotter.XY{X: float64(n), Y: 100 * accuracy(freshCts[:n], fks)})
+	}
+	if err := plotResults("CTR keystream reuse", "Ciphertexts", "Recovered bytes, %", "graphs/ctr_reuse_accuracy.png",
+		"reused IV", reusedPts, "fresh IV", freshPts); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -2,17 +2,28 @@ module github.com/sagilyp/lab2
 
 go 1.23.0
 
-require gonum.org/v1/plot v0.15.2
+require (
+	github.com/sagilyp/lab1 v0.0.0
+	github.com/sagilyp/lab3 v0.0.0
+	go.etcd.io/bbolt v1.3.11
+	gonum.org/v1/plot v0.16.0
+)
 
 require (
-	codeberg.org/go-fonts/liberation v0.4.1 // indirect
-	codeberg.org/go-latex/latex v0.0.1 // indirect
+	codeberg.org/go-fonts/liberation v0.5.0 // indirect
+	codeberg.org/go-latex/latex v0.1.0 // indirect
 	codeberg.org/go-pdf/fpdf v0.10.0 // indirect
 	git.sr.ht/~sbinet/gg v0.6.0 // indirect
 	github.com/ajstarks/svgo v0.0.0-20211024235047-1546f124cd8b // indirect
 	github.com/campoy/embedmd v1.0.0 // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/image v0.24.0 // indirect
-	golang.org/x/text v0.22.0 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/image v0.25.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
 )
+
+replace github.com/sagilyp/lab1 => ../lab1
+
+replace github.com/sagilyp/lab3 => ../lab3
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"crypto/rand"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/sagilyp/lab1/myrand"
 	"github.com/sagilyp/lab2/myattacks"
+	"github.com/sagilyp/lab3/mystats"
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/plotutil"
@@ -34,14 +39,61 @@ func plotResults(title, xLabel, yLabel, filename string, series ...interface{})
 	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
 }
 
+// setupRand подменяет источник случайности атак для записи или воспроизведения прогона
+func setupRand(recordFile, replayFile string) (func(), error) {
+	switch {
+	case recordFile != "" && replayFile != "":
+		return nil, fmt.Errorf("-record and -replay are mutually exclusive")
+	case recordFile != "":
+		f, err := os.Create(recordFile)
+		if err != nil {
+			return nil, err
+		}
+		myattacks.Rand = myrand.NewRecorder(rand.Reader, f)
+		return func() { f.Close() }, nil
+	case replayFile != "":
+		f, err := os.Open(replayFile)
+		if err != nil {
+			return nil, err
+		}
+		myattacks.Rand = myrand.NewReplayer(f)
+		return func() { f.Close() }, nil
+	}
+	return func() {}, nil
+}
+
 func main() {
+	hashName := flag.String("hash", "SHA-256", "attacked hash function: "+strings.Join(myattacks.HashNames(), ", "))
+	recordFile := flag.String("record", "", "record all random draws to this file")
+	replayFile := flag.String("replay", "", "replay random draws from a file written by -record")
+	confidence := flag.Float64("confidence", 0.95, "confidence level of the collision rate estimate")
+	relErr := flag.Float64("relerr", 0.1, "allowed relative error of the collision rate estimate (0.1 gives 385 collisions at 95%)")
+	flag.Parse()
+	// число коллизий - как у счёта пуассоновских событий: погрешность 1/sqrt(k), k = (z/relErr)^2;
+	// по умолчанию - обычные 10% при доверии 95%, т.е. 385 коллизий
+	numColls, err := mystats.EventsNeeded(*relErr, *confidence)
+	if err != nil {
+		log.Fatal(err)
+	}
+	closeRand, err := setupRand(*recordFile, *replayFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeRand()
+	hashFunc, err := myattacks.LookupHash(*hashName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Attacked hash function: %s, %d collisions per run (±%.0f%% at %.0f%% confidence)\n",
+		*hashName, numColls, 100*(*relErr), 100*(*confidence))
+
 	var bResults []Result
 	var pResults []Result
 
 	for _, bits := range OutBitsList {
 		fmt.Printf("\n=== Experiment for truncated output = %d bits ===\n", bits)
 		//Birthday Attack
-		bColls, bIters, bMem, bElapsed, err := myattacks.BirthdayAttack(myattacks.NumCollisionNeeded, bits)
+		bColls, bIters, bMem, bElapsed, err := myattacks.BirthdayAttackHash(hashFunc, numColls, bits)
 		if err != nil {
 			log.Fatalf("Birthday Attack error for %d bits: %v", bits, err)
 		}
@@ -53,7 +105,7 @@ func main() {
 			Collisions: bColls,
 		})
 		//Pollard Attack
-		pColls, pIters, pMem, pElapsed, err := myattacks.PollardAttack(bits, myattacks.DistBits, myattacks.NumCollisionNeeded, myattacks.NumWorkers)
+		pColls, pIters, pMem, pElapsed, err := myattacks.PollardAttackHash(hashFunc, bits, myattacks.DistBits, numColls, myattacks.NumWorkers)
 		if err != nil {
 			log.Fatalf("Pollard error for %d bits: %v", bits, err)
 		}
@@ -113,7 +165,7 @@ func main() {
 		pMemPts[i].Y = float64(res.Memory)
 	}
 	err = plotResults(
-		"Time vs Output Bits (150 collisions)",
+		fmt.Sprintf("Time vs Output Bits (%d collisions)", numColls),
 		"Output Bits", "Time (ms)",
 		"graphs/time_cmp.png",
 		"Birthday Attack", bTimePts,
@@ -123,7 +175,7 @@ func main() {
 		log.Fatal(err)
 	}
 	err = plotResults(
-		"Memory vs Output Bits (150 collisions)",
+		fmt.Sprintf("Memory vs Output Bits (%d collisions)", numColls),
 		"Output Bits", "Memory (bits)",
 		"graphs/memory_cmp.png",
 		"Birthday Attack", bMemPts,
@@ -1,7 +1,6 @@
 package myattacks
 
 import (
-	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -11,25 +10,30 @@ import (
 
 // Атака на основе парадокса о днях рождения
 func BirthdayAttack(num int, outBits int) ([]Collision, int, int, time.Duration, error) {
+	return BirthdayAttackHash(SHA_xx, num, outBits)
+}
+
+// BirthdayAttackHash - атака дней рождений на произвольную хэш-функцию h
+func BirthdayAttackHash(h HashFunc, num int, outBits int) ([]Collision, int, int, time.Duration, error) {
 	collisions := []Collision{}
 	dict := make(map[string]string)
 	iterations := 0
 	start := time.Now()
 	v := make([]byte, MsgLen)
 	for len(collisions) < num {
-		if n, err := rand.Read(v); err != nil || n != MsgLen {
+		if n, err := Rand.Read(v); err != nil || n != MsgLen {
 			return nil, iterations, 0, time.Since(start), errors.New("failed to generate random vector")
 		}
-		h, err := SHA_xx(v, outBits)
+		digest, err := h(v, outBits)
 		if err != nil {
 			return nil, iterations, 0, time.Since(start), err
 		}
-		if prev, ok := dict[h]; ok {
+		if prev, ok := dict[digest]; ok {
 			if prev != hex.EncodeToString(v) && !containColl(collisions, Collision{X: prev, Y: hex.EncodeToString(v)}) {
 				collisions = append(collisions, Collision{X: prev, Y: hex.EncodeToString(v)})
 			}
 		} else {
-			dict[h] = hex.EncodeToString(v)
+			dict[digest] = hex.EncodeToString(v)
 		}
 		iterations++
 	}
@@ -1,19 +1,24 @@
 package myattacks
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 )
 
+// Rand - источник случайности для сообщений и начальных точек цепочек.
+// Для отладки его можно заменить на myrand.Recorder или myrand.Replayer из lab1.
+var Rand io.Reader = rand.Reader
+
 const (
-	MinOut             = 8
-	MaxOut             = 24
-	MsgLen             = 6
-	DistBits           = 2
-	NumWorkers         = 4
-	NumCollisionNeeded = 150
+	MinOut     = 8
+	MaxOut     = 24
+	MsgLen     = 6
+	DistBits   = 2
+	NumWorkers = 4
 )
 
 // Структура для хранения пары сообщений, давшей одинаковый хэш
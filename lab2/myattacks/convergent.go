@@ -0,0 +1,40 @@
+package myattacks
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Атаки на конвергентное шифрование -----
+
+// ConfirmFile - атака подтверждения файла: противник, видящий шифротекст ct в хранилище,
+// шифрует кандидата тем же детерминированным способом и сравнивает результат.
+// secret - известный противнику секрет домена (обычно пустой).
+func ConfirmFile(ct, candidate, secret []byte) (bool, error) {
+	_, guess, err := mycrypto.ConvergentEncrypt(candidate, secret)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(guess, ct), nil
+}
+
+// LearnRemaining - атака «узнать оставшееся»: файл известен, кроме небольшой части
+// (PIN, сумма, пароль). template(i) строит i-го кандидата из space возможных.
+// Возвращает индекс найденного кандидата (-1, если перебор не дал результата) и число попыток.
+func LearnRemaining(ct, secret []byte, space int, template func(i int) []byte) (int, int, error) {
+	if space <= 0 {
+		return -1, 0, errors.New("LearnRemaining: empty candidate space")
+	}
+	for i := 0; i < space; i++ {
+		ok, err := ConfirmFile(ct, template(i), secret)
+		if err != nil {
+			return -1, i + 1, err
+		}
+		if ok {
+			return i, i + 1, nil
+		}
+	}
+	return -1, space, nil
+}
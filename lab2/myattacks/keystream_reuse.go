@@ -0,0 +1,126 @@
+package myattacks
+
+import (
+	"errors"
+	"sort"
+)
+
+// ----- Повтор гаммы CTR (two-time pad) -----
+
+// englishFreq - частоты пробела и букв в английском тексте (доли)
+var englishFreq = map[byte]float64{
+	' ': 0.183, 'e': 0.102, 't': 0.075, 'a': 0.065, 'o': 0.062, 'n': 0.057, 'i': 0.057,
+	's': 0.053, 'r': 0.050, 'h': 0.050, 'l': 0.033, 'd': 0.033, 'u': 0.023, 'c': 0.022,
+	'm': 0.020, 'f': 0.020, 'w': 0.017, 'g': 0.016, 'p': 0.015, 'y': 0.014, 'b': 0.013,
+	'v': 0.008, 'k': 0.006, 'x': 0.001, 'j': 0.001, 'q': 0.001, 'z': 0.001,
+}
+
+// EnglishScore оценивает, насколько text похож на английский текст: буквы и пробел
+// добавляют свою частоту, прочие печатные символы немного, непечатные штрафуются
+func EnglishScore(text []byte) float64 {
+	score := 0.0
+	for _, c := range text {
+		lower := c
+		if c >= 'A' && c <= 'Z' {
+			lower = c + 'a' - 'A'
+		}
+		switch f, ok := englishFreq[lower]; {
+		case ok:
+			score += f
+			if lower != c {
+				score -= 0.01 // заглавные встречаются реже строчных
+			}
+		case c == '\n' || (c >= 0x20 && c < 0x7f):
+			score += 0.001
+		default:
+			score -= 0.2
+		}
+	}
+	return score
+}
+
+// XORBytes возвращает a xor b по длине более короткого. Для двух шифротекстов на одной гамме
+// это xor открытых текстов: гамма сокращается.
+func XORBytes(a, b []byte) []byte {
+	out := make([]byte, min(len(a), len(b)))
+	for i := range out {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+// CribHit - позиция, на которой предполагаемый фрагмент даёт осмысленный текст во втором сообщении
+type CribHit struct {
+	Offset int
+	Text   []byte
+	Score  float64
+}
+
+// CribDrag протаскивает предполагаемый фрагмент открытого текста crib по x = P1 xor P2:
+// если crib стоит в одном сообщении на позиции i, то x[i:] xor crib - фрагмент другого.
+// Возвращает позиции, где получился печатный текст, по убыванию EnglishScore.
+func CribDrag(x, crib []byte) []CribHit {
+	var hits []CribHit
+	for i := 0; i+len(crib) <= len(x); i++ {
+		text := XORBytes(x[i:], crib)
+		printable := true
+		for _, c := range text {
+			if c < 0x20 || c >= 0x7f {
+				printable = false
+				break
+			}
+		}
+		if printable {
+			hits = append(hits, CribHit{Offset: i, Text: text, Score: EnglishScore(text)})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	return hits
+}
+
+// RecoverKeystream восстанавливает общую гамму шифротекстов, зашифрованных с одним IV:
+// байты на позиции j всех шифротекстов - открытые байты, сложенные с одним байтом гаммы,
+// поэтому он подбирается перебором 256 значений по EnglishScore столбца.
+// Длина результата - длина самого длинного шифротекста; позиции, покрытые одним шифротекстом,
+// ненадёжны. Чем больше шифротекстов, тем точнее результат.
+func RecoverKeystream(cts [][]byte) ([]byte, error) {
+	if len(cts) < 2 {
+		return nil, errors.New("RecoverKeystream: need at least two ciphertexts")
+	}
+	n := 0
+	for _, ct := range cts {
+		n = max(n, len(ct))
+	}
+	ks := make([]byte, n)
+	column := make([]byte, 0, len(cts))
+	plain := make([]byte, len(cts))
+	for j := 0; j < n; j++ {
+		column = column[:0]
+		for _, ct := range cts {
+			if j < len(ct) {
+				column = append(column, ct[j])
+			}
+		}
+		best, bestScore := 0, 0.0
+		for k := 0; k < 256; k++ {
+			for i, c := range column {
+				plain[i] = c ^ byte(k)
+			}
+			if s := EnglishScore(plain[:len(column)]); k == 0 || s > bestScore {
+				best, bestScore = k, s
+			}
+		}
+		ks[j] = byte(best)
+	}
+	return ks, nil
+}
+
+// ApplyCrib уточняет гамму по известному фрагменту: если ct на позиции offset
+// расшифровывается в crib, то гамма там равна ct xor crib
+func ApplyCrib(keystream, ct []byte, offset int, crib []byte) error {
+	if offset < 0 || offset+len(crib) > len(ct) || offset+len(crib) > len(keystream) {
+		return errors.New("ApplyCrib: crib does not fit into the ciphertext")
+	}
+	copy(keystream[offset:], XORBytes(ct[offset:], crib))
+	return nil
+}
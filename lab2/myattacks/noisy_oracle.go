@@ -0,0 +1,201 @@
+package myattacks
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ----- Зашумлённые оракулы с ограничением числа запросов -----
+
+// Ошибки зашумлённого оракула
+var (
+	ErrTransient       = errors.New("oracle: transient error, retry")
+	ErrBudgetExhausted = errors.New("oracle: query budget exhausted")
+	ErrNoOracle        = errors.New("oracle: not available")
+)
+
+// NoiseConfig - параметры шума:
+// каждый ответ задерживается на Latency плюс случайную величину из [0, Jitter),
+// с вероятностью ErrorRate вместо ответа возвращается ErrTransient,
+// с вероятностью FlipRate инвертируется ответ булевых оракулов (Verify, PaddingValid),
+// после Budget запросов (0 - без ограничения) все запросы отвергаются.
+type NoiseConfig struct {
+	Latency   time.Duration
+	Jitter    time.Duration
+	ErrorRate float64
+	FlipRate  float64
+	Budget    int
+}
+
+// NoisyOracle оборачивает оракулы и добавляет к ним шум из NoiseConfig.
+// Реализует все четыре интерфейса оракулов; отсутствующие оракулы возвращают ErrNoOracle.
+type NoisyOracle struct {
+	cfg NoiseConfig
+	enc EncryptionOracle
+	dec DecryptionOracle
+	mac MACOracle
+	pad PaddingOracle
+
+	mu      sync.Mutex
+	queries int
+}
+
+// NewNoisyOracle создаёт зашумлённую обёртку; любой из оракулов может быть nil
+func NewNoisyOracle(cfg NoiseConfig, enc EncryptionOracle, dec DecryptionOracle, mac MACOracle, pad PaddingOracle) *NoisyOracle {
+	return &NoisyOracle{cfg: cfg, enc: enc, dec: dec, mac: mac, pad: pad}
+}
+
+// Queries возвращает число запросов, учтённых в бюджете
+func (o *NoisyOracle) Queries() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.queries
+}
+
+// randFloat возвращает равномерное число из [0, 1), используя Rand
+func randFloat() (float64, error) {
+	var b [8]byte
+	if _, err := Rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return float64(binary.BigEndian.Uint64(b[:])>>11) / (1 << 53), nil
+}
+
+// admit учитывает запрос в бюджете, выдерживает задержку и разыгрывает временную ошибку
+func (o *NoisyOracle) admit() error {
+	o.mu.Lock()
+	if o.cfg.Budget > 0 && o.queries >= o.cfg.Budget {
+		o.mu.Unlock()
+		return ErrBudgetExhausted
+	}
+	o.queries++
+	o.mu.Unlock()
+
+	delay := o.cfg.Latency
+	if o.cfg.Jitter > 0 {
+		u, err := randFloat()
+		if err != nil {
+			return err
+		}
+		delay += time.Duration(u * float64(o.cfg.Jitter))
+	}
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if o.cfg.ErrorRate > 0 {
+		u, err := randFloat()
+		if err != nil {
+			return err
+		}
+		if u < o.cfg.ErrorRate {
+			return ErrTransient
+		}
+	}
+	return nil
+}
+
+// flip инвертирует булев ответ с вероятностью FlipRate
+func (o *NoisyOracle) flip(ok bool) (bool, error) {
+	if o.cfg.FlipRate <= 0 {
+		return ok, nil
+	}
+	u, err := randFloat()
+	if err != nil {
+		return false, err
+	}
+	if u < o.cfg.FlipRate {
+		return !ok, nil
+	}
+	return ok, nil
+}
+
+func (o *NoisyOracle) Encrypt(msg []byte) ([]byte, error) {
+	if o.enc == nil {
+		return nil, ErrNoOracle
+	}
+	if err := o.admit(); err != nil {
+		return nil, err
+	}
+	return o.enc.Encrypt(msg)
+}
+
+func (o *NoisyOracle) Decrypt(ct []byte) ([]byte, error) {
+	if o.dec == nil {
+		return nil, ErrNoOracle
+	}
+	if err := o.admit(); err != nil {
+		return nil, err
+	}
+	return o.dec.Decrypt(ct)
+}
+
+func (o *NoisyOracle) Tag(msg []byte) ([]byte, error) {
+	if o.mac == nil {
+		return nil, ErrNoOracle
+	}
+	if err := o.admit(); err != nil {
+		return nil, err
+	}
+	return o.mac.Tag(msg)
+}
+
+func (o *NoisyOracle) Verify(msg, tag []byte) (bool, error) {
+	if o.mac == nil {
+		return false, ErrNoOracle
+	}
+	if err := o.admit(); err != nil {
+		return false, err
+	}
+	ok, err := o.mac.Verify(msg, tag)
+	if err != nil {
+		return false, err
+	}
+	return o.flip(ok)
+}
+
+func (o *NoisyOracle) PaddingValid(ct []byte) (bool, error) {
+	if o.pad == nil {
+		return false, ErrNoOracle
+	}
+	if err := o.admit(); err != nil {
+		return false, err
+	}
+	ok, err := o.pad.PaddingValid(ct)
+	if err != nil {
+		return false, err
+	}
+	return o.flip(ok)
+}
+
+// ----- Повторы и голосование для атак на зашумлённые оракулы -----
+
+// Retry вызывает fn, пока она возвращает ErrTransient, но не более attempts раз
+func Retry[T any](attempts int, fn func() (T, error)) (T, error) {
+	var res T
+	err := ErrTransient
+	for i := 0; i < attempts && errors.Is(err, ErrTransient); i++ {
+		res, err = fn()
+	}
+	return res, err
+}
+
+// Vote опрашивает булев оракул votes раз (каждый раз с повторами при временных ошибках)
+// и возвращает ответ большинства. При чётном votes ничья решается в пользу false.
+func Vote(votes, attempts int, fn func() (bool, error)) (bool, error) {
+	if votes <= 0 {
+		return false, errors.New("Vote: number of votes must be positive")
+	}
+	yes := 0
+	for i := 0; i < votes; i++ {
+		ok, err := Retry(attempts, fn)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			yes++
+		}
+	}
+	return 2*yes > votes, nil
+}
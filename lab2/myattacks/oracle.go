@@ -0,0 +1,250 @@
+package myattacks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// ----- Оракулы, против которых работают атаки -----
+
+// EncryptionOracle шифрует выбранные противником сообщения
+type EncryptionOracle interface {
+	Encrypt(msg []byte) ([]byte, error)
+}
+
+// DecryptionOracle расшифровывает выбранные противником шифротексты
+type DecryptionOracle interface {
+	Decrypt(ct []byte) ([]byte, error)
+}
+
+// MACOracle вычисляет и проверяет теги на секретном ключе
+type MACOracle interface {
+	Tag(msg []byte) ([]byte, error)
+	Verify(msg, tag []byte) (bool, error)
+}
+
+// PaddingOracle сообщает только, корректен ли паддинг шифротекста после расшифрования
+type PaddingOracle interface {
+	PaddingValid(ct []byte) (bool, error)
+}
+
+// CipherOracle - локальный оракул на основе MyCipher.
+// Реализует EncryptionOracle, DecryptionOracle и PaddingOracle. Каждый запрос обрабатывается
+// в своей сессии MyCipher, поэтому оракул можно опрашивать из нескольких горутин (OracleHandler).
+type CipherOracle struct {
+	mc *mycrypto.MyCipher
+	iv []byte
+}
+
+// NewCipherOracle создаёт оракул MyCipher в режиме mode с ключом key.
+// Если iv задан, он используется при каждом шифровании (например, для атак на повтор nonce).
+func NewCipherOracle(mode string, key, iv []byte) (*CipherOracle, error) {
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(mode); err != nil {
+		return nil, err
+	}
+	return &CipherOracle{mc: mc, iv: iv}, nil
+}
+
+func (o *CipherOracle) Encrypt(msg []byte) ([]byte, error) {
+	return o.mc.NewSession().Encrypt(msg, o.iv)
+}
+
+func (o *CipherOracle) Decrypt(ct []byte) ([]byte, error) {
+	return o.mc.NewSession().Decrypt(ct, nil)
+}
+
+// PaddingValid расшифровывает ct (IV в первом блоке) и скрывает всё, кроме признака корректности паддинга
+func (o *CipherOracle) PaddingValid(ct []byte) (bool, error) {
+	_, err := o.mc.NewSession().Decrypt(ct, nil)
+	if errors.Is(err, mycrypto.ErrInvalidPadding) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MACTagOracle - локальный оракул на основе MyMAC; запросы считаются на копиях MyMAC (Clone)
+// и могут приходить из нескольких горутин
+type MACTagOracle struct {
+	mm *mymac.MyMAC
+}
+
+// NewMACOracle создаёт оракул MyMAC в режиме mode (OMAC, HMAC и др.) с ключом key
+func NewMACOracle(mode string, key []byte) (*MACTagOracle, error) {
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(mode); err != nil {
+		return nil, err
+	}
+	if err := mm.SetKey(key); err != nil {
+		return nil, err
+	}
+	return &MACTagOracle{mm: mm}, nil
+}
+
+func (o *MACTagOracle) Tag(msg []byte) ([]byte, error) {
+	mm, err := o.mm.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return mm.ComputeMac(msg)
+}
+
+func (o *MACTagOracle) Verify(msg, tag []byte) (bool, error) {
+	mm, err := o.mm.Clone()
+	if err != nil {
+		return false, err
+	}
+	return mm.VerifyMac(msg, tag)
+}
+
+// ----- Оракулы по HTTP -----
+
+// Пути запросов сетевого оракула
+const (
+	PathEncrypt = "/encrypt"
+	PathDecrypt = "/decrypt"
+	PathTag     = "/tag"
+	PathVerify  = "/verify"
+	PathPadding = "/padding"
+)
+
+// oracleRequest и oracleResponse - тела запросов и ответов сетевого оракула.
+// Срезы байтов кодируются в JSON как base64.
+type oracleRequest struct {
+	Data []byte `json:"data"`
+	Tag  []byte `json:"tag,omitempty"`
+}
+
+type oracleResponse struct {
+	Data  []byte `json:"data,omitempty"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// OracleHandler публикует локальные оракулы по HTTP (POST с JSON-телом).
+// Любой из оракулов может быть nil - соответствующий путь тогда не обслуживается.
+func OracleHandler(enc EncryptionOracle, dec DecryptionOracle, mac MACOracle, pad PaddingOracle) http.Handler {
+	mux := http.NewServeMux()
+	handle := func(path string, fn func(req oracleRequest) (oracleResponse, error)) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			var req oracleRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			resp, err := fn(req)
+			if err != nil {
+				resp = oracleResponse{Error: err.Error()}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(resp)
+		})
+	}
+	if enc != nil {
+		handle(PathEncrypt, func(req oracleRequest) (oracleResponse, error) {
+			ct, err := enc.Encrypt(req.Data)
+			return oracleResponse{Data: ct, OK: err == nil}, err
+		})
+	}
+	if dec != nil {
+		handle(PathDecrypt, func(req oracleRequest) (oracleResponse, error) {
+			pt, err := dec.Decrypt(req.Data)
+			return oracleResponse{Data: pt, OK: err == nil}, err
+		})
+	}
+	if mac != nil {
+		handle(PathTag, func(req oracleRequest) (oracleResponse, error) {
+			tag, err := mac.Tag(req.Data)
+			return oracleResponse{Data: tag, OK: err == nil}, err
+		})
+		handle(PathVerify, func(req oracleRequest) (oracleResponse, error) {
+			ok, err := mac.Verify(req.Data, req.Tag)
+			return oracleResponse{OK: ok}, err
+		})
+	}
+	if pad != nil {
+		handle(PathPadding, func(req oracleRequest) (oracleResponse, error) {
+			ok, err := pad.PaddingValid(req.Data)
+			return oracleResponse{OK: ok}, err
+		})
+	}
+	return mux
+}
+
+// RemoteOracle обращается к оракулам, опубликованным OracleHandler.
+// Реализует все четыре интерфейса оракулов.
+type RemoteOracle struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewRemoteOracle создаёт клиент сетевого оракула по базовому адресу url
+func NewRemoteOracle(url string) *RemoteOracle {
+	return &RemoteOracle{URL: strings.TrimSuffix(url, "/"), Client: http.DefaultClient}
+}
+
+// call отправляет запрос по пути path и разбирает ответ
+func (o *RemoteOracle) call(path string, req oracleRequest) (oracleResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return oracleResponse{}, err
+	}
+	httpResp, err := o.Client.Post(o.URL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return oracleResponse{}, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return oracleResponse{}, fmt.Errorf("oracle %s: %s", path, httpResp.Status)
+	}
+	var resp oracleResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return oracleResponse{}, fmt.Errorf("oracle %s: %v", path, err)
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+func (o *RemoteOracle) Encrypt(msg []byte) ([]byte, error) {
+	resp, err := o.call(PathEncrypt, oracleRequest{Data: msg})
+	return resp.Data, err
+}
+
+func (o *RemoteOracle) Decrypt(ct []byte) ([]byte, error) {
+	resp, err := o.call(PathDecrypt, oracleRequest{Data: ct})
+	return resp.Data, err
+}
+
+func (o *RemoteOracle) Tag(msg []byte) ([]byte, error) {
+	resp, err := o.call(PathTag, oracleRequest{Data: msg})
+	return resp.Data, err
+}
+
+func (o *RemoteOracle) Verify(msg, tag []byte) (bool, error) {
+	resp, err := o.call(PathVerify, oracleRequest{Data: msg, Tag: tag})
+	return resp.OK, err
+}
+
+func (o *RemoteOracle) PaddingValid(ct []byte) (bool, error) {
+	resp, err := o.call(PathPadding, oracleRequest{Data: ct})
+	return resp.OK, err
+}
@@ -0,0 +1,96 @@
+package myattacks
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Атака на оракул паддинга CBC (Vaudenay, 2002) -----
+
+// PaddingOracleStats - статистика атаки: число запросов к оракулу и восстановленных байтов
+type PaddingOracleStats struct {
+	Queries int
+	Blocks  int
+	Bytes   int
+}
+
+// QueriesPerByte возвращает среднее число запросов на байт открытого текста
+func (s PaddingOracleStats) QueriesPerByte() float64 {
+	if s.Bytes == 0 {
+		return 0
+	}
+	return float64(s.Queries) / float64(s.Bytes)
+}
+
+// PaddingOracleAttack восстанавливает открытый текст шифротекста CBC ct = IV || C1 || ... || Cn,
+// спрашивая у оракула только корректность PKCS7-паддинга. Для каждого блока Ci оракулу
+// подаются пары X || Ci с подобранным X: если паддинг верен, последние байты D(Ci) xor X
+// равны pad, откуда находится промежуточное значение D(Ci), а Pi = D(Ci) xor C(i-1).
+// В среднем нужно 128 запросов на байт. Возвращает текст без паддинга и статистику.
+func PaddingOracleAttack(o PaddingOracle, ct []byte, blockSize int) ([]byte, PaddingOracleStats, error) {
+	var st PaddingOracleStats
+	if blockSize <= 0 || blockSize > 255 {
+		return nil, st, fmt.Errorf("PaddingOracleAttack: invalid block size %d", blockSize)
+	}
+	if len(ct) < 2*blockSize || len(ct)%blockSize != 0 {
+		return nil, st, fmt.Errorf("PaddingOracleAttack: ciphertext length %d is not IV plus whole blocks", len(ct))
+	}
+	query := func(x, c []byte) (bool, error) {
+		st.Queries++
+		return o.PaddingValid(append(append([]byte{}, x...), c...))
+	}
+
+	pt := make([]byte, 0, len(ct)-blockSize)
+	for b := blockSize; b < len(ct); b += blockSize {
+		prev, c := ct[b-blockSize:b], ct[b:b+blockSize]
+		inter := make([]byte, blockSize) // D(Ci)
+		x := make([]byte, blockSize)
+		for j := blockSize - 1; j >= 0; j-- {
+			pad := byte(blockSize - j)
+			for k := j + 1; k < blockSize; k++ {
+				x[k] = inter[k] ^ pad
+			}
+			found := false
+			for g := 0; g < 256 && !found; g++ {
+				x[j] = byte(g)
+				ok, err := query(x, c)
+				if err != nil {
+					return nil, st, err
+				}
+				if !ok {
+					continue
+				}
+				// Для последнего байта верный паддинг может оказаться длиннее 1
+				// (например, ..02 02): меняем предыдущий байт и переспрашиваем
+				if j == blockSize-1 && j > 0 {
+					x[j-1] ^= 0xff
+					ok, err = query(x, c)
+					x[j-1] ^= 0xff
+					if err != nil {
+						return nil, st, err
+					}
+					if !ok {
+						continue
+					}
+				}
+				inter[j] = byte(g) ^ pad
+				found = true
+			}
+			if !found {
+				return nil, st, fmt.Errorf("PaddingOracleAttack: no valid padding for byte %d of block %d", j, b/blockSize)
+			}
+			st.Bytes++
+		}
+		for k := range inter {
+			pt = append(pt, inter[k]^prev[k])
+		}
+		st.Blocks++
+	}
+	res, err := mycrypto.Pkcs7Unpad(pt, blockSize)
+	if err != nil {
+		return pt, st, errors.New("PaddingOracleAttack: recovered plaintext has invalid padding, the oracle answers are inconsistent")
+	}
+	return res, st, nil
+}
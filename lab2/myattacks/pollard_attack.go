@@ -13,7 +13,7 @@ import (
 // randomState генерирует случайное состояние в виде двоичной строки длины outBits.
 func randomState(outBits int) (string, error) {
 	max := new(big.Int).Lsh(big.NewInt(1), uint(outBits))
-	n, err := rand.Int(rand.Reader, max)
+	n, err := rand.Int(Rand, max)
 	if err != nil {
 		return "", err
 	}
@@ -52,9 +52,9 @@ func binToBytes(binStr string) ([]byte, error) {
 	return hex.DecodeString(hexStr)
 }
 
-func chainFunc(x string, outBits int) (string, error) {
+func chainFunc(h HashFunc, x string, outBits int) (string, error) {
 	xb, _ := binToBytes(x)
-	hash, err := SHA_xx(xb, outBits)
+	hash, err := h(xb, outBits)
 	if err != nil {
 		return "", err
 	}
@@ -68,14 +68,14 @@ func chainFunc(x string, outBits int) (string, error) {
 // Для двух цепочек, у которых найдено одно и то же отличительное значение,
 // применяем разность d = i - j к цепочке с большим номером, затем итеративно идём синхронно,
 // пока не найдём точное совпадение.
-func findExactCollision(seedA, seedB string, delta int, outBits int) (Collision, error) {
+func findExactCollision(h HashFunc, seedA, seedB string, delta int, outBits int) (Collision, error) {
 	// предполагаем, что цепочка А длиннее цепочки В
 	var valA, valB string
 	var err error
 	valA = seedA
 	valB = seedB
 	for i := 0; i < delta; i++ {
-		valA, err = chainFunc(valA, outBits)
+		valA, err = chainFunc(h, valA, outBits)
 		if err != nil {
 			return Collision{}, err
 		}
@@ -83,8 +83,8 @@ func findExactCollision(seedA, seedB string, delta int, outBits int) (Collision,
 	var collision Collision
 	for i := 0; i < 10e6; i++ {
 		collision = Collision{X: valA, Y: valB}
-		valA, err = chainFunc(valA, outBits)
-		valB, err = chainFunc(valB, outBits)
+		valA, err = chainFunc(h, valA, outBits)
+		valB, err = chainFunc(h, valB, outBits)
 		if err != nil {
 			return Collision{}, err
 		}
@@ -97,7 +97,18 @@ func findExactCollision(seedA, seedB string, delta int, outBits int) (Collision,
 
 // симуляция параллельной атаки Полларда
 func PollardAttack(outBits int, distinguishedBits int, numColls int, numWorkers int) ([]Collision, int, int, time.Duration, error) {
+	return PollardAttackHash(SHA_xx, outBits, distinguishedBits, numColls, numWorkers)
+}
+
+// PollardAttackHash - атака Полларда на произвольную хэш-функцию h
+func PollardAttackHash(h HashFunc, outBits int, distinguishedBits int, numColls int, numWorkers int) ([]Collision, int, int, time.Duration, error) {
+	return PollardAttackTraced(h, outBits, distinguishedBits, numColls, numWorkers, nil)
+}
+
+// PollardAttackTraced - PollardAttackHash, сообщающая trace о каждом шаге каждой цепочки (trace может быть nil)
+func PollardAttackTraced(h HashFunc, outBits int, distinguishedBits int, numColls int, numWorkers int, trace TraceFunc) ([]Collision, int, int, time.Duration, error) {
 	chains := make([]Chain, numWorkers)
+	hits := 0
 	dists := make(map[string]Chain)
 	collisions := []Collision{}
 	iterations := 0
@@ -137,13 +148,21 @@ func PollardAttack(outBits int, distinguishedBits int, numColls int, numWorkers
 		}
 		iterations++
 		for i := 0; i < numWorkers; i++ {
-			next, err := chainFunc(chains[i].val, outBits)
+			next, err := chainFunc(h, chains[i].val, outBits)
 			if err != nil {
 				continue
 			}
 			chains[i].steps++
 			chains[i].val = next
-			if isDistinguished(chains[i].val, distinguishedBits) {
+			distinguished := isDistinguished(chains[i].val, distinguishedBits)
+			if distinguished {
+				hits++
+			}
+			if trace != nil {
+				trace(ChainEvent{Iteration: iterations, Chain: i, Step: chains[i].steps,
+					Distinguished: hits, TableSize: len(dists), Collisions: len(collisions)})
+			}
+			if distinguished {
 				if val, exists := dists[chains[i].val]; exists { // если уже была такая отличительная точка
 					// Здесь фиксируем коллизию – независимо от того, совпадают ли seed или нет,
 					// поскольку по заданию коллизия может быть найдена даже внутри одной цепочки.
@@ -155,7 +174,7 @@ func PollardAttack(outBits int, distinguishedBits int, numColls int, numWorkers
 					}
 					delta := longerChain.steps - shorterChain.steps
 					collisionStart := time.Now()
-					collision, err := findExactCollision(longerChain.seed, shorterChain.seed, delta, outBits)
+					collision, err := findExactCollision(h, longerChain.seed, shorterChain.seed, delta, outBits)
 					if err != nil {
 						return nil, iterations, 0, time.Since(start), err
 					}
@@ -0,0 +1,63 @@
+package myattacks
+
+import (
+	"errors"
+	"io"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Смещение второго байта гаммы RC4 (Мантин–Шамир) -----
+
+// RC4KeystreamCounts шифрует numKeys раз на случайных ключах длины keyLen и считает, сколько раз
+// каждое значение встретилось на позициях гаммы 1..positions (counts[r-1][v]).
+// Для идеального генератора каждое значение встречается с вероятностью 1/256,
+// у RC4 второй байт равен нулю с вероятностью около 2/256.
+func RC4KeystreamCounts(numKeys, keyLen, positions int) ([][256]uint64, error) {
+	if numKeys <= 0 || positions <= 0 {
+		return nil, errors.New("RC4KeystreamCounts: numKeys and positions must be positive")
+	}
+	counts := make([][256]uint64, positions)
+	key := make([]byte, keyLen)
+	zero := make([]byte, positions)
+	z := make([]byte, positions)
+	for k := 0; k < numKeys; k++ {
+		if _, err := io.ReadFull(Rand, key); err != nil {
+			return nil, err
+		}
+		c, err := mycrypto.NewRC4(key)
+		if err != nil {
+			return nil, err
+		}
+		c.XORKeyStream(z, zero)
+		for r, v := range z {
+			counts[r][v]++
+		}
+	}
+	return counts, nil
+}
+
+// RecoverSecondByte - атака широковещательной рассылки: одно сообщение зашифровано
+// на многих независимых ключах RC4. Так как Z2 = 0 вдвое чаще остальных значений,
+// самое частое значение второго байта шифротекстов совпадает со вторым байтом открытого текста.
+// Возвращает найденный байт и число голосов за него.
+func RecoverSecondByte(ciphertexts [][]byte) (byte, int, error) {
+	var votes [256]int
+	n := 0
+	for _, ct := range ciphertexts {
+		if len(ct) >= 2 {
+			votes[ct[1]]++
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0, errors.New("RecoverSecondByte: no ciphertexts of two or more bytes")
+	}
+	best := 0
+	for v := range votes {
+		if votes[v] > votes[best] {
+			best = v
+		}
+	}
+	return byte(best), votes[best], nil
+}
@@ -0,0 +1,209 @@
+package myattacks
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"sync"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// HashFunc - усечённая хэш-функция в формате SHA_xx:
+// по сообщению возвращает последние outBits бит в виде строки из "0" и "1"
+type HashFunc func(msg []byte, outBits int) (string, error)
+
+// CompressionFunc - функция сжатия: новое состояние по текущему состоянию и блоку сообщения
+type CompressionFunc = mycrypto.CompressionFunc
+
+// Доступные функции сжатия конструктора
+const (
+	CompressionDaviesMeyer      = "DM-AES"
+	CompressionMatyasMeyerOseas = "MMO-AES"
+	CompressionXorRotate        = "XOR-ROT"
+)
+
+// ToyHashConfig описывает игрушечную хэш-функцию
+type ToyHashConfig struct {
+	Name        string
+	Compression string // CompressionDaviesMeyer, CompressionMatyasMeyerOseas или CompressionXorRotate
+	Rounds      int    // число раундов для XOR-ROT (по умолчанию 4)
+	StateBits   int    // размер внутреннего состояния XOR-ROT: 32 или 64 бита (по умолчанию 64)
+}
+
+// ToyHash - хэш-функция Меркла–Дамгора над выбранной функцией сжатия
+type ToyHash struct {
+	name string
+	md   *mycrypto.MDHash
+}
+
+// NewToyHash собирает хэш-функцию по конфигурации
+func NewToyHash(cfg ToyHashConfig) (*ToyHash, error) {
+	var compress CompressionFunc
+	var iv []byte
+	switch cfg.Compression {
+	case CompressionDaviesMeyer, CompressionMatyasMeyerOseas:
+		// функции сжатия на AES из lab1
+		iv = make([]byte, mycrypto.AESBlockSize)
+		if cfg.Compression == CompressionDaviesMeyer {
+			compress = mycrypto.DaviesMeyer
+		} else {
+			compress = mycrypto.MatyasMeyerOseas
+		}
+	case CompressionXorRotate:
+		rounds := cfg.Rounds
+		if rounds == 0 {
+			rounds = 4
+		}
+		stateBits := cfg.StateBits
+		if stateBits == 0 {
+			stateBits = 64
+		}
+		if stateBits != 32 && stateBits != 64 {
+			return nil, fmt.Errorf("XOR-ROT: unsupported state size %d", stateBits)
+		}
+		if stateBits < MaxOut {
+			return nil, fmt.Errorf("XOR-ROT: state of %d bits is shorter than max output %d", stateBits, MaxOut)
+		}
+		iv = make([]byte, stateBits/8)
+		for i := range iv {
+			iv[i] = byte(0x5a + i)
+		}
+		compress = xorRotate(rounds)
+	default:
+		return nil, fmt.Errorf("unknown compression function [%s]", cfg.Compression)
+	}
+	md, err := mycrypto.NewMDHash(compress, iv, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Compression
+	}
+	return &ToyHash{name: name, md: md}, nil
+}
+
+// Name возвращает имя хэш-функции
+func (th *ToyHash) Name() string {
+	return th.name
+}
+
+// Sum вычисляет хэш с MD-усилением (0x80, нули, длина в битах) и усекает его до outBits.
+// Сигнатура совпадает с HashFunc, поэтому th.Sum можно передавать в атаки напрямую.
+func (th *ToyHash) Sum(msg []byte, outBits int) (string, error) {
+	if outBits < MinOut || outBits > MaxOut {
+		return "", errors.New("Invalid out vector size")
+	}
+	state, err := th.md.Sum(msg)
+	if err != nil {
+		return "", err
+	}
+	return lastBits(state, outBits), nil
+}
+
+// lastBits возвращает последние outBits бит среза в виде двоичной строки
+func lastBits(data []byte, outBits int) string {
+	out := make([]byte, outBits)
+	for i := 0; i < outBits; i++ {
+		bit := len(data)*8 - outBits + i
+		if data[bit/8]>>(7-uint(bit%8))&1 == 1 {
+			out[i] = '1'
+		} else {
+			out[i] = '0'
+		}
+	}
+	return string(out)
+}
+
+// xorRotate строит ARX-подобную функцию сжатия: в каждом раунде состояние
+// смешивается с блоком через XOR, циклический сдвиг и сложение с константой
+func xorRotate(rounds int) CompressionFunc {
+	return func(state, block []byte) ([]byte, error) {
+		if len(state) != len(block) {
+			return nil, errors.New("XOR-ROT: state and block sizes differ")
+		}
+		out := make([]byte, len(state))
+		if len(state) == 4 {
+			s := binary.BigEndian.Uint32(state)
+			m := binary.BigEndian.Uint32(block)
+			for r := 0; r < rounds; r++ {
+				s ^= m
+				s = bits.RotateLeft32(s, 7) + 0x9e3779b9 + uint32(r)
+				s ^= s >> 13
+			}
+			binary.BigEndian.PutUint32(out, s^binary.BigEndian.Uint32(state))
+			return out, nil
+		}
+		s := binary.BigEndian.Uint64(state)
+		m := binary.BigEndian.Uint64(block)
+		for r := 0; r < rounds; r++ {
+			s ^= m
+			s = bits.RotateLeft64(s, 13) + 0x9e3779b97f4a7c15 + uint64(r)
+			s ^= s >> 29
+		}
+		binary.BigEndian.PutUint64(out, s^binary.BigEndian.Uint64(state))
+		return out, nil
+	}
+}
+
+// ----- Реестр хэш-функций для атак -----
+
+var (
+	hashMu   sync.RWMutex
+	hashFunc = map[string]HashFunc{"SHA-256": SHA_xx}
+)
+
+// RegisterHash добавляет хэш-функцию в реестр под именем name
+func RegisterHash(name string, h HashFunc) error {
+	if name == "" || h == nil {
+		return errors.New("RegisterHash: empty name or function")
+	}
+	hashMu.Lock()
+	defer hashMu.Unlock()
+	if _, ok := hashFunc[name]; ok {
+		return fmt.Errorf("hash [%s] already registered", name)
+	}
+	hashFunc[name] = h
+	return nil
+}
+
+// LookupHash возвращает зарегистрированную хэш-функцию
+func LookupHash(name string) (HashFunc, error) {
+	hashMu.RLock()
+	defer hashMu.RUnlock()
+	h, ok := hashFunc[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash [%s]", name)
+	}
+	return h, nil
+}
+
+// HashNames возвращает имена всех зарегистрированных хэш-функций
+func HashNames() []string {
+	hashMu.RLock()
+	defer hashMu.RUnlock()
+	names := make([]string, 0, len(hashFunc))
+	for name := range hashFunc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// встроенные игрушечные хэш-функции
+func init() {
+	for _, cfg := range []ToyHashConfig{
+		{Name: "TOY-DM-AES", Compression: CompressionDaviesMeyer},
+		{Name: "TOY-MMO-AES", Compression: CompressionMatyasMeyerOseas},
+		{Name: "TOY-XOR-ROT", Compression: CompressionXorRotate},
+	} {
+		th, err := NewToyHash(cfg)
+		if err != nil {
+			panic(err)
+		}
+		hashFunc[th.Name()] = th.Sum
+	}
+}
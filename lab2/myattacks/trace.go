@@ -0,0 +1,116 @@
+package myattacks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ----- Телеметрия цепочек атаки Полларда -----
+
+// ChainEvent - состояние атаки после очередного шага одной цепочки
+type ChainEvent struct {
+	Iteration     int // номер итерации (шаг всех цепочек) с последнего сброса
+	Chain         int // номер цепочки
+	Step          int // длина цепочки после шага
+	Distinguished int // число найденных отличительных точек с начала атаки
+	TableSize     int // число точек в таблице
+	Collisions    int // число найденных коллизий
+}
+
+// TraceFunc получает события атаки
+type TraceFunc func(ev ChainEvent)
+
+// traceColumns - столбцы ColumnarTrace в порядке полей ChainEvent
+var traceColumns = []string{"iteration", "chain", "step", "distinguished", "table_size", "collisions"}
+
+// columnSchema - описание набора столбцов, которое пишется в schema.json
+type columnSchema struct {
+	Format  string   `json:"format"`
+	Rows    int64    `json:"rows"`
+	Columns []string `json:"columns"`
+	DType   string   `json:"dtype"`
+}
+
+// ColumnarTrace пишет события в каталог столбцами: каждый столбец - отдельный файл <name>.i64
+// из int64 little-endian, а schema.json описывает столбцы и число строк. Данные пишутся потоком
+// через буферы, так что память не растёт с числом событий; столбцы читаются без разбора,
+// например numpy.fromfile(path, dtype="<i8").
+type ColumnarTrace struct {
+	dir   string
+	files []*os.File
+	bufs  []*bufio.Writer
+	rows  int64
+	err   error
+}
+
+// NewColumnarTrace создаёт каталог dir и файлы столбцов
+func NewColumnarTrace(dir string) (*ColumnarTrace, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	t := &ColumnarTrace{dir: dir}
+	for _, name := range traceColumns {
+		f, err := os.Create(filepath.Join(dir, name+".i64"))
+		if err != nil {
+			t.closeFiles()
+			return nil, err
+		}
+		t.files = append(t.files, f)
+		t.bufs = append(t.bufs, bufio.NewWriterSize(f, 1<<16))
+	}
+	return t, nil
+}
+
+// Record дописывает событие; первая ошибка записи сохраняется и возвращается из Close
+func (t *ColumnarTrace) Record(ev ChainEvent) {
+	if t.err != nil {
+		return
+	}
+	vals := [...]int{ev.Iteration, ev.Chain, ev.Step, ev.Distinguished, ev.TableSize, ev.Collisions}
+	var buf [8]byte
+	for i, v := range vals {
+		binary.LittleEndian.PutUint64(buf[:], uint64(int64(v)))
+		if _, err := t.bufs[i].Write(buf[:]); err != nil {
+			t.err = fmt.Errorf("trace column %s: %v", traceColumns[i], err)
+			return
+		}
+	}
+	t.rows++
+}
+
+// Rows возвращает число записанных событий
+func (t *ColumnarTrace) Rows() int64 {
+	return t.rows
+}
+
+func (t *ColumnarTrace) closeFiles() {
+	for _, f := range t.files {
+		f.Close()
+	}
+}
+
+// Close сбрасывает буферы, закрывает файлы и пишет schema.json
+func (t *ColumnarTrace) Close() error {
+	defer t.closeFiles()
+	for i, b := range t.bufs {
+		if err := b.Flush(); err != nil && t.err == nil {
+			t.err = fmt.Errorf("trace column %s: %v", traceColumns[i], err)
+		}
+	}
+	if t.err != nil {
+		return t.err
+	}
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false) // dtype "<i8" должен остаться читаемым
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(columnSchema{Format: "raw-columns", Rows: t.rows, Columns: traceColumns, DType: "<i8"}); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(t.dir, "schema.json"), data.Bytes(), 0o644)
+}
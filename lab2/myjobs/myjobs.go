@@ -0,0 +1,316 @@
+package myjobs
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sagilyp/lab2/myattacks"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Алгоритмы атак, доступные через очередь
+const (
+	AlgBirthday = "birthday"
+	AlgPollard  = "pollard"
+)
+
+// Состояния задачи
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Request - параметры атаки
+type Request struct {
+	Algorithm  string `json:"algorithm"`
+	Hash       string `json:"hash"`
+	OutBits    int    `json:"out_bits"`
+	Collisions int    `json:"collisions"`
+}
+
+// Result - результат выполненной атаки
+type Result struct {
+	Collisions []myattacks.Collision `json:"collisions"`
+	Iterations int                   `json:"iterations"`
+	Memory     int                   `json:"memory"`
+	Elapsed    time.Duration         `json:"elapsed"`
+}
+
+// Job - задача в очереди
+type Job struct {
+	ID       int       `json:"id"`
+	Request  Request   `json:"request"`
+	Status   string    `json:"status"`
+	Result   *Result   `json:"result,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Created  time.Time `json:"created"`
+	Started  time.Time `json:"started,omitempty"`
+	Finished time.Time `json:"finished,omitempty"`
+}
+
+// Queue - очередь атак с ограниченным пулом исполнителей.
+// Состояние хранится в файле bbolt: каждая задача - отдельная запись (номер -> JSON),
+// и при изменении задачи в транзакции перезаписывается только она. После перезапуска
+// незавершённые задачи (queued и running) снова попадают в очередь.
+// Если сохранить состояние не удалось, очередь останавливается: см. Err.
+type Queue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	path    string
+	db      *bolt.DB
+	jobs    map[int]*Job
+	pending []int
+	nextID  int
+	closed  bool
+	saveErr error // первая ошибка сохранения состояния; после неё задачи не запускаются
+	wg      sync.WaitGroup
+}
+
+// validate проверяет параметры атаки
+func (r Request) validate() error {
+	if r.Algorithm != AlgBirthday && r.Algorithm != AlgPollard {
+		return fmt.Errorf("unknown algorithm [%s]", r.Algorithm)
+	}
+	if _, err := myattacks.LookupHash(r.Hash); err != nil {
+		return err
+	}
+	if r.OutBits < myattacks.MinOut || r.OutBits > myattacks.MaxOut {
+		return fmt.Errorf("out bits must be in %d..%d", myattacks.MinOut, myattacks.MaxOut)
+	}
+	if r.Collisions <= 0 {
+		return errors.New("number of collisions must be positive")
+	}
+	return nil
+}
+
+// jobsBucket - bucket bbolt с задачами; ключ - номер задачи (8 байт big-endian), значение - JSON задачи
+var jobsBucket = []byte("jobs")
+
+// jobKey возвращает ключ задачи id; big-endian сохраняет порядок номеров при обходе bucket
+func jobKey(id int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(id))
+}
+
+// Open открывает (или создаёт с правами 0600) файл очереди path и загружает задачи, не запуская исполнителей.
+// Файл блокируется на время работы очереди, поэтому её нужно закрыть: см. Close
+func Open(path string) (*Queue, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %v", path, err)
+	}
+	q := &Queue{path: path, db: db, jobs: make(map[int]*Job), nextID: 1}
+	q.cond = sync.NewCond(&q.mu)
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(jobsBucket)
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			j := new(Job)
+			if err := json.Unmarshal(v, j); err != nil {
+				return fmt.Errorf("job %x: %v", k, err)
+			}
+			q.jobs[j.ID] = j
+			if j.ID >= q.nextID {
+				q.nextID = j.ID + 1
+			}
+			// задачи, прерванные перезапуском, выполняются заново
+			if j.Status == StatusRunning {
+				j.Status = StatusQueued
+			}
+			if j.Status == StatusQueued {
+				q.pending = append(q.pending, j.ID)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot load %s: %v", path, err)
+	}
+	sort.Ints(q.pending)
+	return q, nil
+}
+
+// Close закрывает файл очереди; исполнители к этому моменту должны быть остановлены (см. Drain)
+func (q *Queue) Close() error {
+	return q.db.Close()
+}
+
+// Start запускает workers исполнителей
+func (q *Queue) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+}
+
+// Submit ставит атаку в очередь и возвращает номер задачи
+func (q *Queue) Submit(req Request) (int, error) {
+	if err := req.validate(); err != nil {
+		return 0, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return 0, errors.New("queue is closed")
+	}
+	job := &Job{ID: q.nextID, Request: req, Status: StatusQueued, Created: time.Now()}
+	q.nextID++
+	q.jobs[job.ID] = job
+	q.pending = append(q.pending, job.ID)
+	if err := q.saveLocked(job); err != nil {
+		// задача не сохранена - не выполняем её, иначе после перезапуска она пропадёт
+		delete(q.jobs, job.ID)
+		q.pending = q.pending[:len(q.pending)-1]
+		q.nextID--
+		return 0, err
+	}
+	q.cond.Signal()
+	return job.ID, nil
+}
+
+// Get возвращает копию задачи по номеру
+func (q *Queue) Get(id int) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+// List возвращает копии всех задач в порядке номеров
+func (q *Queue) List() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	res := make([]Job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		res = append(res, *j)
+	}
+	sort.Slice(res, func(a, b int) bool { return res[a].ID < res[b].ID })
+	return res
+}
+
+// Pending возвращает число задач, ожидающих исполнителя
+func (q *Queue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Drain закрывает очередь для новых задач и ждёт, пока исполнители выполнят все ожидающие
+func (q *Queue) Drain() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+// Err возвращает ошибку сохранения состояния, из-за которой очередь остановилась, или nil
+func (q *Queue) Err() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.saveErr
+}
+
+// stopLocked запоминает ошибку сохранения err, отмечает её в задаче job и останавливает очередь:
+// состояние на диске устарело, и новые задачи после перезапуска выполнялись бы повторно или терялись
+func (q *Queue) stopLocked(job *Job, err error) {
+	err = fmt.Errorf("cannot save job %d state to %s: %v", job.ID, q.path, err)
+	job.Error = err.Error()
+	if q.saveErr == nil {
+		q.saveErr = err
+	}
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// worker забирает задачи из очереди, пока она не закрыта и не пуста или пока не случилась ошибка сохранения
+func (q *Queue) worker() {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.pending) == 0 || q.saveErr != nil {
+			q.mu.Unlock()
+			return
+		}
+		id := q.pending[0]
+		q.pending = q.pending[1:]
+		job := q.jobs[id]
+		job.Status = StatusRunning
+		job.Started = time.Now()
+		req := job.Request
+		if err := q.saveLocked(job); err != nil {
+			// на диске задача осталась в очереди: возвращаем её туда и не запускаем
+			job.Status = StatusQueued
+			job.Started = time.Time{}
+			q.pending = append([]int{id}, q.pending...)
+			q.stopLocked(job, err)
+			q.mu.Unlock()
+			return
+		}
+		q.mu.Unlock()
+
+		res, err := run(req)
+
+		q.mu.Lock()
+		job.Finished = time.Now()
+		if err != nil {
+			job.Status = StatusFailed
+			job.Error = err.Error()
+		} else {
+			job.Status = StatusDone
+			job.Result = res
+		}
+		if err := q.saveLocked(job); err != nil {
+			// на диске задача осталась running и после перезапуска выполнится заново
+			q.stopLocked(job, err)
+		}
+		q.mu.Unlock()
+	}
+}
+
+// run выполняет атаку
+func run(req Request) (*Result, error) {
+	h, err := myattacks.LookupHash(req.Hash)
+	if err != nil {
+		return nil, err
+	}
+	var res Result
+	switch req.Algorithm {
+	case AlgBirthday:
+		res.Collisions, res.Iterations, res.Memory, res.Elapsed, err = myattacks.BirthdayAttackHash(h, req.Collisions, req.OutBits)
+	case AlgPollard:
+		res.Collisions, res.Iterations, res.Memory, res.Elapsed, err = myattacks.PollardAttackHash(h, req.OutBits, myattacks.DistBits, req.Collisions, myattacks.NumWorkers)
+	default:
+		err = fmt.Errorf("unknown algorithm [%s]", req.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// saveLocked записывает задачу job в файл очереди одной транзакцией (вызывается под q.mu)
+func (q *Queue) saveLocked(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put(jobKey(job.ID), data)
+	})
+}
@@ -0,0 +1,307 @@
+package myperf
+
+import (
+	"crypto/aes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sagilyp/lab1/mycamellia"
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab2/myattacks"
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// ----- Отслеживание производительности по ревизиям git -----
+
+// Benchmark - измеряемое ядро; Bytes > 0 включает пересчёт в МБ/с
+type Benchmark struct {
+	Name  string
+	Bytes int64
+	Fn    func(b *testing.B)
+}
+
+// Result - результат одного ядра
+type Result struct {
+	Name        string  `json:"name"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	MBPerSec    float64 `json:"mb_per_sec,omitempty"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+}
+
+// Record - прогон набора на одной ревизии
+type Record struct {
+	Commit    string    `json:"commit"`
+	Dirty     bool      `json:"dirty,omitempty"`
+	Time      time.Time `json:"time"`
+	GoVersion string    `json:"go_version"`
+	Results   []Result  `json:"results"`
+}
+
+// Regression - ядро, замедлившееся сильнее порога
+type Regression struct {
+	Name      string
+	Base      float64 // нс/оп на базовой ревизии
+	Head      float64 // нс/оп на проверяемой ревизии
+	ChangePct float64
+}
+
+const benchSize = 64 << 10
+
+// cipherBench шифрует 64 КБ в режиме mode блочным шифром b
+func cipherBench(mode string, b mycrypto.BlockCipher) func(*testing.B) {
+	return func(tb *testing.B) {
+		mc := &mycrypto.MyCipher{}
+		if err := mc.SetBlockCipher(b); err != nil {
+			tb.Fatal(err)
+		}
+		if err := mc.SetMode(mode); err != nil {
+			tb.Fatal(err)
+		}
+		msg := make([]byte, benchSize)
+		dst := make([]byte, mc.EncryptedLen(benchSize, nil))
+		tb.ResetTimer()
+		for i := 0; i < tb.N; i++ {
+			if _, err := mc.EncryptTo(dst, msg, nil); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+}
+
+func macBench(mode string, keyLen int) func(*testing.B) {
+	return func(tb *testing.B) {
+		mm := &mymac.MyMAC{}
+		if err := mm.SetMode(mode); err != nil {
+			tb.Fatal(err)
+		}
+		if err := mm.SetKey(make([]byte, keyLen)); err != nil {
+			tb.Fatal(err)
+		}
+		msg := make([]byte, benchSize)
+		tb.ResetTimer()
+		for i := 0; i < tb.N; i++ {
+			if _, err := mm.ComputeMac(msg); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+}
+
+// Suite возвращает стандартный набор ядер: режимы MyCipher, MAC из lab3 и атаки из lab2
+func Suite() []Benchmark {
+	aesBlock, err := aes.NewCipher(make([]byte, mycrypto.AESKeySize16))
+	if err != nil {
+		panic(err)
+	}
+	cam, err := mycamellia.NewCipher(make([]byte, 16))
+	if err != nil {
+		panic(err)
+	}
+	return []Benchmark{
+		{"aes-cbc-64k", benchSize, cipherBench(mycrypto.ModeCBC, aesBlock)},
+		{"aes-ctr-64k", benchSize, cipherBench(mycrypto.ModeCTR, aesBlock)},
+		{"aes-gcm-64k", benchSize, cipherBench(mycrypto.ModeGCM, aesBlock)},
+		{"aes-ocb-64k", benchSize, cipherBench(mycrypto.ModeOCB, aesBlock)},
+		{"camellia-ctr-64k", benchSize, cipherBench(mycrypto.ModeCTR, cam)},
+		{"omac-64k", benchSize, macBench(mymac.OMAC, mymac.AESKeySize)},
+		{"hmac-64k", benchSize, macBench(mymac.HMAC, mymac.SHASize)},
+		{"sha_xx-24", 0, func(tb *testing.B) {
+			msg := make([]byte, myattacks.MsgLen)
+			for i := 0; i < tb.N; i++ {
+				msg[0] = byte(i)
+				if _, err := myattacks.SHA_xx(msg, 24); err != nil {
+					tb.Fatal(err)
+				}
+			}
+		}},
+		{"pollard-16bit", 0, func(tb *testing.B) {
+			// PollardAttack печатает итог каждого запуска; на время замера вывод отключается
+			devNull, err := os.Open(os.DevNull)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			stdout := os.Stdout
+			os.Stdout = devNull
+			defer func() {
+				os.Stdout = stdout
+				devNull.Close()
+			}()
+			for i := 0; i < tb.N; i++ {
+				if _, _, _, _, err := myattacks.PollardAttack(16, myattacks.DistBits, 4, 1); err != nil {
+					tb.Fatal(err)
+				}
+			}
+		}},
+	}
+}
+
+// Run выполняет ядра, имена которых подходят под filter (nil - все), через testing.Benchmark
+func Run(benches []Benchmark, filter *regexp.Regexp) []Result {
+	testing.Init()
+	var results []Result
+	for _, bm := range benches {
+		if filter != nil && !filter.MatchString(bm.Name) {
+			continue
+		}
+		fn := bm.Fn
+		if bm.Bytes > 0 {
+			fn = func(b *testing.B) {
+				b.SetBytes(bm.Bytes)
+				bm.Fn(b)
+			}
+		}
+		r := testing.Benchmark(fn)
+		if r.N == 0 {
+			continue
+		}
+		res := Result{Name: bm.Name, NsPerOp: float64(r.T.Nanoseconds()) / float64(r.N), AllocsPerOp: r.AllocsPerOp()}
+		if bm.Bytes > 0 {
+			res.MBPerSec = float64(bm.Bytes) * float64(r.N) / r.T.Seconds() / 1e6
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// GitRevision возвращает текущий коммит в каталоге dir и признак незафиксированных изменений
+func GitRevision(dir string) (string, bool, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false, fmt.Errorf("git rev-parse: %v", err)
+	}
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain", "--untracked-files=no").Output()
+	if err != nil {
+		return "", false, fmt.Errorf("git status: %v", err)
+	}
+	return strings.TrimSpace(string(out)), len(strings.TrimSpace(string(status))) > 0, nil
+}
+
+// NewRecord прогоняет ядра на текущей ревизии каталога dir
+func NewRecord(dir string, benches []Benchmark, filter *regexp.Regexp) (Record, error) {
+	commit, dirty, err := GitRevision(dir)
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{Commit: commit, Dirty: dirty, Time: time.Now().UTC(), GoVersion: runtime.Version(), Results: Run(benches, filter)}, nil
+}
+
+// DB - история прогонов в JSON-файле, по одной записи на коммит в порядке добавления
+type DB struct {
+	path    string
+	Records []Record
+}
+
+// Open загружает историю из path; отсутствующий файл означает пустую историю
+func Open(path string) (*DB, error) {
+	db := &DB{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &db.Records); err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %v", path, err)
+		}
+	}
+	return db, nil
+}
+
+// Add добавляет запись. Повторный прогон того же коммита обновляет результаты
+// одноимённых ядер, сохраняя остальные, и переносит запись в конец истории
+func (db *DB) Add(rec Record) {
+	for i, r := range db.Records {
+		if r.Commit != rec.Commit {
+			continue
+		}
+		fresh := make(map[string]bool)
+		for _, res := range rec.Results {
+			fresh[res.Name] = true
+		}
+		for _, res := range r.Results {
+			if !fresh[res.Name] {
+				rec.Results = append(rec.Results, res)
+			}
+		}
+		sort.Slice(rec.Results, func(a, b int) bool { return rec.Results[a].Name < rec.Results[b].Name })
+		db.Records = append(db.Records[:i], db.Records[i+1:]...)
+		break
+	}
+	db.Records = append(db.Records, rec)
+}
+
+// Find возвращает запись коммита по префиксу хэша
+func (db *DB) Find(prefix string) (Record, error) {
+	var found []Record
+	for _, r := range db.Records {
+		if strings.HasPrefix(r.Commit, prefix) {
+			found = append(found, r)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Record{}, fmt.Errorf("no record for commit %s", prefix)
+	case 1:
+		return found[0], nil
+	}
+	return Record{}, fmt.Errorf("commit prefix %s is ambiguous", prefix)
+}
+
+// Save записывает историю атомарно через временный файл
+func (db *DB) Save() error {
+	data, err := json.MarshalIndent(db.Records, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := db.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, db.path)
+}
+
+// Names возвращает имена всех ядер, встречавшихся в истории
+func (db *DB) Names() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range db.Records {
+		for _, res := range r.Results {
+			if !seen[res.Name] {
+				seen[res.Name] = true
+				names = append(names, res.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compare сравнивает общие ядра двух записей и возвращает те, что замедлились больше чем на thresholdPct процентов
+func Compare(base, head Record, thresholdPct float64) ([]Regression, error) {
+	if thresholdPct < 0 {
+		return nil, errors.New("Compare: threshold must be non-negative")
+	}
+	baseNs := make(map[string]float64)
+	for _, r := range base.Results {
+		baseNs[r.Name] = r.NsPerOp
+	}
+	var regs []Regression
+	for _, r := range head.Results {
+		b, ok := baseNs[r.Name]
+		if !ok || b == 0 {
+			continue
+		}
+		if change := 100 * (r.NsPerOp/b - 1); change > thresholdPct {
+			regs = append(regs, Regression{Name: r.Name, Base: b, Head: r.NsPerOp, ChangePct: change})
+		}
+	}
+	return regs, nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sagilyp/lab1/mybench"
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/mykdf"
+	"github.com/sagilyp/lab3/mymac"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// sealFunc шифрует и аутентифицирует сообщение
+type sealFunc func(msg []byte) error
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	// у рядов с доверительными интервалами (mybench.ErrorPoints) - планки погрешностей
+	for _, s := range series {
+		if e, ok := s.(interface {
+			plotter.XYer
+			plotter.YErrorer
+		}); ok {
+			bars, err := plotter.NewYErrorBars(e)
+			if err != nil {
+				return err
+			}
+			p.Add(bars)
+		}
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+// newCipher создаёт MyCipher в режиме mode
+func newCipher(mode string, key []byte) *mycrypto.MyCipher {
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		log.Fatal(err)
+	}
+	if err := mc.SetMode(mode); err != nil {
+		log.Fatal(err)
+	}
+	return mc
+}
+
+// aead - однопроходные режимы MyCipher
+func aead(mode string, key []byte) sealFunc {
+	mc := newCipher(mode, key)
+	return func(msg []byte) error {
+		_, err := mc.Encrypt(msg, nil)
+		return err
+	}
+}
+
+// encryptThenMAC - композиция "шифрование CTR, затем MAC шифротекста"
+func encryptThenMAC(macMode string, encKey, macKey []byte) sealFunc {
+	mc := newCipher(mycrypto.ModeCTR, encKey)
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(macMode); err != nil {
+		log.Fatal(err)
+	}
+	if err := mm.SetKey(macKey); err != nil {
+		log.Fatal(err)
+	}
+	var ct []byte
+	return func(msg []byte) error {
+		if n := mc.EncryptedLen(len(msg), nil); len(ct) < n {
+			ct = make([]byte, n)
+		}
+		n, err := mc.EncryptTo(ct, msg, nil)
+		if err != nil {
+			return err
+		}
+		_, err = mm.ComputeMac(ct[:n])
+		return err
+	}
+}
+
+// stdGCM - AES-GCM из crypto/cipher со случайным nonce на каждое сообщение, как у MyCipher
+func stdGCM(key []byte) sealFunc {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(b)
+	if err != nil {
+		log.Fatal(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	var ct []byte
+	return func(msg []byte) error {
+		if _, err := mycrypto.Rand.Read(nonce); err != nil {
+			return err
+		}
+		ct = gcm.Seal(ct[:0], nonce, msg, nil)
+		return nil
+	}
+}
+
+// stdEncryptThenMAC - композиция CTR и HMAC-SHA256 из crypto/cipher и crypto/hmac
+func stdEncryptThenMAC(encKey, macKey []byte) sealFunc {
+	b, err := aes.NewCipher(encKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	h := hmac.New(sha256.New, macKey)
+	iv := make([]byte, aes.BlockSize)
+	var ct []byte
+	return func(msg []byte) error {
+		if _, err := mycrypto.Rand.Read(iv); err != nil {
+			return err
+		}
+		if len(ct) < len(msg) {
+			ct = make([]byte, len(msg))
+		}
+		cipher.NewCTR(b, iv).XORKeyStream(ct[:len(msg)], msg)
+		h.Reset()
+		h.Write(iv)
+		h.Write(ct[:len(msg)])
+		h.Sum(nil)
+		return nil
+	}
+}
+
+// xorLoop - побайтовый XOR, каким mycrypto.XORBytes был до перехода на crypto/subtle
+func xorLoop(dst, a, b []byte) {
+	for i := range a {
+		dst[i] = a[i] ^ b[i]
+	}
+}
+
+// ghashBitSerial поглощает сообщение GHASH'ем на побитовом GFMul, как MyCipher в режиме GCM до ускорения
+func ghashBitSerial(h []byte) sealFunc {
+	return func(msg []byte) error {
+		y := make([]byte, mycrypto.AESBlockSize)
+		for len(msg) > 0 {
+			n := min(len(msg), mycrypto.AESBlockSize)
+			mycrypto.XORInto(y, msg[:n])
+			y = mycrypto.GFMul(y, h, mycrypto.BitOrderReflected)
+			msg = msg[n:]
+		}
+		return nil
+	}
+}
+
+// ghashFast - mycrypto.GHASH: PCLMULQDQ на amd64, 4-битная таблица на остальных платформах
+func ghashFast(h []byte) sealFunc {
+	return func(msg []byte) error {
+		mycrypto.GHASH(h, nil, msg)
+		return nil
+	}
+}
+
+// kernels сравнивает ядра до и после ускорения: побайтовый XOR и XOR mycrypto (XORTo, как XORBytes,
+// без выделения памяти) на crypto/subtle, GHASH на побитовом GFMul и mycrypto.GHASH.
+// Точные сравнения - бенчмарки BenchmarkGHASH и BenchmarkXOR в lab1/mycrypto.
+func kernels(cfg mybench.Config, key []byte) {
+	var dst, other []byte
+	pairs := []struct {
+		name          string
+		before, after sealFunc
+	}{
+		{"XOR", func(msg []byte) error {
+			xorLoop(dst, msg, other)
+			return nil
+		}, func(msg []byte) error {
+			mycrypto.XORTo(dst, msg, other)
+			return nil
+		}},
+		{"GHASH", ghashBitSerial(key), ghashFast(key)},
+	}
+	for _, p := range pairs {
+		for _, sizeKB := range []int{1, 16, 64} {
+			size := sizeKB * 1024
+			dst, other = make([]byte, size), make([]byte, size)
+			before, err := cfg.Run(p.name+" before", size, mybench.Op(p.before))
+			if err != nil {
+				log.Fatal(err)
+			}
+			after, err := cfg.Run(p.name+" after", size, mybench.Op(p.after))
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%-5s %3d KB: before %10.2f MB/s, after %10.2f MB/s (x%.1f)\n",
+				p.name, sizeKB, before.MBPerSec, after.MBPerSec, after.MBPerSec/before.MBPerSec)
+		}
+	}
+	fmt.Println("XOR before: byte loop; after: mycrypto.XORTo on crypto/subtle.XORBytes")
+	fmt.Println("GHASH before: bit-serial GFMul; after: mycrypto.GHASH (PCLMULQDQ or 4-bit table)")
+}
+
+func main() {
+	cfg := mybench.DefaultConfig
+	flag.StringVar(&cfg.Profile.Dir, "profile-dir", "profiles", "directory for profiles and traces, one file per scheme and size")
+	flag.BoolVar(&cfg.Profile.CPU, "cpuprofile", false, "write a CPU profile of the measured runs")
+	flag.BoolVar(&cfg.Profile.Heap, "memprofile", false, "write a heap profile after the measured runs")
+	flag.BoolVar(&cfg.Profile.Trace, "trace", false, "write an execution trace of the measured runs")
+	kernelsOnly := flag.Bool("kernels", false, "only compare the XOR and GHASH kernels before and after acceleration and exit")
+	flag.Parse()
+
+	// ключи шифрования и MAC композиций выводятся из одного секрета через HKDF
+	master := make([]byte, 32)
+	if _, err := rand.Read(master); err != nil {
+		log.Fatal(err)
+	}
+	encKey, macKey, err := mykdf.EtMKeys(master, nil, "aeadbench", mycrypto.AESKeySize16, mymac.AESKeySize)
+	if err != nil {
+		log.Fatal(err)
+	}
+	kernels(cfg, encKey)
+	if *kernelsOnly {
+		return
+	}
+	fmt.Println()
+	schemes := []struct {
+		name string
+		seal sealFunc
+	}{
+		{"OCB3", aead(mycrypto.ModeOCB, encKey)},
+		{"GCM", aead(mycrypto.ModeGCM, encKey)},
+		{"CTR+OMAC", encryptThenMAC(mymac.OMAC, encKey, macKey)},
+		{"CTR+HMAC", encryptThenMAC(mymac.HMAC, encKey, macKey)},
+		// стандартная библиотека на том же графике
+		{"GCM (stdlib)", stdGCM(encKey)},
+		{"CTR+HMAC (stdlib)", stdEncryptThenMAC(encKey, macKey)},
+	}
+	msgSizesKB := []float64{1, 16, 64, 256, 512, 1024}
+	series := []interface{}{}
+	memSeries := []interface{}{}
+	var results []mybench.Result
+	for _, s := range schemes {
+		for _, sizeKB := range msgSizesKB {
+			res, err := cfg.Run(s.name, int(sizeKB*1024), mybench.Op(s.seal))
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%-17s %6.0f KB: %v (median %v, CI %v..%v, %d outliers), %.2f MB/s, %.4g allocs/op, %.4g B/op\n",
+				s.name, sizeKB, res.PerOp, res.Median, res.CILow, res.CIHigh, res.Outliers, res.MBPerSec, res.AllocsPerOp, res.BytesPerOp)
+			results = append(results, res)
+		}
+		series = append(series, s.name, mybench.TimePoints(results, s.name, time.Millisecond))
+		memSeries = append(memSeries, s.name, mybench.MemPoints(results, s.name, 1024))
+	}
+	if err := mybench.Export("graphs/aead_cmp", results); err != nil {
+		log.Fatal(err)
+	}
+	if err := plotResults("AEAD Time vs Message Size", "Message Size (KB)", "Time (ms)", "graphs/aead_cmp.png", series...); err != nil {
+		log.Fatal(err)
+	}
+	if err := plotResults("AEAD Memory vs Message Size", "Message Size (KB)", "Allocated (KB/op)", "graphs/aead_mem.png", memSeries...); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// Число сообщений в пакете и их длина
+const (
+	numItems = 100000
+	msgSize  = 256
+)
+
+func main() {
+	key := make([]byte, mymac.AESKeySize)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	for _, mode := range []string{mymac.OMAC, mymac.HMAC} {
+		mm := &mymac.MyMAC{}
+		if err := mm.SetMode(mode); err != nil {
+			log.Fatal(err)
+		}
+		if err := mm.SetKey(key); err != nil {
+			log.Fatal(err)
+		}
+		items := make([]mymac.BatchItem, numItems)
+		for i := range items {
+			msg := make([]byte, msgSize)
+			if _, err := rand.Read(msg); err != nil {
+				log.Fatal(err)
+			}
+			tag, err := mm.ComputeMac(msg)
+			if err != nil {
+				log.Fatal(err)
+			}
+			items[i] = mymac.BatchItem{Msg: msg, Tag: tag}
+		}
+
+		fmt.Printf("\n<<<--- %s, %d messages of %d bytes --->>>\n", mode, numItems, msgSize)
+		start := time.Now()
+		for _, it := range items {
+			if ok, err := mm.VerifyMac(it.Msg, it.Tag); err != nil || !ok {
+				log.Fatalf("sequential verification failed: %v", err)
+			}
+		}
+		fmt.Printf("sequential VerifyMac: %v\n", time.Since(start))
+		for _, workers := range []int{1, 2, 4, 8} {
+			start := time.Now()
+			_, ok, err := mm.VerifyBatch(items, workers, false)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("VerifyBatch, %d workers: %v (all valid: %v)\n", workers, time.Since(start), ok)
+		}
+
+		// Подделанный тег в начале пакета: с ранним прерыванием остальные теги не проверяются
+		items[numItems/100].Tag[0] ^= 0x01
+		for _, stop := range []bool{false, true} {
+			start := time.Now()
+			res, ok, err := mm.VerifyBatch(items, 4, stop)
+			if err != nil {
+				log.Fatal(err)
+			}
+			counts := map[mymac.BatchResult]int{}
+			for _, r := range res {
+				counts[r]++
+			}
+			fmt.Printf("one forged tag, stopOnFailure=%v: %v, all valid: %v, valid %d, invalid %d, not checked %d\n",
+				stop, time.Since(start), ok, counts[mymac.BatchValid], counts[mymac.BatchInvalid], counts[mymac.BatchNotChecked])
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// Сообщения, теги которых получает атакующий: оба из целых блоков, поэтому CBC-MAC их не дополняет
+var (
+	msg1 = []byte("PAY 100 RUB TO ALICE, REF 000017")
+	msg2 = []byte("PAY 999999 RUB TO MALLORY, REF 000018, URGENT!!!")
+)
+
+// forge склеивает m1 и m2 так, чтобы у результата был тег m2: после m1 состояние CBC равно
+// tag1, и первый блок m2, заранее сложенный с tag1, снова даёт на входе AES первый блок m2.
+// Подделка сработает, только если тег - это состояние CBC после последнего блока.
+func forge(m1, tag1, m2 []byte) ([]byte, error) {
+	first, err := mycrypto.XORBytes(m2[:mymac.AESBlockSize], tag1)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte{}, m1...)
+	out = append(out, first...)
+	return append(out, m2[mymac.AESBlockSize:]...), nil
+}
+
+func main() {
+	key := make([]byte, mymac.AESKeySize)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("m1 = %q\nm2 = %q\n", msg1, msg2)
+	failed := false
+	for _, mode := range []string{mymac.CBCMAC, mymac.EMAC, mymac.OMAC} {
+		// оракул: MyMAC с секретным ключом выдаёт теги сообщений, которые выбрал атакующий
+		mm := &mymac.MyMAC{}
+		if err := mm.SetMode(mode); err != nil {
+			log.Fatal(err)
+		}
+		if err := mm.SetKey(key); err != nil {
+			log.Fatal(err)
+		}
+		tag1, err := mm.ComputeMac(msg1)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tag2, err := mm.ComputeMac(msg2)
+		if err != nil {
+			log.Fatal(err)
+		}
+		forged, err := forge(msg1, tag1, msg2)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ok, err := mm.VerifyMac(forged, tag2)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("\n<<<--- %s --->>>\n", mode)
+		fmt.Printf("tag(m1) = %s\ntag(m2) = %s\n", hex.EncodeToString(tag1), hex.EncodeToString(tag2))
+		fmt.Printf("forged  = m1 || (m2[0:16] ⊕ tag(m1)) || m2[16:] = %s (%d bytes, never sent to the oracle)\n",
+			hex.EncodeToString(forged), len(forged))
+		if ok {
+			fmt.Println("VerifyMac(forged, tag(m2)) = true: FORGERY, the tag of a new message is known without the key")
+		} else {
+			fmt.Println("VerifyMac(forged, tag(m2)) = false: forgery rejected")
+		}
+		// простой CBC-MAC обязан поддаться, EMAC и OMAC - нет
+		if ok != (mode == mymac.CBCMAC) {
+			failed = true
+		}
+	}
+	fmt.Println("\nCBC-MAC is secure only for messages of one fixed length: its tag is the last CBC state,")
+	fmt.Println("so it can be chained further. EMAC encrypts that state once more with a second key,")
+	fmt.Println("OMAC masks the last block with a subkey - in both the tag no longer continues the chain.")
+	if failed {
+		fmt.Println("unexpected result: plain CBC-MAC must be forged, EMAC and OMAC must not")
+		os.Exit(1)
+	}
+}
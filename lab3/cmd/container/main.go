@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sagilyp/lab3/mycontainer"
+)
+
+const usage = `usage:
+  container create -pass pass:P|env:VAR|file:F [-kdf argon2id|scrypt|pbkdf2] [-mode CTR] [-mac HMAC|OMAC] [-keylen 32] [-in file] [-out file.myct]
+  container open -pass pass:P|env:VAR|file:F [-in file.myct] [-out file]
+  container create -keys KEYS -key-id N [-mode CTR] [-mac HMAC|OMAC] [-keylen 32] [-in file] [-out file.myct]
+  container open -keys KEYS [-in file.myct] [-out file]
+  container reencrypt -keys KEYS -key-id N [-in file.myct] [-out file.myct]
+  container create -keys KEYS -to ID,ID,... [-mode CTR] [-mac HMAC|OMAC] [-keylen 32] [-in file] [-out file.myct]
+  container add-recipient -keys KEYS -key-id N [-in file.myct] [-out file.myct]
+  container remove-recipient -keys KEYS -key-id N [-in file.myct] [-out file.myct]
+  container info [-in file.myct]
+
+-in and -out default to stdin and stdout. KDF parameters are the mykdf defaults and are stored in the header;
+open takes everything except the password from the header. KEYS has an "id hexkey" line per key; envelopes
+store the key id, so open picks the key itself and reencrypt moves an envelope to key -key-id.
+With -to the payload is encrypted once and its data key is wrapped (AES-KW) for every listed key id;
+keys of recipients must be 16, 24 or 32 bytes. add-recipient and remove-recipient change recipient -key-id
+using any other recipient key from KEYS and do not re-encrypt the payload.`
+
+// loadKeys читает файл ключей: в каждой строке "id hexkey", строки с # пропускаются
+func loadKeys(path string) (map[uint32][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	keys := make(map[uint32][]byte)
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"id hexkey\"", path, line)
+		}
+		id, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		key, err := hex.DecodeString(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		keys[uint32(id)] = key
+	}
+	return keys, sc.Err()
+}
+
+// newKeyring собирает Keyring из всех ключей файла; текущим становится current
+func newKeyring(keys map[uint32][]byte, current uint32) (*mycontainer.Keyring, error) {
+	key, ok := keys[current]
+	if !ok {
+		return nil, fmt.Errorf("key id %d not found", current)
+	}
+	k, err := mycontainer.NewKeyring(current, key)
+	if err != nil {
+		return nil, err
+	}
+	for id, key := range keys {
+		if id == current {
+			continue
+		}
+		if err := k.AddKey(id, key); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// parseIDs разбирает список идентификаторов ключей через запятую
+func parseIDs(list string) ([]uint32, error) {
+	var ids []uint32
+	for _, f := range strings.Split(list, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(f), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("-to: %v", err)
+		}
+		ids = append(ids, uint32(id))
+	}
+	return ids, nil
+}
+
+// authorizer находит получателя контейнера, чей ключ есть в файле ключей (кроме skip, если есть другие)
+func authorizer(h *mycontainer.Header, keys map[uint32][]byte, skip uint32) (uint32, error) {
+	found, ok := uint32(0), false
+	for _, r := range h.Recipients {
+		if _, have := keys[r.KeyID]; have && (!ok || found == skip) {
+			found, ok = r.KeyID, true
+		}
+	}
+	if !ok {
+		return 0, errors.New("no recipient key in the key file")
+	}
+	return found, nil
+}
+
+// recipientsCmd выполняет create -to, open, add-recipient и remove-recipient для контейнера получателей
+func recipientsCmd(verb string, keys map[uint32][]byte, data []byte, to string, keyID int64, o mycontainer.Options) ([]byte, error) {
+	if verb == "create" {
+		ids, err := parseIDs(to)
+		if err != nil {
+			return nil, err
+		}
+		keks := make(map[uint32][]byte, len(ids))
+		for _, id := range ids {
+			if keks[id] = keys[id]; keks[id] == nil {
+				return nil, fmt.Errorf("key id %d not found", id)
+			}
+		}
+		return mycontainer.CreateForRecipients(keks, data, o)
+	}
+	h, _, err := mycontainer.ReadHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	target := uint32(keyID)
+	if verb != "open" && keyID < 0 {
+		return nil, fmt.Errorf("%s requires -key-id", verb)
+	}
+	id, err := authorizer(h, keys, target)
+	if err != nil {
+		return nil, err
+	}
+	switch verb {
+	case "open":
+		fmt.Fprintf(os.Stderr, "opened as recipient %d\n", id)
+		return mycontainer.OpenForRecipient(id, keys[id], data)
+	case "add-recipient":
+		newKEK, ok := keys[target]
+		if !ok {
+			return nil, fmt.Errorf("key id %d not found", target)
+		}
+		return mycontainer.AddRecipient(data, id, keys[id], target, newKEK)
+	default:
+		return mycontainer.RemoveRecipient(data, id, keys[id], target)
+	}
+}
+
+// readPassword разбирает пароль в нотации "openssl -pass": pass:, env: или file: (первая строка файла)
+func readPassword(spec string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(spec, "pass:"):
+		return []byte(strings.TrimPrefix(spec, "pass:")), nil
+	case strings.HasPrefix(spec, "env:"):
+		name := strings.TrimPrefix(spec, "env:")
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+		return []byte(v), nil
+	case strings.HasPrefix(spec, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(spec, "file:"))
+		if err != nil {
+			return nil, err
+		}
+		line, _, _ := bytes.Cut(data, []byte("\n"))
+		return bytes.TrimSuffix(line, []byte("\r")), nil
+	}
+	return nil, fmt.Errorf("-pass must start with pass:, env: or file:")
+}
+
+func main() {
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	verb := flag.Arg(0)
+	fs := flag.NewFlagSet(verb, flag.ExitOnError)
+	pass := fs.String("pass", "", "password: pass:P, env:VAR or file:F")
+	kdf := fs.String("kdf", mycontainer.KDFArgon2id, "argon2id, scrypt or pbkdf2")
+	mode := fs.String("mode", "", "MyCipher mode (default CTR)")
+	mac := fs.String("mac", "", "HMAC or OMAC (default HMAC)")
+	keyLen := fs.Int("keylen", 0, "AES key length in bytes: 16, 24 or 32 (default 32)")
+	keysFile := fs.String("keys", "", "key file with \"id hexkey\" lines, instead of -pass")
+	keyID := fs.Int64("key-id", -1, "current key id for create and reencrypt, recipient for add-recipient and remove-recipient")
+	to := fs.String("to", "", "comma-separated recipient key ids for create with -keys")
+	in := fs.String("in", "", "input file (default stdin)")
+	out := fs.String("out", "", "output file (default stdout)")
+	fs.Parse(flag.Args()[1:])
+	if fs.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var data []byte
+	var err error
+	if *in != "" {
+		data, err = os.ReadFile(*in)
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var result []byte
+	switch verb {
+	case "info":
+		h, body, err := mycontainer.ReadHeader(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p := h.Params
+		params := fmt.Sprintf("iterations=%d", p.Iterations)
+		switch h.KDF {
+		case mycontainer.KDFArgon2id:
+			params = fmt.Sprintf("t=%d, m=%d KiB, p=%d", p.Time, p.Memory, p.Threads)
+		case mycontainer.KDFScrypt:
+			params = fmt.Sprintf("N=%d, r=%d, p=%d", p.N, p.R, p.P)
+		}
+		if h.KeyID != nil {
+			fmt.Printf("version %d, key id %d\n", h.Version, *h.KeyID)
+		} else if len(h.Recipients) != 0 {
+			ids := make([]string, len(h.Recipients))
+			for i, r := range h.Recipients {
+				ids[i] = strconv.FormatUint(uint64(r.KeyID), 10)
+			}
+			fmt.Printf("version %d, data key wrapped for key ids %s\n", h.Version, strings.Join(ids, ", "))
+		} else {
+			fmt.Printf("version %d, %s (%s), salt %x\n", h.Version, h.KDF, params, h.Salt)
+		}
+		fmt.Printf("AES-%d-%s + %s, tag %x, %d bytes of ciphertext (not verified)\n",
+			8*h.KeyLen, h.Mode, h.MAC, h.Tag, len(body))
+		return
+	case "create", "open", "reencrypt", "add-recipient", "remove-recipient":
+		if *keysFile != "" {
+			keys, err := loadKeys(*keysFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			h, _, _ := mycontainer.ReadHeader(data)
+			if (verb == "create" && *to != "") || verb == "add-recipient" || verb == "remove-recipient" ||
+				(verb == "open" && h != nil && len(h.Recipients) != 0) {
+				o := mycontainer.Options{Mode: strings.ToUpper(*mode), MAC: strings.ToUpper(*mac), KeyLen: *keyLen}
+				if result, err = recipientsCmd(verb, keys, data, *to, *keyID, o); err != nil {
+					log.Fatal(err)
+				}
+				break
+			}
+			current := uint32(*keyID)
+			if *keyID < 0 {
+				if verb != "open" {
+					log.Fatalf("%s with -keys requires -key-id", verb)
+				}
+				// для open текущий ключ не важен: ключ выбирается по заголовку
+				for id := range keys {
+					current = id
+					break
+				}
+			}
+			k, err := newKeyring(keys, current)
+			if err != nil {
+				log.Fatal(err)
+			}
+			switch verb {
+			case "create":
+				result, err = k.Encrypt(data, mycontainer.Options{Mode: strings.ToUpper(*mode), MAC: strings.ToUpper(*mac), KeyLen: *keyLen})
+			case "open":
+				var id uint32
+				result, id, err = k.Decrypt(data)
+				if err == nil {
+					fmt.Fprintf(os.Stderr, "opened with key id %d\n", id)
+				}
+			default:
+				result, err = k.Reencrypt(data)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			break
+		}
+		if verb != "create" && verb != "open" {
+			log.Fatalf("%s requires -keys", verb)
+		}
+		if *pass == "" {
+			log.Fatal("-pass or -keys is required")
+		}
+		password, err := readPassword(*pass)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if verb == "create" {
+			o := mycontainer.Options{KDF: strings.ToLower(*kdf), Mode: strings.ToUpper(*mode), MAC: strings.ToUpper(*mac), KeyLen: *keyLen}
+			result, err = mycontainer.CreateContainer(password, data, o)
+		} else {
+			result, err = mycontainer.OpenContainer(password, data)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(result)
+	} else {
+		err = os.WriteFile(*out, result, 0o600)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
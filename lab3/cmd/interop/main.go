@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// Vector - эталонный вектор: тег out сообщения msg на ключе key.
+// OpenSSL выдаёт полный тег HMAC-SHA256, MyMAC - первые HMACTagSize байт.
+type Vector struct {
+	Tool string `json:"tool"`
+	Alg  string `json:"alg"`
+	Key  string `json:"key"`
+	Msg  string `json:"msg"`
+	Out  string `json:"out"`
+}
+
+// knownDeviations - алгоритмы, заведомо расходящиеся со стандартом; их несовпадение
+// выводится как KNOWN и не считается ошибкой. Сейчас таких нет.
+var knownDeviations = map[string]string{}
+
+// compute вычисляет тег MyMAC для вектора
+func compute(alg string, key, msg []byte) ([]byte, error) {
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(alg); err != nil {
+		return nil, err
+	}
+	if err := mm.SetKey(key); err != nil {
+		return nil, err
+	}
+	return mm.ComputeMac(msg)
+}
+
+// check сверяет тег MyMAC с эталонным
+func check(v Vector) error {
+	key, err := hex.DecodeString(v.Key)
+	if err != nil {
+		return err
+	}
+	msg, err := hex.DecodeString(v.Msg)
+	if err != nil {
+		return err
+	}
+	want, err := hex.DecodeString(v.Out)
+	if err != nil {
+		return err
+	}
+	tag, err := compute(v.Alg, key, msg)
+	if err != nil {
+		return err
+	}
+	if len(tag) > len(want) || !mymac.MacEqual(tag, want[:len(tag)]) {
+		return fmt.Errorf("got %x, want %x", tag, want[:min(len(tag), len(want))])
+	}
+	return nil
+}
+
+func main() {
+	path := flag.String("vectors", "testdata/interop/vectors.json", "reference vectors produced by testdata/interop/gen.sh")
+	out := flag.String("golden", "", "write package tags to this file for testdata/interop/check.sh")
+	flag.Parse()
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var vs []Vector
+	if err := json.Unmarshal(data, &vs); err != nil {
+		log.Fatalf("cannot parse %s: %v", *path, err)
+	}
+	passed, known, failed := 0, 0, 0
+	var golden []Vector
+	for i, v := range vs {
+		err := check(v)
+		reason, deviates := knownDeviations[v.Alg]
+		switch {
+		case err == nil:
+			passed++
+			if deviates {
+				fmt.Printf("NOTE #%d %s now matches %s, remove it from knownDeviations\n", i, v.Alg, v.Tool)
+			}
+		case deviates:
+			known++
+			fmt.Printf("KNOWN #%d %s key=%d bytes msg=%d bytes: %s\n", i, v.Alg, len(v.Key)/2, len(v.Msg)/2, reason)
+		default:
+			failed++
+			fmt.Printf("FAIL #%d %s key=%d bytes msg=%d bytes (%s): %v\n", i, v.Alg, len(v.Key)/2, len(v.Msg)/2, v.Tool, err)
+		}
+		// алгоритмы с известными отклонениями внешний инструмент всё равно не примет
+		if *out != "" && !deviates {
+			key, _ := hex.DecodeString(v.Key)
+			msg, _ := hex.DecodeString(v.Msg)
+			tag, err := compute(v.Alg, key, msg)
+			if err != nil {
+				log.Fatal(err)
+			}
+			golden = append(golden, Vector{Tool: "mymac", Alg: v.Alg, Key: v.Key, Msg: v.Msg, Out: hex.EncodeToString(tag)})
+		}
+	}
+	fmt.Printf("%d/%d vectors passed, %d known deviations, %d failed\n", passed, len(vs), known, failed)
+
+	if *out != "" {
+		data, err := json.MarshalIndent(golden, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*out, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("golden tags written to %s\n", *out)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sagilyp/lab3/mymac"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	p.X.Scale = plot.LogScale{}
+	p.Y.Scale = plot.LogScale{}
+	p.X.Tick.Marker = plot.LogTicks{}
+	p.Y.Tick.Marker = plot.LogTicks{}
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+func main() {
+	maxDepth := flag.Int("max-depth", 100000, "maximal chain depth")
+	runs := flag.Int("runs", 5, "runs per depth")
+	out := flag.String("out", "graphs/kdf_chain.png", "output plot")
+	flag.Parse()
+
+	seed := make([]byte, mymac.AESKeySize)
+	if _, err := rand.Read(seed); err != nil {
+		log.Fatal(err)
+	}
+	label := []byte("lab3 kdf chain")
+	series := []interface{}{}
+	for _, mode := range []string{mymac.OMAC, mymac.HMAC} {
+		pts := make(plotter.XYs, 0)
+		for depth := 1; depth <= *maxDepth; depth *= 10 {
+			var total time.Duration
+			for r := 0; r < *runs; r++ {
+				start := time.Now()
+				if _, err := mymac.DeriveChain(mode, seed, label, depth); err != nil {
+					log.Fatal(err)
+				}
+				total += time.Since(start)
+			}
+			avg := total / time.Duration(*runs)
+			fmt.Printf("%s: depth = %7d, derivation time = %v (%v per step)\n",
+				mode, depth, avg, avg/time.Duration(depth))
+			pts = append(pts, plotter.XY{X: float64(depth), Y: float64(avg.Microseconds()) + 1})
+		}
+		series = append(series, mode, pts)
+	}
+	if err := plotResults("KDF chain derivation time", "Chain depth", "Time (us)", *out, series...); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Plot saved to", *out)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/sagilyp/lab3/mymac"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+func plotResults(title, xLabel, yLabel, filename string, logY bool, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	p.Legend.Top = true
+	p.X.Scale = plot.LogScale{}
+	p.X.Tick.Marker = plot.LogTicks{Prec: -1}
+	if logY {
+		p.Y.Scale = plot.LogScale{}
+		p.Y.Tick.Marker = plot.LogTicks{Prec: -1}
+	}
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+func main() {
+	maxSize := flag.Int("max-size", 1<<20, "largest message size in bytes")
+	budget := flag.Int("budget", 8<<20, "bytes processed per measurement (sets the number of runs)")
+	flag.Parse()
+
+	series := map[string][]interface{}{}
+	for _, mode := range []string{mymac.OMAC, mymac.HMAC} {
+		key := make([]byte, mymac.AESKeySize)
+		if mode == mymac.HMAC {
+			key = make([]byte, mymac.SHASize)
+		}
+		if _, err := rand.Read(key); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s\n%10s %12s %12s %12s %14s %14s\n", mode, "bytes", "setup", "per block", "finalize", "ns/byte", "ns/byte (key)")
+		amortized := make(plotter.XYs, 0)
+		reused := make(plotter.XYs, 0)
+		overhead := make(plotter.XYs, 0)
+		for size := mymac.AESBlockSize; size <= *maxSize; size *= 4 {
+			msg := make([]byte, size)
+			if _, err := rand.Read(msg); err != nil {
+				log.Fatal(err)
+			}
+			runs := max(*budget/size, 10)
+			p, err := mymac.MeasurePhases(mode, key, msg, runs)
+			if err != nil {
+				log.Fatal(err)
+			}
+			withKey := float64(p.Total().Nanoseconds()) / float64(size)
+			withoutKey := float64((p.Update + p.Finalize).Nanoseconds()) / float64(size)
+			fmt.Printf("%10d %12v %12v %12v %14.2f %14.2f\n", size, p.Setup, p.PerBlock(), p.Finalize, withKey, withoutKey)
+			amortized = append(amortized, plotter.XY{X: float64(size), Y: withKey})
+			reused = append(reused, plotter.XY{X: float64(size), Y: withoutKey})
+			overhead = append(overhead, plotter.XY{X: float64(size), Y: 100 * float64(p.Setup+p.Finalize) / float64(p.Total())})
+		}
+		fmt.Println()
+		series["amortization"] = append(series["amortization"], mode+" (SetKey per message)", amortized, mode+" (key reused)", reused)
+		series["overhead"] = append(series["overhead"], mode, overhead)
+	}
+
+	if err := plotResults("MAC cost per byte", "Message size (bytes)", "ns/byte", "graphs/mac_amortization.png", true, series["amortization"]...); err != nil {
+		log.Fatal(err)
+	}
+	if err := plotResults("Share of setup and finalize", "Message size (bytes)", "Setup + finalize (% of total)", "graphs/mac_phases.png", false, series["overhead"]...); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab3/mymanifest"
+)
+
+const usage = `usage:
+  manifest create [-alg sha256,hmac] [-key K] [-sign key.pem] [-workers N] [-m MANIFEST] dir
+  manifest verify [-key K] [-pub key.pem] [-workers N] [-m MANIFEST] dir
+
+-key is used both for MAC algorithms over files and for the MAC of the manifest itself.
+-sign takes a PKCS#8 private key (Ed25519, ECDSA or RSA), -pub a PKIX public or a PKCS#8 private key.
+The manifest defaults to dir/MANIFEST.json and is excluded from the tree.`
+
+// readPEM возвращает DER первого PEM-блока файла
+func readPEM(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+	return block.Bytes, nil
+}
+
+// loadSigner читает закрытый ключ PKCS#8
+func loadSigner(path string) (crypto.Signer, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: key of type %T cannot sign", path, key)
+	}
+	return signer, nil
+}
+
+// loadPublic читает открытый ключ PKIX или берёт открытую часть закрытого ключа PKCS#8
+func loadPublic(path string) (crypto.PublicKey, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if pub, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return pub, nil
+	}
+	signer, err := loadSigner(path)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Public(), nil
+}
+
+// excludeManifest возвращает путь манифеста относительно dir, если он лежит внутри дерева
+func excludeManifest(dir, manifest string) []string {
+	absDir, err1 := filepath.Abs(dir)
+	absM, err2 := filepath.Abs(manifest)
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(absDir, absM)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	return []string{rel}
+}
+
+func main() {
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	verb := flag.Arg(0)
+	fs := flag.NewFlagSet(verb, flag.ExitOnError)
+	algs := fs.String("alg", mymanifest.AlgSHA256, "comma-separated algorithms: sha256, sha512, omac, hmac, truncated")
+	keyFlag := fs.String("key", "", "MAC key (hex:, base64:, file:, ...)")
+	signFlag := fs.String("sign", "", "private key to sign the manifest (PKCS#8 PEM)")
+	pubFlag := fs.String("pub", "", "public key to check the manifest signature (PEM)")
+	workers := fs.Int("workers", 0, "parallel workers (0 = number of CPUs)")
+	manifestFlag := fs.String("m", "", "manifest file (default dir/MANIFEST.json)")
+	fs.Usage = flag.Usage
+	fs.Parse(flag.Args()[1:])
+	if fs.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := fs.Arg(0)
+	path := *manifestFlag
+	if path == "" {
+		path = filepath.Join(dir, "MANIFEST.json")
+	}
+	opts := mymanifest.Options{Workers: *workers, Exclude: excludeManifest(dir, path)}
+	if *keyFlag != "" {
+		key, err := mycrypto.ParseBytes(*keyFlag)
+		if err != nil {
+			log.Fatalf("key: %v", err)
+		}
+		opts.Key = key
+	}
+
+	switch verb {
+	case "create":
+		opts.Algorithms = strings.Split(*algs, ",")
+		var signer crypto.Signer
+		if *signFlag != "" {
+			var err error
+			if signer, err = loadSigner(*signFlag); err != nil {
+				log.Fatal(err)
+			}
+		}
+		m, err := mymanifest.Build(dir, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := m.Seal(opts.Key, signer); err != nil {
+			log.Fatal(err)
+		}
+		if err := m.Save(path); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %d files, algorithms %s, MAC %v, signed %v\n",
+			path, len(m.Files), strings.Join(m.Algorithms, ","), m.MAC != nil, m.Signature != nil)
+
+	case "verify":
+		m, err := mymanifest.Load(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var pub crypto.PublicKey
+		if *pubFlag != "" {
+			if pub, err = loadPublic(*pubFlag); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := m.Authenticate(opts.Key, pub); err != nil {
+			log.Fatal(err)
+		}
+		if m.MAC != nil && opts.Key == nil {
+			fmt.Fprintln(os.Stderr, "warning: manifest MAC not checked, no -key given")
+		}
+		if m.Signature != nil && pub == nil {
+			fmt.Fprintln(os.Stderr, "warning: manifest signature not checked, no -pub given")
+		}
+		d, err := mymanifest.Verify(dir, m, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, p := range d.Added {
+			fmt.Println("added    ", p)
+		}
+		for _, p := range d.Removed {
+			fmt.Println("removed  ", p)
+		}
+		for _, p := range d.Modified {
+			fmt.Println("modified ", p)
+		}
+		fmt.Printf("%d files checked: %d added, %d removed, %d modified\n", d.Checked, len(d.Added), len(d.Removed), len(d.Modified))
+		if !d.Clean() {
+			os.Exit(1)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
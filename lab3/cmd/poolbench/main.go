@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// poolbench сравнивает стоимость одного короткого сообщения, когда MyCipher/MyMAC создаётся
+// заново (расписание ключа на каждое сообщение) и когда берётся из пула; запросы идут из
+// нескольких горутин, как в сервере
+func main() {
+	size := flag.Int("size", 64, "message size in bytes")
+	flag.Parse()
+
+	key := make([]byte, mycrypto.AESKeySize16)
+	if _, err := mycrypto.Rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	msg := make([]byte, *size)
+
+	var cipherPool mycrypto.CipherPool
+	var macPool mymac.MACPool
+	encrypt := func(mode string, pooled bool) func() error {
+		return func() error {
+			var mc *mycrypto.MyCipher
+			var err error
+			if pooled {
+				mc, err = cipherPool.Get(mode, key)
+			} else {
+				mc = &mycrypto.MyCipher{}
+				if err = mc.SetKey(key); err == nil {
+					err = mc.SetMode(mode)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			_, err = mc.Encrypt(msg, nil)
+			if pooled {
+				cipherPool.Put(mc)
+			}
+			return err
+		}
+	}
+	tag := func(mode string, pooled bool) func() error {
+		return func() error {
+			var mm *mymac.MyMAC
+			var err error
+			if pooled {
+				mm, err = macPool.Get(mode, key)
+			} else {
+				mm = &mymac.MyMAC{}
+				if err = mm.SetMode(mode); err == nil {
+					err = mm.SetKey(key)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			_, err = mm.ComputeMac(msg)
+			if pooled {
+				macPool.Put(mm)
+			}
+			return err
+		}
+	}
+
+	kernels := []struct {
+		name string
+		op   func(pooled bool) func() error
+	}{
+		{"MyCipher CTR", func(pooled bool) func() error { return encrypt(mycrypto.ModeCTR, pooled) }},
+		{"MyCipher GCM", func(pooled bool) func() error { return encrypt(mycrypto.ModeGCM, pooled) }},
+		{"MyMAC OMAC", func(pooled bool) func() error { return tag(mymac.OMAC, pooled) }},
+		{"MyMAC HMAC", func(pooled bool) func() error { return tag(mymac.HMAC, pooled) }},
+	}
+	fmt.Printf("%-14s %12s %12s %12s %12s\n", "kernel", "new ns/op", "pool ns/op", "new B/op", "pool B/op")
+	for _, k := range kernels {
+		var res [2]testing.BenchmarkResult
+		for i, pooled := range []bool{false, true} {
+			op := k.op(pooled)
+			res[i] = testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						if err := op(); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		}
+		fmt.Printf("%-14s %12d %12d %12d %12d\n", k.name, res[0].NsPerOp(), res[1].NsPerOp(),
+			res[0].AllocedBytesPerOp(), res[1].AllocedBytesPerOp())
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/sagilyp/lab3/mystats"
+)
+
+// powerplan печатает, сколько повторов и событий нужно экспериментам при заданных
+// доверительной вероятности, точности и величине эффекта
+func main() {
+	confidence := flag.Float64("confidence", 0.95, "confidence level")
+	relErr := flag.Float64("relerr", 0.05, "allowed relative error of a mean or a rate")
+	cv := flag.Float64("cv", 0.3, "coefficient of variation of one measurement (from a pilot run)")
+	effect := flag.Float64("effect", 0.5, "effect size (Cohen's d) to detect between two algorithms")
+	power := flag.Float64("power", 0.8, "probability to detect the effect")
+	flag.Parse()
+
+	runs, err := mystats.SampleSizeMean(*cv, *relErr, *confidence)
+	if err != nil {
+		log.Fatal(err)
+	}
+	events, err := mystats.EventsNeeded(*relErr, *confidence)
+	if err != nil {
+		log.Fatal(err)
+	}
+	perGroup, err := mystats.SampleSizeTwoMeans(*effect, 1-*confidence, *power)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("confidence %.3f, relative error ±%.1f%%\n", *confidence, 100*(*relErr))
+	fmt.Printf("mean of a benchmark with CV %.2f:        %d runs\n", *cv, runs)
+	fmt.Printf("rate of rare events (collisions):       %d events\n", events)
+	fmt.Printf("difference d = %.2f with power %.2f:    %d runs per algorithm\n", *effect, *power, perGroup)
+	fmt.Println()
+	fmt.Printf("relerr   events   runs (CV %.2f)\n", *cv)
+	for _, e := range []float64{0.2, 0.16, 0.1, 0.05, 0.02, 0.01} {
+		k, _ := mystats.EventsNeeded(e, *confidence)
+		n, _ := mystats.SampleSizeMean(*cv, e, *confidence)
+		fmt.Printf("%5.0f%%  %7d  %7d\n", 100*e, k, n)
+	}
+}
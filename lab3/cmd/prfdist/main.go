@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/sagilyp/lab3/mymac"
+	"github.com/sagilyp/lab3/mystats"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+const numMessages = 1 << 16
+
+func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	if err := plotutil.AddLinePoints(p, series...); err != nil {
+		return err
+	}
+	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
+}
+
+// structured - сообщения-счётчики, отличающиеся в одном-двух младших байтах
+func structured(i int) []byte {
+	msg := make([]byte, 2*mymac.AESBlockSize)
+	binary.BigEndian.PutUint64(msg[len(msg)-8:], uint64(i))
+	return msg
+}
+
+func random(int) []byte {
+	msg := make([]byte, 2*mymac.AESBlockSize)
+	if _, err := rand.Read(msg); err != nil {
+		log.Fatal(err)
+	}
+	return msg
+}
+
+func main() {
+	key := make([]byte, mymac.AESKeySize)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	inputs := []struct {
+		name string
+		gen  func(int) []byte
+	}{{"structured", structured}, {"random", random}}
+	ideal := 1 / (2 * math.Sqrt(numMessages))
+	fmt.Printf("Ideal PRF: |bias| ~ %.4f, expected 32-bit collisions = %.3f\n\n",
+		ideal, mystats.ExpectedCollisions(numMessages, 32))
+
+	biasSeries := []interface{}{}
+	collSeries := []interface{}{}
+	tagBits := 0 // длина самого длинного тега в битах: до неё проводится линия идеальной PRF
+	// усечённый MAC - OMAC с 64-битным тегом
+	algs := []struct {
+		name, mode string
+		bits       int
+	}{{mymac.OMAC, mymac.OMAC, 0}, {"OMAC-64", mymac.OMAC, 64}, {mymac.HMAC, mymac.HMAC, 0}}
+	for _, a := range algs {
+		alg := a.name
+		mm := &mymac.MyMAC{}
+		if err := mm.SetMode(a.mode); err != nil {
+			log.Fatal(err)
+		}
+		if a.bits != 0 {
+			if err := mm.SetTagSize(a.bits); err != nil {
+				log.Fatal(err)
+			}
+			if w := mymac.ShortTagWarning(a.mode, a.bits); w != "" {
+				log.Printf("WARNING: %s", w)
+			}
+		}
+		if err := mm.SetKey(key); err != nil {
+			log.Fatal(err)
+		}
+		for _, in := range inputs {
+			tags := make([][]byte, numMessages)
+			for i := range tags {
+				tag, err := mm.ComputeMac(in.gen(i))
+				if err != nil {
+					log.Fatal(err)
+				}
+				tags[i] = tag
+			}
+			bias, err := mystats.BitBias(tags)
+			if err != nil {
+				log.Fatal(err)
+			}
+			tagBits = max(tagBits, len(bias))
+			biasPts := make(plotter.XYs, len(bias))
+			for i, b := range bias {
+				biasPts[i] = plotter.XY{X: float64(i), Y: math.Abs(b)}
+			}
+			collPts := make(plotter.XYs, 0)
+			for nbits := 16; nbits <= 40; nbits += 4 {
+				c, err := mystats.Collisions(tags, nbits)
+				if err != nil {
+					log.Fatal(err)
+				}
+				collPts = append(collPts, plotter.XY{X: float64(nbits), Y: float64(c)})
+			}
+			c32, _ := mystats.Collisions(tags, 32)
+			fmt.Printf("%-9s %-10s max |bias| = %.4f, 32-bit collisions = %d\n",
+				alg, in.name, mystats.MaxAbs(bias), c32)
+			if in.name == "structured" {
+				biasSeries = append(biasSeries, alg, biasPts)
+			}
+			collSeries = append(collSeries, alg+" "+in.name, collPts)
+		}
+	}
+	idealBias := plotter.XYs{{X: 0, Y: ideal}, {X: float64(tagBits - 1), Y: ideal}}
+	biasSeries = append(biasSeries, "ideal PRF", idealBias)
+	idealColl := make(plotter.XYs, 0)
+	for nbits := 16; nbits <= 40; nbits += 4 {
+		idealColl = append(idealColl, plotter.XY{X: float64(nbits), Y: mystats.ExpectedCollisions(numMessages, nbits)})
+	}
+	collSeries = append(collSeries, "ideal PRF", idealColl)
+	if err := plotResults("Per-bit bias of tags (structured inputs)", "Tag bit", "|P(1) - 1/2|",
+		"graphs/prf_bias.png", biasSeries...); err != nil {
+		log.Fatal(err)
+	}
+	if err := plotResults("Tag prefix collisions vs ideal PRF", "Prefix length (bits)", "Colliding pairs",
+		"graphs/prf_collisions.png", collSeries...); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("\nGraphs saved as graphs/prf_bias.png and graphs/prf_collisions.png")
+}
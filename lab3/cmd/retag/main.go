@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// Расширение файлов с тегами: тег файла data.bin хранится в data.bin.tag в hex
+const tagExt = ".tag"
+
+// loadKeys читает файл ключей: в каждой строке "id hexkey", строки с # пропускаются
+func loadKeys(path string) (map[uint32][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	keys := make(map[uint32][]byte)
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"id hexkey\"", path, line)
+		}
+		id, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		key, err := hex.DecodeString(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		keys[uint32(id)] = key
+	}
+	return keys, sc.Err()
+}
+
+// newRotator собирает Rotator: previous (если задан) - текущий ключ до ротации, current - новый
+func newRotator(mode string, keys map[uint32][]byte, current, previous uint32, dual bool) (*mymac.Rotator, error) {
+	first := current
+	if dual {
+		first = previous
+	}
+	key, ok := keys[first]
+	if !ok {
+		return nil, fmt.Errorf("key id %d not found", first)
+	}
+	r, err := mymac.NewRotator(mode, first, key)
+	if err != nil {
+		return nil, err
+	}
+	for id, key := range keys {
+		if id == first {
+			continue
+		}
+		if err := r.AddKey(id, key); err != nil {
+			return nil, err
+		}
+	}
+	if dual {
+		if err := r.BeginRotation(current); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func main() {
+	mode := flag.String("mode", mymac.HMAC, "MAC algorithm")
+	keysFile := flag.String("keys", "", "key file with \"id hexkey\" lines")
+	current := flag.Uint("current", 0, "current key id")
+	previous := flag.Int("previous", -1, "previous key id: emit dual tags during the transition")
+	dir := flag.String("dir", ".", "directory with data files and their .tag files")
+	sign := flag.Bool("sign", false, "tag files that have no .tag file yet")
+	check := flag.Bool("check", false, "only verify tags, do not rewrite them")
+	legacy := flag.Bool("legacy", false, "existing .tag files hold bare MACs without key ids: try every known key")
+	flag.Parse()
+	if *keysFile == "" {
+		log.Fatal("-keys is required")
+	}
+
+	keys, err := loadKeys(*keysFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	r, err := newRotator(*mode, keys, uint32(*current), uint32(*previous), *previous >= 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// голые теги проверяются всеми ключами, теги Rotator - по идентификатору ключа в записи
+	verify, retag := r.Verify, r.Retag
+	if *legacy {
+		verify, retag = r.VerifyAny, r.RetagAny
+	}
+
+	entries, err := os.ReadDir(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var retagged, signed, invalid, untagged int
+	for _, e := range entries {
+		if e.IsDir() || strings.HasSuffix(e.Name(), tagExt) {
+			continue
+		}
+		path := filepath.Join(*dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tagHex, err := os.ReadFile(path + tagExt)
+		if os.IsNotExist(err) {
+			if !*sign || *check {
+				untagged++
+				continue
+			}
+			tag, err := r.Tag(data)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := os.WriteFile(path+tagExt, []byte(hex.EncodeToString(tag)+"\n"), 0o644); err != nil {
+				log.Fatal(err)
+			}
+			signed++
+			continue
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		oldTag, err := hex.DecodeString(strings.TrimSpace(string(tagHex)))
+		if err != nil {
+			fmt.Printf("INVALID %s: %v\n", e.Name(), err)
+			invalid++
+			continue
+		}
+		if *check {
+			ok, id, err := verify(data, oldTag)
+			if err == nil && !ok {
+				err = fmt.Errorf("no entry verifies under a known key")
+			}
+			if err != nil {
+				fmt.Printf("INVALID %s: %v\n", e.Name(), err)
+				invalid++
+				continue
+			}
+			fmt.Printf("ok      %s (key %d)\n", e.Name(), id)
+			continue
+		}
+		newTag, err := retag(data, oldTag)
+		if err != nil {
+			fmt.Printf("INVALID %s: %v\n", e.Name(), err)
+			invalid++
+			continue
+		}
+		if err := os.WriteFile(path+tagExt, []byte(hex.EncodeToString(newTag)+"\n"), 0o644); err != nil {
+			log.Fatal(err)
+		}
+		retagged++
+	}
+	fmt.Printf("retagged %d, signed %d, invalid %d, untagged %d (current key %d, known keys %v)\n",
+		retagged, signed, invalid, untagged, r.Current(), r.KeyIDs())
+	if invalid > 0 {
+		os.Exit(1)
+	}
+}
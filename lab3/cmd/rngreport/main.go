@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/myrand"
+	"github.com/sagilyp/lab3/mystats"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// generator вырабатывает n байт гаммы
+type generator struct {
+	name string
+	gen  func(n int) ([]byte, error)
+}
+
+func randBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	return b
+}
+
+// blockMode - гамма MyCipher в режиме OFB или CTR: шифротекст нулевого сообщения без IV
+func blockMode(mode string) func(int) ([]byte, error) {
+	return func(n int) ([]byte, error) {
+		mc := &mycrypto.MyCipher{}
+		if err := mc.SetKey(randBytes(mycrypto.AESKeySize16)); err != nil {
+			return nil, err
+		}
+		if err := mc.SetMode(mode); err != nil {
+			return nil, err
+		}
+		ct, err := mc.Encrypt(make([]byte, n), nil)
+		if err != nil {
+			return nil, err
+		}
+		return ct[mycrypto.AESBlockSize:], nil
+	}
+}
+
+func chacha20(n int) ([]byte, error) {
+	return mycrypto.ChaCha20XOR(randBytes(mycrypto.ChaCha20KeySize), randBytes(mycrypto.ChaCha20NonceSize), 0, make([]byte, n))
+}
+
+func hmacDRBG(n int) ([]byte, error) {
+	d, err := myrand.NewHMACDRBG(randBytes(32), randBytes(16), []byte("rngreport"))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, n)
+	_, err = d.Read(out)
+	return out, err
+}
+
+func ctrDRBG(n int) ([]byte, error) {
+	d, err := myrand.NewCTRDRBG(randBytes(myrand.CTRDRBGSeedSize), []byte("rngreport"))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, n)
+	_, err = d.Read(out)
+	return out, err
+}
+
+func osRand(n int) ([]byte, error) {
+	return randBytes(n), nil
+}
+
+// lcg - контрольный слабый генератор: младший байт LCG по модулю 2^32, младший бит которого чередуется
+func lcg(n int) ([]byte, error) {
+	x := uint32(12345)
+	out := make([]byte, n)
+	for i := range out {
+		x = x*1103515245 + 12345
+		out[i] = byte(x)
+	}
+	return out, nil
+}
+
+func main() {
+	size := flag.Int("size", 1<<20, "keystream bytes per generator")
+	report := flag.String("report", "", "also write the markdown report to this file")
+	flag.Parse()
+
+	gens := []generator{
+		{"AES-128-CTR", blockMode(mycrypto.ModeCTR)},
+		{"AES-128-OFB", blockMode(mycrypto.ModeOFB)},
+		{"ChaCha20", chacha20},
+		{"CTR_DRBG (AES-256)", ctrDRBG},
+		{"HMAC_DRBG (SHA-256)", hmacDRBG},
+		{"crypto/rand", osRand},
+		{"LCG low byte (control)", lcg},
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Keystream of %d bytes per generator, alpha = %.2f\n\n", *size, mystats.Alpha)
+	header := false
+	var entropy plotter.Values
+	var names []string
+	for _, g := range gens {
+		start := time.Now()
+		data, err := g.gen(*size)
+		if err != nil {
+			log.Fatalf("%s: %v", g.name, err)
+		}
+		speed := float64(*size) / time.Since(start).Seconds() / (1 << 20)
+		res, err := mystats.Battery(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		h, err := mystats.MinEntropyMCV(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !header {
+			sb.WriteString("| Generator | MB/s |")
+			for _, r := range res {
+				fmt.Fprintf(&sb, " %s |", r.Name)
+			}
+			sb.WriteString(" Min-entropy, bits/byte |\n|---|---|")
+			sb.WriteString(strings.Repeat("---|", len(res)+1) + "\n")
+			header = true
+		}
+		fmt.Fprintf(&sb, "| %s | %.1f |", g.name, speed)
+		for _, r := range res {
+			mark := ""
+			if !r.Passed() {
+				mark = " FAIL"
+			}
+			fmt.Fprintf(&sb, " %.3f%s |", r.PValue, mark)
+		}
+		fmt.Fprintf(&sb, " %.3f |\n", h)
+		entropy = append(entropy, h)
+		names = append(names, g.name)
+	}
+	fmt.Print(sb.String())
+	if *report != "" {
+		if err := os.WriteFile(*report, []byte(sb.String()), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	p := plot.New()
+	p.Title.Text = "Min-entropy estimate (most common value)"
+	p.Y.Label.Text = "Bits per byte"
+	bars, err := plotter.NewBarChart(entropy, vg.Points(20))
+	if err != nil {
+		log.Fatal(err)
+	}
+	p.Add(bars)
+	short := make([]string, len(names))
+	for i, n := range names {
+		short[i] = strings.Fields(n)[0]
+	}
+	p.NominalX(short...)
+	if err := p.Save(8*vg.Inch, 4*vg.Inch, "graphs/rng_minentropy.png"); err != nil {
+		log.Fatal(err)
+	}
+}
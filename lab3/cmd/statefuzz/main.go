@@ -0,0 +1,526 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// Фаззер состояний MyCipher и MyMAC: случайные последовательности вызовов в произвольном
+// порядке и с произвольными длинами кусков сверяются с моделью, которая знает, какие вызовы
+// допустимы. Проверяемые свойства:
+//   - ни один вызов не паникует;
+//   - недопустимый вызов возвращает ошибку и не портит состояние (сеанс можно продолжить);
+//   - допустимый вызов не возвращает ошибку;
+//   - сообщение, обработанное кусками с начала сеанса, совпадает с результатом Encrypt/ComputeMac
+//     нового объекта, а одноразовые вызовы не зависят от предыдущей истории объекта.
+
+const bs = mycrypto.AESBlockSize
+
+var cipherModes = []string{mycrypto.ModeECB, mycrypto.ModeCBC, mycrypto.ModeCFB, mycrypto.ModeOFB,
+	mycrypto.ModeCTR, mycrypto.ModeGCM, mycrypto.ModeOCB, mycrypto.ModeCTS}
+
+var macModes = []string{mymac.OMAC, mymac.HMAC, mymac.KMAC128, mymac.KMAC256, mymac.CBCMAC, mymac.EMAC}
+
+// macMaxBits - наибольшая длина тега SetTagSize по режимам (HMAC - на SHA-256)
+var macMaxBits = map[string]int{
+	mymac.OMAC: 128, mymac.HMAC: 256, mymac.KMAC128: 8 * mymac.KMACMaxTagSize, mymac.KMAC256: 8 * mymac.KMACMaxTagSize,
+	mymac.CBCMAC: 128, mymac.EMAC: 128,
+}
+
+type fuzzer struct {
+	rng    *rand.Rand
+	trace  []string
+	calls  int
+	counts map[string]int
+	first  map[string][]string // трасса первого нарушения каждого вида
+}
+
+func (f *fuzzer) bytes(n int) []byte {
+	b := make([]byte, n)
+	f.rng.Read(b)
+	return b
+}
+
+func (f *fuzzer) logf(format string, args ...interface{}) {
+	f.trace = append(f.trace, fmt.Sprintf(format, args...))
+}
+
+func (f *fuzzer) fail(kind, format string, args ...interface{}) {
+	f.counts[kind]++
+	if _, ok := f.first[kind]; !ok {
+		f.first[kind] = append(append([]string{}, f.trace...), "=> "+fmt.Sprintf(format, args...))
+	}
+}
+
+// call выполняет fn, превращая панику в нарушение
+func (f *fuzzer) call(fn func() error) (err error, panicked bool) {
+	f.calls++
+	defer func() {
+		if r := recover(); r != nil {
+			f.fail("panic", "panic: %v", r)
+			panicked = true
+		}
+	}()
+	return fn(), false
+}
+
+// expect сверяет наличие ошибки с ожиданием модели; возвращает true, если вызов прошёл как ожидалось
+func (f *fuzzer) expect(err error, panicked, wantErr bool) bool {
+	switch {
+	case panicked:
+		return false
+	case wantErr && err == nil:
+		f.fail("invalid call accepted", "call succeeded, the model expected an error")
+		return false
+	case !wantErr && err != nil:
+		f.fail("valid call rejected", "unexpected error: %v", err)
+		return false
+	}
+	return true
+}
+
+// ----- MyCipher -----
+
+// cipherModel - ожидаемое состояние MyCipher
+type cipherModel struct {
+	key   []byte
+	mode  string
+	fresh bool        // после SetKey/SetMode следующее сообщение начинается с нового IV
+	enc   *encSession // поблочное шифрование
+	dec   *decSession // поблочное расшифрование
+}
+
+type encSession struct {
+	verifiable bool // сеанс начат с нового IV, и результат можно сравнить с Encrypt
+	pt, out    []byte
+}
+
+type decSession struct {
+	msg, ct []byte
+	pos     int
+	out     []byte
+}
+
+func streamable(mode string) bool {
+	switch mode {
+	case mycrypto.ModeECB, mycrypto.ModeCBC, mycrypto.ModeCFB, mycrypto.ModeOFB, mycrypto.ModeCTR:
+		return true
+	}
+	return false
+}
+
+func padded(mode string) bool {
+	return mode == mycrypto.ModeECB || mode == mycrypto.ModeCBC
+}
+
+func padding(mode string) string {
+	if padded(mode) {
+		return mycrypto.PaddingPKCS7
+	}
+	return mycrypto.PaddingNON
+}
+
+func newRef(key []byte, mode string) *mycrypto.MyCipher {
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		panic(err)
+	}
+	if err := mc.SetMode(mode); err != nil {
+		panic(err)
+	}
+	return mc
+}
+
+// ivLen возвращает длину IV/nonce в начале результата Encrypt
+func ivLen(mode string) int {
+	switch mode {
+	case mycrypto.ModeECB:
+		return 0
+	case mycrypto.ModeGCM, mycrypto.ModeOCB:
+		return mycrypto.GCMNonceSize
+	}
+	return bs
+}
+
+func (f *fuzzer) fuzzCipher(ops int) {
+	mc := &mycrypto.MyCipher{}
+	m := &cipherModel{}
+	reset := func() { m.enc, m.dec, m.fresh = nil, nil, false }
+	for i := 0; i < ops; i++ {
+		switch r := f.rng.Intn(100); {
+		case r < 8:
+			keyLen := []int{16, 24, 32, 16, 24, 32, 0, 15, 17, 31}[f.rng.Intn(10)]
+			key := f.bytes(keyLen)
+			f.logf("SetKey(%d bytes)", keyLen)
+			err, panicked := f.call(func() error { return mc.SetKey(key) })
+			valid := keyLen == 16 || keyLen == 24 || keyLen == 32
+			if !f.expect(err, panicked, !valid) {
+				reset()
+			} else if valid {
+				m.key, m.fresh, m.enc, m.dec = key, true, nil, nil
+			}
+		case r < 16:
+			mode := "XTS"
+			if f.rng.Intn(10) > 0 {
+				mode = cipherModes[f.rng.Intn(len(cipherModes))]
+			}
+			f.logf("SetMode(%s)", mode)
+			err, panicked := f.call(func() error { return mc.SetMode(mode) })
+			if !f.expect(err, panicked, mode == "XTS") {
+				reset()
+			} else if mode != "XTS" {
+				m.mode, m.fresh, m.enc, m.dec = mode, true, nil, nil
+			}
+		case r < 55:
+			f.encChunk(mc, m, reset)
+		case r < 88:
+			f.decChunk(mc, m, reset)
+		default:
+			f.oneShot(mc, m)
+			reset()
+		}
+	}
+}
+
+// encChunk шифрует кусок через ProcessBlockEncrypt
+func (f *fuzzer) encChunk(mc *mycrypto.MyCipher, m *cipherModel, reset func()) {
+	n := f.rng.Intn(3*bs + 1)
+	if padded(m.mode) && f.rng.Intn(4) > 0 {
+		n = bs
+		if f.rng.Intn(3) == 0 {
+			n = f.rng.Intn(bs)
+		}
+	}
+	data := f.bytes(n)
+	final := f.rng.Intn(4) == 0
+	pad := padding(m.mode)
+	if f.rng.Intn(10) == 0 {
+		pad = []string{mycrypto.PaddingPKCS7, mycrypto.PaddingNON, "ZERO"}[f.rng.Intn(3)]
+	}
+	f.logf("ProcessBlockEncrypt(%d bytes, final=%v, %s)", n, final, pad)
+	out := []byte{}
+	err, panicked := f.call(func() error {
+		var err error
+		out, err = mc.ProcessBlockEncrypt(data, final, pad)
+		return err
+	})
+	if m.dec != nil {
+		// смешение шифрования и расшифрования в одном сеансе: проверяется только отсутствие паники
+		reset()
+		return
+	}
+	wantErr := m.key == nil || !streamable(m.mode) || (pad != mycrypto.PaddingPKCS7 && pad != mycrypto.PaddingNON)
+	if !wantErr && padded(m.mode) {
+		// полный блок без паддинга или (в последнем куске) неполный блок с PKCS7
+		wantErr = !(n == bs && (!final || pad == mycrypto.PaddingNON) || final && pad == mycrypto.PaddingPKCS7 && n < bs)
+	} else if !wantErr {
+		wantErr = pad != mycrypto.PaddingNON
+	}
+	if !f.expect(err, panicked, wantErr) {
+		reset()
+		return
+	}
+	if wantErr {
+		return
+	}
+	if m.enc == nil {
+		m.enc = &encSession{verifiable: m.fresh}
+		m.fresh = false
+	}
+	// без паддинга последний блок ECB/CBC не дополняется, и результат отличается от Encrypt
+	if padded(m.mode) && final && pad == mycrypto.PaddingNON {
+		m.enc.verifiable = false
+	}
+	m.enc.pt = append(m.enc.pt, data...)
+	m.enc.out = append(m.enc.out, out...)
+	if !final {
+		return
+	}
+	s := m.enc
+	m.enc = nil
+	if !s.verifiable {
+		return
+	}
+	if len(s.out) < ivLen(m.mode) {
+		f.fail("stream output mismatch", "output of %d bytes has no IV", len(s.out))
+		return
+	}
+	var iv []byte
+	if l := ivLen(m.mode); l > 0 {
+		iv = s.out[:l]
+	}
+	want, err := newRef(m.key, m.mode).Encrypt(s.pt, iv)
+	if err != nil || !bytes.Equal(want, s.out) {
+		f.fail("stream output mismatch", "%s: chunked %x, one-shot %x (%v)", m.mode, s.out, want, err)
+	}
+}
+
+// decChunk расшифровывает кусок через ProcessBlockDecrypt. Сеанс начинается только с нового
+// состояния: шифротекст готовит эталонный объект, затем он подаётся кусками.
+func (f *fuzzer) decChunk(mc *mycrypto.MyCipher, m *cipherModel, reset func()) {
+	if m.enc != nil || m.dec == nil && (!m.fresh || m.key == nil || !streamable(m.mode)) {
+		// вне сеанса: случайный кусок, проверяются паника и отказ без ключа или режима
+		data := f.bytes(f.rng.Intn(2*bs + 1))
+		f.logf("ProcessBlockDecrypt(%d random bytes, final=true, %s)", len(data), padding(m.mode))
+		err, panicked := f.call(func() error {
+			_, err := mc.ProcessBlockDecrypt(data, true, padding(m.mode))
+			return err
+		})
+		if !panicked && err == nil && (m.key == nil || !streamable(m.mode)) {
+			f.fail("invalid call accepted", "decryption without a key or with mode %q succeeded", m.mode)
+		}
+		reset()
+		return
+	}
+	if m.dec == nil {
+		msg := f.bytes(f.rng.Intn(3*bs + 1))
+		ct, err := newRef(m.key, m.mode).Encrypt(msg, nil)
+		if err != nil {
+			panic(err)
+		}
+		m.dec = &decSession{msg: msg, ct: ct}
+		m.fresh = false
+	}
+	s := m.dec
+	rest := s.ct[s.pos:]
+	n := f.rng.Intn(min(len(rest), 2*bs) + 1)
+	wantErr := false
+	if padded(m.mode) {
+		n = bs
+		if f.rng.Intn(8) == 0 {
+			n = f.rng.Intn(min(len(rest), 2*bs) + 1)
+			wantErr = n != bs
+		}
+	}
+	final := s.pos+n == len(s.ct)
+	data := rest[:n]
+	f.logf("ProcessBlockDecrypt(%d bytes, final=%v, %s)", n, final, padding(m.mode))
+	var out []byte
+	err, panicked := f.call(func() error {
+		var err error
+		out, err = mc.ProcessBlockDecrypt(data, final, padding(m.mode))
+		return err
+	})
+	if !f.expect(err, panicked, wantErr) {
+		reset()
+		return
+	}
+	if wantErr {
+		return
+	}
+	s.pos += n
+	s.out = append(s.out, out...)
+	if final {
+		m.dec = nil
+		if !bytes.Equal(s.out, s.msg) {
+			f.fail("stream output mismatch", "%s: chunked decryption %x, message %x", m.mode, s.out, s.msg)
+		}
+	}
+}
+
+// oneShot проверяет, что Encrypt/Decrypt с явным IV не зависят от истории объекта
+func (f *fuzzer) oneShot(mc *mycrypto.MyCipher, m *cipherModel) {
+	msg := f.bytes(f.rng.Intn(3*bs + 1))
+	iv := f.bytes(ivLen(m.mode))
+	if len(iv) == 0 {
+		iv = nil
+	}
+	f.logf("Encrypt(%d bytes, iv) + Decrypt", len(msg))
+	var ct []byte
+	err, panicked := f.call(func() error {
+		var err error
+		ct, err = mc.Encrypt(msg, iv)
+		return err
+	})
+	validMode := false
+	for _, mode := range cipherModes {
+		validMode = validMode || m.mode == mode
+	}
+	wantErr := m.key == nil || !validMode || (m.mode == mycrypto.ModeCTS && len(msg) < bs)
+	if !f.expect(err, panicked, wantErr) || wantErr {
+		return
+	}
+	if want, _ := newRef(m.key, m.mode).Encrypt(msg, iv); !bytes.Equal(ct, want) {
+		f.fail("one-shot depends on history", "%s: Encrypt %x, fresh object %x", m.mode, ct, want)
+		return
+	}
+	var pt []byte
+	err, panicked = f.call(func() error {
+		var err error
+		pt, err = mc.Decrypt(ct, nil)
+		return err
+	})
+	if f.expect(err, panicked, false) && !bytes.Equal(pt, msg) {
+		f.fail("one-shot depends on history", "%s: Decrypt %x, message %x", m.mode, pt, msg)
+	}
+}
+
+// ----- MyMAC -----
+
+// macModel - ожидаемое состояние MyMAC
+type macModel struct {
+	mode  string
+	key   []byte
+	keyed bool
+	bits  int    // длина тега, заданная SetTagSize; 0 - по умолчанию
+	buf   []byte // данные, добавленные MacAddBlock с начала сообщения
+}
+
+func refMAC(mode string, bits int, key, msg []byte) []byte {
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(mode); err != nil {
+		panic(err)
+	}
+	if bits != 0 {
+		if err := mm.SetTagSize(bits); err != nil {
+			panic(err)
+		}
+	}
+	if err := mm.SetKey(key); err != nil {
+		panic(err)
+	}
+	tag, err := mm.ComputeMac(msg)
+	if err != nil {
+		panic(err)
+	}
+	return tag
+}
+
+func (f *fuzzer) fuzzMAC(ops int) {
+	mm := &mymac.MyMAC{}
+	m := &macModel{}
+	for i := 0; i < ops; i++ {
+		switch r := f.rng.Intn(100); {
+		case r < 8:
+			mode := "GMAC"
+			if f.rng.Intn(10) > 0 {
+				mode = macModes[f.rng.Intn(len(macModes))]
+			}
+			f.logf("SetMode(%s)", mode)
+			err, panicked := f.call(func() error { return mm.SetMode(mode) })
+			if f.expect(err, panicked, mode == "GMAC") && mode != "GMAC" && mode != m.mode {
+				// ключ предыдущего алгоритма к новому не относится
+				m.mode, m.keyed, m.key, m.bits, m.buf = mode, false, nil, 0, nil
+			}
+		case r < 20:
+			keyLen := []int{16, 16, 16, 0, 15, 32, 80}[f.rng.Intn(7)]
+			key := f.bytes(keyLen)
+			f.logf("SetKey(%d bytes)", keyLen)
+			err, panicked := f.call(func() error { return mm.SetKey(key) })
+			// HMAC и KMAC принимают ключ любой длины, режимы на AES - только ключ AES
+			anyKey := m.mode == mymac.HMAC || m.mode == mymac.KMAC128 || m.mode == mymac.KMAC256
+			valid := anyKey || m.mode != "" && keyLen == mymac.AESKeySize
+			if f.expect(err, panicked, !valid) && valid {
+				m.key, m.keyed, m.buf = key, true, nil
+			}
+		case r < 24:
+			bits := []int{0, 24, 32, 36, 64, 96, 128, 160, 256, 512}[f.rng.Intn(10)]
+			f.logf("SetTagSize(%d bits)", bits)
+			err, panicked := f.call(func() error { return mm.SetTagSize(bits) })
+			valid := m.mode != "" && bits%8 == 0 && bits >= mymac.MinTagBits && bits <= macMaxBits[m.mode]
+			if f.expect(err, panicked, !valid) && valid {
+				m.bits = bits // незаконченное сообщение и ключ сохраняются
+			}
+		case r < 65:
+			// куски любой длины: границы блоков MyMAC отслеживает сам
+			n := mymac.AESBlockSize
+			if f.rng.Intn(3) == 0 {
+				n = f.rng.Intn(3*mymac.AESBlockSize + 1)
+			}
+			block := f.bytes(n)
+			f.logf("MacAddBlock(%d bytes)", n)
+			err, panicked := f.call(func() error { return mm.MacAddBlock(block) })
+			wantErr := !m.keyed
+			if f.expect(err, panicked, wantErr) && !wantErr {
+				m.buf = append(m.buf, block...)
+			}
+		case r < 88:
+			n := f.rng.Intn(mymac.AESBlockSize + 1)
+			if f.rng.Intn(10) == 0 {
+				n += mymac.AESBlockSize
+			}
+			last := f.bytes(n)
+			f.logf("MacFinalize(%d bytes)", n)
+			var tag []byte
+			err, panicked := f.call(func() error {
+				var err error
+				tag, err = mm.MacFinalize(last)
+				return err
+			})
+			wantErr := !m.keyed
+			if !f.expect(err, panicked, wantErr) || wantErr {
+				continue
+			}
+			msg := append(m.buf, last...)
+			m.buf = nil
+			if want := refMAC(m.mode, m.bits, m.key, msg); !bytes.Equal(tag, want) {
+				f.fail("stream output mismatch", "%s: streamed tag %x, one-shot %x", m.mode, tag, want)
+			}
+		default:
+			msg := f.bytes(f.rng.Intn(3*mymac.AESBlockSize + 1))
+			f.logf("ComputeMac(%d bytes) + VerifyMac", len(msg))
+			var tag []byte
+			var ok bool
+			err, panicked := f.call(func() error {
+				var err error
+				if tag, err = mm.ComputeMac(msg); err != nil {
+					return err
+				}
+				ok, err = mm.VerifyMac(msg, tag)
+				return err
+			})
+			if !f.expect(err, panicked, !m.keyed) || !m.keyed {
+				continue
+			}
+			m.buf = nil
+			if want := refMAC(m.mode, m.bits, m.key, msg); !bytes.Equal(tag, want) || !ok {
+				f.fail("one-shot depends on history", "%s: tag %x, fresh object %x, verify %v", m.mode, tag, want, ok)
+			}
+		}
+	}
+}
+
+func main() {
+	iters := flag.Int("iters", 2000, "call sequences per object type")
+	ops := flag.Int("ops", 60, "calls per sequence")
+	seed := flag.Int64("seed", 1, "random seed (IV generation in mycrypto is seeded too)")
+	show := flag.Int("trace", 20, "trailing calls of the first failing sequence to print")
+	flag.Parse()
+
+	f := &fuzzer{rng: rand.New(rand.NewSource(*seed)), counts: map[string]int{}, first: map[string][]string{}}
+	mycrypto.Rand = rand.New(rand.NewSource(*seed + 1))
+	failed := false
+	for _, target := range []struct {
+		name string
+		run  func(int)
+	}{{"MyCipher", f.fuzzCipher}, {"MyMAC", f.fuzzMAC}} {
+		f.counts, f.first, f.calls = map[string]int{}, map[string][]string{}, 0
+		for i := 0; i < *iters; i++ {
+			f.trace = f.trace[:0]
+			target.run(*ops)
+		}
+		fmt.Printf("%s: %d sequences, %d calls\n", target.name, *iters, f.calls)
+		kinds := make([]string, 0, len(f.counts))
+		for kind := range f.counts {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			trace := f.first[kind]
+			fmt.Printf("  FAIL %s: %d times, first sequence (last %d calls):\n", kind, f.counts[kind], min(*show, len(trace)-1))
+			fmt.Printf("    %s\n", strings.Join(trace[max(0, len(trace)-1-*show):], "\n    "))
+		}
+		failed = failed || len(kinds) > 0
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/sagilyp/lab1/mykdf"
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// newSubkeyMAC создаёт SubkeyMAC и завершает программу при ошибке
+func newSubkeyMAC(mode, kdf string, master []byte) *mymac.SubkeyMAC {
+	s, err := mymac.NewSubkeyMAC(mode, kdf, master)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return s
+}
+
+// separation выводит ключи n сообщений подряд и проверяет разделение ключей: все ключи различны,
+// тег одного и того же сообщения под разными nonce различен и не принимается под чужим nonce
+func separation(mode, kdf string, master []byte, n int) bool {
+	s := newSubkeyMAC(mode, kdf, master)
+	msg := []byte("the same message under every nonce")
+	keys := make(map[string]bool, n)
+	tags := make(map[string]bool, n)
+	var prevNonce, prevTag []byte
+	ok := true
+	for i := 0; i < n; i++ {
+		nonce, tag, err := s.Next(msg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		key, err := s.DeriveKey(nonce)
+		if err != nil {
+			log.Fatal(err)
+		}
+		keys[string(key)] = true
+		tags[string(tag)] = true
+		if prevNonce != nil {
+			cross, err := s.Verify(nonce, msg, prevTag)
+			if err != nil {
+				log.Fatal(err)
+			}
+			ok = ok && !cross
+		}
+		prevNonce, prevTag = nonce, tag
+	}
+	fmt.Printf("%-8s %-8s: %d messages, %d distinct keys, %d distinct tags of one message, cross-nonce tags rejected: %v\n",
+		mode, kdf, n, len(keys), len(tags), ok)
+	return ok && len(keys) == n && len(tags) == n
+}
+
+// leak показывает, что даёт атакующему ключ одного сообщения: он подделывает теги только
+// под этим nonce, тег под соседним nonce, вычисленный на утёкшем ключе, отвергается
+func leak(mode, kdf string, master []byte) bool {
+	s := newSubkeyMAC(mode, kdf, master)
+	leaked := []byte("nonce-17")
+	key, err := s.DeriveKey(leaked)
+	if err != nil {
+		log.Fatal(err)
+	}
+	attacker := &mymac.MyMAC{}
+	if err := attacker.SetMode(mode); err != nil {
+		log.Fatal(err)
+	}
+	if err := attacker.SetKey(key); err != nil {
+		log.Fatal(err)
+	}
+	forged := []byte("PAY 999999 RUB TO MALLORY")
+	tag, err := attacker.ComputeMac(forged)
+	if err != nil {
+		log.Fatal(err)
+	}
+	same, err := s.Verify(leaked, forged, tag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	other, err := s.Verify([]byte("nonce-18"), forged, tag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%-8s %-8s: leaked key of %s (%s...) forges that nonce: %v, forges the next nonce: %v\n",
+		mode, kdf, leaked, hex.EncodeToString(key[:4]), same, other)
+	return same && !other
+}
+
+// perTag возвращает среднее время тега сообщения msg за runs вызовов op
+func perTag(runs int, msg []byte, op func(msg []byte) error) time.Duration {
+	start := time.Now()
+	for i := 0; i < runs; i++ {
+		if err := op(msg); err != nil {
+			log.Fatal(err)
+		}
+	}
+	return time.Since(start) / time.Duration(runs)
+}
+
+func main() {
+	n := flag.Int("n", 10000, "messages in the key separation check")
+	runs := flag.Int("runs", 2000, "tags per timing")
+	flag.Parse()
+
+	master := make([]byte, mymac.AESKeySize)
+	if _, err := rand.Read(master); err != nil {
+		log.Fatal(err)
+	}
+	failed := false
+
+	// ключ HKDF совпадает с HKDF-SHA256 из lab1 с info = SubkeyLabel || 0x00 || nonce
+	nonce := []byte("nonce-0")
+	got, err := newSubkeyMAC(mymac.HMAC, mymac.KDFHKDF, master).DeriveKey(nonce)
+	if err != nil {
+		log.Fatal(err)
+	}
+	want, err := mykdf.HKDF(master, nil, append(append([]byte(mymac.SubkeyLabel), 0), nonce...), len(got))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("HKDF subkey matches mykdf.HKDF: %v\n\n", bytes.Equal(got, want))
+	failed = failed || !bytes.Equal(got, want)
+
+	kdfs := []string{mymac.KDFHKDF, mymac.KDFCMAC}
+	for _, mode := range []string{mymac.OMAC, mymac.HMAC} {
+		for _, kdf := range kdfs {
+			failed = !separation(mode, kdf, master, *n) || failed
+		}
+	}
+	fmt.Println()
+	for _, mode := range []string{mymac.OMAC, mymac.HMAC} {
+		for _, kdf := range kdfs {
+			failed = !leak(mode, kdf, master) || failed
+		}
+	}
+
+	fmt.Println("\nCost of a fresh key per message (average time per tag):")
+	for _, mode := range []string{mymac.OMAC, mymac.HMAC} {
+		plain := &mymac.MyMAC{}
+		if err := plain.SetMode(mode); err != nil {
+			log.Fatal(err)
+		}
+		if err := plain.SetKey(append([]byte{}, master...)); err != nil {
+			log.Fatal(err)
+		}
+		for _, size := range []int{16, 1024, 64 * 1024} {
+			msg := make([]byte, size)
+			base := perTag(*runs, msg, func(m []byte) error {
+				_, err := plain.ComputeMac(m)
+				return err
+			})
+			fmt.Printf("%-5s %6d bytes: one key %10v", mode, size, base)
+			for _, kdf := range kdfs {
+				s := newSubkeyMAC(mode, kdf, master)
+				d := perTag(*runs, msg, func(m []byte) error {
+					_, _, err := s.Next(m)
+					return err
+				})
+				fmt.Printf(", %s %10v (x%.1f)", kdf, d, float64(d)/float64(base))
+			}
+			fmt.Println()
+		}
+	}
+	if failed {
+		fmt.Println("unexpected result: per-message keys must be distinct and a leaked key must forge only its own nonce")
+		os.Exit(1)
+	}
+}
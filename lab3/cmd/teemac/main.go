@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// Размер записываемых данных и размер куска, которым они пишутся
+const (
+	dataSize  = 8 << 20
+	chunkSize = 1000
+)
+
+// newMAC создаёт MyMAC с режимом mode и ключом key
+func newMAC(mode string, key []byte) *mymac.MyMAC {
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(mode); err != nil {
+		log.Fatal(err)
+	}
+	if err := mm.SetKey(key); err != nil {
+		log.Fatal(err)
+	}
+	return mm
+}
+
+// newMulti собирает MultiMAC из OMAC, HMAC и SHA-256
+func newMulti(key []byte) *mymac.MultiMAC {
+	var taggers []mymac.Tagger
+	for _, mode := range []string{mymac.OMAC, mymac.HMAC} {
+		t, err := mymac.NewMACTagger(newMAC(mode, key))
+		if err != nil {
+			log.Fatal(err)
+		}
+		taggers = append(taggers, t)
+	}
+	return mymac.NewMultiMAC(append(taggers, mymac.NewHashTagger(sha256.New()))...)
+}
+
+func main() {
+	key := make([]byte, mymac.AESKeySize)
+	data := make([]byte, dataSize)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := rand.Read(data); err != nil {
+		log.Fatal(err)
+	}
+	f, err := os.CreateTemp("", "teemac-*.bin")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// Один проход: данные пишутся в файл кусками, а теги считаются по пути
+	start := time.Now()
+	multi := newMulti(key)
+	w := mymac.TeeWriter(f, multi)
+	for off := 0; off < len(data); off += chunkSize {
+		if _, err := w.Write(data[off:min(off+chunkSize, len(data))]); err != nil {
+			log.Fatal(err)
+		}
+	}
+	written, err := multi.Sums()
+	if err != nil {
+		log.Fatal(err)
+	}
+	onePass := time.Since(start)
+
+	// Отдельные проходы для каждого тега
+	start = time.Now()
+	var separate [][]byte
+	for _, mode := range []string{mymac.OMAC, mymac.HMAC} {
+		tag, err := newMAC(mode, key).ComputeMac(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		separate = append(separate, tag)
+	}
+	sum := sha256.Sum256(data)
+	separate = append(separate, sum[:])
+	separatePasses := time.Since(start)
+
+	// Проверка при чтении файла обратно
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		log.Fatal(err)
+	}
+	multi = newMulti(key)
+	if _, err := io.Copy(io.Discard, mymac.TeeReader(f, multi)); err != nil {
+		log.Fatal(err)
+	}
+	read, err := multi.Sums()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i, name := range []string{"OMAC", "HMAC", "SHA-256"} {
+		fmt.Printf("%-8s write %x, read back match: %v, separate pass match: %v\n",
+			name, written[i], bytes.Equal(written[i], read[i]), bytes.Equal(written[i], separate[i]))
+	}
+	fmt.Printf("one pass (file write + 3 tags): %v, three separate in-memory passes: %v\n", onePass, separatePasses)
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/sagilyp/lab1/mycamellia"
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/mygost"
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// Vector - тестовый вектор в шестнадцатеричном виде. Входы строятся детерминированно (seq),
+// поэтому повторная генерация даёт тот же файл, пока не изменились реализации.
+// Out - шифротекст без IV/nonce и тега (для KW/KWP - обёрнутый ключ), Tag - тег AEAD или MAC.
+// Segment - размер сегмента CFB в битах (0 - полный блок), Counter - начальный блок ChaCha20.
+type Vector struct {
+	Alg     string `json:"alg"`
+	Mode    string `json:"mode,omitempty"`
+	Segment int    `json:"segment,omitempty"`
+	Key     string `json:"key"`
+	IV      string `json:"iv,omitempty"`
+	Counter uint32 `json:"counter,omitempty"`
+	AAD     string `json:"aad,omitempty"`
+	Msg     string `json:"msg"`
+	Out     string `json:"out"`
+	Tag     string `json:"tag,omitempty"`
+	TagBits int    `json:"tag_bits,omitempty"`
+}
+
+// File - содержимое файла векторов
+type File struct {
+	Comment string   `json:"comment"`
+	Vectors []Vector `json:"vectors"`
+}
+
+const comment = "Generated by lab3/cmd/testvectors. All values are hex. Inputs are byte sequences " +
+	"seq(n, s) = s, s+1, ... (mod 256). out is the ciphertext without IV/nonce and tag " +
+	"(ECB and CBC use PKCS7 padding, CTS is CBC-CS3, CTR iv is the full initial counter block " +
+	"whose counter field, the low 8 bytes (4 for 64-bit blocks), is incremented big-endian; XEX iv is the tweak N || i with a big-endian uint64 i), tag is the AEAD or MAC tag, " +
+	"tag_bits is the MAC tag length set with SetTagSize (the leading bytes of the full tag)."
+
+// seq возвращает n байт s, s+1, ... по модулю 256 (как hexseq в testdata/interop/gen.sh)
+func seq(n int, s byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = s + byte(i)
+	}
+	return b
+}
+
+// Блочные шифры MyCipher и длины их ключей
+var blockCiphers = []struct {
+	alg    string
+	keyLen int
+}{
+	{"AES-128", mycrypto.AESKeySize16},
+	{"AES-192", mycrypto.AESKeySize24},
+	{"AES-256", mycrypto.AESKeySize32},
+	{"Camellia-128", 16},
+	{"Camellia-192", 24},
+	{"Camellia-256", 32},
+	{"Magma", mygost.KeySize},
+	{"Kuznyechik", mygost.KeySize},
+}
+
+var msgLens = []int{0, 1, 15, 16, 17, 33, 64}
+
+// newBlock создаёт блочный шифр по имени алгоритма
+func newBlock(alg string, key []byte) (mycrypto.BlockCipher, error) {
+	switch alg {
+	case "AES-128", "AES-192", "AES-256":
+		// AES подключается через SetKey, см. newCipher
+		return nil, nil
+	case "Camellia-128", "Camellia-192", "Camellia-256":
+		return mycamellia.NewCipher(key)
+	case "Magma":
+		return mygost.NewMagma(key)
+	case "Kuznyechik":
+		return mygost.NewKuznyechik(key)
+	}
+	return nil, fmt.Errorf("unknown block cipher %s", alg)
+}
+
+// newCipher настраивает MyCipher по алгоритму, режиму и сегменту вектора
+func newCipher(v Vector, key, aad []byte) (*mycrypto.MyCipher, error) {
+	mc := &mycrypto.MyCipher{}
+	b, err := newBlock(v.Alg, key)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		err = mc.SetKey(key)
+	} else {
+		err = mc.SetBlockCipher(b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(v.Mode); err != nil {
+		return nil, err
+	}
+	if v.Segment != 0 {
+		if err := mc.SetSegmentSize(v.Segment); err != nil {
+			return nil, err
+		}
+	}
+	mc.SetAAD(aad)
+	return mc, nil
+}
+
+// inputs - разобранные входы вектора
+type inputs struct {
+	key, iv, aad, msg []byte
+}
+
+func parse(v Vector) (inputs, error) {
+	var in inputs
+	for _, f := range []struct {
+		dst *[]byte
+		s   string
+	}{{&in.key, v.Key}, {&in.iv, v.IV}, {&in.aad, v.AAD}, {&in.msg, v.Msg}} {
+		b, err := hex.DecodeString(f.s)
+		if err != nil {
+			return in, err
+		}
+		*f.dst = b
+	}
+	return in, nil
+}
+
+func isAEAD(mode string) bool {
+	return mode == mycrypto.ModeGCM || mode == mycrypto.ModeOCB
+}
+
+// compute вычисляет выходы вектора по его входам. Для обратимых преобразований
+// дополнительно проверяется, что расшифрование возвращает исходное сообщение.
+func compute(v Vector) (out, tag []byte, err error) {
+	in, err := parse(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	var inverse []byte
+	switch v.Alg {
+	case "RC4":
+		c, err := mycrypto.NewRC4(in.key)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = make([]byte, len(in.msg))
+		c.XORKeyStream(out, in.msg)
+		c, _ = mycrypto.NewRC4(in.key)
+		inverse = make([]byte, len(out))
+		c.XORKeyStream(inverse, out)
+	case "ChaCha20":
+		if out, err = mycrypto.ChaCha20XOR(in.key, in.iv, v.Counter, in.msg); err != nil {
+			return nil, nil, err
+		}
+		if inverse, err = mycrypto.ChaCha20XOR(in.key, in.iv, v.Counter, out); err != nil {
+			return nil, nil, err
+		}
+	case "Duplex":
+		d, err := mycrypto.NewDuplexAEAD(in.key)
+		if err != nil {
+			return nil, nil, err
+		}
+		sealed, err := d.Seal(in.iv, in.msg, in.aad)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, tag = sealed[:len(in.msg)], sealed[len(in.msg):]
+		if inverse, err = d.Open(in.iv, sealed, in.aad); err != nil {
+			return nil, nil, err
+		}
+	case "XEX":
+		x, err := mycrypto.NewXEX(in.key)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = make([]byte, len(in.msg))
+		x.Encrypt(out, in.msg, in.iv)
+		inverse = make([]byte, len(out))
+		x.Decrypt(inverse, out, in.iv)
+	case "KW", "KWP":
+		wrap, unwrap := mycrypto.WrapKey, mycrypto.UnwrapKey
+		if v.Alg == "KWP" {
+			wrap, unwrap = mycrypto.WrapKeyPadded, mycrypto.UnwrapKeyPadded
+		}
+		if out, err = wrap(in.key, in.msg); err != nil {
+			return nil, nil, err
+		}
+		if inverse, err = unwrap(in.key, out); err != nil {
+			return nil, nil, err
+		}
+	case mymac.OMAC, mymac.HMAC:
+		mm := &mymac.MyMAC{}
+		if err := mm.SetMode(v.Alg); err != nil {
+			return nil, nil, err
+		}
+		if v.TagBits != 0 {
+			if err := mm.SetTagSize(v.TagBits); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := mm.SetKey(in.key); err != nil {
+			return nil, nil, err
+		}
+		tag, err = mm.ComputeMac(in.msg)
+		return nil, tag, err
+	case "GOST-MAC-Magma", "GOST-MAC-Kuznyechik":
+		b, err := newBlock(v.Alg[len("GOST-MAC-"):], in.key)
+		if err != nil {
+			return nil, nil, err
+		}
+		tag, err = mygost.MAC(b, in.msg, b.BlockSize())
+		return nil, tag, err
+	default:
+		mc, err := newCipher(v, in.key, in.aad)
+		if err != nil {
+			return nil, nil, err
+		}
+		ivArg := in.iv
+		if len(ivArg) == 0 {
+			ivArg = nil
+		}
+		ct, err := mc.Encrypt(in.msg, ivArg)
+		if err != nil {
+			return nil, nil, err
+		}
+		ct = ct[len(in.iv):]
+		if isAEAD(v.Mode) {
+			out, tag = ct[:len(ct)-mycrypto.GCMTagSize], ct[len(ct)-mycrypto.GCMTagSize:]
+		} else {
+			out = ct
+		}
+		if inverse, err = mc.Decrypt(ct, ivArg); err != nil {
+			return nil, nil, fmt.Errorf("decrypt: %v", err)
+		}
+	}
+	if !bytes.Equal(inverse, in.msg) {
+		return nil, nil, fmt.Errorf("decryption does not return the message: got %x", inverse)
+	}
+	return out, tag, nil
+}
+
+// cipherInputs перечисляет векторы режимов MyCipher для всех блочных шифров
+func cipherInputs() []Vector {
+	var vs []Vector
+	for _, bc := range blockCiphers {
+		bs := mycrypto.AESBlockSize
+		if bc.alg == "Magma" {
+			bs = mygost.MagmaBlockSize
+		}
+		key := hex.EncodeToString(seq(bc.keyLen, 0x40))
+		iv := hex.EncodeToString(seq(bs, 0xa0))
+		type modeCfg struct {
+			mode    string
+			segment int
+		}
+		modes := []modeCfg{{mycrypto.ModeECB, 0}, {mycrypto.ModeCBC, 0}, {mycrypto.ModeCFB, 0}, {mycrypto.ModeCFB, 8},
+			{mycrypto.ModeCFB, 1}, {mycrypto.ModeOFB, 0}, {mycrypto.ModeCTR, 0}, {mycrypto.ModeCTS, 0}}
+		if bs == mycrypto.AESBlockSize {
+			modes = append(modes, modeCfg{mycrypto.ModeGCM, 0}, modeCfg{mycrypto.ModeOCB, 0})
+		}
+		for _, m := range modes {
+			for _, n := range msgLens {
+				if m.mode == mycrypto.ModeCTS && n < bs {
+					continue
+				}
+				v := Vector{Alg: bc.alg, Mode: m.mode, Segment: m.segment, Key: key, IV: iv, Msg: hex.EncodeToString(seq(n, 0))}
+				switch m.mode {
+				case mycrypto.ModeECB:
+					v.IV = ""
+				case mycrypto.ModeGCM, mycrypto.ModeOCB:
+					v.IV = hex.EncodeToString(seq(mycrypto.GCMNonceSize, 0xa0))
+					vs = append(vs, v)
+					v.AAD = hex.EncodeToString(seq(20, 0xd0))
+				}
+				vs = append(vs, v)
+			}
+		}
+	}
+	return vs
+}
+
+// otherInputs перечисляет векторы поточных шифров, AEAD на дуплексе, XEX, обёртки ключей и MAC
+func otherInputs() []Vector {
+	var vs []Vector
+	for _, kl := range []int{5, 16, 32} {
+		for _, n := range []int{0, 16, 64} {
+			vs = append(vs, Vector{Alg: "RC4", Key: hex.EncodeToString(seq(kl, 1)), Msg: hex.EncodeToString(seq(n, 0))})
+		}
+	}
+	for _, ctr := range []uint32{0, 1} {
+		for _, n := range []int{0, 1, 63, 64, 65, 130} {
+			vs = append(vs, Vector{Alg: "ChaCha20", Key: hex.EncodeToString(seq(mycrypto.ChaCha20KeySize, 0)),
+				IV: hex.EncodeToString(seq(mycrypto.ChaCha20NonceSize, 0x40)), Counter: ctr, Msg: hex.EncodeToString(seq(n, 0))})
+		}
+	}
+	for _, aad := range []int{0, 20, 200} {
+		for _, n := range []int{0, 1, mycrypto.DuplexBlockSize, mycrypto.DuplexBlockSize + 1, 300} {
+			vs = append(vs, Vector{Alg: "Duplex", Key: hex.EncodeToString(seq(mycrypto.AESKeySize16, 0x40)),
+				IV: hex.EncodeToString(seq(mycrypto.DuplexNonceSize, 0xa0)), AAD: hex.EncodeToString(seq(aad, 0xd0)), Msg: hex.EncodeToString(seq(n, 0))})
+		}
+	}
+	for _, kl := range []int{mycrypto.AESKeySize16, mycrypto.AESKeySize32} {
+		for _, i := range []uint64{1, 2, 64, 65, 1000} {
+			tweak, err := mycrypto.XEXTweak(seq(mycrypto.AESBlockSize, 0xa0), i)
+			if err != nil {
+				log.Fatal(err)
+			}
+			vs = append(vs, Vector{Alg: "XEX", Key: hex.EncodeToString(seq(kl, 0x40)), IV: hex.EncodeToString(tweak),
+				Msg: hex.EncodeToString(seq(mycrypto.AESBlockSize, 0))})
+		}
+	}
+	for _, kek := range []int{mycrypto.AESKeySize16, mycrypto.AESKeySize24, mycrypto.AESKeySize32} {
+		for _, n := range []int{16, 24, 32} {
+			vs = append(vs, Vector{Alg: "KW", Key: hex.EncodeToString(seq(kek, 0)), Msg: hex.EncodeToString(seq(n, 0x11))})
+		}
+		for _, n := range []int{1, 7, 8, 9, 20} {
+			vs = append(vs, Vector{Alg: "KWP", Key: hex.EncodeToString(seq(kek, 0)), Msg: hex.EncodeToString(seq(n, 0x11))})
+		}
+	}
+	macs := []struct {
+		alg  string
+		keys []int
+		bits int // длина тега SetTagSize; 0 - по умолчанию
+	}{
+		{mymac.OMAC, []int{mymac.AESKeySize}, 0},
+		{mymac.OMAC, []int{mymac.AESKeySize}, 64}, // усечённый MAC
+		{mymac.HMAC, []int{16, mymac.SHASize, mymac.SHABlockSize, 80}, 0},
+		{"GOST-MAC-Magma", []int{mygost.KeySize}, 0},
+		{"GOST-MAC-Kuznyechik", []int{mygost.KeySize}, 0},
+	}
+	for _, m := range macs {
+		for _, kl := range m.keys {
+			for _, n := range []int{0, 1, 8, 15, 16, 17, 32, 100} {
+				vs = append(vs, Vector{Alg: m.alg, Key: hex.EncodeToString(seq(kl, 0xa0)), Msg: hex.EncodeToString(seq(n, 0)), TagBits: m.bits})
+			}
+		}
+	}
+	return vs
+}
+
+// generate заполняет выходы векторов
+func generate(vs []Vector) error {
+	for i := range vs {
+		out, tag, err := compute(vs[i])
+		if err != nil {
+			return fmt.Errorf("%s %s msg=%d bytes: %v", vs[i].Alg, vs[i].Mode, len(vs[i].Msg)/2, err)
+		}
+		vs[i].Out, vs[i].Tag = hex.EncodeToString(out), hex.EncodeToString(tag)
+	}
+	return nil
+}
+
+// check пересчитывает векторы и возвращает число несовпадений
+func check(vs []Vector) int {
+	failed := 0
+	for i, v := range vs {
+		out, tag, err := compute(v)
+		if err == nil && (hex.EncodeToString(out) != v.Out || hex.EncodeToString(tag) != v.Tag) {
+			err = fmt.Errorf("got out=%x tag=%x, want out=%s tag=%s", out, tag, v.Out, v.Tag)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL #%d %s %s%s msg=%d bytes: %v\n", i, v.Alg, v.Mode, segmentName(v.Segment), len(v.Msg)/2, err)
+		}
+	}
+	return failed
+}
+
+// segmentName возвращает суффикс размера сегмента для сообщений ("8" для CFB8)
+func segmentName(bits int) string {
+	if bits == 0 {
+		return ""
+	}
+	return fmt.Sprint(bits)
+}
+
+func main() {
+	path := flag.String("o", "testdata/vectors/vectors.json", "vector file")
+	verify := flag.Bool("check", false, "check the implementations against an existing vector file instead of writing it")
+	flag.Parse()
+
+	if *verify {
+		data, err := os.ReadFile(*path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var f File
+		if err := json.Unmarshal(data, &f); err != nil {
+			log.Fatalf("cannot parse %s: %v", *path, err)
+		}
+		failed := check(f.Vectors)
+		fmt.Printf("%d/%d vectors passed\n", len(f.Vectors)-failed, len(f.Vectors))
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	vs := append(cipherInputs(), otherInputs()...)
+	if err := generate(vs); err != nil {
+		log.Fatal(err)
+	}
+	data, err := json.MarshalIndent(File{Comment: comment, Vectors: vs}, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(*path), 0o755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*path, append(data, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, v := range vs {
+		counts[v.Alg]++
+	}
+	fmt.Printf("%d vectors for %d algorithms written to %s\n", len(vs), len(counts), *path)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// throughput возвращает скорость обработки size байт за d в МБ/с
+func throughput(size int64, d time.Duration) float64 {
+	return float64(size) / (1 << 20) / d.Seconds()
+}
+
+// check печатает итог проверки и возвращает true, если он совпал с ожидаемым
+func check(what string, got, want bool) bool {
+	fmt.Printf("%-52s %v\n", what+":", got)
+	return got == want
+}
+
+func main() {
+	mode := flag.String("mode", mymac.OMAC, "MAC algorithm")
+	sizeMB := flag.Int("size", 256, "size of generated data in MB (ignored with -file)")
+	chunk := flag.Int("chunk", mymac.TreeChunkSize, "chunk size in bytes")
+	file := flag.String("file", "", "measure this file instead of generated data")
+	flag.Parse()
+
+	key := make([]byte, mymac.AESKeySize)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(*mode); err != nil {
+		log.Fatal(err)
+	}
+	if err := mm.SetKey(key); err != nil {
+		log.Fatal(err)
+	}
+
+	var data []byte
+	if *file != "" {
+		var err error
+		if data, err = os.ReadFile(*file); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		// случайные данные: у периодических соседние куски могли бы совпасть
+		data = make([]byte, *sizeMB<<20)
+		if _, err := rand.Read(data); err != nil {
+			log.Fatal(err)
+		}
+	}
+	size := int64(len(data))
+	fmt.Printf("%s, %d MB, chunks of %d bytes\n\n", *mode, size>>20, *chunk)
+
+	start := time.Now()
+	if _, err := mm.ComputeMacReader(bytes.NewReader(data)); err != nil {
+		log.Fatal(err)
+	}
+	seq := time.Since(start)
+	fmt.Printf("ComputeMacReader (sequential): %8.1f MB/s\n", throughput(size, seq))
+	var tree *mymac.TreeTag
+	for workers := 1; ; workers *= 2 {
+		if workers > runtime.NumCPU() {
+			workers = runtime.NumCPU()
+		}
+		start := time.Now()
+		t, err := mm.TreeMacReader(bytes.NewReader(data), *chunk, workers)
+		if err != nil {
+			log.Fatal(err)
+		}
+		d := time.Since(start)
+		fmt.Printf("TreeMacReader, %2d workers:     %8.1f MB/s (x%.2f)\n", workers, throughput(size, d), seq.Seconds()/d.Seconds())
+		if tree != nil && !mymac.MacEqual(tree.Root, t.Root) {
+			log.Fatalf("root with %d workers differs", workers)
+		}
+		tree = t
+		if workers == runtime.NumCPU() {
+			break
+		}
+	}
+	fmt.Printf("\n%d chunk tags, root %x\n\n", len(tree.Chunks), tree.Root)
+
+	ok := true
+	verify := func(r io.Reader) bool {
+		valid, err := mm.VerifyTreeReader(r, *chunk, 0, tree.Root)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return valid
+	}
+	ok = check("whole data verifies against the root", verify(bytes.NewReader(data)), true) && ok
+	if len(tree.Chunks) < 2 {
+		fmt.Println("data fit in one chunk: chunk proofs are not demonstrated")
+	} else {
+		j := len(tree.Chunks) / 2
+		piece := data[j**chunk : (j+1)**chunk]
+		proof, err := tree.Proof(j)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("proof of chunk %d: %d tags, %d bytes\n", j, len(proof.Chunks), len(proof.Chunks)*len(proof.Chunks[0]))
+		chunkOK := func(p *mymac.ChunkProof, c []byte) bool {
+			valid, err := mm.VerifyChunk(tree.Root, p, c)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return valid
+		}
+		ok = check("chunk verifies by its proof", chunkOK(proof, piece), true) && ok
+
+		bad := bytes.Clone(piece)
+		bad[len(bad)/2] ^= 1
+		ok = check("chunk with one flipped bit verifies", chunkOK(proof, bad), false) && ok
+		ok = check("neighbouring chunk verifies at its place", chunkOK(proof, data[(j-1)**chunk:j**chunk]), false) && ok
+
+		// без ключа тег изменённого куска не вычислить: атакующий может только переставить теги
+		// в доказательстве, но номера кусков входят в их теги, а порядок тегов - в корень
+		forged := *proof
+		forged.Chunks = append([][]byte{}, proof.Chunks...)
+		forged.Chunks[j], forged.Chunks[j-1] = forged.Chunks[j-1], forged.Chunks[j]
+		ok = check("chunk verifies with swapped tags in the proof", chunkOK(&forged, data[(j-1)**chunk:j**chunk]), false) && ok
+
+		tampered := bytes.Clone(data)
+		tampered[j**chunk] ^= 1
+		ok = check("tampered data verifies against the root", verify(bytes.NewReader(tampered)), false) && ok
+		ok = check("truncated data verifies against the root", verify(bytes.NewReader(data[:size-1])), false) && ok
+	}
+	if !ok {
+		fmt.Println("unexpected result: only untouched data and chunks must verify")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sagilyp/lab3/mywycheproof"
+)
+
+// maxListed - сколько непройденных тестов файла выводить подробно
+const maxListed = 20
+
+func main() {
+	dir := flag.String("dir", "testdata/wycheproof", "directory with Wycheproof JSON files (see fetch.sh there)")
+	verbose := flag.Bool("v", false, "list every failure")
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		var err error
+		if paths, err = filepath.Glob(filepath.Join(*dir, "*.json")); err != nil {
+			log.Fatal(err)
+		}
+		if len(paths) == 0 {
+			log.Fatalf("no vectors in %s, run sh %s/fetch.sh first", *dir, *dir)
+		}
+	}
+	failed := 0
+	for _, path := range paths {
+		f, err := mywycheproof.Load(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r, err := mywycheproof.Run(f)
+		if err != nil {
+			fmt.Printf("%s: %v, skipped\n", filepath.Base(path), err)
+			continue
+		}
+		failed += r.Failed
+		fmt.Printf("%s (%s, %s): %d/%d passed, %d failed, %d known deviations, %d skipped\n",
+			filepath.Base(path), r.Algorithm, f.GeneratorVersion, r.Passed, r.Total, r.Failed, r.Known, r.Skipped)
+		if flags := r.FailedFlags(); len(flags) > 0 {
+			fmt.Printf("  failures by flag: %s\n", strings.Join(flags, ", "))
+		}
+		if r.Known > 0 {
+			fmt.Printf("  KNOWN: %s\n", mywycheproof.KnownDeviations[r.Algorithm])
+		}
+		reasons := make([]string, 0, len(r.SkipReasons))
+		for reason, n := range r.SkipReasons {
+			reasons = append(reasons, fmt.Sprintf("%s: %d", reason, n))
+		}
+		sort.Strings(reasons)
+		if len(reasons) > 0 {
+			fmt.Printf("  skipped: %s\n", strings.Join(reasons, ", "))
+		}
+		listed := 0
+		for _, fl := range r.Failures {
+			if fl.Known {
+				continue
+			}
+			if listed == maxListed && !*verbose {
+				fmt.Printf("  ... %d more, use -v\n", r.Failed-listed)
+				break
+			}
+			listed++
+			fmt.Printf("  FAIL tcId %d (%s) %q %v: %s\n", fl.TcID, fl.Result, fl.Comment, fl.Flags, fl.Reason)
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -2,7 +2,11 @@ module github.com/sagilyp/lab3
 
 go 1.23.0
 
-require gonum.org/v1/plot v0.16.0
+require (
+	github.com/sagilyp/lab1 v0.0.0
+	golang.org/x/crypto v0.31.0
+	gonum.org/v1/plot v0.16.0
+)
 
 require (
 	codeberg.org/go-fonts/liberation v0.5.0 // indirect
@@ -14,5 +18,8 @@ require (
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/image v0.25.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
 )
+
+replace github.com/sagilyp/lab1 => ../lab1
@@ -1,25 +1,26 @@
 package main
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/sagilyp/lab1/mybench"
 	"github.com/sagilyp/lab3/mymac"
+	"github.com/sagilyp/lab3/mystats"
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
 )
 
-type Result struct {
-	Algorithm string
-	MsgSizeKB float64
-	AvgTime   time.Duration
-}
-
 func generateRandomMessage(size int) []byte {
 	msg := make([]byte, size)
 	if _, err := rand.Read(msg); err != nil {
@@ -28,6 +29,121 @@ func generateRandomMessage(size int) []byte {
 	return msg
 }
 
+// stdHMAC - имя ряда HMAC-SHA256 из стандартной библиотеки на графиках и в результатах
+const stdHMAC = "HMAC (crypto/hmac)"
+
+// bench - измеряемый алгоритм: имя ряда на графиках и операция
+type bench struct {
+	name string
+	op   mybench.Op
+}
+
+// macOp возвращает операцию вычисления MAC алгоритмом alg
+func macOp(alg string, key []byte) mybench.Op {
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(alg); err != nil {
+		log.Fatalf("SetAlgorithm error: %v", err)
+	}
+	mm.SetKey(key)
+	return func(msg []byte) error {
+		_, err := mm.ComputeMac(msg)
+		return err
+	}
+}
+
+// hmacHashOp возвращает вычисление HMAC из MyMAC на хэш-функции name из mymac.HashNames
+func hmacHashOp(name string, key []byte) mybench.Op {
+	newHash, err := mymac.HashFunc(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(mymac.HMAC); err != nil {
+		log.Fatalf("SetAlgorithm error: %v", err)
+	}
+	if err := mm.SetHash(newHash); err != nil {
+		log.Fatal(err)
+	}
+	mm.SetKey(key)
+	return func(msg []byte) error {
+		_, err := mm.ComputeMac(msg)
+		return err
+	}
+}
+
+// fileTag вычисляет тег файла path ("-" - stdin) в режиме mode на ключе hexKey через ComputeMacReader,
+// а если задан hexTag - проверяет его через VerifyReader и возвращает тег только при совпадении.
+// С encoded тег выводится и принимается в формате EncodeTag; при проверке режим берётся из тега.
+// Файл читается кусками и в память целиком не загружается.
+func fileTag(path, mode, hexKey, hexTag string, encoded bool) ([]byte, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("-mac-key: %v", err)
+	}
+	tag, err := hex.DecodeString(hexTag)
+	if err != nil {
+		return nil, fmt.Errorf("-mac-tag: %v", err)
+	}
+	var mm *mymac.MyMAC
+	if encoded && hexTag != "" {
+		t, err := mymac.DecodeTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("-mac-tag: %v", err)
+		}
+		if mm, err = t.NewMAC(key); err != nil {
+			return nil, err
+		}
+		tag = t.Tag
+	} else {
+		mm = &mymac.MyMAC{}
+		if err := mm.SetMode(mode); err != nil {
+			return nil, err
+		}
+		if err := mm.SetKey(key); err != nil {
+			return nil, err
+		}
+	}
+	r := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if hexTag == "" {
+		tag, err := mm.ComputeMacReader(r)
+		if err != nil || !encoded {
+			return tag, err
+		}
+		t, err := mm.Describe(tag)
+		if err != nil {
+			return nil, err
+		}
+		return mymac.EncodeTag(t)
+	}
+	ok, err := mm.VerifyReader(r, tag)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s: MAC verification failed", path)
+	}
+	return tag, nil
+}
+
+// stdHMACOp возвращает вычисление HMAC-SHA256 через crypto/hmac - ориентир скорости для MyMAC
+func stdHMACOp(key []byte) mybench.Op {
+	h := hmac.New(sha256.New, key)
+	return func(msg []byte) error {
+		h.Reset()
+		h.Write(msg)
+		h.Sum(nil)
+		return nil
+	}
+}
+
 func plotResults(title, xLabel, yLabel, filename string, series ...interface{}) error {
 	p := plot.New()
 	p.Title.Text = title
@@ -36,21 +152,84 @@ func plotResults(title, xLabel, yLabel, filename string, series ...interface{})
 	if err := plotutil.AddLinePoints(p, series...); err != nil {
 		return err
 	}
+	// у рядов с доверительными интервалами (mybench.ErrorPoints) - планки погрешностей
+	for _, s := range series {
+		if e, ok := s.(interface {
+			plotter.XYer
+			plotter.YErrorer
+		}); ok {
+			bars, err := plotter.NewYErrorBars(e)
+			if err != nil {
+				return err
+			}
+			p.Add(bars)
+		}
+	}
 	return p.Save(6*vg.Inch, 4*vg.Inch, filename)
 }
 
 func main() {
+	plan := mystats.DefaultPlan
+	flag.Float64Var(&plan.Confidence, "confidence", plan.Confidence, "confidence level of the mean MAC time")
+	flag.Float64Var(&plan.RelErr, "relerr", plan.RelErr, "allowed relative half-width of the confidence interval")
+	flag.IntVar(&plan.Pilot, "pilot", plan.Pilot, "pilot runs used to estimate the spread")
+	flag.IntVar(&plan.MaxRuns, "max-runs", plan.MaxRuns, "run budget per message size (0 = unlimited)")
+	var prof mybench.Profile
+	flag.StringVar(&prof.Dir, "profile-dir", "profiles", "directory for profiles and traces, one file per algorithm and size")
+	flag.BoolVar(&prof.CPU, "cpuprofile", false, "write a CPU profile of the measured runs")
+	flag.BoolVar(&prof.Heap, "memprofile", false, "write a heap profile after the measured runs")
+	flag.BoolVar(&prof.Trace, "trace", false, "write an execution trace of the measured runs")
+	hashList := flag.String("hashes", "SHA-1,SHA-512,SHA3-256,BLAKE2b-512", "comma-separated HMAC hash functions compared with HMAC-SHA256 (empty - none)")
+	macFile := flag.String("mac-file", "", "print the MAC of this file (- for stdin) and exit instead of running the experiment")
+	macMode := flag.String("mac-mode", mymac.HMAC, "MAC algorithm for -mac-file")
+	macKey := flag.String("mac-key", "", "hex key for -mac-file")
+	macTag := flag.String("mac-tag", "", "hex tag: verify -mac-file against it instead of printing the tag")
+	macEncoded := flag.Bool("mac-encoded", false, "print and verify -mac-file tags in the self-describing format; -mac-mode is taken from -mac-tag")
+	flag.Parse()
+	if *macFile != "" {
+		tag, err := fileTag(*macFile, *macMode, *macKey, *macTag, *macEncoded)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *macTag != "" {
+			fmt.Printf("%s: OK\n", *macFile)
+			return
+		}
+		fmt.Printf("%s  %s\n", hex.EncodeToString(tag), *macFile)
+		return
+	}
+	if err := plan.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
 	msgSizesKB := []float64{0.1, 1, 10, 1024, 2048, 5096, 10192}
-	algorithms := []string{mymac.OMAC, mymac.TRUNCATED, mymac.HMAC}
+	// усечённый MAC - это OMAC с 64-битным тегом: длина тега задаётся отдельно от алгоритма
+	algorithms := []struct {
+		mode string
+		bits int // 0 - длина тега режима по умолчанию
+	}{
+		{mymac.OMAC, 0}, {mymac.OMAC, 64}, {mymac.HMAC, 0}, {mymac.KMAC128, 0}, {mymac.KMAC256, 0},
+		{mymac.CBCMAC, 0}, {mymac.EMAC, 0},
+	}
 	message := generateRandomMessage(2.5 * mymac.AESBlockSize) // 2.5 блока
 	messageAttacked := make([]byte, 2.5*mymac.AESBlockSize)
 	copy(messageAttacked, message)
 	messageAttacked[0] ^= 0x80 // xor 10000000
 	key := generateRandomMessage(16)
 	fmt.Printf("Исходное сообщение: %s\n\n", hex.EncodeToString(message))
-	for _, alg := range algorithms {
+	for _, a := range algorithms {
+		alg := a.mode
 		mm := &mymac.MyMAC{}
-		mm.SetMode(alg)
+		mm.SetMode(a.mode)
+		if a.bits != 0 {
+			if err := mm.SetTagSize(a.bits); err != nil {
+				log.Fatal(err)
+			}
+			if w := mymac.ShortTagWarning(a.mode, a.bits); w != "" {
+				log.Printf("WARNING: %s", w)
+			}
+			alg = fmt.Sprintf("%s-%d", a.mode, a.bits)
+		}
 		mm.SetKey(key)
 		tag1, err := mm.ComputeMac(message)
 		if err != nil {
@@ -65,63 +244,83 @@ func main() {
 		fmt.Printf("Совпадение MAC: %v\n\n", mymac.MacEqual(tag1, tag2))
 	}
 
-	var results []Result
-	for _, alg := range algorithms {
-		if alg == mymac.TRUNCATED {
+	// прогрев и новое сообщение на каждый прогон вне отсчёта времени - в mybench.Timer;
+	// интервал в результатах - с той же доверительной вероятностью, что и план
+	cfg := mybench.DefaultConfig
+	cfg.Fresh = true
+	cfg.Confidence = plan.Confidence
+	// MyMAC и crypto/hmac на тех же размерах сообщений: видно, во что обходится учебная реализация
+	benches := []bench{
+		{mymac.OMAC, macOp(mymac.OMAC, key)},
+		{mymac.HMAC, macOp(mymac.HMAC, key)},
+		{stdHMAC, stdHMACOp(key)},
+	}
+	// HMAC из MyMAC на других хэш-функциях: сравнение семейств на графике time_hashes
+	var hashNames []string
+	for _, name := range strings.Split(*hashList, ",") {
+		if name = strings.TrimSpace(name); name == "" || name == mymac.HashSHA256 {
 			continue
 		}
+		hashNames = append(hashNames, mymac.HMAC+"-"+name)
+		benches = append(benches, bench{mymac.HMAC + "-" + name, hmacHashOp(name, key)})
+	}
+	var results []mybench.Result
+	for _, b := range benches {
+		alg := b.name
 		for _, sizeKB := range msgSizesKB {
 			msgSize := int(sizeKB * 1024)
-			mm := &mymac.MyMAC{}
-			if err := mm.SetMode(alg); err != nil {
-				log.Fatalf("SetAlgorithm error: %v", err)
+			t, err := cfg.NewTimer(msgSize, b.op)
+			if err != nil {
+				log.Fatalf("ComputeMac error: %v", err)
 			}
-			mm.SetKey(key)
-			numRuns := 1000
-			var duration time.Duration
-			for i := 0; i < numRuns; i++ {
-				msg := generateRandomMessage(msgSize)
-				start := time.Now()
-				_, err := mm.ComputeMac(msg)
-				if err != nil {
-					log.Fatalf("ComputeMac error: %v", err)
-				}
-				end := time.Since(start)
-				duration += end
+			stop, err := prof.Start(alg, msgSize)
+			if err != nil {
+				log.Fatal(err)
 			}
-			avgTime := duration /// time.Duration(numRuns)
-			results = append(results, Result{
-				Algorithm: alg,
-				MsgSizeKB: sizeKB,
-				AvgTime:   avgTime,
+			// число повторов определяет план: пока среднее не известно с заданной точностью
+			est, err := plan.Measure(func() (float64, error) {
+				d, err := t.Run()
+				return float64(d), err
 			})
-			fmt.Printf("%s: Message size = %.1f KB, Avg MAC time = %v\n",
-				alg, sizeKB, avgTime)
+			if err != nil {
+				log.Fatalf("ComputeMac error: %v", err)
+			}
+			if err := stop(); err != nil {
+				log.Fatal(err)
+			}
+			res := t.Result(alg)
+			results = append(results, res)
+			note := ""
+			if est.Capped {
+				note = fmt.Sprintf(" (budget exhausted, %d runs needed)", est.Needed)
+			}
+			fmt.Printf("%s: Message size = %.1f KB, Avg MAC time = %v ±%.1f%% (median %v, CI %v..%v, %d outliers), %.2f MB/s, %.4g allocs/op, %.4g B/op over %d runs%s\n",
+				alg, sizeKB, res.PerOp, 100*est.RelErr, res.Median, res.CILow, res.CIHigh, res.Outliers, res.MBPerSec,
+				res.AllocsPerOp, res.BytesPerOp, est.N, note)
 		}
 	}
-	// построение графиков и подготовка данных
-	timePtsOMAC := make(plotter.XYs, 0)
-	timePtsHMAC := make(plotter.XYs, 0)
-	for _, res := range results {
-		switch res.Algorithm {
-		case mymac.OMAC:
-			timePtsOMAC = append(timePtsOMAC, plotter.XY{X: res.MsgSizeKB, Y: float64(res.AvgTime.Milliseconds())})
-		case mymac.HMAC:
-			timePtsHMAC = append(timePtsHMAC, plotter.XY{X: res.MsgSizeKB, Y: float64(res.AvgTime.Milliseconds())})
-		}
+	// результаты в CSV и JSON рядом с графиками - для обработки и сравнения между коммитами
+	if err := mybench.Export("graphs/time_results", results); err != nil {
+		log.Fatal(err)
 	}
+	// построение графиков и подготовка данных
+	timePtsOMAC := mybench.TimePoints(results, mymac.OMAC, time.Millisecond)
+	timePtsHMAC := mybench.TimePoints(results, mymac.HMAC, time.Millisecond)
+	timePtsStd := mybench.TimePoints(results, stdHMAC, time.Millisecond)
+	precision := fmt.Sprintf("(mean ±%.0f%%, %.0f%% CI)", 100*plan.RelErr, 100*plan.Confidence)
 	err := plotResults(
-		"Compared Time vs Message Size(1000 msgs)",
+		"Compared Time vs Message Size "+precision,
 		"Message Size (KB)", "Time (ms)",
 		"graphs/time_cmp.png",
 		"OMAC", timePtsOMAC,
 		"HMAC", timePtsHMAC,
+		stdHMAC, timePtsStd,
 	)
 	if err != nil {
 		log.Fatal(err)
 	}
 	err = plotResults(
-		"OMAC Time vs Message Size(1000 msgs)",
+		"OMAC Time vs Message Size "+precision,
 		"Message Size (KB)", "Time (ms)",
 		"graphs/time_omac.png",
 		"OMAC", timePtsOMAC,
@@ -130,10 +329,33 @@ func main() {
 		log.Fatal(err)
 	}
 	err = plotResults(
-		"HMAC Time vs Message Size(1000 msgs)",
+		"HMAC Time vs Message Size "+precision,
 		"Message Size (KB)", "Time (ms)",
 		"graphs/time_hmac.png",
 		"HMAC", timePtsHMAC,
+		stdHMAC, timePtsStd,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(hashNames) > 0 {
+		series := []interface{}{"HMAC-" + mymac.HashSHA256, timePtsHMAC}
+		for _, name := range hashNames {
+			series = append(series, name, mybench.TimePoints(results, name, time.Millisecond))
+		}
+		err = plotResults("HMAC Time by Hash Function "+precision, "Message Size (KB)", "Time (ms)", "graphs/time_hashes.png", series...)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	// память, выделенная за одно вычисление MAC
+	err = plotResults(
+		"Compared Memory vs Message Size",
+		"Message Size (KB)", "Allocated (KB/op)",
+		"graphs/memory_cmp.png",
+		"OMAC", mybench.MemPoints(results, mymac.OMAC, 1024),
+		"HMAC", mybench.MemPoints(results, mymac.HMAC, 1024),
+		stdHMAC, mybench.MemPoints(results, stdHMAC, 1024),
 	)
 	if err != nil {
 		log.Fatal(err)
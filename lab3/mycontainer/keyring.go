@@ -0,0 +1,126 @@
+package mycontainer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ----- Связка ключей: конверты с идентификатором ключа -----
+
+// MinKeySize - наименьшая длина ключа связки; ключ служит секретом HKDF, а не ключом AES
+const MinKeySize = 16
+
+// Keyring шифрует конверты текущим ключом и записывает его идентификатор в заголовок.
+// Decrypt выбирает ключ по идентификатору из заголовка, поэтому после Rotate старые конверты
+// продолжают открываться, пока их ключ не удалён через RetireKey.
+type Keyring struct {
+	keys    map[uint32][]byte
+	current uint32
+}
+
+// NewKeyring создаёт связку с первым ключом id, который сразу становится текущим
+func NewKeyring(id uint32, key []byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[uint32][]byte)}
+	if err := k.AddKey(id, key); err != nil {
+		return nil, err
+	}
+	k.current = id
+	return k, nil
+}
+
+// AddKey добавляет ключ для расшифрования (и будущей ротации) без изменения текущего ключа
+func (k *Keyring) AddKey(id uint32, key []byte) error {
+	if _, ok := k.keys[id]; ok {
+		return fmt.Errorf("key id %d already exists", id)
+	}
+	if len(key) < MinKeySize {
+		return fmt.Errorf("key id %d: key must be at least %d bytes, got %d", id, MinKeySize, len(key))
+	}
+	k.keys[id] = append([]byte{}, key...)
+	return nil
+}
+
+// Rotate делает ключ id текущим: новые конверты шифруются под ним
+func (k *Keyring) Rotate(id uint32) error {
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("unknown key id %d", id)
+	}
+	if id == k.current {
+		return errors.New("key is already current")
+	}
+	k.current = id
+	return nil
+}
+
+// RetireKey удаляет ключ id; конверты под ним перестают открываться
+func (k *Keyring) RetireKey(id uint32) error {
+	if id == k.current {
+		return fmt.Errorf("key id %d is in use", id)
+	}
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("unknown key id %d", id)
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// KeyIDs возвращает идентификаторы известных ключей по возрастанию
+func (k *Keyring) KeyIDs() []uint32 {
+	ids := make([]uint32, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+	return ids
+}
+
+// Current возвращает идентификатор текущего ключа
+func (k *Keyring) Current() uint32 {
+	return k.current
+}
+
+// Encrypt шифрует plaintext в конверт формата контейнера под текущим ключом.
+// KDF и Params из o не используются: ключ связки сразу разделяется на ключи шифрования и MAC.
+func (k *Keyring) Encrypt(plaintext []byte, o Options) ([]byte, error) {
+	o = o.withDefaults()
+	id := k.current
+	h := &Header{Version: Version, KeyID: &id, Mode: o.Mode, KeyLen: o.KeyLen, MAC: o.MAC}
+	if err := h.check(); err != nil {
+		return nil, err
+	}
+	return seal(h, k.keys[id], plaintext)
+}
+
+// Decrypt открывает конверт ключом с идентификатором из заголовка и возвращает этот идентификатор.
+// Неизвестный или удалённый ключ - ошибка, неверный тег - ErrAuth.
+func (k *Keyring) Decrypt(data []byte) ([]byte, uint32, error) {
+	h, body, err := ReadHeader(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if h.KeyID == nil {
+		return nil, 0, errors.New("container: not a keyring envelope, open it with OpenContainer or OpenForRecipient")
+	}
+	id := *h.KeyID
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, id, fmt.Errorf("container: unknown key id %d", id)
+	}
+	plaintext, err := open(h, key, body)
+	return plaintext, id, err
+}
+
+// Reencrypt открывает конверт и шифрует его содержимое под текущим ключом с теми же режимом и MAC.
+// Используется для массового перешифрования сохранённых данных после Rotate, перед RetireKey.
+func (k *Keyring) Reencrypt(data []byte) ([]byte, error) {
+	plaintext, _, err := k.Decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := ReadHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt(plaintext, Options{Mode: h.Mode, KeyLen: h.KeyLen, MAC: h.MAC})
+}
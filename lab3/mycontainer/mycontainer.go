@@ -0,0 +1,346 @@
+package mycontainer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab1/mykdf"
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// ----- Контейнер, зашифрованный на пароле: KDF, затем шифрование и MAC (EtM) -----
+
+// Magic - первые байты контейнера
+const Magic = "MYCT"
+
+// Version - версия формата заголовка
+const Version = 1
+
+// Функции выработки ключа из пароля
+const (
+	KDFArgon2id = "argon2id"
+	KDFScrypt   = "scrypt"
+	KDFPBKDF2   = "pbkdf2"
+)
+
+// etmContext - контекст HKDF, разделяющий ключи контейнера и ключи других применений того же секрета
+const etmContext = "mycontainer"
+
+// maxKDFMemory - наибольшая память KDF, которую OpenContainer согласен выделить по заголовку из файла
+const maxKDFMemory = 1 << 30
+
+// ErrAuth возвращается, если тег не сходится: неверный пароль или изменённый контейнер
+var ErrAuth = errors.New("container: authentication failed")
+
+// KDFParams - параметры KDF; заполняются только поля выбранной функции
+type KDFParams struct {
+	Iterations int    `json:"iterations,omitempty"` // PBKDF2
+	N          int    `json:"n,omitempty"`          // scrypt
+	R          int    `json:"r,omitempty"`          // scrypt
+	P          int    `json:"p,omitempty"`          // scrypt
+	Time       uint32 `json:"time,omitempty"`       // Argon2id
+	Memory     uint32 `json:"memory,omitempty"`     // Argon2id, КиБ
+	Threads    uint8  `json:"threads,omitempty"`    // Argon2id
+}
+
+// Header - заголовок контейнера. Tag (MAC из mymac) вычисляется над каноническим JSON
+// заголовка без тега и шифротекстом, поэтому подмена соли, параметров или режима тоже обнаруживается.
+// KeyID задан у конвертов Keyring: секрет берётся из связки ключей, а KDF, Salt и Params пусты.
+// Recipients заданы у контейнеров для нескольких получателей: секрет - DEK, обёрнутый для каждого.
+type Header struct {
+	Version    int         `json:"version"`
+	KeyID      *uint32     `json:"key_id,omitempty"`
+	Recipients []Recipient `json:"recipients,omitempty"`
+	KDF        string      `json:"kdf,omitempty"`
+	Salt       []byte      `json:"salt,omitempty"`
+	Params     KDFParams   `json:"params"`
+	Mode       string      `json:"mode"`
+	KeyLen     int         `json:"key_len"`
+	MAC        string      `json:"mac"`
+	Tag        []byte      `json:"tag,omitempty"`
+}
+
+// Options - параметры CreateContainer и Keyring.Encrypt (KDF и Params связке не нужны);
+// нулевые поля заменяются значениями по умолчанию
+type Options struct {
+	KDF    string    // KDFArgon2id (по умолчанию), KDFScrypt или KDFPBKDF2
+	Params KDFParams // нулевые поля - DefaultArgon2Params, DefaultScryptParams или DefaultIterations из mykdf
+	Mode   string    // режим MyCipher, кроме ECB (по умолчанию CTR)
+	KeyLen int       // длина ключа AES: 16, 24 или 32 (по умолчанию 32)
+	MAC    string    // mymac.HMAC (по умолчанию) или mymac.OMAC
+}
+
+// withDefaults подставляет значения по умолчанию для выбранной KDF
+func (o Options) withDefaults() Options {
+	if o.KDF == "" {
+		o.KDF = KDFArgon2id
+	}
+	p := &o.Params
+	switch o.KDF {
+	case KDFArgon2id:
+		d := mykdf.DefaultArgon2Params
+		if p.Time == 0 {
+			p.Time = d.Time
+		}
+		if p.Memory == 0 {
+			p.Memory = d.Memory
+		}
+		if p.Threads == 0 {
+			p.Threads = d.Threads
+		}
+	case KDFScrypt:
+		d := mykdf.DefaultScryptParams
+		if p.N == 0 {
+			p.N = d.N
+		}
+		if p.R == 0 {
+			p.R = d.R
+		}
+		if p.P == 0 {
+			p.P = d.P
+		}
+	case KDFPBKDF2:
+		if p.Iterations == 0 {
+			p.Iterations = mykdf.DefaultIterations
+		}
+	}
+	if o.Mode == "" {
+		o.Mode = mycrypto.ModeCTR
+	}
+	if o.KeyLen == 0 {
+		o.KeyLen = mycrypto.AESKeySize32
+	}
+	if o.MAC == "" {
+		o.MAC = mymac.HMAC
+	}
+	return o
+}
+
+// canonical возвращает JSON заголовка без тега
+func (h *Header) canonical() ([]byte, error) {
+	c := *h
+	c.Tag = nil
+	return json.Marshal(c)
+}
+
+// masterKey вырабатывает из пароля 32-байтный секрет функцией и параметрами заголовка
+func (h *Header) masterKey(password []byte) ([]byte, error) {
+	const n = 32
+	p := h.Params
+	switch h.KDF {
+	case KDFArgon2id:
+		return mykdf.Argon2id(password, h.Salt, mykdf.Argon2Params{Time: p.Time, Memory: p.Memory, Threads: p.Threads, KeyLen: n})
+	case KDFScrypt:
+		return mykdf.Scrypt(password, h.Salt, mykdf.ScryptParams{N: p.N, R: p.R, P: p.P, KeyLen: n})
+	case KDFPBKDF2:
+		return mykdf.PBKDF2(password, h.Salt, p.Iterations, n)
+	}
+	return nil, fmt.Errorf("container: unknown KDF %q", h.KDF)
+}
+
+// check отвергает заголовки, которые CreateContainer не создаёт, и параметры KDF,
+// требующие больше maxKDFMemory: заголовок читается до проверки тега
+func (h *Header) check() error {
+	if h.Version != Version {
+		return fmt.Errorf("container: unsupported version %d", h.Version)
+	}
+	switch h.KDF {
+	case "":
+		if h.KeyID == nil && len(h.Recipients) == 0 {
+			return errors.New("container: header has neither KDF, key id nor recipients")
+		}
+	case KDFArgon2id:
+		if uint64(h.Params.Memory)<<10 > maxKDFMemory {
+			return fmt.Errorf("container: Argon2id memory %d KiB exceeds the limit", h.Params.Memory)
+		}
+	case KDFScrypt:
+		if h.Params.N < 0 || h.Params.R < 0 || h.Params.P < 0 ||
+			128*uint64(h.Params.N)*uint64(h.Params.R) > maxKDFMemory/uint64(max(h.Params.P, 1)) {
+			return fmt.Errorf("container: scrypt N=%d, r=%d, p=%d exceed the memory limit", h.Params.N, h.Params.R, h.Params.P)
+		}
+	case KDFPBKDF2:
+	default:
+		return fmt.Errorf("container: unknown KDF %q", h.KDF)
+	}
+	if h.KeyID != nil && h.KDF != "" {
+		return errors.New("container: header has both KDF and key id")
+	}
+	if len(h.Recipients) != 0 {
+		if h.KeyID != nil || h.KDF != "" {
+			return errors.New("container: header has recipients together with KDF or key id")
+		}
+		if err := h.checkRecipients(); err != nil {
+			return err
+		}
+	}
+	switch h.KeyLen {
+	case mycrypto.AESKeySize16, mycrypto.AESKeySize24, mycrypto.AESKeySize32:
+	default:
+		return fmt.Errorf("container: invalid key length %d", h.KeyLen)
+	}
+	if h.Mode == mycrypto.ModeECB {
+		return errors.New("container: ECB mode is not allowed")
+	}
+	if h.MAC != mymac.HMAC && h.MAC != mymac.OMAC {
+		return fmt.Errorf("container: unsupported MAC %q", h.MAC)
+	}
+	return nil
+}
+
+// keys вырабатывает ключ шифрования и ключ MAC: секрет KDF или ключ связки разделяется через mykdf.EtMKeys
+func (h *Header) keys(master []byte) (*mycrypto.MyCipher, *mymac.MyMAC, error) {
+	macLen := mymac.AESKeySize
+	if h.MAC == mymac.HMAC {
+		macLen = mymac.SHASize
+	}
+	encKey, macKey, err := mykdf.EtMKeys(master, nil, etmContext, h.KeyLen, macLen)
+	if err != nil {
+		return nil, nil, err
+	}
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(encKey); err != nil {
+		return nil, nil, err
+	}
+	if err := mc.SetMode(h.Mode); err != nil {
+		return nil, nil, err
+	}
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(h.MAC); err != nil {
+		return nil, nil, err
+	}
+	if err := mm.SetKey(macKey); err != nil {
+		return nil, nil, err
+	}
+	return mc, mm, nil
+}
+
+// tag вычисляет MAC канонического заголовка и шифротекста
+func (h *Header) tag(mm *mymac.MyMAC, body []byte) ([]byte, error) {
+	head, err := h.canonical()
+	if err != nil {
+		return nil, err
+	}
+	return mm.ComputeMac(append(head, body...))
+}
+
+// CreateContainer шифрует plaintext на пароле: Magic || длина заголовка (4 байта, big-endian) ||
+// JSON заголовка || шифротекст MyCipher (IV в начале). Соль берётся из mycrypto.Rand.
+func CreateContainer(password, plaintext []byte, o Options) ([]byte, error) {
+	o = o.withDefaults()
+	salt, err := mykdf.NewSalt(mykdf.SaltSize)
+	if err != nil {
+		return nil, err
+	}
+	h := &Header{Version: Version, KDF: o.KDF, Salt: salt, Params: o.Params, Mode: o.Mode, KeyLen: o.KeyLen, MAC: o.MAC}
+	if err := h.check(); err != nil {
+		return nil, err
+	}
+	master, err := h.masterKey(password)
+	if err != nil {
+		return nil, err
+	}
+	return seal(h, master, plaintext)
+}
+
+// seal шифрует plaintext на ключах из master, вычисляет тег и собирает контейнер с заголовком h
+func seal(h *Header, master, plaintext []byte) ([]byte, error) {
+	mc, mm, err := h.keys(master)
+	if err != nil {
+		return nil, err
+	}
+	body, err := mc.Encrypt(plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+	if h.Tag, err = h.tag(mm, body); err != nil {
+		return nil, err
+	}
+	return assemble(h, body)
+}
+
+// assemble собирает контейнер из заголовка с вычисленным тегом и шифротекста
+func assemble(h *Header, body []byte) ([]byte, error) {
+	head, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(Magic)+4+len(head)+len(body))
+	out = append(out, Magic...)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(head)))
+	out = append(out, head...)
+	return append(out, body...), nil
+}
+
+// ReadHeader разбирает заголовок контейнера и возвращает его вместе с шифротекстом.
+// Заголовок не аутентифицирован, пока OpenContainer не проверит тег.
+func ReadHeader(data []byte) (*Header, []byte, error) {
+	if !bytes.HasPrefix(data, []byte(Magic)) {
+		return nil, nil, errors.New("container: bad magic")
+	}
+	data = data[len(Magic):]
+	if len(data) < 4 {
+		return nil, nil, errors.New("container: truncated header")
+	}
+	n := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	if uint64(n) > uint64(len(data)) {
+		return nil, nil, errors.New("container: truncated header")
+	}
+	h := &Header{}
+	if err := json.Unmarshal(data[:n], h); err != nil {
+		return nil, nil, fmt.Errorf("container: header: %v", err)
+	}
+	if err := h.check(); err != nil {
+		return nil, nil, err
+	}
+	return h, data[n:], nil
+}
+
+// OpenContainer проверяет тег и расшифровывает контейнер. Неверный пароль и любое изменение
+// заголовка или шифротекста дают ErrAuth; расшифрование выполняется только после проверки тега.
+func OpenContainer(password, data []byte) ([]byte, error) {
+	h, body, err := ReadHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if h.KeyID != nil {
+		return nil, fmt.Errorf("container: encrypted under key id %d, open it with a Keyring", *h.KeyID)
+	}
+	if len(h.Recipients) != 0 {
+		return nil, errors.New("container: sealed for recipients, open it with OpenForRecipient")
+	}
+	master, err := h.masterKey(password)
+	if err != nil {
+		return nil, err
+	}
+	return open(h, master, body)
+}
+
+// authenticate проверяет тег body на ключах из master и возвращает шифр и MAC этих ключей
+func authenticate(h *Header, master, body []byte) (*mycrypto.MyCipher, *mymac.MyMAC, error) {
+	mc, mm, err := h.keys(master)
+	if err != nil {
+		return nil, nil, err
+	}
+	tag, err := h.tag(mm, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !mymac.MacEqual(tag, h.Tag) {
+		return nil, nil, ErrAuth
+	}
+	return mc, mm, nil
+}
+
+// open проверяет тег и расшифровывает body на ключах из master
+func open(h *Header, master, body []byte) ([]byte, error) {
+	mc, _, err := authenticate(h, master, body)
+	if err != nil {
+		return nil, err
+	}
+	return mc.Decrypt(body, nil)
+}
@@ -0,0 +1,173 @@
+package mycontainer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/sagilyp/lab1/mycrypto"
+)
+
+// ----- Несколько получателей: один DEK, обёрнутый AES-KW для каждого -----
+
+// DEKSize - длина ключа данных (DEK): случайного секрета, из которого EtMKeys выводит ключи
+// шифрования и MAC, как из секрета KDF или ключа связки
+const DEKSize = 32
+
+// Recipient - получатель контейнера: идентификатор его ключа обёртывания (KEK) и DEK,
+// обёрнутый этим ключом по RFC 3394 (mycrypto.WrapKey)
+type Recipient struct {
+	KeyID   uint32 `json:"key_id"`
+	Wrapped []byte `json:"wrapped_dek"`
+}
+
+// checkRecipients отвергает повторяющиеся идентификаторы и обёрнутые ключи не той длины
+func (h *Header) checkRecipients() error {
+	seen := make(map[uint32]bool, len(h.Recipients))
+	for _, r := range h.Recipients {
+		if seen[r.KeyID] {
+			return fmt.Errorf("container: duplicate recipient %d", r.KeyID)
+		}
+		seen[r.KeyID] = true
+		if len(r.Wrapped) != DEKSize+8 {
+			return fmt.Errorf("container: recipient %d: wrapped key of %d bytes", r.KeyID, len(r.Wrapped))
+		}
+	}
+	return nil
+}
+
+// recipient возвращает номер записи получателя id или -1
+func (h *Header) recipient(id uint32) int {
+	for i, r := range h.Recipients {
+		if r.KeyID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// unwrapDEK разворачивает DEK из записи получателя id его ключом kek. Неверный ключ - ErrAuth.
+func (h *Header) unwrapDEK(id uint32, kek []byte) ([]byte, error) {
+	i := h.recipient(id)
+	if i < 0 {
+		return nil, fmt.Errorf("container: %d is not a recipient", id)
+	}
+	dek, err := mycrypto.UnwrapKey(kek, h.Recipients[i].Wrapped)
+	if errors.Is(err, mycrypto.ErrUnwrap) {
+		return nil, ErrAuth
+	}
+	if err != nil {
+		return nil, fmt.Errorf("container: recipient %d: %v", id, err)
+	}
+	return dek, nil
+}
+
+// wrapFor добавляет в заголовок запись получателя id с DEK, обёрнутым ключом kek (16, 24 или 32 байта)
+func (h *Header) wrapFor(id uint32, kek, dek []byte) error {
+	if h.recipient(id) >= 0 {
+		return fmt.Errorf("container: %d is already a recipient", id)
+	}
+	wrapped, err := mycrypto.WrapKey(kek, dek)
+	if err != nil {
+		return fmt.Errorf("container: recipient %d: %v", id, err)
+	}
+	h.Recipients = append(h.Recipients, Recipient{KeyID: id, Wrapped: wrapped})
+	return nil
+}
+
+// CreateForRecipients шифрует plaintext один раз на случайном DEK и записывает в заголовок DEK,
+// обёрнутый ключом каждого получателя (идентификатор -> KEK). KDF и Params из o не используются.
+func CreateForRecipients(keks map[uint32][]byte, plaintext []byte, o Options) ([]byte, error) {
+	if len(keks) == 0 {
+		return nil, errors.New("container: no recipients")
+	}
+	o = o.withDefaults()
+	dek := make([]byte, DEKSize)
+	if _, err := mycrypto.Rand.Read(dek); err != nil {
+		return nil, err
+	}
+	h := &Header{Version: Version, Mode: o.Mode, KeyLen: o.KeyLen, MAC: o.MAC}
+	ids := make([]uint32, 0, len(keks))
+	for id := range keks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+	for _, id := range ids {
+		if err := h.wrapFor(id, keks[id], dek); err != nil {
+			return nil, err
+		}
+	}
+	if err := h.check(); err != nil {
+		return nil, err
+	}
+	return seal(h, dek, plaintext)
+}
+
+// OpenForRecipient разворачивает DEK ключом получателя id, проверяет тег и расшифровывает контейнер
+func OpenForRecipient(id uint32, kek, data []byte) ([]byte, error) {
+	h, body, err := ReadHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.Recipients) == 0 {
+		return nil, errors.New("container: not sealed for recipients")
+	}
+	dek, err := h.unwrapDEK(id, kek)
+	if err != nil {
+		return nil, err
+	}
+	return open(h, dek, body)
+}
+
+// changeRecipients разворачивает DEK ключом получателя id, проверяет тег, применяет change
+// к заголовку и пересчитывает тег. Шифротекст не перешифровывается: меняется только заголовок.
+func changeRecipients(data []byte, id uint32, kek []byte, change func(h *Header, dek []byte) error) ([]byte, error) {
+	h, body, err := ReadHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.Recipients) == 0 {
+		return nil, errors.New("container: not sealed for recipients")
+	}
+	dek, err := h.unwrapDEK(id, kek)
+	if err != nil {
+		return nil, err
+	}
+	_, mm, err := authenticate(h, dek, body)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(h, dek); err != nil {
+		return nil, err
+	}
+	if h.Tag, err = h.tag(mm, body); err != nil {
+		return nil, err
+	}
+	return assemble(h, body)
+}
+
+// AddRecipient добавляет получателя newID с ключом newKEK. Право на это подтверждает
+// существующий получатель id ключом kek: без DEK новую запись не создать.
+func AddRecipient(data []byte, id uint32, kek []byte, newID uint32, newKEK []byte) ([]byte, error) {
+	return changeRecipients(data, id, kek, func(h *Header, dek []byte) error {
+		return h.wrapFor(newID, newKEK, dek)
+	})
+}
+
+// RemoveRecipient удаляет запись получателя removeID; подтверждает получатель id ключом kek
+// (может быть и сам removeID). Последнего получателя удалить нельзя. Копии контейнера, сделанные
+// до удаления, и сам DEK, если получатель его сохранил, по-прежнему открываются: для отзыва
+// доступа содержимое нужно зашифровать заново через CreateForRecipients.
+func RemoveRecipient(data []byte, id uint32, kek []byte, removeID uint32) ([]byte, error) {
+	return changeRecipients(data, id, kek, func(h *Header, _ []byte) error {
+		i := h.recipient(removeID)
+		if i < 0 {
+			return fmt.Errorf("container: %d is not a recipient", removeID)
+		}
+		if len(h.Recipients) == 1 {
+			return errors.New("container: cannot remove the last recipient")
+		}
+		h.Recipients = append(h.Recipients[:i], h.Recipients[i+1:]...)
+		return nil
+	})
+}
@@ -0,0 +1,84 @@
+package mycontainer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func testKey(t *testing.T, n int) []byte {
+	t.Helper()
+	k := make([]byte, n)
+	if _, err := rand.Read(k); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// TestRecipients: каждый получатель открывает контейнер своим ключом, AddRecipient и RemoveRecipient
+// не трогают шифротекст, удалённый получатель и чужой ключ контейнер не открывают
+func TestRecipients(t *testing.T) {
+	keks := map[uint32][]byte{1: testKey(t, 16), 2: testKey(t, 32)}
+	extra := testKey(t, 24)
+	msg := []byte("one payload, several recipients")
+	data, err := CreateForRecipients(keks, msg, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, kek := range keks {
+		got, err := OpenForRecipient(id, kek, data)
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Fatalf("recipient %d: %q, %v", id, got, err)
+		}
+	}
+	if _, err := OpenForRecipient(1, keks[2], data); !errors.Is(err, ErrAuth) {
+		t.Fatalf("recipient 1 with the key of 2: %v, want ErrAuth", err)
+	}
+	_, body, _ := ReadHeader(data)
+
+	added, err := AddRecipient(data, 2, keks[2], 3, extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := OpenForRecipient(3, extra, added); err != nil || !bytes.Equal(got, msg) {
+		t.Fatalf("added recipient: %q, %v", got, err)
+	}
+	if _, err := AddRecipient(added, 1, keks[1], 3, extra); err == nil {
+		t.Fatal("recipient 3 added twice")
+	}
+	removed, err := RemoveRecipient(added, 3, extra, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenForRecipient(1, keks[1], removed); err == nil {
+		t.Fatal("removed recipient opened the container")
+	}
+	h, newBody, err := ReadHeader(removed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, newBody) {
+		t.Fatal("recipient changes re-encrypted the payload")
+	}
+	if len(h.Recipients) != 2 || h.Recipients[0].KeyID != 2 || h.Recipients[1].KeyID != 3 {
+		t.Fatalf("recipients after changes: %+v", h.Recipients)
+	}
+
+	// запись получателя входит в тег: отбросить её без ключа нельзя
+	h.Recipients = h.Recipients[:1]
+	stripped, err := assemble(h, newBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenForRecipient(2, keks[2], stripped); !errors.Is(err, ErrAuth) {
+		t.Fatalf("container with a dropped recipient: %v, want ErrAuth", err)
+	}
+	last, err := RemoveRecipient(removed, 3, extra, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RemoveRecipient(last, 3, extra, 3); err == nil {
+		t.Fatal("last recipient removed")
+	}
+}
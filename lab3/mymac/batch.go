@@ -0,0 +1,111 @@
+package mymac
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ----- Пакетная проверка тегов -----
+
+// BatchItem - пара (сообщение, тег) для пакетной проверки
+type BatchItem struct {
+	Msg []byte
+	Tag []byte
+}
+
+// BatchResult - итог проверки одного элемента пакета
+type BatchResult int
+
+const (
+	BatchNotChecked BatchResult = iota // проверка прервана раньше, чем дошла до элемента
+	BatchValid
+	BatchInvalid
+)
+
+func (r BatchResult) String() string {
+	switch r {
+	case BatchValid:
+		return "valid"
+	case BatchInvalid:
+		return "invalid"
+	default:
+		return "not checked"
+	}
+}
+
+// clone возвращает копию MyMAC с уже вычисленными подключами и собственным состоянием,
+// чтобы несколько горутин могли считать теги одновременно
+func (mm *MyMAC) clone() *MyMAC {
+	c := &MyMAC{key: mm.key, k1: mm.k1, k2: mm.k2, mode: mm.mode, aesBlock: mm.aesBlock, emacOut: mm.emacOut, tagSize: mm.tagSize, newHash: mm.newHash}
+	if mm.mode == HMAC {
+		c.hmacHash = mm.hashFunc()()
+	}
+	if mm.kmacKeyed != nil {
+		// состояние после ключа только копируется, поэтому разделяется между копиями
+		c.custom, c.kmacKeyed, c.kmac = mm.custom, mm.kmacKeyed, mm.kmacKeyed.Clone()
+	}
+	return c
+}
+
+// VerifyBatch проверяет теги пакета в workers горутинах (0 - по числу CPU).
+// Подключи вычисляются один раз в SetKey и разделяются между горутинами.
+// Если stopOnFailure, после первого неверного тега оставшиеся элементы не проверяются
+// и получают BatchNotChecked. Возвращает результаты по элементам и признак того, что все теги верны.
+func (mm *MyMAC) VerifyBatch(items []BatchItem, workers int, stopOnFailure bool) ([]BatchResult, bool, error) {
+	if !knownMode(mm.mode) {
+		return nil, false, errors.New("VerifyBatch: MAC mode is not set")
+	}
+	if mm.key == nil {
+		return nil, false, errors.New("VerifyBatch: key is not set")
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]BatchResult, len(items))
+	var (
+		next    atomic.Int64
+		aborted atomic.Bool
+		errOnce sync.Once
+		firstEr error
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := mm.clone()
+			for !aborted.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= len(items) {
+					return
+				}
+				ok, err := m.VerifyMac(items[i].Msg, items[i].Tag)
+				if err != nil {
+					errOnce.Do(func() { firstEr = err })
+					aborted.Store(true)
+					return
+				}
+				if ok {
+					results[i] = BatchValid
+					continue
+				}
+				results[i] = BatchInvalid
+				if stopOnFailure {
+					aborted.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if firstEr != nil {
+		return results, false, firstEr
+	}
+	for _, r := range results {
+		if r != BatchValid {
+			return results, false, nil
+		}
+	}
+	return results, true, nil
+}
@@ -0,0 +1,38 @@
+package mymac
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// DeriveChain строит цепочку ключей длины depth: k_0 = seed, k_{i+1} = MAC_{k_i}(label || i).
+// В качестве PRF используется MyMAC в режиме mode; каждый ключ цепочки зависит от всех
+// предыдущих, поэтому вычислить k_n можно только последовательно.
+func DeriveChain(mode string, seed, label []byte, depth int) ([][]byte, error) {
+	if depth < 0 {
+		return nil, errors.New("DeriveChain: depth must be non-negative")
+	}
+	if mode != HMAC && mode != OMAC {
+		return nil, errors.New("DeriveChain: only OMAC and HMAC produce full-size keys")
+	}
+	keys := make([][]byte, 0, depth+1)
+	keys = append(keys, append([]byte{}, seed...))
+	msg := make([]byte, len(label)+8)
+	copy(msg, label)
+	mm := &MyMAC{}
+	if err := mm.SetMode(mode); err != nil {
+		return nil, err
+	}
+	for i := 0; i < depth; i++ {
+		if err := mm.SetKey(keys[i]); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint64(msg[len(label):], uint64(i))
+		next, err := mm.ComputeMac(msg)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, next)
+	}
+	return keys, nil
+}
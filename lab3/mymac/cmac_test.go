@@ -0,0 +1,79 @@
+package mymac
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// mustHex декодирует hex-строку вектора
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// cmacSubkeys - подключи K1 и K2 для ключа cmacVectors из раздела 4 RFC 4493
+var cmacSubkeys = [2]string{"fbeed618357133667c85e08f7236a8de", "f7ddac306ae266ccf90bc11ee46d513b"}
+
+// cmacVectors - примеры 1-4 из раздела 4 RFC 4493: пустое сообщение, один полный блок,
+// неполный последний блок и четыре полных блока; ключ, сообщение и полный тег в hex
+var cmacVectors = []struct {
+	key, msg, tag string
+}{
+	{
+		key: "2b7e151628aed2a6abf7158809cf4f3c",
+		tag: "bb1d6929e95937287fa37d129b756746",
+	},
+	{
+		key: "2b7e151628aed2a6abf7158809cf4f3c",
+		msg: "6bc1bee22e409f96e93d7e117393172a",
+		tag: "070a16b46b4d4144f79bdd9dd04a287c",
+	},
+	{
+		key: "2b7e151628aed2a6abf7158809cf4f3c",
+		msg: "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411",
+		tag: "dfa66747de9ae63030ca32611497c827",
+	},
+	{
+		key: "2b7e151628aed2a6abf7158809cf4f3c",
+		msg: "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51" +
+			"30c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710",
+		tag: "51f0bebf7e3b9d92fc49741779363cfe",
+	},
+}
+
+// TestCMAC проверяет OMAC по RFC 4493: подключи, теги cmacVectors и их укороченные через
+// setTagSize варианты всех длин от MinTagBits до полного тега (старшие байты полного тега)
+func TestCMAC(t *testing.T) {
+	for i, v := range cmacVectors {
+		key, msg, want := mustHex(t, v.key), mustHex(t, v.msg), mustHex(t, v.tag)
+		mm := &MyMAC{}
+		if err := mm.SetMode(OMAC); err != nil {
+			t.Fatal(err)
+		}
+		if err := mm.SetKey(key); err != nil {
+			t.Fatalf("vector #%d: %v", i, err)
+		}
+		if i == 0 {
+			if k1, k2 := hex.EncodeToString(mm.k1), hex.EncodeToString(mm.k2); k1 != cmacSubkeys[0] || k2 != cmacSubkeys[1] {
+				t.Fatalf("subkeys: K1 %s, K2 %s, want %s, %s", k1, k2, cmacSubkeys[0], cmacSubkeys[1])
+			}
+		}
+		for size := OMACTagSize; size >= MinTagBits/8; size-- {
+			if err := mm.SetTagSize(8 * size); err != nil {
+				t.Fatal(err)
+			}
+			tag, err := mm.ComputeMac(msg)
+			if err != nil {
+				t.Fatalf("vector #%d: %v", i, err)
+			}
+			if !bytes.Equal(tag, want[:size]) {
+				t.Fatalf("vector #%d, %d-byte tag: %x, want %x", i, size, tag, want[:size])
+			}
+		}
+	}
+}
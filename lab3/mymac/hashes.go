@@ -0,0 +1,48 @@
+package mymac
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// ----- Хэш-функции для HMAC -----
+
+// Имена хэш-функций, которые HMAC принимает через SetHash(HashFunc(name))
+const (
+	HashSHA1     = "SHA-1"
+	HashSHA256   = "SHA-256"
+	HashSHA512   = "SHA-512"
+	HashSHA3_256 = "SHA3-256"
+	HashBLAKE2b  = "BLAKE2b-512"
+)
+
+// HashNames - хэш-функции HMAC в порядке сравнения: от SHA-1 до BLAKE2b
+var HashNames = []string{HashSHA1, HashSHA256, HashSHA512, HashSHA3_256, HashBLAKE2b}
+
+// hashFuncs - конструкторы хэш-функций по именам. BLAKE2b без ключа: HMAC строится
+// поверх обычного хэша, собственный ключевой режим BLAKE2b здесь не используется.
+var hashFuncs = map[string]func() hash.Hash{
+	HashSHA1:     sha1.New,
+	HashSHA256:   sha256.New,
+	HashSHA512:   sha512.New,
+	HashSHA3_256: sha3.New256,
+	HashBLAKE2b: func() hash.Hash {
+		h, _ := blake2b.New512(nil) // без ключа ошибки не бывает
+		return h
+	},
+}
+
+// HashFunc возвращает конструктор хэш-функции name из HashNames
+func HashFunc(name string) (func() hash.Hash, error) {
+	h, ok := hashFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash function %q", name)
+	}
+	return h, nil
+}
@@ -0,0 +1,108 @@
+package mymac
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"hash"
+	"testing"
+)
+
+// hmacTestMsgs - длины сообщений: вокруг границ блока AES, по которым ComputeMac делит сообщение
+var hmacTestMsgs = []int{0, 1, 15, 16, 17, 31, 32, 33, 64, 100, 1000}
+
+// hmacTestKeys возвращает длины ключей для хэш-функции с выходом size и блоком block:
+// вокруг длины выхода и блока (короче блока, ровно блок, длиннее блока и потому хэшируемый)
+func hmacTestKeys(size, block int) []int {
+	return []int{0, 1, 16, size - 1, size, size + 1, block - 1, block, block + 1, 2*block + 3}
+}
+
+// TestHMAC сверяет HMAC из MyMAC с crypto/hmac для каждой хэш-функции из HashNames на всех
+// сочетаниях длин ключей и сообщений: тег MyMAC должен совпасть с первой половиной эталонного
+func TestHMAC(t *testing.T) {
+	msg := make([]byte, hmacTestMsgs[len(hmacTestMsgs)-1])
+	for i := range msg {
+		msg[i] = byte(i*7 + 3)
+	}
+	for _, name := range HashNames {
+		newHash, err := HashFunc(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := newHash()
+		for _, kl := range hmacTestKeys(h.Size(), h.BlockSize()) {
+			key := make([]byte, kl)
+			for i := range key {
+				key[i] = byte(0xa0 + i)
+			}
+			mm := &MyMAC{}
+			if err := mm.SetMode(HMAC); err != nil {
+				t.Fatal(err)
+			}
+			if err := mm.SetHash(newHash); err != nil {
+				t.Fatal(err)
+			}
+			if err := mm.SetKey(key); err != nil {
+				t.Fatal(err)
+			}
+			for _, n := range hmacTestMsgs {
+				tag, err := mm.ComputeMac(msg[:n])
+				if err != nil {
+					t.Fatalf("HMAC-%s key of %d bytes, message of %d bytes: %v", name, kl, n, err)
+				}
+				ref := hmac.New(newHash, key)
+				ref.Write(msg[:n])
+				if want := ref.Sum(nil)[:ref.Size()/2]; !MacEqual(tag, want) {
+					t.Fatalf("HMAC-%s key of %d bytes, message of %d bytes: %x, crypto/hmac gives %x", name, kl, n, tag, want)
+				}
+			}
+		}
+	}
+}
+
+// opaqueHash - хэш без двоичной сериализации состояния, которую требует Clone
+type opaqueHash struct{ hash.Hash }
+
+// TestSetHashState проверяет, что SetHash отвергает хэш без MarshalBinary/UnmarshalBinary,
+// а копия Clone продолжает сообщение с того же места для каждой хэш-функции из HashNames
+func TestSetHashState(t *testing.T) {
+	mm := &MyMAC{}
+	if err := mm.SetMode(HMAC); err != nil {
+		t.Fatal(err)
+	}
+	if err := mm.SetHash(func() hash.Hash { return opaqueHash{sha256.New()} }); err == nil {
+		t.Fatal("SetHash accepted a hash without binary state serialization")
+	}
+	msg := []byte("prefix absorbed before Clone, then the rest of the message")
+	for _, name := range HashNames {
+		newHash, err := HashFunc(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := mm.SetHash(newHash); err != nil {
+			t.Fatalf("SetHash(%s): %v", name, err)
+		}
+		if err := mm.SetKey([]byte("clone key")); err != nil {
+			t.Fatal(err)
+		}
+		want, err := mm.ComputeMac(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := mm.MacAddBlock(msg[:32]); err != nil {
+			t.Fatal(err)
+		}
+		c, err := mm.Clone()
+		if err != nil {
+			t.Fatalf("HMAC-%s: %v", name, err)
+		}
+		for _, m := range []*MyMAC{mm, c} {
+			tag, err := m.MacFinalize(msg[32:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !MacEqual(tag, want) {
+				t.Fatalf("HMAC-%s: tag after Clone %x, want %x", name, tag, want)
+			}
+		}
+	}
+}
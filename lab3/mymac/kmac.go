@@ -0,0 +1,74 @@
+package mymac
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ----- KMAC (NIST SP 800-185) -----
+
+// kmacRate возвращает rate губки cSHAKE для режима KMAC: столько байт дополняет bytepad
+func kmacRate(mode string) int {
+	if mode == KMAC256 {
+		return 136
+	}
+	return 168
+}
+
+// leftEncode кодирует x как left_encode из SP 800-185: число байт, затем сами байты (big-endian)
+func leftEncode(x uint64) []byte {
+	n := 1
+	for v := x >> 8; v != 0; v >>= 8 {
+		n++
+	}
+	out := make([]byte, n+1)
+	out[0] = byte(n)
+	for i := n; i > 0; i-- {
+		out[i] = byte(x)
+		x >>= 8
+	}
+	return out
+}
+
+// rightEncode кодирует x как right_encode из SP 800-185: байты числа, затем их количество
+func rightEncode(x uint64) []byte {
+	l := leftEncode(x)
+	return append(l[1:], l[0])
+}
+
+// kmacState возвращает cSHAKE с именем "KMAC" и строкой настройки custom,
+// в которую уже поглощён префикс bytepad(encode_string(key), rate)
+func kmacState(mode string, key, custom []byte) sha3.ShakeHash {
+	var h sha3.ShakeHash
+	if mode == KMAC256 {
+		h = sha3.NewCShake256([]byte("KMAC"), custom)
+	} else {
+		h = sha3.NewCShake128([]byte("KMAC"), custom)
+	}
+	rate := kmacRate(mode)
+	prefix := leftEncode(uint64(rate))
+	prefix = append(prefix, leftEncode(uint64(len(key))*8)...)
+	prefix = append(prefix, key...)
+	if r := len(prefix) % rate; r != 0 {
+		prefix = append(prefix, make([]byte, rate-r)...)
+	}
+	h.Write(prefix)
+	return h
+}
+
+// SetCustomization задаёт строку настройки S для KMAC128 и KMAC256: теги с разными S
+// независимы, даже если ключ и сообщение совпадают. Строка сохраняется при смене ключа
+// и сбрасывается при смене режима; если ключ уже задан, SetKey повторять не нужно.
+func (mm *MyMAC) SetCustomization(s []byte) error {
+	if mm.mode != KMAC128 && mm.mode != KMAC256 {
+		return fmt.Errorf("SetCustomization: mode %s has no customization string", mm.mode)
+	}
+	mm.custom = bytes.Clone(s)
+	if mm.key != nil {
+		mm.kmacKeyed = kmacState(mm.mode, mm.key, mm.custom)
+		mm.Reset()
+	}
+	return nil
+}
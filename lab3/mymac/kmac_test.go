@@ -0,0 +1,68 @@
+package mymac
+
+import (
+	"bytes"
+	"testing"
+)
+
+// kmacSampleKey и kmacSampleData - ключ 0x40..0x5F и 200 байт 0x00..0xC7 из примеров SP 800-185
+const (
+	kmacSampleKey  = "404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f"
+	kmacSampleData = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f" +
+		"202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f" +
+		"404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f" +
+		"606162636465666768696a6b6c6d6e6f707172737475767778797a7b7c7d7e7f" +
+		"808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f" +
+		"a0a1a2a3a4a5a6a7a8a9aaabacadaeafb0b1b2b3b4b5b6b7b8b9babbbcbdbebf" +
+		"c0c1c2c3c4c5c6c7"
+)
+
+// kmacVectors - примеры 1-6 KMAC из NIST SP 800-185 (KMAC_samples): KMAC128 с 32-байтным
+// тегом и KMAC256 с 64-байтным, на коротких и 200-байтных данных, с настройкой и без неё.
+// Режим, ключ, данные, строка настройки и тег в hex; длина тега - длина tag.
+var kmacVectors = []struct {
+	mode              string
+	key, data, s, tag string
+}{
+	{KMAC128, kmacSampleKey, "00010203", "",
+		"e5780b0d3ea6f7d3a429c5706aa43a00fadbd7d49628839e3187243f456ee14e"},
+	{KMAC128, kmacSampleKey, "00010203", "My Tagged Application",
+		"3b1fba963cd8b0b59e8c1a6d71888b7143651af8ba0a7070c0979e2811324aa5"},
+	{KMAC128, kmacSampleKey, kmacSampleData, "My Tagged Application",
+		"1f5b4e6cca02209e0dcb5ca635b89a15e271ecc760071dfd805faa38f9729230"},
+	{KMAC256, kmacSampleKey, "00010203", "My Tagged Application",
+		"20c570c31346f703c9ac36c61c03cb64c3970d0cfc787e9b79599d273a68d2f7" +
+			"f69d4cc3de9d104a351689f27cf6f5951f0103f33f4f24871024d9c27773a8dd"},
+	{KMAC256, kmacSampleKey, kmacSampleData, "",
+		"75358cf39e41494e949707927cee0af20a3ff553904c86b08f21cc414bcfd691" +
+			"589d27cf5e15369cbbff8b9a4c2eb17800855d0235ff635da82533ec6b759b69"},
+	{KMAC256, kmacSampleKey, kmacSampleData, "My Tagged Application",
+		"b58618f71f92e1d56c1b8c55ddd7cd188b97b4ca4d99831eb2699a837da2e4d9" +
+			"70fbacfde50033aea585f1a2708510c32d07880801bd182898fe476876fc8965"},
+}
+
+func TestKMAC(t *testing.T) {
+	for i, v := range kmacVectors {
+		want := mustHex(t, v.tag)
+		mm := &MyMAC{}
+		if err := mm.SetMode(v.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := mm.SetKey(mustHex(t, v.key)); err != nil {
+			t.Fatalf("vector #%d: %v", i, err)
+		}
+		if err := mm.SetCustomization([]byte(v.s)); err != nil {
+			t.Fatal(err)
+		}
+		if err := mm.SetTagSize(8 * len(want)); err != nil {
+			t.Fatalf("vector #%d: %v", i, err)
+		}
+		tag, err := mm.ComputeMac(mustHex(t, v.data))
+		if err != nil {
+			t.Fatalf("vector #%d: %v", i, err)
+		}
+		if !bytes.Equal(tag, want) {
+			t.Fatalf("vector #%d (%s): %x, want %x", i, v.mode, tag, want)
+		}
+	}
+}
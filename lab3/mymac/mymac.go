@@ -5,29 +5,57 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding"
 	"errors"
 	"fmt"
 	"hash"
+	"io"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"golang.org/x/crypto/sha3"
 )
 
 // --- Константы ---
 const (
 	AESBlockSize = 16
 	AESKeySize   = 16
-	SHABlockSize = 32
+	SHABlockSize = sha256.BlockSize // блок SHA-256, к которому HMAC приводит ключ (RFC 2104)
+	SHASize      = sha256.Size      // выход SHA-256
 	OMACTagSize  = 16
-	TruncTagSize = 8
-	HMACTagSize  = 16
+	HMACTagSize  = SHASize / 2 // тег HMAC - половина выхода хэш-функции (для SHA-256 - 16 байт)
+
+	ReaderChunkSize = 64 * 1024 // кусок, которым ComputeMacReader читает данные
+
+	MinTagBits  = 32 // наименьший тег через SetTagSize: 32 бита, как допускает NIST SP 800-38B
+	SafeTagBits = 64 // тег короче подделывается перебором слишком быстро: см. ShortTagWarning
+
+	KMAC128TagSize = 32  // тег KMAC128 по умолчанию: 256 бит, как в примерах SP 800-185
+	KMAC256TagSize = 64  // тег KMAC256 по умолчанию: 512 бит
+	KMACMaxTagSize = 256 // наибольший тег KMAC через SetTagSize
 
 	Rn = 0x87
 )
 
 const (
-	OMAC      = "OMAC"
-	HMAC      = "HMAC"
-	TRUNCATED = "TRUNCATED"
+	OMAC    = "OMAC"
+	HMAC    = "HMAC"
+	KMAC128 = "KMAC128"
+	KMAC256 = "KMAC256"
+	CBCMAC  = "CBCMAC" // простой CBC-MAC без подключей: подделывается на сообщениях разной длины
+	EMAC    = "EMAC"   // CBC-MAC с последним шифрованием на втором ключе (ISO/IEC 9797-1, алгоритм 2)
 )
 
+// ShortTagWarning возвращает предупреждение для тега длиной bits бит в режиме mode, если он короче
+// SafeTagBits (такой тег угадывается примерно за 2^bits попыток), и пустую строку иначе.
+// SetTagSize короткие теги задаёт молча: предупреждать пользователя - дело вызывающей программы.
+func ShortTagWarning(mode string, bits int) string {
+	if bits >= SafeTagBits {
+		return ""
+	}
+	return fmt.Sprintf("%d-bit %s tag can be forged with about 2^%d attempts; use at least %d bits", bits, mode, bits, SafeTagBits)
+}
+
 // MyMAC - структура для вычисления подписи с потоковым интерфейсом
 type MyMAC struct {
 	key      []byte
@@ -35,13 +63,29 @@ type MyMAC struct {
 	state    []byte
 	mode     string
 	aesBlock cipher.Block
+	emacOut  cipher.Block // шифр второго ключа EMAC
 	hmacHash hash.Hash
+	tagSize  int              // длина тега в байтах, заданная SetTagSize; 0 - длина режима по умолчанию
+	newHash  func() hash.Hash // хэш-функция HMAC, заданная SetHash; nil - SHA-256
+
+	buf []byte // придержанный хвост сообщения: до AESBlockSize байт, ещё не поглощённых
+
+	custom    []byte         // строка настройки KMAC, заданная SetCustomization
+	kmacKeyed sha3.ShakeHash // cSHAKE KMAC после поглощения ключа; только копируется
+	kmac      sha3.ShakeHash // cSHAKE KMAC текущего сообщения
 }
 
 // SetMode задает алгоритм вычисления подписи
 func (mm *MyMAC) SetMode(newmode string) error {
 	switch newmode {
-	case TRUNCATED, HMAC, OMAC:
+	case HMAC, OMAC, KMAC128, KMAC256, CBCMAC, EMAC:
+		if newmode != mm.mode {
+			// ключ и подключи прежнего алгоритма к новому не подходят
+			mm.key, mm.k1, mm.k2, mm.state = nil, nil, nil, nil
+			mm.aesBlock, mm.emacOut, mm.hmacHash = nil, nil, nil
+			mm.tagSize, mm.newHash = 0, nil
+			mm.custom, mm.kmacKeyed, mm.kmac = nil, nil, nil
+		}
 		mm.mode = newmode
 		return nil
 	default:
@@ -49,11 +93,145 @@ func (mm *MyMAC) SetMode(newmode string) error {
 	}
 }
 
+// cbcChain сообщает, строится ли тег режима mode цепочкой CBC на AES
+func cbcChain(mode string) bool {
+	switch mode {
+	case OMAC, CBCMAC, EMAC:
+		return true
+	}
+	return false
+}
+
+// knownMode сообщает, вычисляет ли MyMAC теги в режиме mode
+func knownMode(mode string) bool {
+	switch mode {
+	case HMAC, OMAC, KMAC128, KMAC256, CBCMAC, EMAC:
+		return true
+	}
+	return false
+}
+
+// SetTagSize задаёт длину тега в битах в любом режиме: кратно 8, от MinTagBits до 8*MaxTagSize().
+// OMAC, CBC-MAC, EMAC и HMAC усекают полный тег до старших bits/8 байт, как в SP 800-38B
+// и RFC 2104; у KMAC128 и KMAC256 длина L входит в вычисление (right_encode(L)), поэтому их теги
+// разной длины не являются префиксами друг друга. Тег короче SafeTagBits задаётся без ошибки:
+// см. ShortTagWarning. Длина сохраняется при смене ключа и сбрасывается к длине по умолчанию
+// при смене режима и хэш-функции HMAC.
+func (mm *MyMAC) SetTagSize(bits int) error {
+	if !knownMode(mm.mode) {
+		return fmt.Errorf("SetTagSize: undefined algorithm %s", mm.mode)
+	}
+	if bits%8 != 0 {
+		return fmt.Errorf("SetTagSize: tag length must be a multiple of 8 bits, got %d", bits)
+	}
+	if maxBits := 8 * mm.MaxTagSize(); bits < MinTagBits || bits > maxBits {
+		return fmt.Errorf("SetTagSize: tag length in mode %s must be %d to %d bits, got %d", mm.mode, MinTagBits, maxBits, bits)
+	}
+	mm.tagSize = bits / 8
+	return nil
+}
+
+// MaxTagSize возвращает наибольшую длину тега в байтах для текущего режима: полный тег без усечения
+func (mm *MyMAC) MaxTagSize() int {
+	switch mm.mode {
+	case OMAC, CBCMAC, EMAC:
+		return AESBlockSize
+	case HMAC:
+		if mm.hmacHash != nil {
+			return mm.hmacHash.Size()
+		}
+		return mm.hashFunc()().Size()
+	case KMAC128, KMAC256:
+		return KMACMaxTagSize
+	}
+	return 0
+}
+
+// TagSize возвращает длину тега в байтах, который выдаёт MacFinalize в текущем режиме
+func (mm *MyMAC) TagSize() int {
+	if mm.tagSize != 0 {
+		return mm.tagSize
+	}
+	switch mm.mode {
+	case OMAC, CBCMAC, EMAC:
+		return OMACTagSize
+	case HMAC:
+		return mm.MaxTagSize() / 2
+	case KMAC128:
+		return KMAC128TagSize
+	case KMAC256:
+		return KMAC256TagSize
+	}
+	return 0
+}
+
+// SetHash задаёт хэш-функцию HMAC (например, sha512.New или конструктор из HashFunc); nil - SHA-256.
+// Блок, к которому приводится ключ, и длина тега (половина выхода) берутся у хэш-функции.
+// Ключ и длина тега, заданная SetTagSize, сбрасываются: подключи зависят от блока хэш-функции,
+// поэтому SetKey (и SetTagSize) вызывается после SetHash. Хэш-функция сохраняется до смены режима.
+// Clone копирует промежуточное состояние хэша через его двоичную сериализацию, поэтому
+// хэш-функция должна реализовывать encoding.BinaryMarshaler и encoding.BinaryUnmarshaler.
+func (mm *MyMAC) SetHash(newHash func() hash.Hash) error {
+	if mm.mode != HMAC {
+		return fmt.Errorf("SetHash: mode %s does not use a hash function", mm.mode)
+	}
+	if newHash != nil {
+		if err := checkHashState(newHash); err != nil {
+			return fmt.Errorf("SetHash: %v", err)
+		}
+	}
+	mm.newHash = newHash
+	mm.key, mm.k1, mm.k2, mm.state, mm.hmacHash = nil, nil, nil, nil, nil
+	mm.tagSize = 0
+	return nil
+}
+
+// checkHashState проверяет, что состояние хэша newHash переносится в другой экземпляр
+// через MarshalBinary/UnmarshalBinary, как это делает Clone
+func checkHashState(newHash func() hash.Hash) error {
+	h := newHash()
+	if h == nil {
+		return errors.New("hash constructor returned nil")
+	}
+	m, ok := h.(encoding.BinaryMarshaler)
+	if !ok {
+		return fmt.Errorf("hash %T does not implement encoding.BinaryMarshaler", h)
+	}
+	if _, ok := newHash().(encoding.BinaryUnmarshaler); !ok {
+		return fmt.Errorf("hash %T does not implement encoding.BinaryUnmarshaler", h)
+	}
+	return copyHashState(newHash(), m)
+}
+
+// copyHashState переносит промежуточное состояние src в dst через двоичную сериализацию
+func copyHashState(dst hash.Hash, src encoding.BinaryMarshaler) error {
+	st, err := src.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("cannot save hash state: %v", err)
+	}
+	u, ok := dst.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return fmt.Errorf("hash %T does not implement encoding.BinaryUnmarshaler", dst)
+	}
+	if err := u.UnmarshalBinary(st); err != nil {
+		return fmt.Errorf("cannot restore hash state: %v", err)
+	}
+	return nil
+}
+
+// hashFunc возвращает конструктор хэш-функции HMAC
+func (mm *MyMAC) hashFunc() func() hash.Hash {
+	if mm.newHash != nil {
+		return mm.newHash
+	}
+	return sha256.New
+}
+
 // SetKey устанавливает ключ шифрования/расшифрования и инициализирует AES‑блочный шифр
 func (mm *MyMAC) SetKey(newkey []byte) error {
 	var err error
 	switch mm.mode {
-	case OMAC, TRUNCATED:
+	case OMAC:
 		if len(newkey) != AESKeySize {
 			return fmt.Errorf("invalid key length: got %d, expected %d", len(newkey), AESKeySize)
 		}
@@ -66,24 +244,65 @@ func (mm *MyMAC) SetKey(newkey []byte) error {
 		if err != nil {
 			return err
 		}
-	case HMAC:
-		mm.hmacHash = sha256.New()
-		if len(newkey) != SHABlockSize {
-			hash := sha256.Sum256(newkey)
-			newkey = hash[:]
+	case CBCMAC, EMAC:
+		if len(newkey) != AESKeySize {
+			return fmt.Errorf("invalid key length: got %d, expected %d", len(newkey), AESKeySize)
 		}
 		mm.key = newkey
+		mm.aesBlock, err = aes.NewCipher(newkey)
+		if err != nil {
+			return err
+		}
+		if mm.mode == EMAC {
+			mm.emacOut, err = aes.NewCipher(emacKey(newkey))
+			if err != nil {
+				return err
+			}
+		}
+	case HMAC:
+		mm.hmacHash = mm.hashFunc()()
+		mm.key = hmacKey(mm.hmacHash, newkey)
 		err = mm.generateSubkeys()
 		if err != nil {
 			return err
 		}
-		mm.hmacHash.Write(mm.k1)
+	case KMAC128, KMAC256:
+		// ключ любой длины: он целиком поглощается cSHAKE, подключей у KMAC нет
+		mm.key = append([]byte{}, newkey...)
+		mm.kmacKeyed = kmacState(mm.mode, mm.key, mm.custom)
 	default:
 		return fmt.Errorf("undefined algorithm %s", mm.mode)
 	}
+	// новый ключ начинает новое сообщение; k1 попадёт в хэш HMAC с первым блоком
+	mm.Reset()
 	return nil
 }
 
+// emacKey выводит второй ключ EMAC из первого: K' = K ⊕ F0F0...F0 (инвертированы чередующиеся
+// полубайты), как допускает ISO/IEC 9797-1. Ключи различны, поэтому последнее шифрование
+// не совпадает ни с одним шагом цепочки CBC.
+func emacKey(key []byte) []byte {
+	out := make([]byte, len(key))
+	for i := range key {
+		out[i] = key[i] ^ 0xf0
+	}
+	return out
+}
+
+// hmacKey приводит ключ HMAC к длине блока хэш-функции h, как в RFC 2104: ключ длиннее блока
+// сначала заменяется его хэшем, затем ключ дополняется нулями до полного блока. h сбрасывается.
+func hmacKey(h hash.Hash, key []byte) []byte {
+	h.Reset()
+	if len(key) > h.BlockSize() {
+		h.Write(key)
+		key = h.Sum(nil)
+		h.Reset()
+	}
+	out := make([]byte, h.BlockSize())
+	copy(out, key)
+	return out
+}
+
 // BlockCipherEncrypt выполняет одноблочное шифрование с помощью AES
 func (mm *MyMAC) AesBlockEncrypt(data []byte) ([]byte, error) {
 	if len(data) != AESBlockSize {
@@ -94,13 +313,37 @@ func (mm *MyMAC) AesBlockEncrypt(data []byte) ([]byte, error) {
 	return out, nil
 }
 
-// MacAddBlock обновляет внутреннее состояние MAC для блока данных
-func (mm *MyMAC) MacAddBlock(dataBlock []byte) error {
-	if len(dataBlock) != AESBlockSize {
-		return fmt.Errorf("MacAddBlock: data length must be %d", AESBlockSize)
+// MacAddBlock добавляет к сообщению кусок данных любой длины, в том числе пустой. Границы блоков
+// MyMAC отслеживает сам: полные блоки поглощаются сразу, а последний (возможно полный) блок
+// придерживается во внутреннем буфере, пока не станет ясно, что он не последний в сообщении.
+func (mm *MyMAC) MacAddBlock(data []byte) error {
+	if mm.key == nil {
+		return errors.New("MacAddBlock: key is not set")
+	}
+	if len(mm.buf) > 0 && len(mm.buf)+len(data) > AESBlockSize {
+		// за придержанным блоком есть данные: дополняем его и поглощаем
+		n := AESBlockSize - len(mm.buf)
+		mm.buf = append(mm.buf, data[:n]...)
+		data = data[n:]
+		if err := mm.addBlock(mm.buf); err != nil {
+			return err
+		}
+		mm.buf = mm.buf[:0]
+	}
+	for len(data) > AESBlockSize {
+		if err := mm.addBlock(data[:AESBlockSize]); err != nil {
+			return err
+		}
+		data = data[AESBlockSize:]
 	}
+	mm.buf = append(mm.buf, data...)
+	return nil
+}
+
+// addBlock обновляет внутреннее состояние MAC для полного блока, который точно не последний
+func (mm *MyMAC) addBlock(dataBlock []byte) error {
 	switch mm.mode {
-	case OMAC, TRUNCATED:
+	case OMAC, CBCMAC, EMAC:
 		var prevState []byte
 		if len(mm.state) == AESBlockSize {
 			prevState = mm.state
@@ -108,7 +351,7 @@ func (mm *MyMAC) MacAddBlock(dataBlock []byte) error {
 			prevState = make([]byte, AESBlockSize)
 			mm.state = make([]byte, AESBlockSize)
 		}
-		xored, err := xorBytes(prevState, dataBlock)
+		xored, err := mycrypto.XORBytes(prevState, dataBlock)
 		if err != nil {
 			return err
 		}
@@ -130,104 +373,176 @@ func (mm *MyMAC) MacAddBlock(dataBlock []byte) error {
 		if err != nil {
 			return err
 		}
-		mm.state = dataBlock
+	case KMAC128, KMAC256:
+		mm.kmac.Write(dataBlock)
+		if len(mm.state) != AESBlockSize {
+			mm.state = make([]byte, AESBlockSize) // признак того, что блоки уже поглощены
+		}
 	default:
 		return fmt.Errorf("undefined algorithm %s", mm.mode)
 	}
 	return nil
 }
 
-// MacFinalize завершает вычисление MAC и возвращает тег
-func (mm *MyMAC) MacFinalize(lastBlock []byte) ([]byte, error) {
-	var err error
+// MacFinalize добавляет к сообщению последний кусок данных любой длины (возможно пустой)
+// и возвращает тег всего сообщения. Последним блоком становится придержанный MacAddBlock хвост.
+// После вызова состояние сброшено, и следующий MacAddBlock начинает новое сообщение.
+func (mm *MyMAC) MacFinalize(lastChunk []byte) ([]byte, error) {
+	if mm.key == nil {
+		return nil, errors.New("MacFinalize: key is not set")
+	}
+	defer mm.Reset()
+	if err := mm.MacAddBlock(lastChunk); err != nil {
+		return nil, err
+	}
+	lastBlock := mm.buf // не длиннее AESBlockSize и непуст, если блоки уже поглощались
+	if cbcChain(mm.mode) && len(mm.state) != AESBlockSize {
+		// сообщение не длиннее одного блока: блоки не поглощались, состояние нулевое
+		mm.state = make([]byte, AESBlockSize)
+	}
 	switch mm.mode {
 	case OMAC:
-		// если последний блок полон, то используем k1, иначе – k2
-		var xored []byte
-		if len(lastBlock) == AESBlockSize {
-			xored, err = xorBytes(lastBlock, mm.state)
-			if err != nil {
-				return nil, err
-			}
-			xored, err = xorBytes(xored, mm.k1)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			padded := pad(lastBlock, AESBlockSize)
-			xored, err = xorBytes(padded, mm.state)
-			if err != nil {
-				return nil, err
-			}
-			xored, err = xorBytes(xored, mm.k2)
-			if err != nil {
-				return nil, err
-			}
+		// CMAC (RFC 4493): полный последний блок маскируется подключом k1, неполный (и пустое сообщение)
+		// дополняется 10...0 и маскируется k2
+		last, subkey := lastBlock, mm.k1
+		if len(lastBlock) < AESBlockSize {
+			// дополнение пишется в копию, а не в придержанный буфер
+			last, subkey = pad(lastBlock[:len(lastBlock):len(lastBlock)], AESBlockSize), mm.k2
+		}
+		xored, err := mycrypto.XORBytes(last, mm.state)
+		if err != nil {
+			return nil, err
+		}
+		xored, err = mycrypto.XORBytes(xored, subkey)
+		if err != nil {
+			return nil, err
 		}
 		tag, err := mm.AesBlockEncrypt(xored)
 		if err != nil {
 			return nil, err
 		}
-		return tag[:OMACTagSize], nil
-	case TRUNCATED:
-		var xored []byte
-		if len(lastBlock) == AESBlockSize {
-			xored, err = xorBytes(lastBlock, mm.state)
-			if err != nil {
-				return nil, err
-			}
-			xored, err = xorBytes(xored, mm.k1)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			padded := Pkcs7Pad(lastBlock, AESBlockSize)
-			xored, err = xorBytes(padded, mm.state)
-			if err != nil {
-				return nil, err
-			}
-			xored, err = xorBytes(xored, mm.k2)
-			if err != nil {
-				return nil, err
-			}
+		return tag[:mm.TagSize()], nil
+	case CBCMAC, EMAC:
+		// тот же CBC, но последний блок не маскируется подключом: неполный блок (и пустое
+		// сообщение) дополняется 10...0, полный остаётся как есть
+		last := lastBlock
+		if len(lastBlock) < AESBlockSize {
+			last = pad(lastBlock[:len(lastBlock):len(lastBlock)], AESBlockSize)
+		}
+		xored, err := mycrypto.XORBytes(last, mm.state)
+		if err != nil {
+			return nil, err
 		}
 		tag, err := mm.AesBlockEncrypt(xored)
 		if err != nil {
 			return nil, err
 		}
-		return tag[:TruncTagSize], nil
+		if mm.mode == EMAC {
+			mm.emacOut.Encrypt(tag, tag) // E_K'(CBC-MAC_K(m))
+		}
+		return tag[:mm.TagSize()], nil
 	case HMAC:
-		mm.MacAddBlock(lastBlock)
-		innerHash := mm.hmacHash.Sum(nil)                       // H(k1 || message)
-		outerHash := sha256.Sum256(append(mm.k2, innerHash...)) // H(k2 || H(k1 || message))
-		return outerHash[:HMACTagSize], nil
+		// последний блок может быть неполным, поэтому пишем его в хэш напрямую
+		if len(mm.state) != AESBlockSize {
+			mm.hmacHash.Write(mm.k1)
+			mm.state = make([]byte, AESBlockSize)
+		}
+		mm.hmacHash.Write(lastBlock)
+		innerHash := mm.hmacHash.Sum(nil) // H(k1 || message)
+		mm.hmacHash.Reset()
+		mm.hmacHash.Write(mm.k2)
+		mm.hmacHash.Write(innerHash)
+		return mm.hmacHash.Sum(nil)[:mm.TagSize()], nil // H(k2 || H(k1 || message))
+	case KMAC128, KMAC256:
+		// KMAC(K, X, L, S) = cSHAKE(bytepad(encode_string(K)) || X || right_encode(L), L, "KMAC", S)
+		mm.kmac.Write(lastBlock)
+		mm.kmac.Write(rightEncode(uint64(mm.TagSize()) * 8))
+		tag := make([]byte, mm.TagSize())
+		mm.kmac.Read(tag)
+		return tag, nil
 	default:
 		return nil, fmt.Errorf("undefined algorithm %s", mm.mode)
 	}
 }
 
-// ComputeMac вычисляет MAC для данных за один вызов, используя MacAddBlock и MacFinalize
+// ComputeMac вычисляет MAC для данных за один вызов, используя MacFinalize
 func (mm *MyMAC) ComputeMac(message []byte) ([]byte, error) {
-	if mm.mode != HMAC && mm.mode != OMAC && mm.mode != TRUNCATED {
+	if !knownMode(mm.mode) {
 		return nil, fmt.Errorf("undefined algorithm %s", mm.mode)
 	}
+	if mm.key == nil {
+		return nil, errors.New("ComputeMac: key is not set")
+	}
+	mm.Reset()
+	return mm.MacFinalize(message)
+}
+
+// ComputeMacReader вычисляет MAC всех данных из r до io.EOF, читая их кусками по ReaderChunkSize:
+// память не зависит от длины данных, поэтому так можно вычислить тег файла любого размера или stdin.
+// Тег совпадает с ComputeMac тех же данных. При ошибке чтения незаконченное сообщение отбрасывается.
+func (mm *MyMAC) ComputeMacReader(r io.Reader) ([]byte, error) {
+	if !knownMode(mm.mode) {
+		return nil, fmt.Errorf("undefined algorithm %s", mm.mode)
+	}
+	if mm.key == nil {
+		return nil, errors.New("ComputeMacReader: key is not set")
+	}
+	mm.Reset()
+	buf := make([]byte, ReaderChunkSize)
+	for {
+		n, err := r.Read(buf)
+		if err := mm.MacAddBlock(buf[:n]); err != nil {
+			mm.Reset()
+			return nil, err
+		}
+		if err == io.EOF {
+			return mm.MacFinalize(nil)
+		}
+		if err != nil {
+			mm.Reset()
+			return nil, fmt.Errorf("ComputeMacReader: %w", err)
+		}
+	}
+}
+
+// Reset отбрасывает незаконченное сообщение; режим, ключ и подключи сохраняются
+func (mm *MyMAC) Reset() {
 	mm.state = nil // сброс состояний
-	if mm.mode == HMAC {
+	mm.buf = mm.buf[:0]
+	if mm.hmacHash != nil {
 		mm.hmacHash.Reset() // чистим от мусора
 	}
-	for len(message) > AESBlockSize {
-		block := message[:AESBlockSize]
-		if err := mm.MacAddBlock(block); err != nil {
-			return nil, err
+	if mm.kmacKeyed != nil {
+		mm.kmac = mm.kmacKeyed.Clone() // продолжаем от поглощённого ключа
+	}
+}
+
+// Clone возвращает независимую копию с теми же ключом и подключами и с текущим состоянием
+// незаконченного сообщения: продолжения копии и оригинала дают теги своих сообщений
+// с общим началом. Копия и оригинал могут использоваться в разных горутинах.
+// Ошибка возможна только для HMAC, если состояние хэш-функции не удалось перенести.
+func (mm *MyMAC) Clone() (*MyMAC, error) {
+	c := mm.clone()
+	c.state = bytes.Clone(mm.state)
+	c.buf = bytes.Clone(mm.buf)
+	if mm.hmacHash != nil {
+		// промежуточное состояние хэш-функции переносится через его двоичную сериализацию
+		m, ok := mm.hmacHash.(encoding.BinaryMarshaler)
+		if !ok {
+			return nil, fmt.Errorf("Clone: hash %T does not implement encoding.BinaryMarshaler", mm.hmacHash)
+		}
+		if err := copyHashState(c.hmacHash, m); err != nil {
+			return nil, fmt.Errorf("Clone: %v", err)
 		}
-		message = message[AESBlockSize:]
 	}
-	return mm.MacFinalize(message)
+	if mm.kmac != nil {
+		c.kmac = mm.kmac.Clone()
+	}
+	return c, nil
 }
 
-// VerifyMac вычисляет MAC для данных и сравнивает его с переданным тегом
+// VerifyMac вычисляет MAC для данных и сравнивает его с переданным тегом в константное время
 func (mm *MyMAC) VerifyMac(message, tag []byte) (bool, error) {
-	mm.state = make([]byte, AESBlockSize) // сбрасываем внутреннее состояние перед проверкой
 	computed, err := mm.ComputeMac(message)
 	if err != nil {
 		return false, err
@@ -235,11 +550,21 @@ func (mm *MyMAC) VerifyMac(message, tag []byte) (bool, error) {
 	return MacEqual(computed, tag), nil
 }
 
+// VerifyReader вычисляет MAC всех данных из r через ComputeMacReader, не загружая их в память,
+// и сравнивает его с переданным тегом в константное время
+func (mm *MyMAC) VerifyReader(r io.Reader, tag []byte) (bool, error) {
+	computed, err := mm.ComputeMacReader(r)
+	if err != nil {
+		return false, err
+	}
+	return MacEqual(computed, tag), nil
+}
+
 // generateSubkeys вычисляет ключи k1 и k2
 func (mm *MyMAC) generateSubkeys() error {
 	var K1, K2 []byte
 	switch mm.mode {
-	case OMAC, TRUNCATED:
+	case OMAC:
 		zero := make([]byte, AESBlockSize)
 		L, err := mm.AesBlockEncrypt(zero)
 		if err != nil {
@@ -247,19 +572,16 @@ func (mm *MyMAC) generateSubkeys() error {
 		}
 		K1 = leftShift(L)
 		if L[0]&0x80 != 0 { // L & 10000000
-			for i := range K1 {
-				K1[i] ^= Rn
-			}
+			K1[AESBlockSize-1] ^= Rn
 		}
 		K2 = leftShift(K1)
 		if K1[0]&0x80 != 0 {
-			for i := range K2 {
-				K2[i] ^= Rn
-			}
+			K2[AESBlockSize-1] ^= Rn
 		}
 	case HMAC:
-		K1 = make([]byte, SHABlockSize)
-		K2 = make([]byte, SHABlockSize)
+		// ipad и opad накладываются на весь блок хэш-функции
+		K1 = make([]byte, len(mm.key))
+		K2 = make([]byte, len(mm.key))
 		for i := range K1 {
 			K1[i] = mm.key[i] ^ 0x36
 			K2[i] = mm.key[i] ^ 0x5c
@@ -293,36 +615,9 @@ func pad(data []byte, blockSize int) []byte {
 	return append(data, padding...)
 }
 
-// pkcs7Pad добавляет PKCS7-паддинг к данным
-func Pkcs7Pad(data []byte, blockSize int) []byte {
-	padLen := blockSize - (len(data) % blockSize)
-	if padLen == 0 {
-		padLen = blockSize
-	}
-	padding := bytes.Repeat([]byte{byte(padLen)}, padLen)
-	return append(data, padding...)
-}
-
-// xorBytes выполняет операцию XOR двух срезов байтов одинаковой длины
-func xorBytes(a, b []byte) ([]byte, error) {
-	if len(a) != len(b) {
-		return nil, errors.New("xor: slices must have equal length")
-	}
-	res := make([]byte, len(a))
-	for i := range a {
-		res[i] = a[i] ^ b[i]
-	}
-	return res, nil
-}
-
-// hmacEqual сравнивает два тега в константное время
+// MacEqual сравнивает два тега в константное время через crypto/subtle: время зависит
+// только от длины тегов, которая не секретна, но не от позиции первого различия
 func MacEqual(a, b []byte) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	result := 0
-	for i := range a {
-		result |= int(a[i] ^ b[i])
-	}
-	return result == 0
+	mycrypto.AuditPoint("MacEqual/length")
+	return subtle.ConstantTimeCompare(a, b) == 1
 }
@@ -0,0 +1,87 @@
+package mymac
+
+import (
+	"fmt"
+	"time"
+)
+
+// finalizeRuns - минимальное число повторений при замере MacFinalize
+const finalizeRuns = 10000
+
+// PhaseTimes - среднее время фаз вычисления одного тега
+type PhaseTimes struct {
+	Setup    time.Duration // SetKey: расширение ключа AES и подключи k1, k2 (OMAC) или k1 ^ ipad, k2 ^ opad (HMAC)
+	Update   time.Duration // все вызовы MacAddBlock
+	Finalize time.Duration // MacFinalize: последний блок и выработка тега
+	Blocks   int           // число вызовов MacAddBlock
+}
+
+// Total возвращает время вычисления тега вместе с установкой ключа
+func (p PhaseTimes) Total() time.Duration {
+	return p.Setup + p.Update + p.Finalize
+}
+
+// PerBlock возвращает среднее время одного вызова MacAddBlock
+func (p PhaseTimes) PerBlock() time.Duration {
+	if p.Blocks == 0 {
+		return 0
+	}
+	return p.Update / time.Duration(p.Blocks)
+}
+
+// MeasurePhases замеряет фазы вычисления MAC сообщения с тем же разбиением на блоки, что и ComputeMac.
+// Каждая фаза измеряется отдельным циклом, чтобы накладные расходы таймера не искажали короткие
+// сообщения. Стоимость MacFinalize не зависит от предыдущих блоков, поэтому она замеряется
+// на одном последнем блоке не менее чем за finalizeRuns повторений.
+func MeasurePhases(mode string, key, message []byte, runs int) (PhaseTimes, error) {
+	if runs < 1 {
+		return PhaseTimes{}, fmt.Errorf("MeasurePhases: runs must be positive, got %d", runs)
+	}
+	mm := &MyMAC{}
+	if err := mm.SetMode(mode); err != nil {
+		return PhaseTimes{}, err
+	}
+	var p PhaseTimes
+	start := time.Now()
+	for i := 0; i < runs; i++ {
+		if err := mm.SetKey(key); err != nil {
+			return PhaseTimes{}, err
+		}
+	}
+	p.Setup = time.Since(start) / time.Duration(runs)
+
+	// Разбиение как в ComputeMac: последний (возможно полный) блок уходит в MacFinalize
+	body := message
+	for len(body) > AESBlockSize {
+		body = body[AESBlockSize:]
+		p.Blocks++
+	}
+	last := body
+	update := func() error {
+		mm.Reset()
+		for i := 0; i < p.Blocks; i++ {
+			if err := mm.MacAddBlock(message[i*AESBlockSize : (i+1)*AESBlockSize]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start = time.Now()
+	for i := 0; i < runs; i++ {
+		if err := update(); err != nil {
+			return PhaseTimes{}, err
+		}
+	}
+	p.Update = time.Since(start) / time.Duration(runs)
+
+	n := max(runs, finalizeRuns)
+	start = time.Now()
+	for i := 0; i < n; i++ {
+		mm.Reset()
+		if _, err := mm.MacFinalize(last); err != nil {
+			return PhaseTimes{}, err
+		}
+	}
+	p.Finalize = time.Since(start) / time.Duration(n)
+	return p, nil
+}
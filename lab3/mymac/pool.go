@@ -0,0 +1,75 @@
+package mymac
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sync"
+)
+
+// ----- Пул настроенных MyMAC -----
+
+type macPoolKey struct {
+	mode string
+	key  string
+}
+
+// MACPool хранит готовые MyMAC по паре (режим, ключ), чтобы сервер, считающий теги множества
+// коротких сообщений, не повторял расписание ключа AES и вычисление подключей на каждое сообщение.
+// Нулевое значение готово к работе, методы безопасны для одновременного вызова. Записи о парах
+// (режим, ключ) не удаляются, поэтому пул рассчитан на ограниченный набор ключей.
+type MACPool struct {
+	mu    sync.Mutex
+	pools map[macPoolKey]*sync.Pool
+}
+
+// pool возвращает sync.Pool для пары (mode, key); если его нет, создаёт при create, иначе возвращает nil
+func (p *MACPool) pool(mode string, key []byte, create bool) *sync.Pool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k := macPoolKey{mode: mode, key: string(key)}
+	sp, ok := p.pools[k]
+	if !ok && create {
+		if p.pools == nil {
+			p.pools = make(map[macPoolKey]*sync.Pool)
+		}
+		sp = &sync.Pool{}
+		p.pools[k] = sp
+	}
+	return sp
+}
+
+// Get возвращает MyMAC в режиме mode с ключом key: из пула или новый.
+// Экземпляр принадлежит вызывающему, пока он не вернёт его через Put.
+func (p *MACPool) Get(mode string, key []byte) (*MyMAC, error) {
+	// в пуле экземпляры лежат под ключом после SetKey (для HMAC - приведённым)
+	effective := key
+	if mode == HMAC {
+		effective = hmacKey(sha256.New(), key)
+	}
+	if sp := p.pool(mode, effective, false); sp != nil {
+		if mm, ok := sp.Get().(*MyMAC); ok {
+			return mm, nil
+		}
+	}
+	mm := &MyMAC{}
+	if err := mm.SetMode(mode); err != nil {
+		return nil, err
+	}
+	if err := mm.SetKey(bytes.Clone(key)); err != nil {
+		return nil, err
+	}
+	return mm, nil
+}
+
+// Put сбрасывает незаконченное сообщение и длину тега, заданную SetTagSize, и возвращает экземпляр
+// в пул по текущим режиму и ключу: Get всегда выдаёт экземпляр с тегом полной длины.
+// HMAC с хэш-функцией, заданной SetHash, в пул не возвращается - Get выдаёт только HMAC-SHA256;
+// так же не возвращается KMAC со строкой настройки.
+func (p *MACPool) Put(mm *MyMAC) {
+	if mm == nil || mm.key == nil || mm.newHash != nil || len(mm.custom) != 0 {
+		return
+	}
+	mm.Reset()
+	mm.tagSize = 0
+	p.pool(mm.mode, mm.key, true).Put(mm)
+}
@@ -0,0 +1,253 @@
+package mymac
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ----- Ротация ключей MAC -----
+
+// KeyIDSize - длина идентификатора ключа в начале каждой записи тега
+const KeyIDSize = 4
+
+// Rotator вычисляет и проверяет теги с идентификатором ключа.
+// Тег - последовательность записей keyID || MAC. Вне переходного периода запись одна
+// (текущий ключ), в переходный период - две: под старым и под новым ключом,
+// чтобы проверяющие, ещё не получившие новый ключ, продолжали принимать теги.
+type Rotator struct {
+	mode     string
+	keys     map[uint32]*MyMAC
+	current  uint32
+	previous uint32
+	dual     bool
+}
+
+// TagSize возвращает длину тега MyMAC для режима mode
+func TagSize(mode string) (int, error) {
+	switch mode {
+	case OMAC:
+		return OMACTagSize, nil
+	case HMAC:
+		return HMACTagSize, nil
+	case KMAC128:
+		return KMAC128TagSize, nil
+	case KMAC256:
+		return KMAC256TagSize, nil
+	case CBCMAC, EMAC:
+		return AESBlockSize, nil
+	default:
+		return 0, fmt.Errorf("undefined algorithm %s", mode)
+	}
+}
+
+// NewRotator создаёт Rotator для режима mode с первым ключом id, который сразу становится текущим
+func NewRotator(mode string, id uint32, key []byte) (*Rotator, error) {
+	if _, err := TagSize(mode); err != nil {
+		return nil, err
+	}
+	r := &Rotator{mode: mode, keys: make(map[uint32]*MyMAC)}
+	if err := r.AddKey(id, key); err != nil {
+		return nil, err
+	}
+	r.current = id
+	return r, nil
+}
+
+// AddKey добавляет ключ для проверки (и будущей ротации) без изменения текущего ключа
+func (r *Rotator) AddKey(id uint32, key []byte) error {
+	if _, ok := r.keys[id]; ok {
+		return fmt.Errorf("key id %d already exists", id)
+	}
+	mm := &MyMAC{}
+	if err := mm.SetMode(r.mode); err != nil {
+		return err
+	}
+	if err := mm.SetKey(append([]byte{}, key...)); err != nil {
+		return err
+	}
+	r.keys[id] = mm
+	return nil
+}
+
+// BeginRotation делает ключ id текущим и открывает переходный период:
+// новые теги содержат записи под прежним и под новым ключом
+func (r *Rotator) BeginRotation(id uint32) error {
+	if _, ok := r.keys[id]; !ok {
+		return fmt.Errorf("unknown key id %d", id)
+	}
+	if id == r.current {
+		return errors.New("key is already current")
+	}
+	r.previous, r.current, r.dual = r.current, id, true
+	return nil
+}
+
+// CompleteRotation завершает переходный период: теги снова содержат одну запись.
+// Прежний ключ остаётся доступен для проверки до вызова RetireKey.
+func (r *Rotator) CompleteRotation() {
+	r.dual = false
+}
+
+// RetireKey удаляет ключ id; теги, содержащие только записи под ним, перестают приниматься
+func (r *Rotator) RetireKey(id uint32) error {
+	if id == r.current || (r.dual && id == r.previous) {
+		return fmt.Errorf("key id %d is in use", id)
+	}
+	if _, ok := r.keys[id]; !ok {
+		return fmt.Errorf("unknown key id %d", id)
+	}
+	delete(r.keys, id)
+	return nil
+}
+
+// KeyIDs возвращает идентификаторы известных ключей по возрастанию
+func (r *Rotator) KeyIDs() []uint32 {
+	ids := make([]uint32, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+	return ids
+}
+
+// Current возвращает идентификатор текущего ключа
+func (r *Rotator) Current() uint32 {
+	return r.current
+}
+
+// entry вычисляет запись keyID || MAC
+func (r *Rotator) entry(id uint32, msg []byte) ([]byte, error) {
+	tag, err := r.keys[id].ComputeMac(msg)
+	if err != nil {
+		return nil, err
+	}
+	out := binary.BigEndian.AppendUint32(nil, id)
+	return append(out, tag...), nil
+}
+
+// Tag вычисляет тег сообщения: запись под текущим ключом, а в переходный период
+// сначала запись под прежним ключом
+func (r *Rotator) Tag(msg []byte) ([]byte, error) {
+	var out []byte
+	if r.dual {
+		e, err := r.entry(r.previous, msg)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e...)
+	}
+	e, err := r.entry(r.current, msg)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, e...), nil
+}
+
+// Verify принимает тег, если хотя бы одна его запись с известным идентификатором ключа верна.
+// Возвращает идентификатор ключа, под которым тег принят.
+func (r *Rotator) Verify(msg, tag []byte) (bool, uint32, error) {
+	size, _ := TagSize(r.mode)
+	entry := KeyIDSize + size
+	if len(tag) == 0 || len(tag)%entry != 0 {
+		return false, 0, fmt.Errorf("tag length %d is not a multiple of %d", len(tag), entry)
+	}
+	for ; len(tag) > 0; tag = tag[entry:] {
+		id := binary.BigEndian.Uint32(tag[:KeyIDSize])
+		mm, ok := r.keys[id]
+		if !ok {
+			continue
+		}
+		valid, err := mm.VerifyMac(msg, tag[KeyIDSize:entry])
+		if err != nil {
+			return false, 0, err
+		}
+		if valid {
+			return true, id, nil
+		}
+	}
+	return false, 0, nil
+}
+
+// Retag проверяет старый тег и возвращает новый тег под текущим ключом (ключами).
+// Используется для массовой перевыработки тегов сохранённых данных после ротации.
+func (r *Rotator) Retag(msg, tag []byte) ([]byte, error) {
+	ok, _, err := r.Verify(msg, tag)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("Retag: old tag is invalid")
+	}
+	return r.Tag(msg)
+}
+
+// VerifyAny проверяет тег без идентификатора ключа (голый MAC, выданный до перехода на Rotator)
+// всеми известными ключами по очереди. MAC вычисляется под каждым ключом, и совпадение не прерывает
+// перебор: число вычислений не зависит от того, каким ключом выдан тег, и время проверки его не выдаёт.
+// Возвращает идентификатор ключа, под которым тег принят.
+func (r *Rotator) VerifyAny(msg, tag []byte) (bool, uint32, error) {
+	if size, _ := TagSize(r.mode); len(tag) != size {
+		return false, 0, fmt.Errorf("tag length %d, want %d", len(tag), size)
+	}
+	var found int
+	var match uint32
+	for _, id := range r.KeyIDs() {
+		want, err := r.keys[id].ComputeMac(msg)
+		if err != nil {
+			return false, 0, err
+		}
+		eq := subtle.ConstantTimeCompare(want, tag)
+		mask := -uint32(eq)
+		match = match&^mask | id&mask
+		found |= eq
+	}
+	return found == 1, match, nil
+}
+
+// RetagAny проверяет голый тег через VerifyAny и возвращает тег под текущим ключом (ключами).
+// Так теги, выданные до перехода на Rotator, переводятся в формат с идентификатором ключа.
+func (r *Rotator) RetagAny(msg, tag []byte) ([]byte, error) {
+	ok, _, err := r.VerifyAny(msg, tag)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("RetagAny: old tag is invalid")
+	}
+	return r.Tag(msg)
+}
+
+// VerifyEncoded проверяет тег в формате EncodeTag: с идентификатором ключа - только этим ключом,
+// без него - всеми ключами, как VerifyAny. Алгоритм и длина тега должны совпадать с режимом Rotator
+// (HMAC - на SHA-256): иначе атакующий мог бы подсунуть тег слабее или короче выдаваемых.
+func (r *Rotator) VerifyEncoded(msg, enc []byte) (bool, uint32, error) {
+	t, err := DecodeTag(enc)
+	if err != nil {
+		return false, 0, err
+	}
+	if t.Mode != r.mode || (t.Mode == HMAC && t.Hash != HashSHA256) {
+		alg := t.Mode
+		if t.Hash != "" {
+			alg += "-" + t.Hash
+		}
+		return false, 0, fmt.Errorf("encoded tag algorithm %s, rotator uses %s", alg, r.mode)
+	}
+	if !t.HasKeyID {
+		return r.VerifyAny(msg, t.Tag)
+	}
+	if size, _ := TagSize(r.mode); len(t.Tag) != size {
+		return false, 0, fmt.Errorf("tag length %d, want %d", len(t.Tag), size)
+	}
+	mm, ok := r.keys[t.KeyID]
+	if !ok {
+		return false, 0, nil
+	}
+	valid, err := mm.VerifyMac(msg, t.Tag)
+	if err != nil || !valid {
+		return false, 0, err
+	}
+	return true, t.KeyID, nil
+}
@@ -0,0 +1,135 @@
+package mymac
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/sagilyp/lab1/mykdf"
+)
+
+// ----- Ключ на каждое сообщение -----
+
+// KDF, которыми SubkeyMAC выводит ключ сообщения из главного ключа
+const (
+	KDFHKDF = "HKDF"     // HKDF-SHA256 (RFC 5869): PRK из главного ключа, Expand на каждое сообщение
+	KDFCMAC = "CMAC-CTR" // KDF в режиме счётчика на AES-CMAC (NIST SP 800-108); главный ключ - 16 байт
+)
+
+// SubkeyLabel - метка (Label из SP 800-108, начало info у HKDF) ключей сообщений
+const SubkeyLabel = "mymac per-message key"
+
+// SubkeyMAC вычисляет тег каждого сообщения на собственном ключе, выведенном из главного ключа
+// и nonce сообщения (номера или случайной строки): K_m = KDF(master, SubkeyLabel, nonce).
+// Главный ключ в MAC не участвует, поэтому утечка ключа одного сообщения не раскрывает
+// ни главный ключ, ни ключи остальных сообщений. Nonce хранится или передаётся вместе с тегом.
+type SubkeyMAC struct {
+	kdf     string
+	prk     []byte // PRK HKDF
+	prf     *MyMAC // OMAC на главном ключе - PRF для KDFCMAC
+	mac     *MyMAC // MAC сообщений; ключ меняется на каждое сообщение
+	keySize int
+	counter uint64
+}
+
+// subkeySize возвращает длину ключа сообщения для режима mode: ключ AES у режимов на AES,
+// 32 байта (выход SHA-256) у HMAC и KMAC
+func subkeySize(mode string) int {
+	if cbcChain(mode) {
+		return AESKeySize
+	}
+	return SHASize
+}
+
+// NewSubkeyMAC создаёт SubkeyMAC для режима mode с выводом ключей через kdf (KDFHKDF или KDFCMAC)
+func NewSubkeyMAC(mode, kdf string, master []byte) (*SubkeyMAC, error) {
+	s := &SubkeyMAC{kdf: kdf, mac: &MyMAC{}, keySize: subkeySize(mode)}
+	if err := s.mac.SetMode(mode); err != nil {
+		return nil, err
+	}
+	switch kdf {
+	case KDFHKDF:
+		if len(master) == 0 {
+			return nil, errors.New("NewSubkeyMAC: empty master key")
+		}
+		s.prk = mykdf.HKDFExtract(nil, master)
+	case KDFCMAC:
+		s.prf = &MyMAC{}
+		if err := s.prf.SetMode(OMAC); err != nil {
+			return nil, err
+		}
+		if err := s.prf.SetKey(append([]byte{}, master...)); err != nil {
+			return nil, fmt.Errorf("NewSubkeyMAC: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("NewSubkeyMAC: unknown KDF %q", kdf)
+	}
+	return s, nil
+}
+
+// SetTagSize задаёт длину тега в битах, как MyMAC.SetTagSize; сохраняется для всех сообщений
+func (s *SubkeyMAC) SetTagSize(bits int) error {
+	return s.mac.SetTagSize(bits)
+}
+
+// DeriveKey выводит ключ сообщения с данным nonce
+func (s *SubkeyMAC) DeriveKey(nonce []byte) ([]byte, error) {
+	if s.kdf == KDFHKDF {
+		info := append([]byte(SubkeyLabel), 0)
+		return mykdf.HKDFExpand(s.prk, append(info, nonce...), s.keySize)
+	}
+	return s.counterKDF(nonce)
+}
+
+// counterKDF - KDF в режиме счётчика из SP 800-108 с PRF = AES-CMAC и 32-битными счётчиком и длиной:
+// K(i) = PRF(master, [i]_4 || Label || 0x00 || Context || [L]_4), ключ - K(1) || K(2) || ... длины L бит
+func (s *SubkeyMAC) counterKDF(context []byte) ([]byte, error) {
+	in := make([]byte, 4, 4+len(SubkeyLabel)+1+len(context)+4)
+	in = append(in, SubkeyLabel...)
+	in = append(in, 0)
+	in = append(in, context...)
+	in = binary.BigEndian.AppendUint32(in, uint32(8*s.keySize))
+	out := make([]byte, 0, s.keySize+OMACTagSize)
+	for i := uint32(1); len(out) < s.keySize; i++ {
+		binary.BigEndian.PutUint32(in, i)
+		k, err := s.prf.ComputeMac(in)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, k...)
+	}
+	return out[:s.keySize], nil
+}
+
+// Tag вычисляет тег message на ключе, выведенном из nonce
+func (s *SubkeyMAC) Tag(nonce, message []byte) ([]byte, error) {
+	key, err := s.DeriveKey(nonce)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mac.SetKey(key); err != nil {
+		return nil, err
+	}
+	return s.mac.ComputeMac(message)
+}
+
+// Next вычисляет тег следующего сообщения: nonce - номер сообщения (8 байт, big-endian),
+// который SubkeyMAC увеличивает сам. Возвращает nonce и тег.
+func (s *SubkeyMAC) Next(message []byte) ([]byte, []byte, error) {
+	nonce := binary.BigEndian.AppendUint64(nil, s.counter)
+	tag, err := s.Tag(nonce, message)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.counter++
+	return nonce, tag, nil
+}
+
+// Verify проверяет тег message, вычисленный с данным nonce
+func (s *SubkeyMAC) Verify(nonce, message, tag []byte) (bool, error) {
+	want, err := s.Tag(nonce, message)
+	if err != nil {
+		return false, err
+	}
+	return MacEqual(want, tag), nil
+}
@@ -0,0 +1,184 @@
+package mymac
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// ----- Самоописывающий формат тега -----
+
+// Идентификаторы алгоритмов в первом байте закодированного тега. У HMAC хэш-функция
+// входит в идентификатор: тег HMAC-SHA-1 нельзя проверить на HMAC-SHA-256.
+const (
+	AlgOMAC         = 0x01
+	AlgCBCMAC       = 0x02
+	AlgEMAC         = 0x03
+	AlgHMACSHA1     = 0x10
+	AlgHMACSHA256   = 0x11
+	AlgHMACSHA512   = 0x12
+	AlgHMACSHA3_256 = 0x13
+	AlgHMACBLAKE2b  = 0x14
+	AlgKMAC128      = 0x20
+	AlgKMAC256      = 0x21
+
+	algHasKeyID = 0x80 // старший бит первого байта: после длины тега идёт идентификатор ключа
+)
+
+// tagHeaderSize - заголовок без идентификатора ключа: байт алгоритма и длина тега (uint16, big-endian)
+const tagHeaderSize = 3
+
+// tagAlg - режим MyMAC и хэш-функция HMAC, которые обозначает идентификатор алгоритма
+type tagAlg struct {
+	mode, hash string
+}
+
+var tagAlgs = map[byte]tagAlg{
+	AlgOMAC:         {OMAC, ""},
+	AlgCBCMAC:       {CBCMAC, ""},
+	AlgEMAC:         {EMAC, ""},
+	AlgHMACSHA1:     {HMAC, HashSHA1},
+	AlgHMACSHA256:   {HMAC, HashSHA256},
+	AlgHMACSHA512:   {HMAC, HashSHA512},
+	AlgHMACSHA3_256: {HMAC, HashSHA3_256},
+	AlgHMACBLAKE2b:  {HMAC, HashBLAKE2b},
+	AlgKMAC128:      {KMAC128, ""},
+	AlgKMAC256:      {KMAC256, ""},
+}
+
+// EncodedTag - тег вместе с описанием алгоритма, которым он вычислен.
+// Строка настройки KMAC не кодируется: её, как и ключ, знает проверяющий.
+type EncodedTag struct {
+	Mode     string // режим MyMAC
+	Hash     string // хэш-функция HMAC из HashNames; пусто для остальных режимов
+	HasKeyID bool   // записан ли идентификатор ключа
+	KeyID    uint32 // идентификатор ключа, как в записях Rotator
+	Tag      []byte
+}
+
+// algID возвращает идентификатор алгоритма режима mode с хэш-функцией hashName
+func algID(mode, hashName string) (byte, error) {
+	for id, a := range tagAlgs {
+		if a.mode == mode && (mode != HMAC || a.hash == hashName) {
+			return id, nil
+		}
+	}
+	if mode == HMAC {
+		return 0, fmt.Errorf("EncodeTag: HMAC with hash function %q has no algorithm id", hashName)
+	}
+	return 0, fmt.Errorf("EncodeTag: undefined algorithm %s", mode)
+}
+
+// hashName находит имя хэш-функции HMAC среди HashNames, сравнивая хэш пустой строки;
+// nil - SHA-256. Для посторонних конструкторов возвращает пустую строку.
+func hashName(newHash func() hash.Hash) string {
+	if newHash == nil {
+		return HashSHA256
+	}
+	sum := newHash().Sum(nil)
+	for _, name := range HashNames {
+		if bytes.Equal(hashFuncs[name]().Sum(nil), sum) {
+			return name
+		}
+	}
+	return ""
+}
+
+// EncodeTag кодирует t как: идентификатор алгоритма (1 байт, старший бит - есть ли
+// идентификатор ключа), длина тега (2 байта, big-endian), идентификатор ключа
+// (KeyIDSize байт, если HasKeyID) и сам тег
+func EncodeTag(t EncodedTag) ([]byte, error) {
+	id, err := algID(t.Mode, t.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Tag) == 0 || len(t.Tag) > 0xffff {
+		return nil, fmt.Errorf("EncodeTag: tag of %d bytes", len(t.Tag))
+	}
+	if t.HasKeyID {
+		id |= algHasKeyID
+	}
+	out := binary.BigEndian.AppendUint16([]byte{id}, uint16(len(t.Tag)))
+	if t.HasKeyID {
+		out = binary.BigEndian.AppendUint32(out, t.KeyID)
+	}
+	return append(out, t.Tag...), nil
+}
+
+// DecodeTag разбирает тег, закодированный EncodeTag. Неизвестный алгоритм, длина тега,
+// не совпадающая с данными, и лишние байты в конце - ошибка.
+func DecodeTag(b []byte) (EncodedTag, error) {
+	if len(b) < tagHeaderSize {
+		return EncodedTag{}, errors.New("DecodeTag: encoded tag too short")
+	}
+	a, ok := tagAlgs[b[0]&^algHasKeyID]
+	if !ok {
+		return EncodedTag{}, fmt.Errorf("DecodeTag: unknown algorithm id 0x%02x", b[0]&^algHasKeyID)
+	}
+	t := EncodedTag{Mode: a.mode, Hash: a.hash, HasKeyID: b[0]&algHasKeyID != 0}
+	n := int(binary.BigEndian.Uint16(b[1:tagHeaderSize]))
+	rest := b[tagHeaderSize:]
+	if t.HasKeyID {
+		if len(rest) < KeyIDSize {
+			return EncodedTag{}, errors.New("DecodeTag: truncated key id")
+		}
+		t.KeyID = binary.BigEndian.Uint32(rest)
+		rest = rest[KeyIDSize:]
+	}
+	if n == 0 || len(rest) != n {
+		return EncodedTag{}, fmt.Errorf("DecodeTag: tag length %d, %d bytes follow", n, len(rest))
+	}
+	t.Tag = bytes.Clone(rest)
+	return t, nil
+}
+
+// Describe возвращает EncodedTag для тега tag, вычисленного этим MyMAC: режим и хэш-функцию HMAC.
+// HMAC с хэш-функцией не из HashNames закодировать нельзя.
+func (mm *MyMAC) Describe(tag []byte) (EncodedTag, error) {
+	t := EncodedTag{Mode: mm.mode, Tag: bytes.Clone(tag)}
+	if mm.mode == HMAC {
+		if t.Hash = hashName(mm.newHash); t.Hash == "" {
+			return EncodedTag{}, errors.New("Describe: HMAC hash function is not one of HashNames")
+		}
+	}
+	if _, err := algID(t.Mode, t.Hash); err != nil {
+		return EncodedTag{}, err
+	}
+	return t, nil
+}
+
+// NewMAC создаёт MyMAC с алгоритмом и длиной тега t на ключе key. Строку настройки KMAC,
+// если она была, вызывающий задаёт сам через SetCustomization.
+func (t EncodedTag) NewMAC(key []byte) (*MyMAC, error) {
+	mm := &MyMAC{}
+	if err := mm.SetMode(t.Mode); err != nil {
+		return nil, err
+	}
+	if t.Mode == HMAC {
+		newHash, err := HashFunc(t.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if err := mm.SetHash(newHash); err != nil {
+			return nil, err
+		}
+	}
+	if err := mm.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := mm.SetTagSize(8 * len(t.Tag)); err != nil {
+		return nil, err
+	}
+	return mm, nil
+}
+
+// Verify проверяет t для message на ключе key: режим, хэш-функция и длина тега берутся из t
+func (t EncodedTag) Verify(key, message []byte) (bool, error) {
+	mm, err := t.NewMAC(key)
+	if err != nil {
+		return false, err
+	}
+	return mm.VerifyMac(message, t.Tag)
+}
@@ -0,0 +1,225 @@
+package mymac
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ----- Древовидный MAC больших данных -----
+
+// TreeChunkSize - длина куска TreeMacReader по умолчанию: 1 МБ
+const TreeChunkSize = 1 << 20
+
+// Префиксы, разделяющие входы MAC куска и корня: тег куска не совпадёт с корнем
+const (
+	treeLeaf = 0x00
+	treeRoot = 0x01
+)
+
+// TreeTag - древовидный тег данных: теги кусков и корень, MAC их конкатенации.
+// Проверяющему с ключом достаточно Root; Chunks нужны, чтобы выдавать ChunkProof.
+type TreeTag struct {
+	ChunkSize int
+	Size      int64    // длина всех данных
+	Chunks    [][]byte // теги кусков по порядку
+	Root      []byte
+}
+
+// ChunkProof - доказательство целостности одного куска: его номер и теги всех кусков.
+// Длина - len(Chunks) тегов, у 1 ГБ кусками по 1 МБ - 16 КБ при 16-байтном теге.
+type ChunkProof struct {
+	Index     int
+	ChunkSize int
+	Size      int64
+	Chunks    [][]byte
+}
+
+// leafTag вычисляет тег куска i: MAC(0x00 || [i]_8 || chunk). Номер привязывает кусок к позиции.
+func (mm *MyMAC) leafTag(i int, chunk []byte) ([]byte, error) {
+	mm.Reset()
+	prefix := binary.BigEndian.AppendUint64([]byte{treeLeaf}, uint64(i))
+	if err := mm.MacAddBlock(prefix); err != nil {
+		return nil, err
+	}
+	if err := mm.MacAddBlock(chunk); err != nil {
+		return nil, err
+	}
+	return mm.MacFinalize(nil)
+}
+
+// rootTag вычисляет корень: MAC(0x01 || [chunkSize]_8 || [size]_8 || [n]_8 || t_0 || ... || t_{n-1}).
+// Длины и число кусков входят в корень, поэтому куски нельзя отбросить, переставить или перерезать.
+func (mm *MyMAC) rootTag(chunkSize int, size int64, chunks [][]byte) ([]byte, error) {
+	mm.Reset()
+	head := binary.BigEndian.AppendUint64([]byte{treeRoot}, uint64(chunkSize))
+	head = binary.BigEndian.AppendUint64(head, uint64(size))
+	head = binary.BigEndian.AppendUint64(head, uint64(len(chunks)))
+	if err := mm.MacAddBlock(head); err != nil {
+		return nil, err
+	}
+	for _, t := range chunks {
+		if err := mm.MacAddBlock(t); err != nil {
+			return nil, err
+		}
+	}
+	return mm.MacFinalize(nil)
+}
+
+// treeReady проверяет, что у MyMAC заданы режим и ключ, а длина куска положительна
+func (mm *MyMAC) treeReady(chunkSize int) error {
+	if !knownMode(mm.mode) {
+		return errors.New("tree MAC: MAC mode is not set")
+	}
+	if mm.key == nil {
+		return errors.New("tree MAC: key is not set")
+	}
+	if chunkSize <= 0 {
+		return fmt.Errorf("tree MAC: chunk size must be positive, got %d", chunkSize)
+	}
+	return nil
+}
+
+// TreeMacReader вычисляет древовидный тег данных из r: данные читаются кусками по chunkSize байт
+// (0 - TreeChunkSize), теги кусков вычисляются в workers горутинах (0 - по числу CPU), затем
+// вычисляется корень. В памяти одновременно не больше 2*workers кусков.
+func (mm *MyMAC) TreeMacReader(r io.Reader, chunkSize, workers int) (*TreeTag, error) {
+	if chunkSize == 0 {
+		chunkSize = TreeChunkSize
+	}
+	if err := mm.treeReady(chunkSize); err != nil {
+		return nil, err
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	type job struct {
+		i   int
+		buf []byte
+	}
+	var (
+		mu      sync.Mutex
+		chunks  [][]byte
+		aborted atomic.Bool
+		errOnce sync.Once
+		firstEr error
+		wg      sync.WaitGroup
+	)
+	fail := func(err error) {
+		errOnce.Do(func() { firstEr = err })
+		aborted.Store(true)
+	}
+	jobs := make(chan job)
+	free := make(chan []byte, 2*workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := mm.clone()
+			for j := range jobs {
+				if !aborted.Load() {
+					tag, err := m.leafTag(j.i, j.buf)
+					if err != nil {
+						fail(err)
+					}
+					mu.Lock()
+					chunks[j.i] = tag
+					mu.Unlock()
+				}
+				free <- j.buf[:cap(j.buf)]
+			}
+		}()
+	}
+
+	var size int64
+	for i, allocated := 0, 0; !aborted.Load(); i++ {
+		var buf []byte
+		select {
+		case buf = <-free:
+		default:
+			if allocated < cap(free) {
+				buf = make([]byte, chunkSize)
+				allocated++
+			} else {
+				buf = <-free
+			}
+		}
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			fail(err)
+			break
+		}
+		size += int64(n)
+		mu.Lock()
+		chunks = append(chunks, nil)
+		mu.Unlock()
+		jobs <- job{i, buf[:n]}
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstEr != nil {
+		return nil, firstEr
+	}
+	root, err := mm.rootTag(chunkSize, size, chunks)
+	if err != nil {
+		return nil, err
+	}
+	return &TreeTag{ChunkSize: chunkSize, Size: size, Chunks: chunks, Root: root}, nil
+}
+
+// VerifyTreeReader вычисляет древовидный тег данных из r и сравнивает его корень с root
+func (mm *MyMAC) VerifyTreeReader(r io.Reader, chunkSize, workers int, root []byte) (bool, error) {
+	t, err := mm.TreeMacReader(r, chunkSize, workers)
+	if err != nil {
+		return false, err
+	}
+	return MacEqual(t.Root, root), nil
+}
+
+// Proof возвращает доказательство целостности куска i
+func (t *TreeTag) Proof(i int) (*ChunkProof, error) {
+	if i < 0 || i >= len(t.Chunks) {
+		return nil, fmt.Errorf("Proof: chunk %d out of range [0, %d)", i, len(t.Chunks))
+	}
+	return &ChunkProof{Index: i, ChunkSize: t.ChunkSize, Size: t.Size, Chunks: t.Chunks}, nil
+}
+
+// VerifyChunk проверяет кусок chunk по доказательству p и корню root, не читая остальные данные:
+// теги кусков из p должны дать root, а тег chunk - совпасть с тегом p.Index. Длина куска
+// должна совпадать с его местом в данных длины p.Size.
+func (mm *MyMAC) VerifyChunk(root []byte, p *ChunkProof, chunk []byte) (bool, error) {
+	if err := mm.treeReady(p.ChunkSize); err != nil {
+		return false, err
+	}
+	n := int((p.Size + int64(p.ChunkSize) - 1) / int64(p.ChunkSize))
+	if len(p.Chunks) != n || p.Index < 0 || p.Index >= n {
+		return false, nil
+	}
+	want := int64(p.ChunkSize)
+	if p.Index == n-1 {
+		want = p.Size - int64(n-1)*int64(p.ChunkSize)
+	}
+	if int64(len(chunk)) != want {
+		return false, nil
+	}
+	r, err := mm.rootTag(p.ChunkSize, p.Size, p.Chunks)
+	if err != nil {
+		return false, err
+	}
+	leaf, err := mm.leafTag(p.Index, chunk)
+	if err != nil {
+		return false, err
+	}
+	rootOK, leafOK := MacEqual(r, root), MacEqual(leaf, p.Chunks[p.Index])
+	return rootOK && leafOK, nil
+}
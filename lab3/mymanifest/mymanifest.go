@@ -0,0 +1,455 @@
+package mymanifest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// ----- Манифест каталога: хэши и MAC файлов, MAC и подпись манифеста -----
+
+// Алгоритмы, доступные для файлов манифеста
+const (
+	AlgSHA256    = "sha256"
+	AlgSHA512    = "sha512"
+	AlgOMAC      = "omac"
+	AlgHMAC      = "hmac"
+	AlgTruncated = "truncated"
+)
+
+// macModes сопоставляет алгоритмам манифеста режимы MyMAC
+var macModes = map[string]string{
+	AlgOMAC:      mymac.OMAC,
+	AlgHMAC:      mymac.HMAC,
+	AlgTruncated: mymac.OMAC,
+}
+
+// truncBits - длина тега в битах для алгоритмов манифеста с усечённым тегом
+var truncBits = map[string]int{
+	AlgTruncated: 64,
+}
+
+// ErrAuth возвращается, если MAC или подпись манифеста не сходятся
+var ErrAuth = errors.New("manifest: authentication failed")
+
+// Entry - файл манифеста: путь относительно корня через '/', размер и значения алгоритмов в hex
+type Entry struct {
+	Path    string            `json:"path"`
+	Size    int64             `json:"size"`
+	Digests map[string]string `json:"digests"`
+}
+
+// Signature - подпись манифеста. PublicKey (PKIX DER) записан для справки:
+// проверять подпись нужно ключом, полученным не из самого манифеста.
+type Signature struct {
+	Alg       string `json:"alg"`
+	PublicKey []byte `json:"public_key"`
+	Value     []byte `json:"value"`
+}
+
+// Manifest - список файлов каталога. MAC (HMAC-SHA256 из mymac) и Signature вычисляются
+// над каноническим JSON манифеста без этих двух полей.
+type Manifest struct {
+	Version    int        `json:"version"`
+	Algorithms []string   `json:"algorithms"`
+	Files      []Entry    `json:"files"`
+	MAC        []byte     `json:"mac,omitempty"`
+	Signature  *Signature `json:"signature,omitempty"`
+}
+
+// Options - параметры построения и проверки манифеста
+type Options struct {
+	Algorithms []string // для Build; Verify берёт алгоритмы из манифеста
+	Key        []byte   // ключ MAC-алгоритмов (omac требует 16 байт)
+	Workers    int      // число горутин (0 - по числу CPU)
+	Exclude    []string // относительные пути, которые не попадают в манифест
+}
+
+// newTaggers создаёт по Tagger на каждый алгоритм
+func newTaggers(algs []string, key []byte) ([]mymac.Tagger, error) {
+	taggers := make([]mymac.Tagger, len(algs))
+	for i, alg := range algs {
+		switch alg {
+		case AlgSHA256:
+			taggers[i] = mymac.NewHashTagger(sha256.New())
+		case AlgSHA512:
+			taggers[i] = mymac.NewHashTagger(sha512.New())
+		case AlgOMAC, AlgHMAC, AlgTruncated:
+			if key == nil {
+				return nil, fmt.Errorf("manifest: %s requires a key", alg)
+			}
+			mm := &mymac.MyMAC{}
+			if err := mm.SetMode(macModes[alg]); err != nil {
+				return nil, err
+			}
+			if bits, ok := truncBits[alg]; ok {
+				if err := mm.SetTagSize(bits); err != nil {
+					return nil, err
+				}
+			}
+			if err := mm.SetKey(key); err != nil {
+				return nil, fmt.Errorf("manifest: %s: %v", alg, err)
+			}
+			t, err := mymac.NewMACTagger(mm)
+			if err != nil {
+				return nil, err
+			}
+			taggers[i] = t
+		default:
+			return nil, fmt.Errorf("manifest: unknown algorithm %q", alg)
+		}
+	}
+	return taggers, nil
+}
+
+// digestFile вычисляет все алгоритмы за один проход по файлу
+func digestFile(path string, algs []string, key []byte) (map[string]string, int64, error) {
+	taggers, err := newTaggers(algs, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	multi := mymac.NewMultiMAC(taggers...)
+	n, err := io.Copy(multi, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	sums, err := multi.Sums()
+	if err != nil {
+		return nil, 0, err
+	}
+	res := make(map[string]string, len(algs))
+	for i, alg := range algs {
+		res[alg] = hex.EncodeToString(sums[i])
+	}
+	return res, n, nil
+}
+
+// walk возвращает отсортированные относительные пути обычных файлов дерева root.
+// Символические ссылки и специальные файлы пропускаются.
+func walk(root string, exclude []string) ([]string, error) {
+	skip := make(map[string]bool, len(exclude))
+	for _, e := range exclude {
+		skip[filepath.ToSlash(filepath.Clean(e))] = true
+	}
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !skip[rel] {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths, err
+}
+
+// digestAll вычисляет записи для paths в opts.Workers горутинах
+func digestAll(root string, paths []string, algs []string, opts Options) ([]Entry, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	entries := make([]Entry, len(paths))
+	errs := make([]error, len(paths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				digests, size, err := digestFile(filepath.Join(root, filepath.FromSlash(paths[i])), algs, opts.Key)
+				entries[i] = Entry{Path: paths[i], Size: size, Digests: digests}
+				errs[i] = err
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", paths[i], err)
+		}
+	}
+	return entries, nil
+}
+
+// Build обходит каталог root и строит манифест с алгоритмами opts.Algorithms
+func Build(root string, opts Options) (*Manifest, error) {
+	if len(opts.Algorithms) == 0 {
+		return nil, errors.New("manifest: no algorithms selected")
+	}
+	if _, err := newTaggers(opts.Algorithms, opts.Key); err != nil {
+		return nil, err
+	}
+	paths, err := walk(root, opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	files, err := digestAll(root, paths, opts.Algorithms, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Manifest{Version: 1, Algorithms: opts.Algorithms, Files: files}, nil
+}
+
+// ----- Аутентификация манифеста -----
+
+// canonical возвращает JSON манифеста без MAC и подписи: файлы отсортированы,
+// ключи словарей json.Marshal сортирует сам
+func (m *Manifest) canonical() ([]byte, error) {
+	c := *m
+	c.MAC, c.Signature = nil, nil
+	return json.Marshal(c)
+}
+
+// manifestMAC вычисляет HMAC канонического JSON на ключе key
+func (m *Manifest) manifestMAC(key []byte) ([]byte, error) {
+	body, err := m.canonical()
+	if err != nil {
+		return nil, err
+	}
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(mymac.HMAC); err != nil {
+		return nil, err
+	}
+	if err := mm.SetKey(key); err != nil {
+		return nil, err
+	}
+	return mm.ComputeMac(body)
+}
+
+// sigDigest - сообщение для подписи: Ed25519 подписывает сам канонический JSON,
+// ECDSA и RSA-PSS - его SHA-256
+func sigDigest(body []byte, pub crypto.PublicKey) ([]byte, crypto.SignerOpts) {
+	if _, ok := pub.(ed25519.PublicKey); ok {
+		return body, crypto.Hash(0)
+	}
+	sum := sha256.Sum256(body)
+	if _, ok := pub.(*rsa.PublicKey); ok {
+		return sum[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	}
+	return sum[:], crypto.SHA256
+}
+
+// sigAlg возвращает название алгоритма подписи для открытого ключа
+func sigAlg(pub crypto.PublicKey) (string, error) {
+	switch pub.(type) {
+	case ed25519.PublicKey:
+		return "ed25519", nil
+	case *ecdsa.PublicKey:
+		return "ecdsa-sha256", nil
+	case *rsa.PublicKey:
+		return "rsa-pss-sha256", nil
+	}
+	return "", fmt.Errorf("manifest: unsupported public key type %T", pub)
+}
+
+// Seal вычисляет MAC манифеста на macKey и подписывает его signer (Ed25519, ECDSA или RSA).
+// Любой из параметров может быть nil.
+func (m *Manifest) Seal(macKey []byte, signer crypto.Signer) error {
+	m.MAC, m.Signature = nil, nil
+	if macKey != nil {
+		tag, err := m.manifestMAC(macKey)
+		if err != nil {
+			return err
+		}
+		m.MAC = tag
+	}
+	if signer == nil {
+		return nil
+	}
+	body, err := m.canonical()
+	if err != nil {
+		return err
+	}
+	alg, err := sigAlg(signer.Public())
+	if err != nil {
+		return err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return err
+	}
+	msg, opts := sigDigest(body, signer.Public())
+	sig, err := signer.Sign(rand.Reader, msg, opts)
+	if err != nil {
+		return err
+	}
+	m.Signature = &Signature{Alg: alg, PublicKey: pubDER, Value: sig}
+	return nil
+}
+
+// Authenticate проверяет MAC манифеста ключом macKey и подпись ключом pub.
+// Проверяется только то, для чего передан ключ; если ключ передан, а MAC или подписи
+// в манифесте нет, это тоже ошибка. Несовпадение даёт ErrAuth.
+func (m *Manifest) Authenticate(macKey []byte, pub crypto.PublicKey) error {
+	if macKey != nil {
+		if m.MAC == nil {
+			return fmt.Errorf("%w: manifest has no MAC", ErrAuth)
+		}
+		tag, err := m.manifestMAC(macKey)
+		if err != nil {
+			return err
+		}
+		if !mymac.MacEqual(tag, m.MAC) {
+			return fmt.Errorf("%w: MAC mismatch", ErrAuth)
+		}
+	}
+	if pub == nil {
+		return nil
+	}
+	if m.Signature == nil {
+		return fmt.Errorf("%w: manifest is not signed", ErrAuth)
+	}
+	body, err := m.canonical()
+	if err != nil {
+		return err
+	}
+	msg, opts := sigDigest(body, pub)
+	ok := false
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(k, msg, m.Signature.Value)
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(k, msg, m.Signature.Value)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPSS(k, crypto.SHA256, msg, m.Signature.Value, opts.(*rsa.PSSOptions)) == nil
+	default:
+		return fmt.Errorf("manifest: unsupported public key type %T", pub)
+	}
+	if !ok {
+		return fmt.Errorf("%w: bad signature", ErrAuth)
+	}
+	return nil
+}
+
+// ----- Проверка дерева -----
+
+// Diff - расхождения дерева с манифестом
+type Diff struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+	Checked  int
+}
+
+// Clean сообщает, что дерево совпадает с манифестом
+func (d *Diff) Clean() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Verify пересчитывает алгоритмы манифеста для файлов root и сравнивает с записями.
+// Файл считается изменённым, если отличается размер или значение любого алгоритма.
+// Подлинность самого манифеста проверяет Authenticate.
+func Verify(root string, m *Manifest, opts Options) (*Diff, error) {
+	if _, err := newTaggers(m.Algorithms, opts.Key); err != nil {
+		return nil, err
+	}
+	paths, err := walk(root, opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]Entry, len(m.Files))
+	for _, e := range m.Files {
+		want[e.Path] = e
+	}
+	d := &Diff{}
+	var present []string
+	for _, p := range paths {
+		if _, ok := want[p]; ok {
+			present = append(present, p)
+		} else {
+			d.Added = append(d.Added, p)
+		}
+	}
+	got, err := digestAll(root, present, m.Algorithms, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range got {
+		w := want[e.Path]
+		delete(want, e.Path)
+		d.Checked++
+		if e.Size != w.Size || len(e.Digests) != len(w.Digests) {
+			d.Modified = append(d.Modified, e.Path)
+			continue
+		}
+		for alg, v := range w.Digests {
+			// значения MAC сравниваются в константное время, как в MacEqual
+			if subtle.ConstantTimeCompare([]byte(e.Digests[alg]), []byte(v)) != 1 {
+				d.Modified = append(d.Modified, e.Path)
+				break
+			}
+		}
+	}
+	for p := range want {
+		d.Removed = append(d.Removed, p)
+	}
+	sort.Strings(d.Removed)
+	return d, nil
+}
+
+// ----- Файл манифеста -----
+
+// Save записывает манифест в JSON
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Load читает манифест из JSON
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if m.Version != 1 {
+		return nil, fmt.Errorf("%s: unsupported manifest version %d", path, m.Version)
+	}
+	return m, nil
+}
@@ -0,0 +1,75 @@
+package mystats
+
+import (
+	"errors"
+	"math"
+)
+
+// BitBias для каждого бита тегов возвращает отклонение доли единиц от 1/2.
+// Для идеальной PRF отклонение порядка 1/(2*sqrt(n)).
+func BitBias(tags [][]byte) ([]float64, error) {
+	if len(tags) == 0 {
+		return nil, errors.New("BitBias: no tags")
+	}
+	bits := len(tags[0]) * 8
+	ones := make([]int, bits)
+	for _, t := range tags {
+		if len(t)*8 != bits {
+			return nil, errors.New("BitBias: tags of different length")
+		}
+		for i := 0; i < bits; i++ {
+			if t[i/8]>>(7-uint(i%8))&1 == 1 {
+				ones[i]++
+			}
+		}
+	}
+	bias := make([]float64, bits)
+	for i, c := range ones {
+		bias[i] = float64(c)/float64(len(tags)) - 0.5
+	}
+	return bias, nil
+}
+
+// prefix возвращает первые nbits бит тега в виде числа
+func prefix(t []byte, nbits int) uint64 {
+	var v uint64
+	for i := 0; i < nbits; i++ {
+		v = v<<1 | uint64(t[i/8]>>(7-uint(i%8))&1)
+	}
+	return v
+}
+
+// Collisions считает число пар тегов, совпадающих в первых nbits битах (nbits <= 64)
+func Collisions(tags [][]byte, nbits int) (int, error) {
+	if nbits <= 0 || nbits > 64 {
+		return 0, errors.New("Collisions: nbits must be in 1..64")
+	}
+	counts := make(map[uint64]int)
+	for _, t := range tags {
+		if len(t)*8 < nbits {
+			return 0, errors.New("Collisions: tag shorter than prefix")
+		}
+		counts[prefix(t, nbits)]++
+	}
+	pairs := 0
+	for _, c := range counts {
+		pairs += c * (c - 1) / 2
+	}
+	return pairs, nil
+}
+
+// ExpectedCollisions - ожидаемое для идеальной PRF число совпавших пар среди n значений из 2^nbits
+func ExpectedCollisions(n, nbits int) float64 {
+	return float64(n) * float64(n-1) / 2 / math.Pow(2, float64(nbits))
+}
+
+// MaxAbs возвращает максимум модуля элементов
+func MaxAbs(xs []float64) float64 {
+	m := 0.0
+	for _, x := range xs {
+		if math.Abs(x) > m {
+			m = math.Abs(x)
+		}
+	}
+	return m
+}
@@ -0,0 +1,171 @@
+package mystats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ----- Планирование экспериментов: число повторов по точности и мощности -----
+
+// NormalQuantile возвращает квантиль стандартного нормального распределения уровня p
+func NormalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// twoSided возвращает z_(1-α/2) для доверительной вероятности confidence = 1-α
+func twoSided(confidence float64) float64 {
+	return NormalQuantile(1 - (1-confidence)/2)
+}
+
+// checkConfidence проверяет, что вероятность лежит строго между 0 и 1
+func checkConfidence(name string, p float64) error {
+	if !(p > 0 && p < 1) {
+		return fmt.Errorf("%s must be in (0, 1), got %v", name, p)
+	}
+	return nil
+}
+
+// SampleSizeMean - число повторов, при котором доверительный интервал среднего с вероятностью
+// confidence имеет полуширину не больше relErr от среднего, если коэффициент вариации
+// наблюдений равен cv: n = (z * cv / relErr)^2
+func SampleSizeMean(cv, relErr, confidence float64) (int, error) {
+	if err := checkConfidence("confidence", confidence); err != nil {
+		return 0, err
+	}
+	if relErr <= 0 || cv < 0 {
+		return 0, errors.New("SampleSizeMean: relErr must be positive and cv non-negative")
+	}
+	z := twoSided(confidence)
+	return int(math.Ceil(z * z * cv * cv / (relErr * relErr))), nil
+}
+
+// AchievedRelErr - полуширина доверительного интервала среднего относительно среднего,
+// достигнутая на n наблюдениях с коэффициентом вариации cv
+func AchievedRelErr(cv float64, n int, confidence float64) float64 {
+	if n <= 0 {
+		return math.Inf(1)
+	}
+	return twoSided(confidence) * cv / math.Sqrt(float64(n))
+}
+
+// EventsNeeded - число редких событий (например, коллизий), которое нужно дождаться, чтобы
+// оценить их интенсивность с относительной погрешностью relErr. Число событий за заданную
+// работу распределено по Пуассону, его коэффициент вариации 1/sqrt(k), поэтому k = (z / relErr)^2.
+// Для confidence = 0.95 и relErr = 0.16 получается 151 - прежнее «150 коллизий».
+func EventsNeeded(relErr, confidence float64) (int, error) {
+	return SampleSizeMean(1, relErr, confidence)
+}
+
+// SampleSizeTwoMeans - число повторов в каждой из двух групп, чтобы различие средних
+// величиной effect (d Коэна: разность средних в единицах стандартного отклонения)
+// обнаруживалось двусторонним тестом уровня alpha с мощностью power:
+// n = 2 (z_(1-α/2) + z_power)^2 / d^2
+func SampleSizeTwoMeans(effect, alpha, power float64) (int, error) {
+	if err := checkConfidence("alpha", alpha); err != nil {
+		return 0, err
+	}
+	if err := checkConfidence("power", power); err != nil {
+		return 0, err
+	}
+	if effect <= 0 {
+		return 0, errors.New("SampleSizeTwoMeans: effect size must be positive")
+	}
+	z := twoSided(1-alpha) + NormalQuantile(power)
+	return int(math.Ceil(2 * z * z / (effect * effect))), nil
+}
+
+// MeanCV возвращает среднее и выборочный коэффициент вариации наблюдений
+func MeanCV(xs []float64) (mean, cv float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	if len(xs) < 2 || mean == 0 {
+		return mean, 0
+	}
+	var ss float64
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(ss/float64(len(xs)-1)) / math.Abs(mean)
+}
+
+// Plan - требования к измерению среднего: доверительная вероятность, допустимая
+// относительная погрешность и бюджет повторов
+type Plan struct {
+	Confidence float64 // например, 0.95
+	RelErr     float64 // полуширина доверительного интервала относительно среднего, например, 0.05
+	Pilot      int     // пробные повторы для оценки разброса (не меньше 2)
+	MaxRuns    int     // бюджет: наибольшее число повторов (0 - без ограничения)
+}
+
+// DefaultPlan - 95% доверительный интервал шириной ±5% среднего, 30 пробных повторов, не больше 10000
+var DefaultPlan = Plan{Confidence: 0.95, RelErr: 0.05, Pilot: 30, MaxRuns: 10000}
+
+// Estimate - результат измерения по плану
+type Estimate struct {
+	Mean   float64
+	CV     float64
+	N      int     // выполнено повторов
+	Needed int     // требуется повторов по последней оценке разброса
+	RelErr float64 // достигнутая относительная погрешность
+	Capped bool    // бюджет исчерпан раньше, чем достигнута точность
+}
+
+// Validate проверяет параметры плана
+func (p Plan) Validate() error {
+	if err := checkConfidence("confidence", p.Confidence); err != nil {
+		return err
+	}
+	if p.RelErr <= 0 {
+		return fmt.Errorf("relative error must be positive, got %v", p.RelErr)
+	}
+	if p.Pilot < 2 {
+		return fmt.Errorf("pilot must be at least 2 runs, got %d", p.Pilot)
+	}
+	if p.MaxRuns != 0 && p.MaxRuns < p.Pilot {
+		return fmt.Errorf("run budget %d is smaller than the pilot %d", p.MaxRuns, p.Pilot)
+	}
+	return nil
+}
+
+// Measure вызывает run, пока среднее его результатов не будет известно с точностью плана:
+// после пробных повторов по оценке разброса вычисляется нужное число повторов, измерение
+// продолжается, и оценка уточняется, пока её хватает или пока не исчерпан бюджет MaxRuns.
+func (p Plan) Measure(run func() (float64, error)) (Estimate, error) {
+	if err := p.Validate(); err != nil {
+		return Estimate{}, err
+	}
+	var xs []float64
+	target := p.Pilot
+	for {
+		for len(xs) < target {
+			x, err := run()
+			if err != nil {
+				return Estimate{}, err
+			}
+			xs = append(xs, x)
+		}
+		mean, cv := MeanCV(xs)
+		needed, err := SampleSizeMean(cv, p.RelErr, p.Confidence)
+		if err != nil {
+			return Estimate{}, err
+		}
+		est := Estimate{Mean: mean, CV: cv, N: len(xs), Needed: needed, RelErr: AchievedRelErr(cv, len(xs), p.Confidence)}
+		if needed <= len(xs) {
+			return est, nil
+		}
+		if p.MaxRuns > 0 && len(xs) >= p.MaxRuns {
+			est.Capped = true
+			return est, nil
+		}
+		target = needed
+		if p.MaxRuns > 0 && target > p.MaxRuns {
+			target = p.MaxRuns
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package mystats
+
+import (
+	"errors"
+	"math"
+)
+
+// ----- Статистические тесты случайности (NIST SP 800-22) и оценка энтропии (SP 800-90B) -----
+
+// Alpha - уровень значимости: последовательность считается не прошедшей тест при p < Alpha
+const Alpha = 0.01
+
+// TestResult - p-значение одного теста
+type TestResult struct {
+	Name   string
+	PValue float64
+}
+
+// Passed сообщает, прошла ли последовательность тест
+func (r TestResult) Passed() bool {
+	return r.PValue >= Alpha
+}
+
+// bitAt возвращает i-й бит data, начиная со старшего бита первого байта
+func bitAt(data []byte, i int) int {
+	return int(data[i/8] >> (7 - uint(i%8)) & 1)
+}
+
+// igamc - регуляризованная верхняя неполная гамма-функция Q(a, x)
+// (ряд при x < a+1, иначе цепная дробь по Лентцу)
+func igamc(a, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	lg, _ := math.Lgamma(a)
+	if x < a+1 {
+		sum, term := 1/a, 1/a
+		for n := 1.0; n < 1e6; n++ {
+			term *= x / (a + n)
+			sum += term
+			if term < sum*1e-15 {
+				break
+			}
+		}
+		return 1 - sum*math.Exp(-x+a*math.Log(x)-lg)
+	}
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1.0; i < 1e6; i++ {
+		an := -i * (i - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
+
+// Monobit - частотный тест: доля единиц во всей последовательности
+func Monobit(data []byte) (float64, error) {
+	n := len(data) * 8
+	if n < 100 {
+		return 0, errors.New("Monobit: need at least 100 bits")
+	}
+	s := 0
+	for i := 0; i < n; i++ {
+		s += 2*bitAt(data, i) - 1
+	}
+	return math.Erfc(math.Abs(float64(s)) / math.Sqrt(2*float64(n))), nil
+}
+
+// BlockFrequency - частотный тест в блоках по m бит
+func BlockFrequency(data []byte, m int) (float64, error) {
+	n := len(data) * 8
+	if m < 20 || n/m < 1 {
+		return 0, errors.New("BlockFrequency: need m >= 20 and at least one block")
+	}
+	blocks := n / m
+	chi := 0.0
+	for b := 0; b < blocks; b++ {
+		ones := 0
+		for i := b * m; i < (b+1)*m; i++ {
+			ones += bitAt(data, i)
+		}
+		pi := float64(ones)/float64(m) - 0.5
+		chi += pi * pi
+	}
+	chi *= 4 * float64(m)
+	return igamc(float64(blocks)/2, chi/2), nil
+}
+
+// Runs - тест серий: число серий одинаковых битов подряд
+func Runs(data []byte) (float64, error) {
+	n := len(data) * 8
+	if n < 100 {
+		return 0, errors.New("Runs: need at least 100 bits")
+	}
+	ones := 0
+	for i := 0; i < n; i++ {
+		ones += bitAt(data, i)
+	}
+	pi := float64(ones) / float64(n)
+	// предварительный частотный тест: при сильном перекосе тест серий не применим
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(float64(n)) {
+		return 0, nil
+	}
+	runs := 1
+	for i := 1; i < n; i++ {
+		if bitAt(data, i) != bitAt(data, i-1) {
+			runs++
+		}
+	}
+	num := math.Abs(float64(runs) - 2*float64(n)*pi*(1-pi))
+	den := 2 * math.Sqrt(2*float64(n)) * pi * (1 - pi)
+	return math.Erfc(num / den), nil
+}
+
+// ByteFrequency - критерий хи-квадрат для частот байтов (255 степеней свободы)
+func ByteFrequency(data []byte) (float64, error) {
+	if len(data) < 256*5 {
+		return 0, errors.New("ByteFrequency: need at least 1280 bytes")
+	}
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	exp := float64(len(data)) / 256
+	chi := 0.0
+	for _, c := range counts {
+		d := float64(c) - exp
+		chi += d * d / exp
+	}
+	return igamc(255.0/2, chi/2), nil
+}
+
+// BytePairs - критерий хи-квадрат для неперекрывающихся пар байтов (65535 степеней свободы).
+// Ловит зависимость соседних байтов, невидимую для частот отдельных байтов.
+func BytePairs(data []byte) (float64, error) {
+	pairs := len(data) / 2
+	if pairs < 65536*5 {
+		return 0, errors.New("BytePairs: need at least 655360 bytes")
+	}
+	counts := make([]int, 65536)
+	for i := 0; i < pairs; i++ {
+		counts[int(data[2*i])<<8|int(data[2*i+1])]++
+	}
+	exp := float64(pairs) / 65536
+	chi := 0.0
+	for _, c := range counts {
+		d := float64(c) - exp
+		chi += d * d / exp
+	}
+	return igamc(65535.0/2, chi/2), nil
+}
+
+// MinEntropyMCV - оценка минимальной энтропии на байт по самому частому значению (SP 800-90B, 6.3.1):
+// верхняя 99%-я граница вероятности самого частого байта p_u, оценка -log2(p_u), не больше 8 бит
+func MinEntropyMCV(data []byte) (float64, error) {
+	if len(data) < 2 {
+		return 0, errors.New("MinEntropyMCV: need at least 2 samples")
+	}
+	var counts [256]int
+	max := 0
+	for _, b := range data {
+		counts[b]++
+		if counts[b] > max {
+			max = counts[b]
+		}
+	}
+	l := float64(len(data))
+	p := float64(max) / l
+	pu := math.Min(1, p+2.576*math.Sqrt(p*(1-p)/(l-1)))
+	return -math.Log2(pu), nil
+}
+
+// Battery прогоняет все тесты над последовательностью (не короче 655360 байт)
+func Battery(data []byte) ([]TestResult, error) {
+	tests := []struct {
+		name string
+		fn   func([]byte) (float64, error)
+	}{
+		{"Monobit", Monobit},
+		{"BlockFrequency(128)", func(d []byte) (float64, error) { return BlockFrequency(d, 128) }},
+		{"Runs", Runs},
+		{"ByteFrequency", ByteFrequency},
+		{"BytePairs", BytePairs},
+	}
+	res := make([]TestResult, 0, len(tests))
+	for _, t := range tests {
+		p, err := t.fn(data)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, TestResult{Name: t.name, PValue: p})
+	}
+	return res, nil
+}
@@ -0,0 +1,320 @@
+package mywycheproof
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/sagilyp/lab1/mycrypto"
+	"github.com/sagilyp/lab3/mymac"
+)
+
+// ----- Векторы Project Wycheproof: загрузка и прогон на mycrypto и mymac -----
+
+// Алгоритмы файлов Wycheproof, которые умеет прогонять Run
+const (
+	AlgCBC  = "AES-CBC-PKCS5"
+	AlgGCM  = "AES-GCM"
+	AlgCMAC = "AES-CMAC"
+	AlgHMAC = "HMACSHA256"
+)
+
+// Ожидаемые результаты теста
+const (
+	ResultValid      = "valid"
+	ResultInvalid    = "invalid"
+	ResultAcceptable = "acceptable"
+)
+
+// KnownDeviations - алгоритмы, заведомо расходящиеся со стандартом; невыполненные тесты
+// с результатом valid у них считаются известными отклонениями, а не ошибками.
+// Сейчас таких нет: HMAC приводит ключ к 64-байтному блоку SHA-256, как в RFC 2104.
+var KnownDeviations = map[string]string{}
+
+// HexBytes - байты, записанные в JSON строкой hex
+type HexBytes []byte
+
+// UnmarshalJSON декодирует строку hex
+func (h *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*h = b
+	return nil
+}
+
+// Test - тест Wycheproof; поля, которых нет у алгоритма, остаются пустыми
+type Test struct {
+	TcID    int      `json:"tcId"`
+	Comment string   `json:"comment"`
+	Flags   []string `json:"flags"`
+	Key     HexBytes `json:"key"`
+	IV      HexBytes `json:"iv"`
+	AAD     HexBytes `json:"aad"`
+	Msg     HexBytes `json:"msg"`
+	CT      HexBytes `json:"ct"`
+	Tag     HexBytes `json:"tag"`
+	Result  string   `json:"result"`
+}
+
+// Group - группа тестов с общими размерами (в битах)
+type Group struct {
+	Type    string `json:"type"`
+	KeySize int    `json:"keySize"`
+	IVSize  int    `json:"ivSize"`
+	TagSize int    `json:"tagSize"`
+	Tests   []Test `json:"tests"`
+}
+
+// File - файл векторов. Notes в старых версиях формата - строки, в новых - объекты, поэтому не разбирается.
+type File struct {
+	Algorithm        string          `json:"algorithm"`
+	GeneratorVersion string          `json:"generatorVersion"`
+	Schema           string          `json:"schema"`
+	NumberOfTests    int             `json:"numberOfTests"`
+	Header           []string        `json:"header"`
+	Notes            json.RawMessage `json:"notes"`
+	TestGroups       []Group         `json:"testGroups"`
+}
+
+// Load читает файл векторов Wycheproof
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(f.TestGroups) == 0 {
+		return nil, fmt.Errorf("%s: no test groups", path)
+	}
+	return f, nil
+}
+
+// Failure - непройденный тест
+type Failure struct {
+	TcID    int
+	Comment string
+	Flags   []string
+	Result  string // ожидаемый результат
+	Reason  string
+	Known   bool // известное отклонение алгоритма (KnownDeviations)
+}
+
+// Report - итоги прогона файла. Skipped - тесты с параметрами, которых реализация не поддерживает
+// (длина ключа, тега или IV); причины пропуска собраны в SkipReasons.
+type Report struct {
+	Algorithm   string
+	Total       int
+	Passed      int
+	Failed      int
+	Known       int
+	Skipped     int
+	Failures    []Failure
+	SkipReasons map[string]int
+}
+
+// FailedFlags возвращает число непройденных тестов (без известных отклонений) на каждый флаг,
+// например BadPadding или ModifiedTag, по убыванию
+func (r *Report) FailedFlags() []string {
+	counts := map[string]int{}
+	for _, f := range r.Failures {
+		if f.Known {
+			continue
+		}
+		for _, flag := range f.Flags {
+			counts[flag]++
+		}
+	}
+	flags := make([]string, 0, len(counts))
+	for flag := range counts {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(a, b int) bool {
+		if counts[flags[a]] != counts[flags[b]] {
+			return counts[flags[a]] > counts[flags[b]]
+		}
+		return flags[a] < flags[b]
+	})
+	for i, flag := range flags {
+		flags[i] = fmt.Sprintf("%s: %d", flag, counts[flag])
+	}
+	return flags
+}
+
+// errSkip оборачивает причину пропуска теста
+type errSkip struct{ reason string }
+
+func (e errSkip) Error() string { return e.reason }
+
+func skip(format string, args ...any) error {
+	return errSkip{fmt.Sprintf(format, args...)}
+}
+
+// outcome сводит результат операции к проверке ожидания: valid требует успеха, invalid - отказа,
+// acceptable принимает оба исхода. rejected - реализация отвергла вход, err - причина несовпадения при успехе.
+func outcome(expected string, rejected bool, err error) error {
+	switch expected {
+	case ResultValid:
+		return err
+	case ResultInvalid:
+		if !rejected {
+			return errors.New("invalid input accepted")
+		}
+	}
+	return nil
+}
+
+// newCipher создаёт MyCipher (AES) в режиме mode
+func newCipher(mode string, key []byte) (*mycrypto.MyCipher, error) {
+	mc := &mycrypto.MyCipher{}
+	if err := mc.SetKey(key); err != nil {
+		return nil, err
+	}
+	if err := mc.SetMode(mode); err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
+
+// runCBC: для valid шифрование с IV теста должно дать IV || ct, расшифрование - msg;
+// для invalid (в основном неверный паддинг) расшифрование должно завершиться ошибкой
+func runCBC(g Group, t Test) error {
+	if len(t.IV) != mycrypto.AESBlockSize {
+		return skip("IV of %d bits", 8*len(t.IV))
+	}
+	mc, err := newCipher(mycrypto.ModeCBC, t.Key)
+	if err != nil {
+		return skip("%v", err)
+	}
+	pt, decErr := mc.Decrypt(t.CT, t.IV)
+	if decErr != nil {
+		return outcome(t.Result, true, fmt.Errorf("decrypt: %v", decErr))
+	}
+	if !bytes.Equal(pt, t.Msg) {
+		return outcome(t.Result, true, fmt.Errorf("decrypt: got %x, want %x", pt, []byte(t.Msg)))
+	}
+	if t.Result == ResultValid {
+		ct, err := mc.Encrypt(t.Msg, t.IV)
+		if err != nil {
+			return fmt.Errorf("encrypt: %v", err)
+		}
+		if !bytes.Equal(ct[len(t.IV):], t.CT) {
+			return fmt.Errorf("encrypt: got %x, want %x", ct[len(t.IV):], []byte(t.CT))
+		}
+	}
+	return outcome(t.Result, false, nil)
+}
+
+// runGCM: для valid шифрование должно дать ct || tag, для всех тестов расшифрование ct || tag
+// должно вернуть msg или, для invalid, ошибку. Теги короче 16 байт MyCipher не поддерживает.
+func runGCM(g Group, t Test) error {
+	if g.TagSize != 8*mycrypto.GCMTagSize {
+		return skip("tag of %d bits", g.TagSize)
+	}
+	if len(t.IV) == 0 {
+		// пустой nonce MyCipher заменяет случайным, поэтому проверять нечего: стандарт его запрещает
+		return skip("empty IV")
+	}
+	mc, err := newCipher(mycrypto.ModeGCM, t.Key)
+	if err != nil {
+		return skip("%v", err)
+	}
+	mc.SetAAD(t.AAD)
+	sealed := append(append([]byte{}, t.CT...), t.Tag...)
+	pt, decErr := mc.Decrypt(sealed, t.IV)
+	if decErr != nil {
+		return outcome(t.Result, true, fmt.Errorf("decrypt: %v", decErr))
+	}
+	if !bytes.Equal(pt, t.Msg) {
+		return outcome(t.Result, true, fmt.Errorf("decrypt: got %x, want %x", pt, []byte(t.Msg)))
+	}
+	if t.Result == ResultValid {
+		out, err := mc.Encrypt(t.Msg, t.IV)
+		if err != nil {
+			return fmt.Errorf("encrypt: %v", err)
+		}
+		if !bytes.Equal(out[len(t.IV):], sealed) {
+			return fmt.Errorf("encrypt: got %x, want %x", out[len(t.IV):], sealed)
+		}
+	}
+	return outcome(t.Result, false, nil)
+}
+
+// runMAC проверяет тег через VerifyMac, так что укороченный или изменённый тег в тестах
+// invalid должен быть отвергнут. Длину тега группа задаёт через SetTagSize; группы с длиной,
+// которую режим не допускает (короче MinTagBits или длиннее полного тега), пропускаются.
+func runMAC(mode string, g Group, t Test) error {
+	mm := &mymac.MyMAC{}
+	if err := mm.SetMode(mode); err != nil {
+		return err
+	}
+	if err := mm.SetTagSize(g.TagSize); err != nil {
+		return skip("tag of %d bits", g.TagSize)
+	}
+	if err := mm.SetKey(t.Key); err != nil {
+		return skip("%v", err)
+	}
+	ok, err := mm.VerifyMac(t.Msg, t.Tag)
+	if err != nil {
+		return outcome(t.Result, true, err)
+	}
+	if !ok {
+		tag, _ := mm.ComputeMac(t.Msg)
+		return outcome(t.Result, true, fmt.Errorf("got %x, want %x", tag, []byte(t.Tag)))
+	}
+	return outcome(t.Result, false, nil)
+}
+
+// Run прогоняет все тесты файла
+func Run(f *File) (*Report, error) {
+	var run func(Group, Test) error
+	switch f.Algorithm {
+	case AlgCBC:
+		run = runCBC
+	case AlgGCM:
+		run = runGCM
+	case AlgCMAC:
+		run = func(g Group, t Test) error { return runMAC(mymac.OMAC, g, t) }
+	case AlgHMAC:
+		run = func(g Group, t Test) error { return runMAC(mymac.HMAC, g, t) }
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", f.Algorithm)
+	}
+	_, deviates := KnownDeviations[f.Algorithm]
+	r := &Report{Algorithm: f.Algorithm, SkipReasons: map[string]int{}}
+	for _, g := range f.TestGroups {
+		for _, t := range g.Tests {
+			r.Total++
+			err := run(g, t)
+			var s errSkip
+			switch {
+			case err == nil:
+				r.Passed++
+			case errors.As(err, &s):
+				r.Skipped++
+				r.SkipReasons[s.reason]++
+			default:
+				known := deviates && t.Result == ResultValid
+				if known {
+					r.Known++
+				} else {
+					r.Failed++
+				}
+				r.Failures = append(r.Failures, Failure{TcID: t.TcID, Comment: t.Comment, Flags: t.Flags, Result: t.Result, Reason: err.Error(), Known: known})
+			}
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,33 @@
+#!/bin/sh
+# Пересчитывает с помощью OpenSSL теги, записанные "go run ./cmd/interop -golden file",
+# и сравнивает их с тегами MyMAC (для HMAC - по первым байтам тега).
+# Запуск: sh check.sh golden.json
+set -e
+
+[ $# -eq 1 ] || { echo "usage: sh check.sh golden.json" >&2; exit 2; }
+
+field() {
+	printf '%s' "$1" | sed -n "s/.*\"$2\":\"\([0-9a-zA-Z]*\)\".*/\1/p"
+}
+
+total=0
+failed=0
+for v in $(tr -d ' \n\t' < "$1" | sed 's/},{/}\n{/g'); do
+	alg=$(field "$v" alg)
+	key=$(field "$v" key)
+	msg=$(field "$v" msg)
+	tag=$(field "$v" out)
+	case $alg in
+	OMAC) ref=$(printf '%s' "$msg" | xxd -r -p | openssl mac -cipher AES-128-CBC -macopt "hexkey:$key" CMAC) ;;
+	HMAC) ref=$(printf '%s' "$msg" | xxd -r -p | openssl mac -digest SHA256 -macopt "hexkey:$key" HMAC) ;;
+	*) echo "skip $alg: no reference algorithm"; continue ;;
+	esac
+	ref=$(printf '%s' "$ref" | tr 'A-F' 'a-f' | cut -c1-${#tag})
+	total=$((total + 1))
+	if [ "$ref" != "$tag" ]; then
+		failed=$((failed + 1))
+		echo "FAIL $alg key=$((${#key} / 2)) bytes msg=$((${#msg} / 2)) bytes: got $tag, want $ref"
+	fi
+done
+echo "$((total - failed))/$total golden tags accepted by $(openssl version | cut -d' ' -f1-2)"
+[ $failed -eq 0 ]
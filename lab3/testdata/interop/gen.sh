@@ -0,0 +1,37 @@
+#!/bin/sh
+# Генерирует эталонные векторы CMAC (AES-128) и HMAC-SHA256 с помощью OpenSSL (>= 3.0).
+# Запуск: sh gen.sh > vectors.json
+set -e
+
+CMACKEY=2b7e151628aed2a6abf7158809cf4f3c
+
+# hexseq N START - N байтов START, START+1, ... в шестнадцатеричном виде
+hexseq() {
+	awk -v n="$1" -v s="$2" 'BEGIN { for (i = 0; i < n; i++) printf "%02x", (s + i) % 256 }'
+}
+
+mac() {
+	printf '%s' "$3" | xxd -r -p | openssl mac $1 -macopt "hexkey:$2" "$4" | tr 'A-F' 'a-f'
+}
+
+tool=$(openssl version | cut -d' ' -f1-2)
+echo "["
+first=1
+emit() {
+	[ $first -eq 1 ] || echo ","
+	first=0
+	printf '  {"tool": "%s", "alg": "%s", "key": "%s", "msg": "%s", "out": "%s"}' "$tool" "$1" "$2" "$3" "$4"
+}
+for n in 0 1 15 16 17 32 40 64; do
+	m=$(hexseq "$n" 0)
+	emit OMAC "$CMACKEY" "$m" "$(mac "-cipher AES-128-CBC" "$CMACKEY" "$m" CMAC)"
+done
+for klen in 16 32 80; do
+	key=$(hexseq "$klen" 160)
+	for n in 0 16 33 100; do
+		m=$(hexseq "$n" 0)
+		emit HMAC "$key" "$m" "$(mac "-digest SHA256" "$key" "$m" HMAC)"
+	done
+done
+echo
+echo "]"
@@ -0,0 +1,12 @@
+#!/bin/sh
+# Скачивает векторы Project Wycheproof (https://github.com/C2SP/wycheproof, Apache 2.0)
+# для алгоритмов, которые прогоняет "go run ./cmd/wycheproof".
+# Запуск из каталога lab3: sh testdata/wycheproof/fetch.sh
+set -e
+
+base=https://raw.githubusercontent.com/C2SP/wycheproof/main/testvectors_v1
+dir=$(dirname "$0")
+for name in aes_cbc_pkcs5_test aes_gcm_test aes_cmac_test hmac_sha256_test; do
+	curl -fsSL -o "$dir/$name.json" "$base/$name.json"
+	echo "$dir/$name.json"
+done